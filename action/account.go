@@ -0,0 +1,266 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// Account is an action that manages accounts of registered users: signup,
+// approval, profile, password and deletion.
+type Account struct {
+	UserRepo entity.Users
+
+	// SessionAction closes sessions owned by deleted users.
+	SessionAction interface {
+		List(ctx context.Context,
+			filter entity.SessionFilter) ([]*entity.Session, int, error)
+		Delete(ctx context.Context, sessionName string, userName string) error
+	}
+
+	// KeyRepo removes API keys of deleted users. Keys are kept if it is
+	// nil.
+	KeyRepo entity.APIKeys
+
+	// RefreshRepo logs out token clients of users that change password or
+	// are deleted. Refresh tokens are kept if it is nil.
+	RefreshRepo entity.RefreshTokens
+
+	// DefaultRole is a role of signed up users.
+	DefaultRole entity.UserRole
+
+	// Approval requires signed up users to be approved by moderator before
+	// they can log in.
+	Approval bool
+
+	// AuditSink records approvals of accounts. Audit log is disabled if
+	// nil.
+	AuditSink entity.AuditSink
+}
+
+// Signup registers new user with default role. The user is pending if
+// approval is required.
+//
+// parameters:
+//  ctx          context.Context  Context of request.
+//  userName     string           Login of new user.
+//  displayName  string           Name shown to other users, may be empty.
+//  email        string           Mail address of new user, may be empty.
+//  password     string           Password of new user.
+func (a *Account) Signup(ctx context.Context, userName string,
+	displayName string, email string, password string) (*entity.User, error) {
+	userName = strings.TrimSpace(userName)
+	if userName == "" {
+		return nil, errors.New("user name is empty")
+	}
+	if strings.HasPrefix(userName, entity.GuestPrefix) {
+		return nil, fmt.Errorf("user name can not start with %s",
+			entity.GuestPrefix)
+	}
+	if err := entity.CheckPassword(password); err != nil {
+		return nil, err
+	}
+	email, err := parseEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	user := &entity.User{
+		Name:        userName,
+		Role:        a.DefaultRole,
+		DisplayName: strings.TrimSpace(displayName),
+		Email:       email,
+		Pending:     a.Approval,
+	}
+	if err = user.SetPassword(password); err != nil {
+		return nil, err
+	}
+	if err = a.UserRepo.Create(user); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":    userName,
+		"pending": user.Pending,
+	}).Info("user signed up")
+	return user, nil
+}
+
+// Pending returns users awaiting approval. Only moderators can list them.
+func (a *Account) Pending(moderatorName string) ([]*entity.User, error) {
+	if err := a.checkModerator(moderatorName); err != nil {
+		return nil, err
+	}
+	list, err := a.UserRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*entity.User, 0)
+	for _, user := range list {
+		if user.Pending {
+			pending = append(pending, user)
+		}
+	}
+	return pending, nil
+}
+
+// Approve allows pending user with given name to log in. Only moderators
+// can approve users.
+func (a *Account) Approve(
+	ctx context.Context, userName string, moderatorName string) error {
+	if err := a.checkModerator(moderatorName); err != nil {
+		return err
+	}
+	err := a.UserRepo.Modify(userName, func(user *entity.User) error {
+		user.Pending = false
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":      userName,
+		"moderator": moderatorName,
+	}).Info("user approved")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action: entity.AuditApprove,
+		Actor:  moderatorName,
+		Target: userName,
+	})
+	return nil
+}
+
+// UpdateProfile changes display name and mail address of user with given
+// name.
+func (a *Account) UpdateProfile(ctx context.Context,
+	userName string, displayName string, email string) error {
+	email, err := parseEmail(email)
+	if err != nil {
+		return err
+	}
+	err = a.UserRepo.Modify(userName, func(user *entity.User) error {
+		user.DisplayName = strings.TrimSpace(displayName)
+		user.Email = email
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithField("user", userName).
+		Info("profile updated")
+	return nil
+}
+
+// ChangePassword replaces password of user with given name if given
+// current password is correct.
+func (a *Account) ChangePassword(ctx context.Context,
+	userName string, current string, password string) error {
+	if _, err := a.verify(userName, current); err != nil {
+		return err
+	}
+	if err := entity.CheckPassword(password); err != nil {
+		return err
+	}
+	// Current password is checked again under the lock, so the password
+	// can not be changed twice by one knowing the old one.
+	err := a.UserRepo.Modify(userName, func(user *entity.User) error {
+		if !user.ValidPassword(current) {
+			return errors.New("current password incorrect")
+		}
+		return user.SetPassword(password)
+	})
+	if err != nil {
+		return err
+	}
+	if a.RefreshRepo != nil {
+		if err = a.RefreshRepo.DeleteByUser(userName); err != nil {
+			return err
+		}
+	}
+	logging.FromContext(ctx).WithField("user", userName).
+		Info("password changed")
+	return nil
+}
+
+// Delete removes account of user with given name if given password is
+// correct. Sessions owned by the user are closed.
+func (a *Account) Delete(
+	ctx context.Context, userName string, password string) error {
+	if _, err := a.verify(userName, password); err != nil {
+		return err
+	}
+	owned, _, err := a.SessionAction.List(
+		ctx, entity.SessionFilter{Owner: userName})
+	if err != nil {
+		return err
+	}
+	for _, session := range owned {
+		if err = a.SessionAction.Delete(
+			ctx, session.Name, userName); err != nil {
+			return err
+		}
+	}
+	if a.KeyRepo != nil {
+		if err = a.KeyRepo.DeleteByUser(userName); err != nil {
+			return err
+		}
+	}
+	if a.RefreshRepo != nil {
+		if err = a.RefreshRepo.DeleteByUser(userName); err != nil {
+			return err
+		}
+	}
+	if err = a.UserRepo.Delete(userName); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":     userName,
+		"sessions": len(owned),
+	}).Info("account deleted")
+	return nil
+}
+
+// verify returns user with given name if given password is correct.
+func (a *Account) verify(
+	userName string, password string) (*entity.User, error) {
+	user, err := a.UserRepo.Get(userName)
+	if err != nil {
+		return nil, err
+	}
+	if !user.ValidPassword(password) {
+		return nil, errors.New("current password incorrect")
+	}
+	return user, nil
+}
+
+// checkModerator returns an error if user with given name is not a
+// moderator.
+func (a *Account) checkModerator(userName string) error {
+	user, err := a.UserRepo.Get(userName)
+	if err != nil {
+		return err
+	}
+	if user.Role != entity.Moderator {
+		return fmt.Errorf("user %s is not a moderator", userName)
+	}
+	return nil
+}
+
+// parseEmail returns bare mail address parsed from given one, or an empty
+// string if it is empty.
+func parseEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", nil
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return "", fmt.Errorf("invalid email %s", email)
+	}
+	return addr.Address, nil
+}
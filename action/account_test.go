@@ -0,0 +1,187 @@
+package action
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+func newAccountAction() *Account {
+	userRepo := repository.NewUsersRepository()
+	userRepo.Add("test user", "test password", 1)
+	userRepo.Add("test moderator", "test password", 2)
+	return &Account{
+		UserRepo: userRepo,
+		SessionAction: &Session{
+			UserRepo:    userRepo,
+			SessionRepo: repository.NewSessionsRepository(),
+		},
+		KeyRepo:     repository.NewAPIKeysRepository(),
+		RefreshRepo: repository.NewRefreshTokensRepository(),
+		DefaultRole: entity.Subscriber,
+		AuditSink:   &auditSinkMock{behavior: "ok"},
+	}
+}
+
+func TestAccount_Signup(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Creates user with default role", t, func() {
+		a := newAccountAction()
+		user, err := a.Signup(ctx,
+			" new user ", "New User", "new@example.com", "new password")
+
+		So(err, ShouldBeNil)
+		So(user.Name, ShouldEqual, "new user")
+		So(user.DisplayName, ShouldEqual, "New User")
+		So(user.Email, ShouldEqual, "new@example.com")
+		So(user.Role, ShouldEqual, entity.Subscriber)
+		So(user.Pending, ShouldBeFalse)
+		stored, _ := a.UserRepo.Get("new user")
+		So(stored, ShouldEqual, user)
+	})
+
+	Convey("Creates pending user if approval is required", t, func() {
+		a := newAccountAction()
+		a.Approval = true
+		user, err := a.Signup(ctx, "new user", "", "", "new password")
+
+		So(err, ShouldBeNil)
+		So(user.Pending, ShouldBeTrue)
+
+		Convey("Moderator lists pending users", func() {
+			list, err := a.Pending("test moderator")
+
+			So(err, ShouldBeNil)
+			So(list, ShouldHaveLength, 1)
+			So(list[0].Name, ShouldEqual, "new user")
+		})
+
+		Convey("Moderator approves user", func() {
+			So(a.Approve(ctx, "new user", "test moderator"), ShouldBeNil)
+			user, _ := a.UserRepo.Get("new user")
+			So(user.Pending, ShouldBeFalse)
+			sink := a.AuditSink.(*auditSinkMock)
+			So(sink.actions(), ShouldResemble,
+				[]entity.AuditAction{entity.AuditApprove})
+			So(sink.events[0].Actor, ShouldEqual, "test moderator")
+			So(sink.events[0].Target, ShouldEqual, "new user")
+		})
+
+		Convey("Returns an error if user is not a moderator", func() {
+			err := a.Approve(ctx, "new user", "test user")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "user test user is not a moderator")
+			_, err = a.Pending("test user")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Returns an error", t, func() {
+		a := newAccountAction()
+
+		Convey("if user exists", func() {
+			_, err := a.Signup(ctx, "test user", "", "", "new password")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("if user name is empty", func() {
+			_, err := a.Signup(ctx, " ", "", "", "new password")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("if user name looks like guest", func() {
+			_, err := a.Signup(ctx,
+				entity.GuestPrefix+"x", "", "", "new password")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("if email is invalid", func() {
+			_, err := a.Signup(ctx, "new user", "", "wrong", "new password")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "invalid email wrong")
+		})
+
+		Convey("if password is too short", func() {
+			_, err := a.Signup(ctx, "new user", "", "", "short")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "at least 8 characters")
+		})
+	})
+}
+
+func TestAccount_UpdateProfile(t *testing.T) {
+	Convey("Changes display name", t, func() {
+		a := newAccountAction()
+		err := a.UpdateProfile(context.Background(),
+			"test user", "Test", "Test <test@example.com>")
+
+		So(err, ShouldBeNil)
+		user, _ := a.UserRepo.Get("test user")
+		So(user.DisplayName, ShouldEqual, "Test")
+		So(user.Email, ShouldEqual, "test@example.com")
+	})
+}
+
+func TestAccount_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Changes password", t, func() {
+		a := newAccountAction()
+		a.RefreshRepo.Add(&entity.RefreshToken{
+			TokenHash: "test hash", UserName: "test user"})
+		err := a.ChangePassword(ctx,
+			"test user", "test password", "new password")
+
+		So(err, ShouldBeNil)
+		user, _ := a.UserRepo.Get("test user")
+		So(user.ValidPassword("new password"), ShouldBeTrue)
+		_, err = a.RefreshRepo.Use("test hash")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns an error if current password is wrong", t, func() {
+		a := newAccountAction()
+		err := a.ChangePassword(ctx,
+			"test user", "wrong password", "new password")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "current password incorrect")
+	})
+}
+
+func TestAccount_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Deletes user and its sessions", t, func() {
+		a := newAccountAction()
+		sessions := a.SessionAction.(*Session)
+		sessions.Add(ctx, "test id", "test session", "test user", "", nil)
+		sessions.Add(ctx, "other id", "other session", "test moderator", "", nil)
+		a.KeyRepo.Add(&entity.APIKey{
+			ID: "test key", TokenHash: "test hash", UserName: "test user"})
+		err := a.Delete(ctx, "test user", "test password")
+
+		So(err, ShouldBeNil)
+		_, err = a.UserRepo.Get("test user")
+		So(err, ShouldNotBeNil)
+		So(sessions.IsExists(ctx, "test session"), ShouldBeFalse)
+		So(sessions.IsExists(ctx, "other session"), ShouldBeTrue)
+		_, err = a.KeyRepo.Get("test hash")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns an error if password is wrong", t, func() {
+		a := newAccountAction()
+		err := a.Delete(ctx, "test user", "wrong password")
+
+		So(err, ShouldNotBeNil)
+		_, err = a.UserRepo.Get("test user")
+		So(err, ShouldBeNil)
+	})
+}
@@ -0,0 +1,290 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// Admin is an action that manages users and data of the application on
+// behalf of its operator, so no logged user is checked.
+type Admin struct {
+	UserRepo    entity.Users
+	SessionRepo entity.Sessions
+
+	// Registry closes sessions on OpenViDu server. Its events record
+	// closes to audit log.
+	Registry interface {
+		Close(ctx context.Context,
+			sessionName string, userName string, details string) error
+	}
+
+	// AuditSink records changes made by admin commands. Audit log is
+	// disabled if nil.
+	AuditSink entity.AuditSink
+}
+
+// adminCommand describes audit events of admin commands.
+const adminCommand = "admin command"
+
+// adminData is a format of data exported by Admin.
+type adminData struct {
+	Users    []*entity.User    `json:"users"`
+	Sessions []*entity.Session `json:"sessions"`
+}
+
+// Users returns all users sorted by name.
+func (a *Admin) Users() ([]*entity.User, error) {
+	return a.UserRepo.List()
+}
+
+// Sessions returns all active sessions.
+func (a *Admin) Sessions() ([]*entity.Session, error) {
+	list, _, err := a.SessionRepo.List(entity.SessionFilter{})
+	return list, err
+}
+
+// CloseSession closes session with given name on OpenViDu server and
+// removes it.
+func (a *Admin) CloseSession(ctx context.Context, sessionName string) error {
+	err := a.Registry.Close(ctx, sessionName, "", adminCommand)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"session": sessionName,
+		"audit":   true,
+	}).Info("session closed by admin")
+	return nil
+}
+
+// AddUser creates user with given name, role and password.
+func (a *Admin) AddUser(ctx context.Context, userName string,
+	role entity.UserRole, password string) error {
+	userName = strings.TrimSpace(userName)
+	if userName == "" {
+		return errors.New("user name is empty")
+	}
+	if strings.HasPrefix(userName, entity.GuestPrefix) {
+		return fmt.Errorf("user name can not start with %s",
+			entity.GuestPrefix)
+	}
+	if err := entity.CheckPassword(password); err != nil {
+		return err
+	}
+	user := &entity.User{Name: userName, Role: role}
+	if err := user.SetPassword(password); err != nil {
+		return err
+	}
+	if err := a.UserRepo.Create(user); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":  userName,
+		"role":  role.String(),
+		"audit": true,
+	}).Info("user added by admin")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action:  entity.AuditUserAdd,
+		Target:  userName,
+		Details: adminCommand + ", role " + role.String(),
+	})
+	return nil
+}
+
+// RemoveUser removes user with given name and closes sessions it owns.
+func (a *Admin) RemoveUser(ctx context.Context, userName string) error {
+	if _, err := a.user(userName); err != nil {
+		return err
+	}
+	owned, _, err := a.SessionRepo.List(
+		entity.SessionFilter{Owner: userName})
+	if err != nil {
+		return err
+	}
+	for _, session := range owned {
+		err = a.Registry.Close(ctx, session.Name, "", adminCommand)
+		if err != nil {
+			return err
+		}
+	}
+	if err = a.UserRepo.Delete(userName); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":     userName,
+		"sessions": len(owned),
+		"audit":    true,
+	}).Info("user removed by admin")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action:  entity.AuditUserRemove,
+		Target:  userName,
+		Details: adminCommand,
+	})
+	return nil
+}
+
+// SetRole changes role of user with given name.
+func (a *Admin) SetRole(
+	ctx context.Context, userName string, role entity.UserRole) error {
+	var from entity.UserRole
+	err := a.modify(userName, func(user *entity.User) error {
+		from = user.Role
+		user.Role = role
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":  userName,
+		"from":  from.String(),
+		"role":  role.String(),
+		"audit": true,
+	}).Info("user role changed by admin")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action: entity.AuditRoleChange,
+		Target: userName,
+		Details: adminCommand + ", " + from.String() + " -> " +
+			role.String(),
+	})
+	return nil
+}
+
+// ResetPassword replaces password of user with given name without checking
+// the current one.
+func (a *Admin) ResetPassword(
+	ctx context.Context, userName string, password string) error {
+	if err := entity.CheckPassword(password); err != nil {
+		return err
+	}
+	err := a.modify(userName, func(user *entity.User) error {
+		return user.SetPassword(password)
+	})
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":  userName,
+		"audit": true,
+	}).Info("password reset by admin")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action:  entity.AuditPasswordReset,
+		Target:  userName,
+		Details: adminCommand,
+	})
+	return nil
+}
+
+// Export writes all users and active sessions to given writer as JSON.
+// Password hashes, one-time password secrets and recovery codes are left
+// out unless credentials is true, so export without credentials is safe to
+// share.
+func (a *Admin) Export(w io.Writer, credentials bool) error {
+	users, err := a.UserRepo.List()
+	if err != nil {
+		return err
+	}
+	if !credentials {
+		for i, user := range users {
+			users[i] = withoutCredentials(user)
+		}
+	}
+	sessions, _, err := a.SessionRepo.List(entity.SessionFilter{})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(adminData{Users: users, Sessions: sessions})
+}
+
+// Import reads data written by Export from given reader. Users are created
+// or replaced, sessions are added to repository. Replaced users exported
+// without credentials keep their current ones. Returns numbers of imported
+// users and sessions.
+func (a *Admin) Import(
+	ctx context.Context, r io.Reader) (users int, sessions int, err error) {
+	var data adminData
+	if err = json.NewDecoder(r).Decode(&data); err != nil {
+		return 0, 0, err
+	}
+	for _, user := range data.Users {
+		if user == nil || user.Name == "" {
+			return users, sessions, errors.New("user name is empty")
+		}
+		err = a.UserRepo.Modify(user.Name, func(current *entity.User) error {
+			if user.Password == "" {
+				user.Password = current.Password
+				user.TOTPSecret = current.TOTPSecret
+				user.TOTPCounter = current.TOTPCounter
+				user.RecoveryCodes = current.RecoveryCodes
+			}
+			*current = *user
+			return nil
+		})
+		if err == entity.ErrUnknownUser {
+			err = a.UserRepo.Create(user)
+		}
+		if err != nil {
+			return users, sessions, err
+		}
+		users++
+	}
+	for _, session := range data.Sessions {
+		if err = a.SessionRepo.Restore(session); err != nil {
+			return users, sessions, err
+		}
+		sessions++
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"users":    users,
+		"sessions": sessions,
+		"audit":    true,
+	}).Info("data imported by admin")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action: entity.AuditImport,
+		Details: fmt.Sprintf("%s, %d users, %d sessions",
+			adminCommand, users, sessions),
+	})
+	return users, sessions, nil
+}
+
+// withoutCredentials returns copy of given user without password hash and
+// second factor secrets.
+func withoutCredentials(user *entity.User) *entity.User {
+	stripped := *user
+	stripped.Password = ""
+	stripped.TOTPSecret = ""
+	stripped.TOTPCounter = 0
+	stripped.RecoveryCodes = nil
+	return &stripped
+}
+
+// user returns user with given name. Unknown user is reported by name
+// rather than as failed login.
+func (a *Admin) user(userName string) (*entity.User, error) {
+	user, err := a.UserRepo.Get(userName)
+	if err == entity.ErrUnknownUser {
+		return nil, fmt.Errorf("user %s does not exist", userName)
+	}
+	return user, err
+}
+
+// modify applies given change to user with given name in repository.
+func (a *Admin) modify(
+	userName string, change func(user *entity.User) error) error {
+	err := a.UserRepo.Modify(userName, change)
+	if err == entity.ErrUnknownUser {
+		return fmt.Errorf("user %s does not exist", userName)
+	}
+	return err
+}
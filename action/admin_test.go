@@ -0,0 +1,221 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+func TestAdmin_AddUser(t *testing.T) {
+	Convey("Adds user", t, func() {
+		a := newAdminAction("ok")
+
+		So(a.AddUser(context.Background(), " test user ",
+			entity.Moderator, "new password"), ShouldBeNil)
+		user, err := a.UserRepo.Get("test user")
+		So(err, ShouldBeNil)
+		So(user.Role, ShouldEqual, entity.Moderator)
+		So(user.ValidPassword("new password"), ShouldBeTrue)
+	})
+
+	Convey("Returns an error for invalid user", t, func() {
+		a := newAdminAction("ok")
+		ctx := context.Background()
+
+		So(a.AddUser(ctx, " ", entity.Publisher, "new password"),
+			ShouldNotBeNil)
+		So(a.AddUser(ctx, entity.GuestPrefix+"user", entity.Publisher,
+			"new password"), ShouldNotBeNil)
+		So(a.AddUser(ctx, "test user", entity.Publisher, "short"),
+			ShouldNotBeNil)
+		So(a.AddUser(ctx, "test owner", entity.Publisher, "new password"),
+			ShouldNotBeNil)
+	})
+}
+
+func TestAdmin_RemoveUser(t *testing.T) {
+	Convey("Removes user and closes its sessions", t, func() {
+		a := newAdminAction("ok")
+
+		So(a.RemoveUser(context.Background(), "test owner"), ShouldBeNil)
+		_, err := a.UserRepo.Get("test owner")
+		So(err, ShouldEqual, entity.ErrUnknownUser)
+		_, total, _ := a.SessionRepo.List(entity.SessionFilter{})
+		So(total, ShouldEqual, 0)
+	})
+
+	Convey("Keeps user if its session fails to close", t, func() {
+		a := newAdminAction("failure")
+
+		So(a.RemoveUser(context.Background(), "test owner"), ShouldNotBeNil)
+		_, err := a.UserRepo.Get("test owner")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Returns an error for unknown user", t, func() {
+		a := newAdminAction("ok")
+
+		err := a.RemoveUser(context.Background(), "unknown")
+		So(err.Error(), ShouldEqual, "user unknown does not exist")
+	})
+}
+
+func TestAdmin_CloseSession(t *testing.T) {
+	Convey("Closes session", t, func() {
+		a := newAdminAction("ok")
+
+		So(a.CloseSession(context.Background(), "test session"), ShouldBeNil)
+		list, err := a.Sessions()
+		So(err, ShouldBeNil)
+		So(list, ShouldBeEmpty)
+	})
+
+	Convey("Returns OpenViDu error", t, func() {
+		a := newAdminAction("failure")
+
+		So(a.CloseSession(context.Background(), "test session"),
+			ShouldNotBeNil)
+		list, _ := a.Sessions()
+		So(list, ShouldHaveLength, 1)
+	})
+}
+
+func TestAdmin_SetRole(t *testing.T) {
+	Convey("Changes role of user", t, func() {
+		a := newAdminAction("ok")
+
+		So(a.SetRole(context.Background(), "test owner", entity.Subscriber),
+			ShouldBeNil)
+		user, _ := a.UserRepo.Get("test owner")
+		So(user.Role, ShouldEqual, entity.Subscriber)
+	})
+
+	Convey("Records role change to audit log", t, func() {
+		sink := &auditSinkMock{behavior: "ok"}
+		a := newAdminAction("ok")
+		a.AuditSink = sink
+
+		So(a.SetRole(context.Background(), "test owner", entity.Subscriber),
+			ShouldBeNil)
+		So(sink.actions(), ShouldResemble,
+			[]entity.AuditAction{entity.AuditRoleChange})
+		So(sink.events[0].Actor, ShouldBeEmpty)
+		So(sink.events[0].Target, ShouldEqual, "test owner")
+		So(sink.events[0].Details, ShouldEqual,
+			"admin command, PUBLISHER -> SUBSCRIBER")
+	})
+
+	Convey("Returns an error for unknown user", t, func() {
+		a := newAdminAction("ok")
+
+		So(a.SetRole(context.Background(), "unknown", entity.Subscriber),
+			ShouldNotBeNil)
+	})
+}
+
+func TestAdmin_ResetPassword(t *testing.T) {
+	Convey("Replaces password of user", t, func() {
+		a := newAdminAction("ok")
+
+		So(a.ResetPassword(context.Background(), "test owner",
+			"new password"), ShouldBeNil)
+		user, _ := a.UserRepo.Get("test owner")
+		So(user.ValidPassword("new password"), ShouldBeTrue)
+	})
+
+	Convey("Returns an error for weak password", t, func() {
+		a := newAdminAction("ok")
+
+		So(a.ResetPassword(context.Background(), "test owner", "short"),
+			ShouldNotBeNil)
+		user, _ := a.UserRepo.Get("test owner")
+		So(user.ValidPassword("test password"), ShouldBeTrue)
+	})
+}
+
+func TestAdmin_Export(t *testing.T) {
+	Convey("Imports exported data", t, func() {
+		a := newAdminAction("ok")
+		buf := &bytes.Buffer{}
+		So(a.Export(buf, true), ShouldBeNil)
+
+		imported := &Admin{
+			UserRepo:    repository.NewUsersRepository(),
+			SessionRepo: repository.NewSessionsRepository(),
+		}
+		imported.UserRepo.Add("test owner", "old password", 0)
+		users, sessions, err := imported.Import(context.Background(), buf)
+		So(err, ShouldBeNil)
+		So(users, ShouldEqual, 1)
+		So(sessions, ShouldEqual, 1)
+		user, _ := imported.UserRepo.Get("test owner")
+		So(user.ValidPassword("test password"), ShouldBeTrue)
+		So(user.Role, ShouldEqual, entity.Publisher)
+		s, err := imported.SessionRepo.Get("test session")
+		So(err, ShouldBeNil)
+		So(s.ID, ShouldEqual, "test id")
+	})
+
+	Convey("Leaves credentials out by default", t, func() {
+		a := newAdminAction("ok")
+		owner, _ := a.UserRepo.Get("test owner")
+		owner.TOTPSecret = "test secret"
+		owner.RecoveryCodes = []string{"test code"}
+		buf := &bytes.Buffer{}
+		So(a.Export(buf, false), ShouldBeNil)
+		So(buf.String(), ShouldNotContainSubstring, owner.Password)
+		So(buf.String(), ShouldNotContainSubstring, "test secret")
+		So(buf.String(), ShouldNotContainSubstring, "test code")
+		So(owner.TOTPSecret, ShouldEqual, "test secret")
+
+		users, _, err := a.Import(context.Background(),
+			bytes.NewBufferString(strings.Replace(
+				buf.String(), "test session", "other session", 1)))
+		So(err, ShouldBeNil)
+		So(users, ShouldEqual, 1)
+		user, _ := a.UserRepo.Get("test owner")
+		So(user.ValidPassword("test password"), ShouldBeTrue)
+		So(user.TOTPSecret, ShouldEqual, "test secret")
+		So(user.RecoveryCodes, ShouldResemble, []string{"test code"})
+	})
+
+	Convey("Returns import errors", t, func() {
+		a := newAdminAction("ok")
+		ctx := context.Background()
+
+		_, _, err := a.Import(ctx, bytes.NewBufferString("wrong"))
+		So(err, ShouldNotBeNil)
+		_, _, err = a.Import(ctx, bytes.NewBufferString(
+			`{"users": [{"Name": ""}]}`))
+		So(err, ShouldNotBeNil)
+		users, _, err := a.Import(ctx, bytes.NewBufferString(
+			`{"users": [{"Name": "new user"}],
+			  "sessions": [{"ID": "test id", "Name": "test session"}]}`))
+		So(err, ShouldNotBeNil)
+		So(users, ShouldEqual, 1)
+	})
+}
+
+// newAdminAction returns admin action with user that owns a session, which
+// OpenViDu mock closes with given behavior.
+func newAdminAction(behavior string) *Admin {
+	sessionRepo := repository.NewSessionsRepository()
+	a := &Admin{
+		UserRepo:    repository.NewUsersRepository(),
+		SessionRepo: sessionRepo,
+		Registry: &Registry{
+			SessionRepo: sessionRepo,
+			OpenViDu:    &sessionCloserMock{behavior: behavior},
+		},
+	}
+	a.UserRepo.Add("test owner", "test password", 1)
+	owner, _ := a.UserRepo.Get("test owner")
+	sessionRepo.Add("test id", "test session", owner)
+	return a
+}
@@ -0,0 +1,195 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+const (
+	// apiKeyPrefix starts every API key, so leaked keys are easy to find
+	// and tell from other bearer tokens.
+	apiKeyPrefix = "ovk_"
+
+	// maxAPIKeys is a maximal number of API keys of one user.
+	maxAPIKeys = 20
+
+	// maxAPIKeyName is a maximal length of API key name.
+	maxAPIKeyName = 64
+)
+
+// errAPIKey is returned for any API key that is not accepted, so the
+// result does not disclose which keys exist.
+var errAPIKey = errors.New("API key is invalid")
+
+// APIKey is an action that manages API keys machine clients authenticate
+// with on behalf of users.
+type APIKey struct {
+	KeyRepo  entity.APIKeys
+	UserRepo entity.Users
+
+	// AuditSink records creation and revocation of API keys. Audit log is
+	// disabled if nil.
+	AuditSink entity.AuditSink
+
+	// Now returns current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// Create issues new API key of user with given name and returns the key.
+// Only the hash of the key is stored, so it is shown to the user once.
+//
+// parameters:
+//  ctx       context.Context    Context of request.
+//  userName  string             Logged user name.
+//  name      string             Name of client that uses the key.
+//  scopes    []entity.APIScope  Operations the key is allowed to perform.
+func (a *APIKey) Create(ctx context.Context, userName string, name string,
+	scopes []entity.APIScope) (string, *entity.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPIKeyName {
+		return "", nil, fmt.Errorf(
+			"API key name must be 1 to %d characters long", maxAPIKeyName)
+	}
+	if len(scopes) == 0 {
+		return "", nil, errors.New("API key must have at least one scope")
+	}
+	user, err := a.UserRepo.Get(userName)
+	if err != nil {
+		return "", nil, err
+	}
+	if user.Guest || user.Pending {
+		return "", nil, fmt.Errorf("user %s can not create API keys", userName)
+	}
+	keys, err := a.KeyRepo.List(userName)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(keys) >= maxAPIKeys {
+		return "", nil, fmt.Errorf(
+			"user %s already has %d API keys", userName, maxAPIKeys)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := newResetToken()
+	if err != nil {
+		return "", nil, err
+	}
+	token := apiKeyPrefix + secret
+	key := &entity.APIKey{
+		ID:        id,
+		UserName:  userName,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Scopes:    uniqueScopes(scopes),
+		CreatedAt: a.now(),
+	}
+	if err = a.KeyRepo.Add(key); err != nil {
+		return "", nil, err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":   userName,
+		"key":    id,
+		"scopes": key.Scopes,
+		"audit":  true,
+	}).Info("API key created")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action:  entity.AuditAPIKeyCreate,
+		Actor:   userName,
+		Target:  id,
+		Details: name + ", scopes " + scopesText(key.Scopes),
+	})
+	return token, key, nil
+}
+
+// List returns API keys of user with given name.
+func (a *APIKey) List(userName string) ([]*entity.APIKey, error) {
+	return a.KeyRepo.List(userName)
+}
+
+// Revoke removes API key with given ID of user with given name, so it is
+// not accepted anymore.
+func (a *APIKey) Revoke(
+	ctx context.Context, userName string, id string) error {
+	if err := a.KeyRepo.Delete(userName, id); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":  userName,
+		"key":   id,
+		"audit": true,
+	}).Info("API key revoked")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action: entity.AuditAPIKeyRevoke,
+		Actor:  userName,
+		Target: id,
+	})
+	return nil
+}
+
+// Authenticate returns API key given by its token and user it belongs to,
+// and records the time the key was used at.
+func (a *APIKey) Authenticate(ctx context.Context,
+	token string) (*entity.User, *entity.APIKey, error) {
+	if !strings.HasPrefix(token, apiKeyPrefix) {
+		return nil, nil, errAPIKey
+	}
+	hash := hashToken(token)
+	key, err := a.KeyRepo.Get(hash)
+	if err != nil {
+		return nil, nil, errAPIKey
+	}
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user": key.UserName,
+		"key":  key.ID,
+	})
+	user, err := a.UserRepo.Get(key.UserName)
+	if err != nil || user.Pending {
+		log.Warn("API key of unavailable user refused")
+		return nil, nil, errAPIKey
+	}
+	if err = a.KeyRepo.Touch(hash, a.now()); err != nil {
+		return nil, nil, errAPIKey
+	}
+	return user, key, nil
+}
+
+// now returns current time.
+func (a *APIKey) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
+
+// uniqueScopes returns given scopes without duplicates.
+func uniqueScopes(scopes []entity.APIScope) []entity.APIScope {
+	seen := make(map[entity.APIScope]bool, len(scopes))
+	unique := make([]entity.APIScope, 0, len(scopes))
+	for _, s := range scopes {
+		if !seen[s] {
+			seen[s] = true
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
+
+// scopesText returns given scopes separated by commas.
+func scopesText(scopes []entity.APIScope) string {
+	list := make([]string, len(scopes))
+	for i, s := range scopes {
+		list[i] = string(s)
+	}
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,109 @@
+package action
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+func TestAPIKey(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	newAction := func() *APIKey {
+		userRepo := repository.NewUsersRepository()
+		userRepo.Add("test user", "test password", 1)
+		userRepo.Create(&entity.User{Name: "pending user", Pending: true})
+		return &APIKey{
+			KeyRepo:   repository.NewAPIKeysRepository(),
+			UserRepo:  userRepo,
+			AuditSink: &auditSinkMock{behavior: "ok"},
+			Now:       func() time.Time { return now },
+		}
+	}
+
+	Convey("Creates API key", t, func() {
+		a := newAction()
+		token, key, err := a.Create(ctx, "test user", " backend ",
+			[]entity.APIScope{entity.ScopeGetToken, entity.ScopeGetToken})
+
+		So(err, ShouldBeNil)
+		So(strings.HasPrefix(token, apiKeyPrefix), ShouldBeTrue)
+		So(key.Name, ShouldEqual, "backend")
+		So(key.Scopes, ShouldResemble, []entity.APIScope{entity.ScopeGetToken})
+		So(key.TokenHash, ShouldEqual, hashToken(token))
+		So(key.TokenHash, ShouldNotContainSubstring, token)
+		sink := a.AuditSink.(*auditSinkMock)
+		So(sink.actions(), ShouldResemble,
+			[]entity.AuditAction{entity.AuditAPIKeyCreate})
+		So(sink.events[0].Target, ShouldEqual, key.ID)
+		So(sink.events[0].Details, ShouldEqual, "backend, scopes get-token")
+
+		Convey("Authenticates with it", func() {
+			now = now.Add(time.Minute)
+			user, found, err := a.Authenticate(ctx, token)
+
+			So(err, ShouldBeNil)
+			So(user.Name, ShouldEqual, "test user")
+			So(found.ID, ShouldEqual, key.ID)
+			list, _ := a.List("test user")
+			So(list[0].LastUsedAt, ShouldEqual, now)
+		})
+
+		Convey("Does not authenticate after revoke", func() {
+			So(a.Revoke(ctx, "test user", key.ID), ShouldBeNil)
+			_, _, err := a.Authenticate(ctx, token)
+
+			So(err, ShouldEqual, errAPIKey)
+			So(sink.actions(), ShouldResemble, []entity.AuditAction{
+				entity.AuditAPIKeyCreate, entity.AuditAPIKeyRevoke})
+		})
+
+		Convey("Does not authenticate after user deletion", func() {
+			a.UserRepo.Delete("test user")
+			_, _, err := a.Authenticate(ctx, token)
+
+			So(err, ShouldEqual, errAPIKey)
+		})
+	})
+
+	Convey("Returns an error on creation", t, func() {
+		a := newAction()
+		scopes := []entity.APIScope{entity.ScopeListSessions}
+
+		Convey("if name is empty", func() {
+			_, _, err := a.Create(ctx, "test user", " ", scopes)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("if scopes are empty", func() {
+			_, _, err := a.Create(ctx, "test user", "backend", nil)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("if user is pending", func() {
+			_, _, err := a.Create(ctx, "pending user", "backend", scopes)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("if user has too many keys", func() {
+			for i := 0; i < maxAPIKeys; i++ {
+				a.Create(ctx, "test user", "backend", scopes)
+			}
+			_, _, err := a.Create(ctx, "test user", "backend", scopes)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Refuses unknown API key", t, func() {
+		a := newAction()
+		_, _, err := a.Authenticate(ctx, apiKeyPrefix+"unknown")
+
+		So(err, ShouldEqual, errAPIKey)
+	})
+}
@@ -0,0 +1,72 @@
+package action
+
+import (
+	"context"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// audit records given event to given sink. Request ID is taken from given
+// context and current time is used if event has none. Failure to record
+// the event is logged, so it never fails the audited action. Nothing is
+// recorded if sink is nil.
+func audit(ctx context.Context, sink entity.AuditSink, e entity.AuditEvent) {
+	if sink == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.RequestID = logging.RequestID(ctx)
+	if err := sink.Record(&e); err != nil {
+		logging.FromContext(ctx).WithError(err).
+			WithField("action", string(e.Action)).
+			Error("audit event is not recorded")
+	}
+}
+
+// errorText returns message of given error or empty string if it is nil.
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// sessionAudit maps session events to audit actions. Events missing here
+// are not audited.
+var sessionAudit = map[entity.SessionEventType]entity.AuditAction{
+	entity.SessionCreated:    entity.AuditSessionCreate,
+	entity.ParticipantJoined: entity.AuditSessionJoin,
+	entity.ParticipantLeft:   entity.AuditSessionLeave,
+	entity.SessionClosed:     entity.AuditSessionClose,
+	entity.OwnerChanged:      entity.AuditOwnerChange,
+	entity.RecordingStarted:  entity.AuditRecordingStart,
+	entity.RecordingStopped:  entity.AuditRecordingStop,
+}
+
+// AuditSessions returns subscriber of session events bus that records
+// session lifecycle to given sink. Failed changes publish no events, so
+// actions audit them themselves.
+func AuditSessions(sink entity.AuditSink) entity.SessionEventHandler {
+	return func(ctx context.Context, e *entity.SessionEvent) {
+		action, ok := sessionAudit[e.Type]
+		if !ok {
+			return
+		}
+		event := entity.AuditEvent{
+			Time:    e.Time,
+			Action:  action,
+			Actor:   e.User,
+			Session: e.SessionName,
+			Details: e.Details,
+		}
+		// Previous owner hands the session over to the new one.
+		if e.Type == entity.OwnerChanged {
+			event.Actor, event.Target = e.PreviousOwner, e.User
+		}
+		audit(ctx, sink, event)
+	}
+}
@@ -0,0 +1,125 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// auditSinkMock is a mock that imitates audit sink behavior.
+type auditSinkMock struct {
+	behavior string
+	events   []*entity.AuditEvent
+}
+
+// Record remembers given event or returns an error depending on behavior.
+func (m *auditSinkMock) Record(event *entity.AuditEvent) error {
+	if m.behavior != "ok" {
+		return errors.New("some error")
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+// Query returns remembered events.
+func (m *auditSinkMock) Query(
+	filter entity.AuditFilter) ([]*entity.AuditEvent, int, error) {
+	if m.behavior != "ok" {
+		return nil, 0, errors.New("some error")
+	}
+	return m.events, len(m.events), nil
+}
+
+// actions returns actions of remembered events.
+func (m *auditSinkMock) actions() []entity.AuditAction {
+	var list []entity.AuditAction
+	for _, e := range m.events {
+		list = append(list, e.Action)
+	}
+	return list
+}
+
+// sessionEventsFunc is a mock of session events bus that calls itself for
+// every published event.
+type sessionEventsFunc entity.SessionEventHandler
+
+// Publish calls the function with given event.
+func (f sessionEventsFunc) Publish(
+	ctx context.Context, event *entity.SessionEvent) {
+	f(ctx, event)
+}
+
+func TestAudit(t *testing.T) {
+	Convey("Records event with time and request ID", t, func() {
+		sink := &auditSinkMock{behavior: "ok"}
+		ctx := logging.WithRequestID(context.Background(), "test request")
+		audit(ctx, sink, entity.AuditEvent{Action: entity.AuditLogin})
+
+		So(sink.events, ShouldHaveLength, 1)
+		So(sink.events[0].RequestID, ShouldEqual, "test request")
+		So(sink.events[0].Time.IsZero(), ShouldBeFalse)
+	})
+
+	Convey("Ignores failure of sink", t, func() {
+		audit(context.Background(), &auditSinkMock{behavior: "failure"},
+			entity.AuditEvent{Action: entity.AuditLogin})
+	})
+
+	Convey("Does nothing without sink", t, func() {
+		audit(context.Background(), nil,
+			entity.AuditEvent{Action: entity.AuditLogin})
+	})
+}
+
+func TestAuditSessions(t *testing.T) {
+	Convey("Records session events", t, func() {
+		sink := &auditSinkMock{behavior: "ok"}
+		handle := AuditSessions(sink)
+		ctx := logging.WithRequestID(context.Background(), "test request")
+		now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+
+		handle(ctx, &entity.SessionEvent{
+			Type: entity.SessionClosed, Time: now, SessionName: "test session",
+			User: "test user", Details: "test details",
+		})
+		handle(ctx, &entity.SessionEvent{Type: "unknown"})
+
+		So(sink.actions(), ShouldResemble,
+			[]entity.AuditAction{entity.AuditSessionClose})
+		So(sink.events[0].Time, ShouldEqual, now)
+		So(sink.events[0].Actor, ShouldEqual, "test user")
+		So(sink.events[0].Session, ShouldEqual, "test session")
+		So(sink.events[0].Details, ShouldEqual, "test details")
+		So(sink.events[0].RequestID, ShouldEqual, "test request")
+	})
+	Convey("Records handover by previous owner", t, func() {
+		sink := &auditSinkMock{behavior: "ok"}
+		AuditSessions(sink)(context.Background(), &entity.SessionEvent{
+			Type: entity.OwnerChanged, SessionName: "test session",
+			User: "new owner", PreviousOwner: "old owner",
+		})
+
+		So(sink.actions(), ShouldResemble,
+			[]entity.AuditAction{entity.AuditOwnerChange})
+		So(sink.events[0].Actor, ShouldEqual, "old owner")
+		So(sink.events[0].Target, ShouldEqual, "new owner")
+	})
+
+	Convey("Records start and stop of recording", t, func() {
+		sink := &auditSinkMock{behavior: "ok"}
+		handle := AuditSessions(sink)
+		handle(context.Background(),
+			&entity.SessionEvent{Type: entity.RecordingStarted})
+		handle(context.Background(),
+			&entity.SessionEvent{Type: entity.RecordingStopped})
+
+		So(sink.actions(), ShouldResemble, []entity.AuditAction{
+			entity.AuditRecordingStart, entity.AuditRecordingStop})
+	})
+}
@@ -0,0 +1,128 @@
+package action
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// LocalAuthenticator verifies passwords of local accounts stored in users
+// repository. Users provisioned from external stores are unknown to it.
+//
+// implements entity.Authenticator interface.
+type LocalAuthenticator struct {
+	UserRepo entity.Users
+}
+
+// Authenticate returns local user with given name if given password is
+// correct.
+func (a *LocalAuthenticator) Authenticate(ctx context.Context,
+	username string, password string) (*entity.User, error) {
+	user, err := a.UserRepo.Get(username)
+	if err != nil {
+		return nil, err
+	}
+	if user.Subject != "" {
+		return nil, entity.ErrUnknownUser
+	}
+	if !user.ValidPassword(password) {
+		return nil, errors.New("password incorrect")
+	}
+	return user, nil
+}
+
+// ChainAuthenticator tries its authenticators in order until one of them
+// knows the user. The next authenticator is also tried if previous one is
+// unavailable, so local accounts work while directory server is down.
+//
+// implements entity.Authenticator interface.
+type ChainAuthenticator []entity.Authenticator
+
+// Authenticate returns user authenticated by the first authenticator that
+// knows the user.
+func (c ChainAuthenticator) Authenticate(ctx context.Context,
+	username string, password string) (*entity.User, error) {
+	result := entity.ErrUnknownUser
+	for _, a := range c {
+		user, err := a.Authenticate(ctx, username, password)
+		switch {
+		case err == nil:
+			return user, nil
+		case errors.Is(err, entity.ErrAuthUnavailable):
+			logging.FromContext(ctx).WithError(err).
+				Warn("authenticator unavailable")
+			result = err
+		case !errors.Is(err, entity.ErrUnknownUser):
+			return nil, err
+		}
+	}
+	return nil, result
+}
+
+// storeExternalUser creates or updates given user authenticated by
+// external store and returns stored user. The user is created only if
+// provision is true.
+//
+// Local account with the same name is never taken over: an error is
+// returned unless stored user has the same subject.
+func storeExternalUser(userRepo entity.Users,
+	mapped *entity.User, provision bool) (*entity.User, error) {
+	if mapped.Name == "" {
+		return nil, errors.New("user name is empty")
+	}
+	if strings.HasPrefix(mapped.Name, entity.GuestPrefix) {
+		return nil, fmt.Errorf("user name can not start with %s",
+			entity.GuestPrefix)
+	}
+	user, err := userRepo.Get(mapped.Name)
+	if err != nil {
+		if !provision {
+			return nil, fmt.Errorf("user %s is not registered", mapped.Name)
+		}
+		created := *mapped
+		created.Password = newUnusablePassword()
+		if err = userRepo.Create(&created); err != nil {
+			return nil, err
+		}
+		return &created, nil
+	}
+	var updated entity.User
+	err = userRepo.Modify(user.Name, func(user *entity.User) error {
+		if user.Subject != mapped.Subject {
+			return fmt.Errorf(
+				"user %s is not linked to identity provider", mapped.Name)
+		}
+		if user.Pending {
+			return fmt.Errorf("account %s is awaiting approval", user.Name)
+		}
+		user.Role = mapped.Role
+		if mapped.DisplayName != "" {
+			user.DisplayName = mapped.DisplayName
+		}
+		if mapped.Email != "" {
+			user.Email = mapped.Email
+		}
+		updated = *user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// newUnusablePassword returns random value that is not a password hash, so
+// provisioned user can log in only through its external store.
+func newUnusablePassword() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,122 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+// mockAuthenticator is a mock that imitates Authenticator behavior.
+// "ok" behavior returns directory user, "unknown" and "unavailable" return
+// corresponding errors.
+type mockAuthenticator struct {
+	behavior string
+	role     entity.UserRole
+}
+
+// Authenticate imitates Authenticator Authenticate method behavior
+// depending on one defined.
+func (a *mockAuthenticator) Authenticate(ctx context.Context,
+	username string, password string) (*entity.User, error) {
+	switch a.behavior {
+	case "ok":
+		return &entity.User{
+			Name:        username,
+			Role:        a.role,
+			DisplayName: "Directory User",
+			Subject:     "ldap://test uid=" + username,
+		}, nil
+	case "unknown":
+		return nil, entity.ErrUnknownUser
+	case "unavailable":
+		return nil, entity.ErrAuthUnavailable
+	}
+	return nil, errors.New("password incorrect")
+}
+
+func TestLocalAuthenticator_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	r := repository.NewUsersRepository()
+	r.Add("test user", "test password", 1)
+	r.Create(&entity.User{
+		Name: "external user", Password: "test password", Subject: "test"})
+	a := &LocalAuthenticator{UserRepo: r}
+
+	Convey("Returns local user", t, func() {
+		user, err := a.Authenticate(ctx, "test user", "test password")
+
+		So(err, ShouldBeNil)
+		So(user.Name, ShouldEqual, "test user")
+	})
+
+	Convey("Returns an error", t, func() {
+		_, err := a.Authenticate(ctx, "test user", "wrong password")
+		So(err.Error(), ShouldEqual, "password incorrect")
+
+		_, err = a.Authenticate(ctx, "wrong user", "test password")
+		So(err, ShouldEqual, entity.ErrUnknownUser)
+
+		Convey("for user of external store", func() {
+			_, err := a.Authenticate(ctx, "external user", "test password")
+			So(err, ShouldEqual, entity.ErrUnknownUser)
+		})
+	})
+}
+
+func TestChainAuthenticator_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	r := repository.NewUsersRepository()
+	r.Add("test user", "test password", 1)
+	local := &LocalAuthenticator{UserRepo: r}
+
+	Convey("Falls back to next authenticator", t, func() {
+		for _, behavior := range []string{"unknown", "unavailable"} {
+			c := ChainAuthenticator{
+				&mockAuthenticator{behavior: behavior}, local}
+			user, err := c.Authenticate(ctx, "test user", "test password")
+
+			So(err, ShouldBeNil)
+			So(user.Name, ShouldEqual, "test user")
+		}
+	})
+
+	Convey("Returns user of first authenticator", t, func() {
+		c := ChainAuthenticator{&mockAuthenticator{behavior: "ok"}, local}
+		user, err := c.Authenticate(ctx, "test user", "wrong password")
+
+		So(err, ShouldBeNil)
+		So(user.Subject, ShouldNotBeEmpty)
+	})
+
+	Convey("Returns an error", t, func() {
+		Convey("if password is rejected", func() {
+			c := ChainAuthenticator{
+				&mockAuthenticator{behavior: "failure"}, local}
+			_, err := c.Authenticate(ctx, "test user", "test password")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "password incorrect")
+		})
+
+		Convey("if nobody knows user", func() {
+			c := ChainAuthenticator{
+				&mockAuthenticator{behavior: "unknown"}, local}
+			_, err := c.Authenticate(ctx, "wrong user", "test password")
+
+			So(err, ShouldEqual, entity.ErrUnknownUser)
+		})
+
+		Convey("if authenticator is unavailable", func() {
+			c := ChainAuthenticator{
+				&mockAuthenticator{behavior: "unavailable"}, local}
+			_, err := c.Authenticate(ctx, "wrong user", "test password")
+
+			So(err, ShouldEqual, entity.ErrAuthUnavailable)
+		})
+	})
+}
@@ -0,0 +1,155 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// maxGuestNameLength is a maximal length of guest display name.
+const maxGuestNameLength = 32
+
+// Guest is an action that performs operations with anonymous guests of
+// OpenViDu sessions.
+type Guest struct {
+	GuestRepo   entity.Guests
+	SessionRepo entity.Sessions
+
+	// DefaultRole is a role of guests that join public session without
+	// invitation.
+	DefaultRole entity.UserRole
+
+	// JoinTimeout is a period after which guest that has not joined its
+	// session is removed. Guests are not expired if it is zero.
+	JoinTimeout time.Duration
+
+	InvitationAction interface {
+		Verify(token string) (*entity.Invitation, error)
+	}
+
+	// Now returns current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// Join registers new guest with given display name in session. Guest can
+// join the session if it is opened for guests or has an invitation.
+//
+// parameters:
+//  displayName string   The name chosen by guest.
+//  sessionName string   The name of session to join.
+//  token       string   Invitation token, may be empty.
+func (a *Guest) Join(
+	displayName string, sessionName string,
+	token string) (*entity.User, error) {
+	displayName = strings.TrimSpace(displayName)
+	if displayName == "" {
+		return nil, errors.New("guest name is empty")
+	}
+	if len(displayName) > maxGuestNameLength {
+		return nil, fmt.Errorf(
+			"guest name is longer than %d characters", maxGuestNameLength)
+	}
+
+	role := a.DefaultRole
+	if token != "" {
+		invitation, err := a.InvitationAction.Verify(token)
+		if err != nil {
+			return nil, err
+		}
+		sessionName = invitation.SessionName
+		role = invitation.Role
+	}
+
+	session, err := a.SessionRepo.Get(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" && !session.AllowGuests {
+		return nil, fmt.Errorf(
+			"session %s is not open for guests", sessionName)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	guest := entity.NewGuest(id[:8], displayName, sessionName, role)
+	guest.CreatedAt = a.now()
+	if err = a.GuestRepo.Add(guest); err != nil {
+		return nil, err
+	}
+	return guest.User, nil
+}
+
+// Get returns guest user by given user name.
+func (a *Guest) Get(name string) (*entity.User, error) {
+	guest, err := a.GuestRepo.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return guest.User, nil
+}
+
+// Expire removes guests that have not joined their session within
+// JoinTimeout. Guest is checked under the sessions repository lock, so guest
+// that joins at the moment is not removed.
+func (a *Guest) Expire() error {
+	if a.JoinTimeout <= 0 {
+		return nil
+	}
+	list, err := a.GuestRepo.List()
+	if err != nil {
+		return err
+	}
+	deadline := a.now().Add(-a.JoinTimeout)
+	for _, g := range list {
+		if g.CreatedAt.After(deadline) {
+			continue
+		}
+		name := g.User.Name
+		e := a.SessionRepo.Modify(g.SessionName,
+			func(session *entity.Session) error {
+				if _, ok := session.Subscribers[name]; !ok {
+					a.GuestRepo.Delete(name)
+				}
+				return nil
+			})
+		if e != nil {
+			// Session is gone, so guest can not join it anymore.
+			a.GuestRepo.Delete(name)
+		}
+	}
+	return nil
+}
+
+// Run removes expired guests with given interval until stop channel is
+// closed.
+func (a *Guest) Run(interval time.Duration, stop <-chan struct{}) {
+	ctx := context.Background()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.Expire(); err != nil {
+				logging.FromContext(ctx).WithError(err).
+					Error("failed to remove expired guests")
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// now returns current time.
+func (a *Guest) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
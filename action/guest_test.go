@@ -0,0 +1,174 @@
+package action
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+// invitationVerifierMock is a mock that imitates InvitationAction behavior.
+type invitationVerifierMock struct {
+	behavior string
+}
+
+// Verify imitates InvitationAction Verify method behavior depending on one
+// defined.
+func (a *invitationVerifierMock) Verify(
+	token string) (*entity.Invitation, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	return &entity.Invitation{SessionName: "test session name", Role: 1}, nil
+}
+
+// newGuestAction returns guest action with session owned by "test user".
+func newGuestAction(behavior string) *Guest {
+	a := &Guest{
+		GuestRepo:        repository.NewGuestsRepository(),
+		SessionRepo:      repository.NewSessionsRepository(),
+		InvitationAction: &invitationVerifierMock{behavior},
+	}
+	a.SessionRepo.Add("test session id", "test session name",
+		&entity.User{Name: "test user", Role: 1})
+	return a
+}
+
+func TestGuest_Join(t *testing.T) {
+	Convey("Joins guest to public session", t, func() {
+		a := newGuestAction("ok")
+		a.SessionRepo.Modify("test session name",
+			func(s *entity.Session) error {
+				s.AllowGuests = true
+				return nil
+			})
+		user, err := a.Join(" test guest ", "test session name", "")
+
+		So(err, ShouldBeNil)
+
+		Convey("with default role", func() {
+			So(user.Name, ShouldStartWith, "guest:test guest#")
+			So(user.DisplayName, ShouldEqual, "test guest")
+			So(user.Role, ShouldEqual, 0)
+			So(user.Guest, ShouldBeTrue)
+		})
+
+		Convey("Guest is stored in repository", func() {
+			g, err := a.GuestRepo.Get(user.Name)
+			So(err, ShouldBeNil)
+			So(g.SessionName, ShouldEqual, "test session name")
+		})
+
+		Convey("Guests with the same name do not collide", func() {
+			other, err := a.Join("test guest", "test session name", "")
+			So(err, ShouldBeNil)
+			So(other.Name, ShouldNotEqual, user.Name)
+		})
+	})
+
+	Convey("Joins guest by invitation", t, func() {
+		a := newGuestAction("ok")
+		user, err := a.Join("test guest", "", "test token")
+
+		So(err, ShouldBeNil)
+		So(user.Role, ShouldEqual, 1)
+	})
+
+	Convey("Returns an invitation error", t, func() {
+		a := newGuestAction("failure")
+		_, err := a.Join("test guest", "", "test token")
+
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns a private session error", t, func() {
+		a := newGuestAction("ok")
+		_, err := a.Join("test guest", "test session name", "")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"session test session name is not open for guests")
+	})
+
+	Convey("Returns a session error", t, func() {
+		a := newGuestAction("ok")
+		_, err := a.Join("test guest", "wrong session", "")
+
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns name errors", t, func() {
+		a := newGuestAction("ok")
+		_, err := a.Join(" ", "test session name", "")
+		So(err, ShouldNotBeNil)
+
+		_, err = a.Join(strings.Repeat("a", 33), "test session name", "")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestGuest_Get(t *testing.T) {
+	Convey("Returns guest user", t, func() {
+		a := newGuestAction("ok")
+		a.GuestRepo.Add(entity.NewGuest("1", "test guest", "test session name", 0))
+		user, err := a.Get("guest:test guest#1")
+
+		So(err, ShouldBeNil)
+		So(user.Name, ShouldEqual, "guest:test guest#1")
+
+		Convey("Returns an error", func() {
+			_, err := a.Get("guest:wrong")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGuest_Expire(t *testing.T) {
+	Convey("Removes guests that have not joined in time", t, func() {
+		now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		a := newGuestAction("ok")
+		a.JoinTimeout = 10 * time.Minute
+		a.Now = func() time.Time { return now }
+		for _, g := range []*entity.Guest{
+			entity.NewGuest("1", "joined", "test session name", 0),
+			entity.NewGuest("1", "late", "test session name", 0),
+			entity.NewGuest("1", "fresh", "test session name", 0),
+			entity.NewGuest("1", "lost", "closed session", 0),
+		} {
+			g.CreatedAt = now.Add(-time.Hour)
+			a.GuestRepo.Add(g)
+		}
+		fresh, _ := a.GuestRepo.Get("guest:fresh#1")
+		fresh.CreatedAt = now.Add(-time.Minute)
+		a.SessionRepo.Modify("test session name",
+			func(s *entity.Session) error {
+				s.Subscribers["guest:joined#1"] = &entity.User{
+					Name: "guest:joined#1", Guest: true}
+				return nil
+			})
+		err := a.Expire()
+
+		So(err, ShouldBeNil)
+		list, _ := a.GuestRepo.List()
+		So(list, ShouldHaveLength, 2)
+		_, err = a.GuestRepo.Get("guest:joined#1")
+		So(err, ShouldBeNil)
+		_, err = a.GuestRepo.Get("guest:fresh#1")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Keeps guests if timeout is not set", t, func() {
+		a := newGuestAction("ok")
+		a.GuestRepo.Add(entity.NewGuest("1", "test guest", "test session name", 0))
+		err := a.Expire()
+
+		So(err, ShouldBeNil)
+		list, _ := a.GuestRepo.List()
+		So(list, ShouldHaveLength, 1)
+	})
+}
@@ -0,0 +1,214 @@
+package action
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Invitation is an action that issues and verifies signed invitations to
+// OpenViDu sessions.
+type Invitation struct {
+	InvitationRepo entity.Invitations
+	SessionRepo    entity.Sessions
+
+	// Secret is a key used for signing of invitation tokens.
+	Secret []byte
+
+	// Now returns current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// invitationGrant is a payload of invitation token.
+type invitationGrant struct {
+	ID          string          `json:"id"`
+	SessionName string          `json:"session"`
+	SessionID   string          `json:"sessionId"`
+	Role        entity.UserRole `json:"role"`
+	MaxUses     int             `json:"maxUses"`
+	ExpiresAt   int64           `json:"exp"`
+}
+
+// Create issues new invitation to session with given name and returns its
+// signed token. Only the session owner can invite.
+//
+// parameters:
+//  sessionName string          The name of session to invite to.
+//  userName    string          Logged user name.
+//  role        entity.UserRole Role granted to invited user.
+//  maxUses     int             Number of uses, zero means unlimited.
+//  ttl         time.Duration   Period of invitation validity.
+func (a *Invitation) Create(
+	sessionName string, userName string, role entity.UserRole,
+	maxUses int, ttl time.Duration) (string, *entity.Invitation, error) {
+	session, err := a.SessionRepo.Get(sessionName)
+	if err != nil {
+		return "", nil, err
+	}
+	if session.Owner.Name != userName {
+		return "", nil, fmt.Errorf(
+			"user %s is not owner of session %s", userName, sessionName)
+	}
+	if role > session.Owner.Role {
+		return "", nil, fmt.Errorf(
+			"user %s can not grant role %s", userName, role)
+	}
+	if ttl <= 0 {
+		return "", nil, errors.New("invitation lifetime must be positive")
+	}
+	if maxUses < 0 {
+		return "", nil, errors.New("invitation uses can not be negative")
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", nil, err
+	}
+	invitation := &entity.Invitation{
+		ID:          id,
+		SessionName: sessionName,
+		SessionID:   session.ID,
+		Role:        role,
+		CreatedBy:   userName,
+		ExpiresAt:   a.now().Add(ttl),
+		MaxUses:     maxUses,
+	}
+	if err = a.InvitationRepo.Add(invitation); err != nil {
+		return "", nil, err
+	}
+	token, err := a.sign(invitation)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, invitation, nil
+}
+
+// Verify checks signature of given token and returns invitation if it can be
+// used now and its session is not closed. Use of invitation is spent when
+// invited user joins session, see Session action.
+func (a *Invitation) Verify(token string) (*entity.Invitation, error) {
+	grant, err := a.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := a.InvitationRepo.Get(grant.ID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.SessionName != grant.SessionName ||
+		invitation.SessionID != grant.SessionID ||
+		invitation.Role != grant.Role ||
+		invitation.MaxUses != grant.MaxUses ||
+		invitation.ExpiresAt.Unix() != grant.ExpiresAt {
+		return nil, errors.New("invitation token is invalid")
+	}
+	if err = invitation.Check(a.now()); err != nil {
+		return nil, err
+	}
+	session, err := a.SessionRepo.Get(invitation.SessionName)
+	if err != nil || session.ID != invitation.SessionID {
+		return nil, fmt.Errorf(
+			"session %s is closed", invitation.SessionName)
+	}
+	return invitation, nil
+}
+
+// Revoke revokes invitation with given ID. Only the session owner or the
+// invitation creator can revoke it.
+func (a *Invitation) Revoke(id string, userName string) error {
+	invitation, err := a.InvitationRepo.Get(id)
+	if err != nil {
+		return err
+	}
+	if invitation.CreatedBy != userName {
+		session, err := a.SessionRepo.Get(invitation.SessionName)
+		if err != nil || session.Owner.Name != userName {
+			return fmt.Errorf(
+				"user %s can not revoke invitation %s", userName, id)
+		}
+	}
+	return a.InvitationRepo.Revoke(id)
+}
+
+// List returns invitations to session with given name. Only the session
+// owner can list them.
+func (a *Invitation) List(
+	sessionName string, userName string) ([]*entity.Invitation, error) {
+	session, err := a.SessionRepo.Get(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	if session.Owner.Name != userName {
+		return nil, fmt.Errorf(
+			"user %s is not owner of session %s", userName, sessionName)
+	}
+	return a.InvitationRepo.List(sessionName)
+}
+
+// sign returns token of given invitation signed with HMAC-SHA256.
+func (a *Invitation) sign(invitation *entity.Invitation) (string, error) {
+	payload, err := json.Marshal(&invitationGrant{
+		ID:          invitation.ID,
+		SessionName: invitation.SessionName,
+		SessionID:   invitation.SessionID,
+		Role:        invitation.Role,
+		MaxUses:     invitation.MaxUses,
+		ExpiresAt:   invitation.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + a.signature(encoded), nil
+}
+
+// parse checks signature of given token and returns its payload.
+func (a *Invitation) parse(token string) (*invitationGrant, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 ||
+		!hmac.Equal([]byte(parts[1]), []byte(a.signature(parts[0]))) {
+		return nil, errors.New("invitation token is invalid")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("invitation token is invalid")
+	}
+	grant := &invitationGrant{}
+	if err = json.Unmarshal(payload, grant); err != nil {
+		return nil, errors.New("invitation token is invalid")
+	}
+	return grant, nil
+}
+
+// signature returns encoded HMAC-SHA256 signature of given data.
+func (a *Invitation) signature(data string) string {
+	mac := hmac.New(sha256.New, a.Secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// now returns current time.
+func (a *Invitation) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
+
+// newID returns new random identifier.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,204 @@
+package action
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+// newInvitationAction returns invitation action with session owned by
+// "test user" and clock stopped at returned time.
+func newInvitationAction() (*Invitation, *time.Time) {
+	now := time.Unix(1000, 0)
+	a := &Invitation{
+		InvitationRepo: repository.NewInvitationsRepository(),
+		SessionRepo:    repository.NewSessionsRepository(),
+		Secret:         []byte("test secret"),
+		Now:            func() time.Time { return now },
+	}
+	a.SessionRepo.Add("test session id", "test session name",
+		&entity.User{Name: "test user", Role: 1})
+	return a, &now
+}
+
+func TestInvitation_Create(t *testing.T) {
+	Convey("Creates new invitation", t, func() {
+		a, now := newInvitationAction()
+		token, i, err := a.Create(
+			"test session name", "test user", 0, 1, time.Hour)
+
+		So(err, ShouldBeNil)
+		So(token, ShouldNotBeEmpty)
+
+		Convey("with correct grant", func() {
+			So(i.SessionName, ShouldEqual, "test session name")
+			So(i.SessionID, ShouldEqual, "test session id")
+			So(i.Role, ShouldEqual, 0)
+			So(i.MaxUses, ShouldEqual, 1)
+			So(i.CreatedBy, ShouldEqual, "test user")
+			So(i.ExpiresAt, ShouldResemble, now.Add(time.Hour))
+		})
+
+		Convey("stored in repository", func() {
+			stored, err := a.InvitationRepo.Get(i.ID)
+			So(err, ShouldBeNil)
+			So(stored, ShouldEqual, i)
+		})
+	})
+
+	Convey("Returns a session error", t, func() {
+		a, _ := newInvitationAction()
+		_, _, err := a.Create("wrong session", "test user", 0, 1, time.Hour)
+
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns an owner error", t, func() {
+		a, _ := newInvitationAction()
+		_, _, err := a.Create(
+			"test session name", "other user", 0, 1, time.Hour)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"user other user is not owner of session test session name")
+	})
+
+	Convey("Returns a role error", t, func() {
+		a, _ := newInvitationAction()
+		_, _, err := a.Create(
+			"test session name", "test user", 2, 1, time.Hour)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"user test user can not grant role MODERATOR")
+	})
+
+	Convey("Returns a lifetime error", t, func() {
+		a, _ := newInvitationAction()
+		_, _, err := a.Create("test session name", "test user", 0, 1, 0)
+
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns an uses error", t, func() {
+		a, _ := newInvitationAction()
+		_, _, err := a.Create(
+			"test session name", "test user", 0, -1, time.Hour)
+
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestInvitation_Verify(t *testing.T) {
+	Convey("Returns invitation by token", t, func() {
+		a, now := newInvitationAction()
+		token, created, _ := a.Create(
+			"test session name", "test user", 1, 0, time.Hour)
+		i, err := a.Verify(token)
+
+		So(err, ShouldBeNil)
+		So(i, ShouldEqual, created)
+
+		Convey("Returns an expiration error", func() {
+			*now = now.Add(time.Hour)
+			_, err := a.Verify(token)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "invitation expired")
+		})
+
+		Convey("Returns a revocation error", func() {
+			a.Revoke(created.ID, "test user")
+			_, err := a.Verify(token)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "invitation revoked")
+		})
+
+		Convey("Returns a signature error", func() {
+			forged, _ := (&Invitation{Secret: []byte("wrong")}).sign(created)
+			_, err := a.Verify(forged)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"invitation token is invalid")
+		})
+
+		Convey("Returns a tampering error", func() {
+			created.Role = 0
+			_, err := a.Verify(token)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"invitation token is invalid")
+		})
+
+		Convey("Returns a closed session error", func() {
+			a.SessionRepo.Delete("test session name")
+			_, err := a.Verify(token)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"session test session name is closed")
+
+			a.SessionRepo.Add("other session id", "test session name",
+				&entity.User{Name: "other user", Role: 1})
+			_, err = a.Verify(token)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"session test session name is closed")
+		})
+
+		Convey("Returns a format error", func() {
+			_, err := a.Verify("wrong token")
+			So(err, ShouldNotBeNil)
+
+			_, err = a.Verify(strings.Replace(token, ".", "..", 1))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestInvitation_Revoke(t *testing.T) {
+	Convey("Revokes invitation", t, func() {
+		a, _ := newInvitationAction()
+		_, i, _ := a.Create("test session name", "test user", 0, 1, time.Hour)
+		err := a.Revoke(i.ID, "test user")
+
+		So(err, ShouldBeNil)
+		So(i.Revoked, ShouldBeTrue)
+
+		Convey("Returns a permission error", func() {
+			err := a.Revoke(i.ID, "other user")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"user other user can not revoke invitation")
+		})
+
+		Convey("Returns an invitation error", func() {
+			err := a.Revoke("wrong ID", "test user")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestInvitation_List(t *testing.T) {
+	Convey("Returns invitations of session", t, func() {
+		a, _ := newInvitationAction()
+		a.Create("test session name", "test user", 0, 1, time.Hour)
+		list, err := a.List("test session name", "test user")
+
+		So(err, ShouldBeNil)
+		So(list, ShouldHaveLength, 1)
+
+		Convey("Returns an owner error", func() {
+			_, err := a.List("test session name", "other user")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Returns a session error", func() {
+			_, err := a.List("wrong session", "test user")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -1,26 +1,271 @@
 package action
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
 
 	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+	"github.com/flexconstructor/openvidu-tutorial/metrics"
 )
 
 // Login is an action that performs authorization of user with login and
 // password.
 type Login struct {
 	UserRepo entity.Users
+
+	// Authenticator verifies credentials. Users it returns from external
+	// stores are created or updated in UserRepo. Local accounts of UserRepo
+	// are verified if nil.
+	Authenticator entity.Authenticator
+
+	// AttemptRepo stores failed login attempts by user name and client IP.
+	// Attempts are not limited if nil.
+	AttemptRepo entity.LoginAttempts
+
+	// MaxFailures is a number of consecutive failures after which account
+	// is locked. Zero disables lockout of accounts.
+	MaxFailures int
+
+	// MaxIPFailures is a number of consecutive failures after which client
+	// IP is locked. Zero disables lockout of client IPs.
+	MaxIPFailures int
+
+	// Lockout is a period of lockout.
+	Lockout time.Duration
+
+	// Delay is a time next attempt is refused after first failure. It
+	// doubles with every next failure.
+	Delay time.Duration
+
+	// AuditSink records logins, failures and lockouts. Audit log is
+	// disabled if nil.
+	AuditSink entity.AuditSink
+
+	// Now returns current time. time.Now is used if nil.
+	Now func() time.Time
 }
 
-// Do performs authorization action for given login and password.
-// To authorize user password must be correct.
-func (a *Login) Do(username string, password string) error {
-	user, err := a.UserRepo.Get(username)
+// Do performs authorization action for given login and password made from
+// given client IP.
+// To authorize user password must be correct, the user must be approved and
+// neither the user nor the IP must be locked out by previous failures.
+func (a *Login) Do(ctx context.Context,
+	ip string, username string, password string) error {
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user": username,
+		"ip":   ip,
+	})
+	if err := a.Begin(ip, username); err != nil {
+		log.WithError(err).Warn("login refused")
+		metrics.CountLogin(false)
+		a.audit(ctx, entity.AuditLoginFailed, ip, username, err)
+		return err
+	}
+	user, err := a.authenticator().Authenticate(ctx, username, password)
+	if err == nil && user.Subject != "" {
+		user, err = storeExternalUser(a.UserRepo, user, true)
+	}
 	if err != nil {
+		log.WithError(err).Warn("login failed")
+		metrics.CountLogin(false)
+		a.audit(ctx, entity.AuditLoginFailed, ip, username, err)
+		if errors.Is(err, entity.ErrAuthUnavailable) {
+			a.cancel(ip, username)
+		} else {
+			a.fail(ctx, log, ip, username)
+		}
+		return err
+	}
+	if user.Pending {
+		a.cancel(ip, username)
+		err = fmt.Errorf("account %s is awaiting approval", username)
+		log.WithError(err).Warn("login refused")
+		metrics.CountLogin(false)
+		a.audit(ctx, entity.AuditLoginFailed, ip, username, err)
 		return err
 	}
-	if user.Password != password {
-		return errors.New("password incorrect")
+	a.Succeed(ip, username)
+	log.Info("user logged in")
+	metrics.CountLogin(true)
+	a.audit(ctx, entity.AuditLogin, ip, username, nil)
+	return nil
+}
+
+// Unlock removes lockout and failures of user with given name on behalf of
+// user with given actor name, e.g. moderator or the user who reset password.
+func (a *Login) Unlock(
+	ctx context.Context, actorName string, username string) {
+	if a.AttemptRepo == nil {
+		return
+	}
+	a.AttemptRepo.Delete(userKey(username))
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":  username,
+		"actor": actorName,
+		"audit": true,
+	}).Info("account unlocked")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Time:   a.now(),
+		Action: entity.AuditUnlock,
+		Actor:  actorName,
+		Target: username,
+	})
+}
+
+// authenticator returns authenticator of credentials.
+func (a *Login) authenticator() entity.Authenticator {
+	if a.Authenticator != nil {
+		return a.Authenticator
+	}
+	return &LocalAuthenticator{UserRepo: a.UserRepo}
+}
+
+// Begin registers attempt of given user from given IP or returns an error
+// if it must be refused. Attempt is counted as failure until it is ended by
+// Succeed or Fail, so parallel attempts can not pass the limits.
+func (a *Login) Begin(ip string, username string) error {
+	if a.AttemptRepo == nil {
+		return nil
+	}
+	now := a.now()
+	keys := attemptKeys(ip, username)
+	for i, key := range keys {
+		limit := a.limit(key, username)
+		err := a.AttemptRepo.Update(key, now,
+			func(f *entity.LoginFailures) error {
+				return f.Begin(now, limit, a.Delay, a.Lockout)
+			})
+		if err != nil {
+			a.undo(now, keys[:i])
+			return err
+		}
 	}
 	return nil
 }
+
+// Succeed ends successful attempt of given user from given IP: failures of
+// the user are forgotten and the attempt is not counted for the IP.
+func (a *Login) Succeed(ip string, username string) {
+	if a.AttemptRepo == nil {
+		return
+	}
+	a.AttemptRepo.Delete(userKey(username))
+	a.undo(a.now(), attemptKeys(ip, username)[1:])
+}
+
+// Fail ends failed attempt of given user from given IP made in other login
+// step, e.g. wrong one-time password.
+func (a *Login) Fail(ctx context.Context, ip string, username string) {
+	a.fail(ctx, logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user": username,
+		"ip":   ip,
+	}), ip, username)
+}
+
+// cancel ends attempt of given user from given IP that neither succeeded
+// nor failed, e.g. when authenticator is unavailable.
+func (a *Login) cancel(ip string, username string) {
+	if a.AttemptRepo == nil {
+		return
+	}
+	a.undo(a.now(), attemptKeys(ip, username))
+}
+
+// undo uncounts attempts registered by Begin by given keys.
+func (a *Login) undo(now time.Time, keys []string) {
+	for _, key := range keys {
+		_ = a.AttemptRepo.Update(key, now, func(f *entity.LoginFailures) error {
+			f.Undo()
+			return nil
+		})
+	}
+}
+
+// fail ends failed attempt of given user from given IP and writes audit
+// record of every lockout it causes.
+func (a *Login) fail(ctx context.Context,
+	log *logrus.Entry, ip string, username string) {
+	if a.AttemptRepo == nil {
+		return
+	}
+	now := a.now()
+	for _, key := range attemptKeys(ip, username) {
+		limit := a.limit(key, username)
+		msg, target := "account locked", username
+		if key != userKey(username) {
+			msg, target = "client IP locked", ip
+		}
+		var locked entity.LoginFailures
+		_ = a.AttemptRepo.Update(key, now, func(f *entity.LoginFailures) error {
+			if f.Lock(now, limit, a.Lockout) {
+				locked = *f
+			}
+			return nil
+		})
+		if locked.LockedUntil.IsZero() {
+			continue
+		}
+		log.WithFields(logrus.Fields{
+			"audit":        true,
+			"failures":     locked.Count,
+			"locked_until": locked.LockedUntil,
+		}).Warn(msg)
+		audit(ctx, a.AuditSink, entity.AuditEvent{
+			Time:   now,
+			Action: entity.AuditLockout,
+			Actor:  username,
+			Target: target,
+			IP:     ip,
+			Details: fmt.Sprintf("%s after %d failures until %s", msg,
+				locked.Count, locked.LockedUntil.Format(time.RFC3339)),
+		})
+	}
+}
+
+// limit returns max number of failures by given key of attempts of user
+// with given name.
+func (a *Login) limit(key string, username string) int {
+	if key == userKey(username) {
+		return a.MaxFailures
+	}
+	return a.MaxIPFailures
+}
+
+// audit records event of given user made from given IP with given error.
+func (a *Login) audit(ctx context.Context, action entity.AuditAction,
+	ip string, username string, err error) {
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Time:   a.now(),
+		Action: action,
+		Actor:  username,
+		IP:     ip,
+		Error:  errorText(err),
+	})
+}
+
+// now returns current time.
+func (a *Login) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
+
+// attemptKeys returns keys of login attempts made by given user from given
+// IP.
+func attemptKeys(ip string, username string) []string {
+	if ip == "" {
+		return []string{userKey(username)}
+	}
+	return []string{userKey(username), "ip:" + ip}
+}
+
+// userKey returns key of login attempts made by given user.
+func userKey(username string) string {
+	return "user:" + username
+}
@@ -1,35 +1,235 @@
 package action
 
 import (
+	"context"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/bcrypt"
 
+	"github.com/flexconstructor/openvidu-tutorial/entity"
 	"github.com/flexconstructor/openvidu-tutorial/repository"
 )
 
+func init() {
+	// Minimal cost keeps tests that hash passwords fast.
+	entity.PasswordCost = bcrypt.MinCost
+}
+
 func TestLogin_Do(t *testing.T) {
 	r := repository.NewUsersRepository()
 	r.Add("test login", "test password", 1)
 	a := &Login{UserRepo: r}
 
 	Convey("Returns no error", t, func() {
-		err := a.Do("test login", "test password")
+		err := a.Do(context.Background(),
+			"127.0.0.1", "test login", "test password")
 
 		So(err, ShouldBeNil)
 	})
 
 	Convey("Returns login error", t, func() {
-		err := a.Do("wrong login", "test password")
+		err := a.Do(context.Background(),
+			"127.0.0.1", "wrong login", "test password")
 
 		So(err, ShouldNotBeNil)
 		So(err.Error(), ShouldContainSubstring, "login incorrect")
 	})
 
+	Convey("Returns error for pending user", t, func() {
+		pending := &entity.User{Name: "pending login", Pending: true}
+		pending.SetPassword("test password")
+		r.Create(pending)
+		err := a.Do(context.Background(),
+			"127.0.0.1", "pending login", "test password")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "account pending login is awaiting approval")
+	})
+
 	Convey("Returns password error", t, func() {
-		err := a.Do("test login", "wrong password")
+		err := a.Do(context.Background(),
+			"127.0.0.1", "test login", "wrong password")
 
 		So(err, ShouldNotBeNil)
 		So(err.Error(), ShouldContainSubstring, "password incorrect")
 	})
 }
+
+func TestLogin_Do_Audit(t *testing.T) {
+	r := repository.NewUsersRepository()
+	r.Add("test login", "test password", 1)
+	ctx := context.Background()
+
+	Convey("Records logins and failures", t, func() {
+		sink := &auditSinkMock{behavior: "ok"}
+		a := &Login{UserRepo: r, AuditSink: sink}
+		a.Do(ctx, "127.0.0.1", "test login", "test password")
+		a.Do(ctx, "127.0.0.1", "test login", "wrong password")
+
+		So(sink.actions(), ShouldResemble, []entity.AuditAction{
+			entity.AuditLogin, entity.AuditLoginFailed,
+		})
+		So(sink.events[0].Actor, ShouldEqual, "test login")
+		So(sink.events[0].IP, ShouldEqual, "127.0.0.1")
+		So(sink.events[1].Error, ShouldContainSubstring, "password incorrect")
+	})
+
+	Convey("Records lockouts and unlocks", t, func() {
+		sink := &auditSinkMock{behavior: "ok"}
+		a := &Login{
+			UserRepo:    r,
+			AttemptRepo: repository.NewAttemptsRepository(time.Minute),
+			MaxFailures: 1,
+			Lockout:     time.Minute,
+			AuditSink:   sink,
+		}
+		a.Do(ctx, "127.0.0.1", "test login", "wrong password")
+		a.Unlock(ctx, "moderator", "test login")
+
+		So(sink.actions(), ShouldResemble, []entity.AuditAction{
+			entity.AuditLoginFailed, entity.AuditLockout, entity.AuditUnlock,
+		})
+		So(sink.events[1].Target, ShouldEqual, "test login")
+		So(sink.events[2].Actor, ShouldEqual, "moderator")
+		So(sink.events[2].Target, ShouldEqual, "test login")
+	})
+}
+
+func TestLogin_Do_Authenticator(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Stores user of external store", t, func() {
+		r := repository.NewUsersRepository()
+		auth := &mockAuthenticator{behavior: "ok", role: entity.Publisher}
+		a := &Login{UserRepo: r, Authenticator: auth}
+		err := a.Do(ctx, "127.0.0.1", "directory user", "test password")
+
+		So(err, ShouldBeNil)
+		user, _ := r.Get("directory user")
+		So(user.Role, ShouldEqual, entity.Publisher)
+		So(user.DisplayName, ShouldEqual, "Directory User")
+
+		Convey("Updates its role on next login", func() {
+			auth.role = entity.Moderator
+			err := a.Do(ctx, "127.0.0.1", "directory user", "test password")
+
+			So(err, ShouldBeNil)
+			user, _ := r.Get("directory user")
+			So(user.Role, ShouldEqual, entity.Moderator)
+		})
+	})
+
+	Convey("Does not count unavailable authenticator as failure", t, func() {
+		attempts := repository.NewAttemptsRepository(time.Minute)
+		a := &Login{
+			UserRepo:      repository.NewUsersRepository(),
+			Authenticator: &mockAuthenticator{behavior: "unavailable"},
+			AttemptRepo:   attempts,
+			MaxFailures:   1,
+		}
+		err := a.Do(ctx, "127.0.0.1", "directory user", "test password")
+
+		So(err, ShouldEqual, entity.ErrAuthUnavailable)
+		So(attempts.Get(userKey("directory user")).Count, ShouldEqual, 0)
+	})
+}
+
+func TestLogin_Do_Throttling(t *testing.T) {
+	r := repository.NewUsersRepository()
+	r.Add("test login", "test password", 1)
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	newLogin := func() *Login {
+		return &Login{
+			UserRepo:      r,
+			AttemptRepo:   repository.NewAttemptsRepository(time.Minute),
+			MaxFailures:   3,
+			MaxIPFailures: 5,
+			Lockout:       time.Minute,
+			Delay:         time.Second,
+			Now:           func() time.Time { return now },
+		}
+	}
+	ctx := context.Background()
+
+	Convey("Refuses attempt until delay after failure passes", t, func() {
+		a := newLogin()
+		a.Do(ctx, "127.0.0.1", "test login", "wrong password")
+		err := a.Do(ctx, "127.0.0.1", "test login", "test password")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"too many failed login attempts")
+
+		Convey("Accepts attempt after delay", func() {
+			a.Now = func() time.Time { return now.Add(time.Second) }
+
+			So(a.Do(ctx, "127.0.0.1", "test login", "test password"),
+				ShouldBeNil)
+			So(a.AttemptRepo.Get("user:test login").Count, ShouldEqual, 0)
+		})
+	})
+
+	Convey("Locks account after max failures", t, func() {
+		a := newLogin()
+		for i := 0; i < 3; i++ {
+			at := now.Add(time.Duration(i) * 10 * time.Second)
+			a.Now = func() time.Time { return at }
+			a.Do(ctx, "127.0.0.1", "test login", "wrong password")
+		}
+		a.Now = func() time.Time { return now.Add(40 * time.Second) }
+
+		So(a.Do(ctx, "10.0.0.1", "test login", "test password"),
+			ShouldNotBeNil)
+
+		Convey("Unlocks account after lockout", func() {
+			a.Now = func() time.Time { return now.Add(90 * time.Second) }
+
+			So(a.Do(ctx, "10.0.0.1", "test login", "test password"),
+				ShouldBeNil)
+		})
+
+		Convey("Unlocks account on demand", func() {
+			a.Unlock(ctx, "moderator", "test login")
+
+			So(a.Do(ctx, "10.0.0.1", "test login", "test password"),
+				ShouldBeNil)
+		})
+	})
+
+	Convey("Locks client IP after max failures", t, func() {
+		a := newLogin()
+		for i := 0; i < 5; i++ {
+			at := now.Add(time.Duration(i) * 10 * time.Second)
+			a.Now = func() time.Time { return at }
+			a.Do(ctx, "127.0.0.1", "other login "+strconv.Itoa(i),
+				"wrong password")
+		}
+		a.Now = func() time.Time { return now.Add(50 * time.Second) }
+
+		So(a.Do(ctx, "127.0.0.1", "test login", "test password"),
+			ShouldNotBeNil)
+		So(a.Do(ctx, "10.0.0.1", "test login", "test password"),
+			ShouldBeNil)
+	})
+	Convey("Does not let parallel attempts pass the limit", t, func() {
+		a := newLogin()
+		a.Delay = 0
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.Do(ctx, "127.0.0.1", "test login", "wrong password")
+			}()
+		}
+		wg.Wait()
+
+		f := a.AttemptRepo.Get("user:test login")
+		So(f.Count, ShouldEqual, 3)
+		So(f.LockedUntil, ShouldResemble, now.Add(time.Minute))
+	})
+}
@@ -0,0 +1,232 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// Meeting is an action that schedules OpenViDu sessions in advance and
+// closes them when they are over.
+type Meeting struct {
+	MeetingRepo entity.Meetings
+	SessionRepo entity.Sessions
+	UserRepo    entity.Users
+
+	// GuestRepo stores guests of sessions. Guests of closed meetings are
+	// removed if it is not nil.
+	GuestRepo entity.Guests
+
+	// OpenViDu closes sessions on OpenViDu server.
+	OpenViDu interface {
+		CloseSession(ctx context.Context, sessionID string) error
+	}
+
+	// AuditSink records failed close of meeting sessions; successful close
+	// is recorded by AuditSessions subscriber of Events. Audit log is
+	// disabled if nil.
+	AuditSink entity.AuditSink
+
+	// Events receives close of meeting sessions. Events are not published
+	// if nil.
+	Events entity.SessionEvents
+
+	// Now returns current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// Schedule adds new meeting. Only publishers can schedule meetings.
+//
+// parameters:
+//  userName    string     Logged user name.
+//  sessionName string     The name of session of meeting.
+//  title       string     Title of meeting.
+//  start       time.Time  Start of meeting in its time zone.
+//  end         time.Time  End of meeting.
+//  invited     []string   Names of users invited to meeting.
+func (a *Meeting) Schedule(userName string, sessionName string, title string,
+	start time.Time, end time.Time, invited []string) (*entity.Meeting, error) {
+	user, err := a.UserRepo.Get(userName)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role < 1 {
+		return nil, fmt.Errorf("user %s can not schedule meetings", userName)
+	}
+	if sessionName == "" {
+		return nil, errors.New("session name is empty")
+	}
+	if !end.After(start) {
+		return nil, errors.New("meeting end must be after its start")
+	}
+	if !end.After(a.now()) {
+		return nil, errors.New("meeting end is in the past")
+	}
+	if _, err = a.SessionRepo.Get(sessionName); err == nil {
+		return nil, fmt.Errorf("session %s already exists", sessionName)
+	}
+
+	meeting := &entity.Meeting{
+		SessionName: sessionName,
+		Title:       title,
+		Owner:       user,
+		Start:       start,
+		End:         end,
+		Location:    start.Location(),
+		Invited:     invited,
+	}
+	if err = a.MeetingRepo.Add(meeting); err != nil {
+		return nil, err
+	}
+	return meeting, nil
+}
+
+// List returns meetings that user with given name owns or is invited to.
+func (a *Meeting) List(userName string) ([]*entity.Meeting, error) {
+	all, err := a.MeetingRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*entity.Meeting, 0, len(all))
+	for _, m := range all {
+		if m.IsInvited(userName) {
+			list = append(list, m)
+		}
+	}
+	return list, nil
+}
+
+// Cancel removes meeting and closes its session. Only the meeting owner can
+// cancel it.
+func (a *Meeting) Cancel(
+	ctx context.Context, sessionName string, userName string) error {
+	meeting, err := a.MeetingRepo.Get(sessionName)
+	if err != nil {
+		return err
+	}
+	if meeting.Owner.Name != userName {
+		return fmt.Errorf(
+			"user %s is not owner of meeting %s", userName, sessionName)
+	}
+	return a.close(ctx, meeting, userName)
+}
+
+// CloseExpired closes all meetings which end time has passed.
+func (a *Meeting) CloseExpired(ctx context.Context) error {
+	list, err := a.MeetingRepo.List()
+	if err != nil {
+		return err
+	}
+	now := a.now()
+	for _, m := range list {
+		if now.Before(m.End) {
+			continue
+		}
+		if e := a.close(ctx, m, ""); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Run closes expired meetings with given interval until stop channel is
+// closed.
+func (a *Meeting) Run(interval time.Duration, stop <-chan struct{}) {
+	ctx := context.Background()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.CloseExpired(ctx); err != nil {
+				logging.FromContext(ctx).WithError(err).
+					Error("failed to close expired meetings")
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// close closes session of meeting on OpenViDu server if it is started and
+// removes meeting from repository. Meeting is kept if its session fails to
+// close, so closing of expired meeting is retried. Given user name is empty
+// if meeting is closed at its end.
+func (a *Meeting) close(ctx context.Context,
+	meeting *entity.Meeting, userName string) error {
+	log := logging.FromContext(ctx).WithField("session", meeting.SessionName)
+	session, err := a.SessionRepo.Get(meeting.SessionName)
+	if err == nil {
+		if err = a.closeSession(ctx, session, userName); err != nil {
+			log.WithError(err).Error("failed to close meeting session")
+			a.audit(ctx, meeting.SessionName, userName, err)
+			return err
+		}
+	}
+	if err = a.MeetingRepo.Delete(meeting.SessionName); err != nil {
+		return err
+	}
+	log.Info("meeting closed")
+	return nil
+}
+
+// closeSession closes given session of meeting on OpenViDu server and
+// removes it from repository along with its guests.
+func (a *Meeting) closeSession(ctx context.Context,
+	session *entity.Session, userName string) error {
+	if err := a.OpenViDu.CloseSession(ctx, session.ID); err != nil {
+		return err
+	}
+	if err := a.SessionRepo.Delete(session.Name); err != nil {
+		return err
+	}
+	if a.Events != nil {
+		a.Events.Publish(ctx, &entity.SessionEvent{
+			Type:        entity.SessionClosed,
+			Time:        a.now(),
+			SessionID:   session.ID,
+			SessionName: session.Name,
+			User:        userName,
+			Details:     closeDetails(userName),
+		})
+	}
+	if a.GuestRepo != nil {
+		return a.GuestRepo.DeleteBySession(session.Name)
+	}
+	return nil
+}
+
+// audit records failed close of meeting session with given name by user
+// with given name, or at the end of meeting if name is empty.
+func (a *Meeting) audit(ctx context.Context,
+	sessionName string, userName string, err error) {
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Time:    a.now(),
+		Action:  entity.AuditSessionClose,
+		Actor:   userName,
+		Session: sessionName,
+		Details: closeDetails(userName),
+		Error:   errorText(err),
+	})
+}
+
+// closeDetails describes close of meeting session by user with given name,
+// or at the end of meeting if name is empty.
+func closeDetails(userName string) string {
+	if userName == "" {
+		return "meeting ended"
+	}
+	return "meeting canceled"
+}
+
+// now returns current time.
+func (a *Meeting) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,229 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+var meetingTestNow = time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func TestMeeting_Schedule(t *testing.T) {
+	Convey("Schedules new meeting", t, func() {
+		a := newMeetingAction("ok")
+		start := meetingTestNow.Add(time.Hour)
+		m, err := a.Schedule("test publisher", "test meeting", "test title",
+			start, start.Add(time.Hour), []string{"test subscriber"})
+
+		So(err, ShouldBeNil)
+		So(m.Owner.Name, ShouldEqual, "test publisher")
+		So(m.Location, ShouldEqual, time.UTC)
+		stored, _ := a.MeetingRepo.Get("test meeting")
+		So(stored, ShouldEqual, m)
+
+		Convey("Returns an error if meeting already exists", func() {
+			_, err := a.Schedule("test publisher", "test meeting", "",
+				start, start.Add(time.Hour), nil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Returns an error", t, func() {
+		a := newMeetingAction("ok")
+		start := meetingTestNow.Add(time.Hour)
+
+		Convey("If user is not a publisher", func() {
+			_, err := a.Schedule("test subscriber", "test meeting", "",
+				start, start.Add(time.Hour), nil)
+			So(err.Error(), ShouldContainSubstring,
+				"user test subscriber can not schedule meetings")
+		})
+
+		Convey("If session name is empty", func() {
+			_, err := a.Schedule("test publisher", "", "",
+				start, start.Add(time.Hour), nil)
+			So(err.Error(), ShouldContainSubstring, "session name is empty")
+		})
+
+		Convey("If end is before start", func() {
+			_, err := a.Schedule("test publisher", "test meeting", "",
+				start, start, nil)
+			So(err.Error(), ShouldContainSubstring,
+				"meeting end must be after its start")
+		})
+
+		Convey("If end is in the past", func() {
+			_, err := a.Schedule("test publisher", "test meeting", "",
+				start.Add(-3*time.Hour), start.Add(-2*time.Hour), nil)
+			So(err.Error(), ShouldContainSubstring, "meeting end is in the past")
+		})
+
+		Convey("If session is already started", func() {
+			a.SessionRepo.Add("test id", "test meeting",
+				&entity.User{Name: "test publisher"})
+			_, err := a.Schedule("test publisher", "test meeting", "",
+				start, start.Add(time.Hour), nil)
+			So(err.Error(), ShouldContainSubstring,
+				"session test meeting already exists")
+		})
+	})
+}
+
+func TestMeeting_List(t *testing.T) {
+	Convey("Returns meetings of user", t, func() {
+		a := newMeetingAction("ok")
+		start := meetingTestNow.Add(time.Hour)
+		a.Schedule("test publisher", "first", "", start, start.Add(time.Hour),
+			[]string{"test subscriber"})
+		a.Schedule("test publisher", "second", "", start, start.Add(time.Hour),
+			nil)
+
+		list, err := a.List("test subscriber")
+		So(err, ShouldBeNil)
+		So(list, ShouldHaveLength, 1)
+		So(list[0].SessionName, ShouldEqual, "first")
+
+		list, _ = a.List("test publisher")
+		So(list, ShouldHaveLength, 2)
+	})
+}
+
+func TestMeeting_Cancel(t *testing.T) {
+	Convey("Cancels meeting and closes its session", t, func() {
+		a := newMeetingAction("ok")
+		events := &sessionEventsMock{}
+		a.Events = events
+		start := meetingTestNow.Add(-time.Minute)
+		a.Schedule("test publisher", "test meeting", "", start,
+			start.Add(time.Hour), nil)
+		a.SessionRepo.Add("test id", "test meeting",
+			&entity.User{Name: "test publisher"})
+
+		err := a.Cancel(context.Background(), "test meeting", "test publisher")
+		So(err, ShouldBeNil)
+		_, err = a.MeetingRepo.Get("test meeting")
+		So(err, ShouldNotBeNil)
+		_, err = a.SessionRepo.Get("test meeting")
+		So(err, ShouldNotBeNil)
+		So(a.OpenViDu.(*sessionCloserMock).closed, ShouldResemble,
+			[]string{"test id"})
+		So(events.types(), ShouldResemble,
+			[]entity.SessionEventType{entity.SessionClosed})
+	})
+
+	Convey("Returns an error if user is not owner", t, func() {
+		a := newMeetingAction("ok")
+		start := meetingTestNow.Add(time.Hour)
+		a.Schedule("test publisher", "test meeting", "", start,
+			start.Add(time.Hour), nil)
+
+		err := a.Cancel(context.Background(), "test meeting", "test subscriber")
+		So(err.Error(), ShouldContainSubstring,
+			"user test subscriber is not owner of meeting test meeting")
+	})
+}
+
+func TestMeeting_CloseExpired(t *testing.T) {
+	Convey("Closes expired meetings only", t, func() {
+		a := newMeetingAction("ok")
+		a.Schedule("test publisher", "expired", "",
+			meetingTestNow.Add(-time.Minute), meetingTestNow.Add(time.Minute),
+			nil)
+		a.Schedule("test publisher", "active", "",
+			meetingTestNow, meetingTestNow.Add(time.Hour), nil)
+		a.SessionRepo.Add("test id", "expired",
+			&entity.User{Name: "test publisher"})
+		a.Now = func() time.Time {
+			return meetingTestNow.Add(time.Minute)
+		}
+
+		err := a.CloseExpired(context.Background())
+		So(err, ShouldBeNil)
+		list, _ := a.MeetingRepo.List()
+		So(list, ShouldHaveLength, 1)
+		So(list[0].SessionName, ShouldEqual, "active")
+		_, err = a.SessionRepo.Get("expired")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Audits close of expired meeting", t, func() {
+		a := newMeetingAction("ok")
+		sink := &auditSinkMock{behavior: "ok"}
+		a.AuditSink = sink
+		a.Events = sessionEventsFunc(AuditSessions(sink))
+		a.Schedule("test publisher", "expired", "",
+			meetingTestNow, meetingTestNow.Add(time.Minute), nil)
+		a.SessionRepo.Add("test id", "expired",
+			&entity.User{Name: "test publisher"})
+		a.Now = func() time.Time {
+			return meetingTestNow.Add(time.Hour)
+		}
+
+		So(a.CloseExpired(context.Background()), ShouldBeNil)
+		So(sink.actions(), ShouldResemble,
+			[]entity.AuditAction{entity.AuditSessionClose})
+		So(sink.events[0].Actor, ShouldBeEmpty)
+		So(sink.events[0].Session, ShouldEqual, "expired")
+		So(sink.events[0].Details, ShouldEqual, "meeting ended")
+	})
+
+	Convey("Returns OpenViDu error and keeps meeting", t, func() {
+		a := newMeetingAction("failure")
+		sink := &auditSinkMock{behavior: "ok"}
+		a.AuditSink = sink
+		a.Schedule("test publisher", "expired", "",
+			meetingTestNow, meetingTestNow.Add(time.Minute), nil)
+		a.SessionRepo.Add("test id", "expired",
+			&entity.User{Name: "test publisher"})
+		a.Now = func() time.Time {
+			return meetingTestNow.Add(time.Hour)
+		}
+
+		err := a.CloseExpired(context.Background())
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "test error")
+		_, err = a.MeetingRepo.Get("expired")
+		So(err, ShouldBeNil)
+		_, err = a.SessionRepo.Get("expired")
+		So(err, ShouldBeNil)
+		So(sink.events, ShouldHaveLength, 1)
+		So(sink.events[0].Error, ShouldContainSubstring, "test error")
+	})
+}
+
+// newMeetingAction returns meeting action with fixed clock.
+func newMeetingAction(behavior string) *Meeting {
+	users := repository.NewUsersRepository()
+	users.Add("test publisher", "test password", 1)
+	users.Add("test subscriber", "test password", 0)
+	return &Meeting{
+		MeetingRepo: repository.NewMeetingsRepository(),
+		SessionRepo: repository.NewSessionsRepository(),
+		UserRepo:    users,
+		GuestRepo:   repository.NewGuestsRepository(),
+		OpenViDu:    &sessionCloserMock{behavior: behavior},
+		Now: func() time.Time {
+			return meetingTestNow
+		},
+	}
+}
+
+type sessionCloserMock struct {
+	behavior string
+	closed   []string
+}
+
+func (m *sessionCloserMock) CloseSession(
+	ctx context.Context, sessionID string) error {
+	if m.behavior == "failure" {
+		return errors.New("test error")
+	}
+	m.closed = append(m.closed, sessionID)
+	return nil
+}
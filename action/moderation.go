@@ -0,0 +1,307 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// Moderation is an action that lets moderators oversee users and active
+// sessions: disconnect participants, stop their streams, close sessions
+// and change roles of users.
+type Moderation struct {
+	UserRepo    entity.Users
+	SessionRepo entity.Sessions
+
+	// Registry closes sessions on OpenViDu server. Its events record
+	// closes to audit log.
+	Registry interface {
+		Close(ctx context.Context,
+			sessionName string, userName string, details string) error
+	}
+
+	// OpenViDu manages participants of sessions on OpenViDu server.
+	OpenViDu interface {
+		Connections(ctx context.Context,
+			sessionID string) ([]*entity.Connection, error)
+		Disconnect(ctx context.Context,
+			sessionID string, connectionID string) error
+		Unpublish(ctx context.Context,
+			sessionID string, streamID string) error
+	}
+
+	// SessionAction removes kicked participants from sessions, so their
+	// leave is audited and published as if they left themselves.
+	SessionAction interface {
+		Delete(ctx context.Context, sessionName string, userName string) error
+	}
+
+	// LoginAction unlocks accounts locked by failed logins. Accounts can not
+	// be unlocked by moderators if it is nil.
+	LoginAction interface {
+		Unlock(ctx context.Context, actorName string, username string)
+	}
+
+	// AuditSink records changes made by moderators and is queried by them.
+	// Audit log is disabled if nil.
+	AuditSink entity.AuditSink
+}
+
+// Users returns all users sorted by name.
+func (a *Moderation) Users(moderatorName string) ([]*entity.User, error) {
+	if err := a.checkModerator(moderatorName); err != nil {
+		return nil, err
+	}
+	return a.UserRepo.List()
+}
+
+// Sessions returns all active sessions.
+func (a *Moderation) Sessions(
+	moderatorName string) ([]*entity.Session, error) {
+	if err := a.checkModerator(moderatorName); err != nil {
+		return nil, err
+	}
+	list, _, err := a.SessionRepo.List(entity.SessionFilter{})
+	return list, err
+}
+
+// Session returns active session with given name and participants
+// connected to it on OpenViDu server.
+func (a *Moderation) Session(ctx context.Context, moderatorName string,
+	sessionName string) (*entity.Session, []*entity.Connection, error) {
+	if err := a.checkModerator(moderatorName); err != nil {
+		return nil, nil, err
+	}
+	session, err := a.SessionRepo.Get(sessionName)
+	if err != nil {
+		return nil, nil, err
+	}
+	connections, err := a.OpenViDu.Connections(ctx, session.ID)
+	if err != nil {
+		return session, nil, err
+	}
+	return session, connections, nil
+}
+
+// Kick disconnects participant with given connection ID from session with
+// given name and removes user the connection belongs to from the session.
+// User is told by login name given in server data of connection, as
+// nickname may differ from it. Owner can not be kicked, as its session would
+// be left without owner; the session is closed instead.
+//
+// parameters:
+//  ctx            context.Context  Context of request.
+//  moderatorName  string           Logged moderator name.
+//  sessionName    string           Name of session.
+//  connectionID   string           OpenViDu connection of participant.
+func (a *Moderation) Kick(ctx context.Context, moderatorName string,
+	sessionName string, connectionID string) error {
+	session, err := a.session(moderatorName, sessionName)
+	if err != nil {
+		return err
+	}
+	if connectionID == "" {
+		return errors.New("connection is not given")
+	}
+	connection, err := a.connection(ctx, session, connectionID)
+	if err != nil {
+		return err
+	}
+	userName := connection.UserName
+	if userName != "" && userName == session.Owner.Name {
+		return fmt.Errorf("owner of session %s can not be kicked, "+
+			"close the session instead", sessionName)
+	}
+	if err = a.OpenViDu.Disconnect(ctx, session.ID, connectionID); err != nil {
+		return err
+	}
+	details := connection.Participant
+	if userName != "" {
+		details = userName
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"moderator":   moderatorName,
+		"session":     sessionName,
+		"connection":  connectionID,
+		"participant": connection.Participant,
+		"user":        userName,
+		"audit":       true,
+	}).Info("participant kicked")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action:  entity.AuditKick,
+		Actor:   moderatorName,
+		Session: sessionName,
+		Target:  connectionID,
+		Details: details,
+	})
+	if _, ok := session.Subscribers[userName]; !ok {
+		return nil
+	}
+	return a.SessionAction.Delete(ctx, sessionName, userName)
+}
+
+// connection returns connection with given ID to given session on OpenViDu
+// server.
+func (a *Moderation) connection(ctx context.Context,
+	session *entity.Session, connectionID string) (*entity.Connection, error) {
+	connections, err := a.OpenViDu.Connections(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range connections {
+		if c.ID == connectionID {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("connection %s is not found in session %s",
+		connectionID, session.Name)
+}
+
+// Unpublish stops media stream with given ID published to session with
+// given name. Its publisher stays in session.
+//
+// parameters:
+//  ctx            context.Context  Context of request.
+//  moderatorName  string           Logged moderator name.
+//  sessionName    string           Name of session.
+//  streamID       string           OpenViDu stream of publisher.
+func (a *Moderation) Unpublish(ctx context.Context, moderatorName string,
+	sessionName string, streamID string) error {
+	session, err := a.session(moderatorName, sessionName)
+	if err != nil {
+		return err
+	}
+	if streamID == "" {
+		return errors.New("stream is not given")
+	}
+	if err = a.OpenViDu.Unpublish(ctx, session.ID, streamID); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"moderator": moderatorName,
+		"session":   sessionName,
+		"stream":    streamID,
+		"audit":     true,
+	}).Info("stream unpublished")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action:  entity.AuditUnpublish,
+		Actor:   moderatorName,
+		Session: sessionName,
+		Target:  streamID,
+	})
+	return nil
+}
+
+// CloseSession closes session with given name on OpenViDu server, which
+// disconnects all its participants, and removes it.
+func (a *Moderation) CloseSession(ctx context.Context,
+	moderatorName string, sessionName string) error {
+	if err := a.checkModerator(moderatorName); err != nil {
+		return err
+	}
+	err := a.Registry.Close(ctx, sessionName, moderatorName, "")
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"moderator": moderatorName,
+		"session":   sessionName,
+		"audit":     true,
+	}).Info("session closed by moderator")
+	return nil
+}
+
+// SetRole changes role of user with given name. Moderators can not change
+// their own role, so the last moderator can not be lost by mistake.
+func (a *Moderation) SetRole(ctx context.Context, moderatorName string,
+	userName string, role entity.UserRole) error {
+	if err := a.checkModerator(moderatorName); err != nil {
+		return err
+	}
+	if userName == moderatorName {
+		return errors.New("moderators can not change their own role")
+	}
+	var from entity.UserRole
+	err := a.UserRepo.Modify(userName, func(user *entity.User) error {
+		from = user.Role
+		user.Role = role
+		return nil
+	})
+	if err == entity.ErrUnknownUser {
+		return fmt.Errorf("user %s does not exist", userName)
+	}
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"moderator": moderatorName,
+		"user":      userName,
+		"from":      from.String(),
+		"role":      role.String(),
+		"audit":     true,
+	}).Info("user role changed by moderator")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action:  entity.AuditRoleChange,
+		Actor:   moderatorName,
+		Target:  userName,
+		Details: from.String() + " -> " + role.String(),
+	})
+	return nil
+}
+
+// Unlock removes lockout of user with given name caused by failed logins.
+func (a *Moderation) Unlock(ctx context.Context,
+	moderatorName string, userName string) error {
+	if err := a.checkModerator(moderatorName); err != nil {
+		return err
+	}
+	if a.LoginAction == nil {
+		return errors.New("login attempts are not limited")
+	}
+	if _, err := a.UserRepo.Get(userName); err != nil {
+		return fmt.Errorf("user %s does not exist", userName)
+	}
+	a.LoginAction.Unlock(ctx, moderatorName, userName)
+	return nil
+}
+
+// Audit returns audit events matching given filter, newest first, and
+// total number of matching events.
+func (a *Moderation) Audit(moderatorName string,
+	filter entity.AuditFilter) ([]*entity.AuditEvent, int, error) {
+	if err := a.checkModerator(moderatorName); err != nil {
+		return nil, 0, err
+	}
+	if a.AuditSink == nil {
+		return nil, 0, errors.New("audit log is disabled")
+	}
+	return a.AuditSink.Query(filter)
+}
+
+// session returns active session with given name if user with given name
+// is a moderator.
+func (a *Moderation) session(
+	moderatorName string, sessionName string) (*entity.Session, error) {
+	if err := a.checkModerator(moderatorName); err != nil {
+		return nil, err
+	}
+	return a.SessionRepo.Get(sessionName)
+}
+
+// checkModerator returns an error if user with given name is not a
+// moderator.
+func (a *Moderation) checkModerator(userName string) error {
+	user, err := a.UserRepo.Get(userName)
+	if err != nil {
+		return err
+	}
+	if user.Role != entity.Moderator || user.Pending {
+		return fmt.Errorf("user %s is not a moderator", userName)
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+// connectionsMock is a mock that imitates OpenViDu participants
+// management.
+type connectionsMock struct {
+	behavior string
+	calls    []string
+}
+
+func (m *connectionsMock) Connections(ctx context.Context,
+	sessionID string) ([]*entity.Connection, error) {
+	if m.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	return []*entity.Connection{
+		{ID: "con_1", Participant: "Boss", UserName: "test owner",
+			Streams: []string{"str_1"}},
+		{ID: "con_2", Participant: "Bobby", UserName: "test participant"},
+		{ID: "con_3", Participant: "test participant"},
+	}, nil
+}
+
+func (m *connectionsMock) Disconnect(ctx context.Context,
+	sessionID string, connectionID string) error {
+	m.calls = append(m.calls, "disconnect "+sessionID+" "+connectionID)
+	if m.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+func (m *connectionsMock) Unpublish(ctx context.Context,
+	sessionID string, streamID string) error {
+	m.calls = append(m.calls, "unpublish "+sessionID+" "+streamID)
+	if m.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+func TestModeration_Users(t *testing.T) {
+	Convey("Returns users to moderator", t, func() {
+		a := newModerationAction("ok")
+		list, err := a.Users("test moderator")
+
+		So(err, ShouldBeNil)
+		So(list, ShouldHaveLength, 4)
+	})
+
+	Convey("Returns an error to other users", t, func() {
+		a := newModerationAction("ok")
+
+		_, err := a.Users("test owner")
+		So(err, ShouldNotBeNil)
+		_, err = a.Users("pending moderator")
+		So(err, ShouldNotBeNil)
+		_, err = a.Sessions("test owner")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestModeration_Session(t *testing.T) {
+	Convey("Returns session with its participants", t, func() {
+		a := newModerationAction("ok")
+		list, err := a.Sessions("test moderator")
+		So(err, ShouldBeNil)
+		So(list, ShouldHaveLength, 1)
+
+		session, connections, err := a.Session(
+			context.Background(), "test moderator", "test session")
+		So(err, ShouldBeNil)
+		So(session.ID, ShouldEqual, "test id")
+		So(connections, ShouldHaveLength, 3)
+	})
+
+	Convey("Returns session with OpenViDu error", t, func() {
+		a := newModerationAction("failure")
+		session, connections, err := a.Session(
+			context.Background(), "test moderator", "test session")
+
+		So(err, ShouldNotBeNil)
+		So(session, ShouldNotBeNil)
+		So(connections, ShouldBeNil)
+	})
+
+	Convey("Returns an error for unknown session", t, func() {
+		a := newModerationAction("ok")
+		_, _, err := a.Session(
+			context.Background(), "test moderator", "unknown")
+
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestModeration_Kick(t *testing.T) {
+	Convey("Disconnects participant and removes its user", t, func() {
+		a := newModerationAction("ok")
+		events := &sessionEventsMock{}
+		a.SessionAction.(*Session).Events = events
+
+		So(a.Kick(context.Background(), "test moderator", "test session",
+			"con_2"), ShouldBeNil)
+		So(a.OpenViDu.(*connectionsMock).calls, ShouldResemble,
+			[]string{"disconnect test id con_2"})
+		s, _ := a.SessionRepo.Get("test session")
+		So(s.Subscribers, ShouldNotContainKey, "test participant")
+		So(events.events, ShouldHaveLength, 1)
+		So(events.events[0].Type, ShouldEqual, entity.ParticipantLeft)
+		So(events.events[0].User, ShouldEqual, "test participant")
+	})
+
+	Convey("Keeps user whose name is other's nickname", t, func() {
+		a := newModerationAction("ok")
+
+		So(a.Kick(context.Background(), "test moderator", "test session",
+			"con_3"), ShouldBeNil)
+		So(a.OpenViDu.(*connectionsMock).calls, ShouldResemble,
+			[]string{"disconnect test id con_3"})
+		s, _ := a.SessionRepo.Get("test session")
+		So(s.Subscribers, ShouldContainKey, "test participant")
+	})
+
+	Convey("Returns an error", t, func() {
+		ctx := context.Background()
+		a := newModerationAction("ok")
+
+		So(a.Kick(ctx, "test owner", "test session", "con_2"), ShouldNotBeNil)
+		So(a.Kick(ctx, "test moderator", "unknown", "con_2"), ShouldNotBeNil)
+		So(a.Kick(ctx, "test moderator", "test session", ""), ShouldNotBeNil)
+		So(a.Kick(ctx, "test moderator", "test session", "con_4"),
+			ShouldNotBeNil)
+		So(a.Kick(ctx, "test moderator", "test session", "con_1"),
+			ShouldNotBeNil)
+		So(a.OpenViDu.(*connectionsMock).calls, ShouldBeEmpty)
+		So(newModerationAction("failure").Kick(ctx, "test moderator",
+			"test session", "con_2"), ShouldNotBeNil)
+	})
+}
+
+func TestModeration_Unpublish(t *testing.T) {
+	Convey("Stops stream", t, func() {
+		a := newModerationAction("ok")
+
+		So(a.Unpublish(context.Background(), "test moderator",
+			"test session", "str_1"), ShouldBeNil)
+		So(a.OpenViDu.(*connectionsMock).calls, ShouldResemble,
+			[]string{"unpublish test id str_1"})
+	})
+
+	Convey("Returns an error", t, func() {
+		ctx := context.Background()
+		a := newModerationAction("ok")
+
+		So(a.Unpublish(ctx, "test owner", "test session", "str_1"),
+			ShouldNotBeNil)
+		So(a.Unpublish(ctx, "test moderator", "test session", ""),
+			ShouldNotBeNil)
+		So(newModerationAction("failure").Unpublish(ctx, "test moderator",
+			"test session", "str_1"), ShouldNotBeNil)
+	})
+}
+
+func TestModeration_CloseSession(t *testing.T) {
+	Convey("Closes session", t, func() {
+		a := newModerationAction("ok")
+
+		So(a.CloseSession(context.Background(), "test moderator",
+			"test session"), ShouldBeNil)
+		_, err := a.SessionRepo.Get("test session")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns an error", t, func() {
+		ctx := context.Background()
+
+		So(newModerationAction("ok").CloseSession(ctx, "test owner",
+			"test session"), ShouldNotBeNil)
+		So(newModerationAction("failure").CloseSession(ctx,
+			"test moderator", "test session"), ShouldNotBeNil)
+	})
+}
+
+func TestModeration_SetRole(t *testing.T) {
+	Convey("Changes role of user", t, func() {
+		a := newModerationAction("ok")
+
+		So(a.SetRole(context.Background(), "test moderator", "test owner",
+			entity.Moderator), ShouldBeNil)
+		user, _ := a.UserRepo.Get("test owner")
+		So(user.Role, ShouldEqual, entity.Moderator)
+	})
+
+	Convey("Records role change to audit log", t, func() {
+		a := newModerationAction("ok")
+		sink := a.AuditSink.(*auditSinkMock)
+
+		So(a.SetRole(context.Background(), "test moderator", "test owner",
+			entity.Moderator), ShouldBeNil)
+		So(sink.events, ShouldHaveLength, 1)
+		So(sink.events[0].Action, ShouldEqual, entity.AuditRoleChange)
+		So(sink.events[0].Actor, ShouldEqual, "test moderator")
+		So(sink.events[0].Target, ShouldEqual, "test owner")
+		So(sink.events[0].Details, ShouldEqual, "PUBLISHER -> MODERATOR")
+	})
+
+	Convey("Returns an error", t, func() {
+		ctx := context.Background()
+		a := newModerationAction("ok")
+
+		So(a.SetRole(ctx, "test owner", "test owner", entity.Moderator),
+			ShouldNotBeNil)
+		So(a.SetRole(ctx, "test moderator", "test moderator",
+			entity.Subscriber), ShouldNotBeNil)
+		So(a.SetRole(ctx, "test moderator", "unknown", entity.Subscriber),
+			ShouldNotBeNil)
+		user, _ := a.UserRepo.Get("test moderator")
+		So(user.Role, ShouldEqual, entity.Moderator)
+	})
+}
+
+func TestModeration_Unlock(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Unlocks user on behalf of moderator", t, func() {
+		a := newModerationAction("ok")
+		unlocker := &unlockerMock{}
+		a.LoginAction = unlocker
+
+		So(a.Unlock(ctx, "test moderator", "test owner"), ShouldBeNil)
+		So(unlocker.unlocked, ShouldEqual, "test owner")
+		So(unlocker.actor, ShouldEqual, "test moderator")
+	})
+
+	Convey("Returns an error", t, func() {
+		a := newModerationAction("ok")
+
+		So(a.Unlock(ctx, "test moderator", "test owner"), ShouldNotBeNil)
+		a.LoginAction = &unlockerMock{}
+		So(a.Unlock(ctx, "test owner", "test owner"), ShouldNotBeNil)
+		So(a.Unlock(ctx, "test moderator", "unknown"), ShouldNotBeNil)
+	})
+}
+
+func TestModeration_Audit(t *testing.T) {
+	Convey("Returns audit events to moderator", t, func() {
+		a := newModerationAction("ok")
+		a.Kick(context.Background(), "test moderator", "test session",
+			"con_2")
+
+		list, total, err := a.Audit("test moderator", entity.AuditFilter{})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 1)
+		So(list[0].Action, ShouldEqual, entity.AuditKick)
+		So(list[0].Target, ShouldEqual, "con_2")
+		So(list[0].Details, ShouldEqual, "test participant")
+	})
+
+	Convey("Returns an error", t, func() {
+		a := newModerationAction("ok")
+		_, _, err := a.Audit("test owner", entity.AuditFilter{})
+		So(err, ShouldNotBeNil)
+
+		a.AuditSink = nil
+		_, _, err = a.Audit("test moderator", entity.AuditFilter{})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+// newModerationAction returns moderation action with moderator and user
+// that owns a session, which OpenViDu mocks handle with given behavior.
+func newModerationAction(behavior string) *Moderation {
+	sessionRepo := repository.NewSessionsRepository()
+	a := &Moderation{
+		UserRepo:    repository.NewUsersRepository(),
+		SessionRepo: sessionRepo,
+		Registry: &Registry{
+			SessionRepo: sessionRepo,
+			OpenViDu:    &sessionCloserMock{behavior: behavior},
+		},
+		OpenViDu:  &connectionsMock{behavior: behavior},
+		AuditSink: &auditSinkMock{behavior: "ok"},
+	}
+	a.UserRepo.Add("test moderator", "test password", 2)
+	a.UserRepo.Add("test owner", "test password", 1)
+	a.UserRepo.Create(&entity.User{
+		Name: "pending moderator", Role: entity.Moderator, Pending: true,
+	})
+	a.UserRepo.Add("test participant", "test password", 0)
+	a.SessionAction = &Session{
+		SessionRepo: sessionRepo,
+		UserRepo:    a.UserRepo,
+	}
+	owner, _ := a.UserRepo.Get("test owner")
+	participant, _ := a.UserRepo.Get("test participant")
+	sessionRepo.Add("test id", "test session", owner)
+	sessionRepo.Join("test session", participant)
+	return a
+}
@@ -0,0 +1,117 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// Registry is an action that saves, restores or closes all active sessions
+// when application stops.
+type Registry struct {
+	SessionRepo entity.Sessions
+
+	// GuestRepo stores guests of sessions. Guests of closed sessions are
+	// removed if it is not nil.
+	GuestRepo entity.Guests
+
+	// OpenViDu closes sessions on OpenViDu server.
+	OpenViDu interface {
+		CloseSession(ctx context.Context, sessionID string) error
+	}
+
+	// Events receives close of sessions, which is audited by AuditSessions
+	// subscriber. Events are not published if nil.
+	Events entity.SessionEvents
+}
+
+// Persist writes all active sessions to given writer as JSON.
+func (a *Registry) Persist(w io.Writer) error {
+	list, _, err := a.SessionRepo.List(entity.SessionFilter{})
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(list)
+}
+
+// Restore reads sessions written by Persist from given reader and puts them
+// to repository.
+func (a *Registry) Restore(r io.Reader) error {
+	var list []*entity.Session
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return err
+	}
+	for _, s := range list {
+		if err := a.SessionRepo.Restore(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseAll closes all active sessions on OpenViDu server and removes them
+// from repository. Closing continues if some session fails to close, and the
+// last error is returned.
+func (a *Registry) CloseAll(ctx context.Context) error {
+	list, _, err := a.SessionRepo.List(entity.SessionFilter{})
+	if err != nil {
+		return err
+	}
+	for _, s := range list {
+		if e := a.close(ctx, s, "", "application shutdown"); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Close closes session with given name on OpenViDu server and removes it
+// from repository.
+//
+// parameters:
+//  ctx          context.Context  Context of request.
+//  sessionName  string           Name of session.
+//  userName     string           Name of user that closes session, empty
+//                                if it is closed by admin command.
+//  details      string           Circumstances of close, may be empty.
+func (a *Registry) Close(ctx context.Context,
+	sessionName string, userName string, details string) error {
+	s, err := a.SessionRepo.Get(sessionName)
+	if err != nil {
+		return err
+	}
+	return a.close(ctx, s, userName, details)
+}
+
+// close closes given session on OpenViDu server on behalf of user with
+// given name and removes it from repository.
+func (a *Registry) close(ctx context.Context,
+	s *entity.Session, userName string, details string) error {
+	log := logging.FromContext(ctx).WithField("session", s.Name)
+	if err := a.OpenViDu.CloseSession(ctx, s.ID); err != nil {
+		log.WithError(err).Error("failed to close session")
+		return err
+	}
+	if err := a.SessionRepo.Delete(s.Name); err != nil {
+		return err
+	}
+	if a.GuestRepo != nil {
+		a.GuestRepo.DeleteBySession(s.Name)
+	}
+	log.Info("session closed")
+	if a.Events != nil {
+		a.Events.Publish(ctx, &entity.SessionEvent{
+			Type:        entity.SessionClosed,
+			Time:        time.Now(),
+			SessionID:   s.ID,
+			SessionName: s.Name,
+			User:        userName,
+			Details:     details,
+		})
+	}
+	return nil
+}
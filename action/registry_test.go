@@ -0,0 +1,106 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+func TestRegistry_Persist(t *testing.T) {
+	Convey("Restores persisted sessions", t, func() {
+		a := &Registry{SessionRepo: repository.NewSessionsRepository()}
+		a.SessionRepo.Add("test id", "test session",
+			&entity.User{Name: "test owner", Role: 1})
+		a.SessionRepo.Join("test session", &entity.User{Name: "test user"})
+
+		buf := &bytes.Buffer{}
+		So(a.Persist(buf), ShouldBeNil)
+
+		restored := &Registry{SessionRepo: repository.NewSessionsRepository()}
+		So(restored.Restore(buf), ShouldBeNil)
+		r, err := restored.SessionRepo.Get("test session")
+		So(err, ShouldBeNil)
+		So(r.ID, ShouldEqual, "test id")
+		So(r.Owner.Name, ShouldEqual, "test owner")
+		So(r.Subscribers["test user"], ShouldNotBeNil)
+	})
+
+	Convey("Returns restore error", t, func() {
+		a := &Registry{SessionRepo: repository.NewSessionsRepository()}
+
+		So(a.Restore(bytes.NewBufferString("wrong")), ShouldNotBeNil)
+	})
+}
+
+func TestRegistry_CloseAll(t *testing.T) {
+	Convey("Closes all sessions", t, func() {
+		closer := &sessionCloserMock{behavior: "ok"}
+		a := &Registry{
+			SessionRepo: repository.NewSessionsRepository(),
+			GuestRepo:   repository.NewGuestsRepository(),
+			OpenViDu:    closer,
+		}
+		a.SessionRepo.Add("first id", "first", &entity.User{Name: "owner"})
+		a.SessionRepo.Add("second id", "second", &entity.User{Name: "owner"})
+
+		So(a.CloseAll(context.Background()), ShouldBeNil)
+		So(closer.closed, ShouldHaveLength, 2)
+		_, total, _ := a.SessionRepo.List(entity.SessionFilter{})
+		So(total, ShouldEqual, 0)
+	})
+
+	Convey("Returns OpenViDu error and keeps session", t, func() {
+		a := &Registry{
+			SessionRepo: repository.NewSessionsRepository(),
+			OpenViDu:    &sessionCloserMock{behavior: "failure"},
+		}
+		a.SessionRepo.Add("first id", "first", &entity.User{Name: "owner"})
+
+		So(a.CloseAll(context.Background()), ShouldNotBeNil)
+		_, err := a.SessionRepo.Get("first")
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestRegistry_Close(t *testing.T) {
+	Convey("Closes session with given name", t, func() {
+		closer := &sessionCloserMock{behavior: "ok"}
+		events := &sessionEventsMock{}
+		a := &Registry{
+			SessionRepo: repository.NewSessionsRepository(),
+			OpenViDu:    closer,
+			Events:      events,
+		}
+		a.SessionRepo.Add("first id", "first", &entity.User{Name: "owner"})
+		a.SessionRepo.Add("second id", "second", &entity.User{Name: "owner"})
+
+		So(a.Close(context.Background(), "first", "moderator", "details"),
+			ShouldBeNil)
+		So(closer.closed, ShouldResemble, []string{"first id"})
+		So(events.types(), ShouldResemble,
+			[]entity.SessionEventType{entity.SessionClosed})
+		So(events.events[0].SessionID, ShouldEqual, "first id")
+		So(events.events[0].User, ShouldEqual, "moderator")
+		So(events.events[0].Details, ShouldEqual, "details")
+		_, err := a.SessionRepo.Get("first")
+		So(err, ShouldNotBeNil)
+		_, err = a.SessionRepo.Get("second")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Returns error for unknown session", t, func() {
+		closer := &sessionCloserMock{behavior: "ok"}
+		a := &Registry{
+			SessionRepo: repository.NewSessionsRepository(),
+			OpenViDu:    closer,
+		}
+
+		So(a.Close(context.Background(), "unknown", "", ""), ShouldNotBeNil)
+		So(closer.closed, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,185 @@
+package action
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// PasswordReset is an action that recovers forgotten passwords with single
+// use expiring links sent to users by mail.
+type PasswordReset struct {
+	UserRepo  entity.Users
+	ResetRepo entity.PasswordResets
+
+	// Mailer sends password reset links to users. It must not wait for
+	// mail server, so request for existing account is answered as fast as
+	// for unknown one.
+	Mailer interface {
+		Send(ctx context.Context, to string, subject string, body string) error
+	}
+
+	// LoginAction unlocks accounts whose password is reset. Accounts stay
+	// locked if it is nil.
+	LoginAction interface {
+		Unlock(ctx context.Context, actorName string, username string)
+	}
+
+	// RefreshRepo logs out token clients of users whose password is reset.
+	// Refresh tokens are kept if it is nil.
+	RefreshRepo entity.RefreshTokens
+
+	// TTL is a period of reset link validity.
+	TTL time.Duration
+
+	// Now returns current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// Request sends password reset link to mail address of user given by name
+// or mail address. Link is given prefix followed by reset token.
+//
+// No error is returned for unknown user, user without mail address or
+// failed mail, so the result does not disclose which accounts exist.
+func (a *PasswordReset) Request(
+	ctx context.Context, login string, linkPrefix string) error {
+	log := logging.FromContext(ctx).WithField("login", login)
+	user := a.find(login)
+	if user == nil || user.Email == "" {
+		log.Warn("password reset requested for unknown user")
+		return nil
+	}
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+	if err = a.ResetRepo.DeleteByUser(user.Name); err != nil {
+		return err
+	}
+	reset := &entity.PasswordReset{
+		TokenHash: hashToken(token),
+		UserName:  user.Name,
+		ExpiresAt: a.now().Add(a.TTL),
+	}
+	if err = a.ResetRepo.Add(reset); err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"somebody requested a password reset for your account. Open the "+
+		"link below to set a new password:\n\n%s%s\n\n"+
+		"The link is valid for %s and can be used once. Ignore this "+
+		"message if you did not request it.\n",
+		user.Title(), linkPrefix, token, a.TTL)
+	// Mail error is not returned, so failing mail server does not
+	// disclose that the account exists either.
+	if err = a.Mailer.Send(
+		ctx, user.Email, "Password reset", body); err != nil {
+		log.WithError(err).Error("password reset mail failed")
+		return nil
+	}
+	log.WithField("user", user.Name).Info("password reset requested")
+	return nil
+}
+
+// Verify returns password reset by given token if it can be used.
+func (a *PasswordReset) Verify(token string) (*entity.PasswordReset, error) {
+	reset, err := a.ResetRepo.Get(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if err = reset.Check(a.now()); err != nil {
+		return nil, err
+	}
+	return reset, nil
+}
+
+// Reset sets given password to user of password reset by given token. The
+// token can not be used again.
+func (a *PasswordReset) Reset(
+	ctx context.Context, token string, password string) error {
+	if _, err := a.Verify(token); err != nil {
+		return err
+	}
+	if err := entity.CheckPassword(password); err != nil {
+		return err
+	}
+	reset, err := a.ResetRepo.Take(hashToken(token))
+	if err != nil {
+		return err
+	}
+	if err = reset.Check(a.now()); err != nil {
+		return err
+	}
+	err = a.UserRepo.Modify(reset.UserName, func(user *entity.User) error {
+		return user.SetPassword(password)
+	})
+	if err != nil {
+		return err
+	}
+	if err = a.ResetRepo.DeleteByUser(reset.UserName); err != nil {
+		return err
+	}
+	if a.RefreshRepo != nil {
+		if err = a.RefreshRepo.DeleteByUser(reset.UserName); err != nil {
+			return err
+		}
+	}
+	if a.LoginAction != nil {
+		a.LoginAction.Unlock(ctx, reset.UserName, reset.UserName)
+	}
+	logging.FromContext(ctx).WithField("user", reset.UserName).
+		Info("password reset")
+	return nil
+}
+
+// find returns user with given name or mail address, or nil if there is
+// no such user.
+func (a *PasswordReset) find(login string) *entity.User {
+	if user, err := a.UserRepo.Get(login); err == nil {
+		return user
+	}
+	email, err := parseEmail(login)
+	if err != nil || email == "" {
+		return nil
+	}
+	list, err := a.UserRepo.List()
+	if err != nil {
+		return nil
+	}
+	for _, user := range list {
+		if user.Email == email {
+			return user
+		}
+	}
+	return nil
+}
+
+// now returns current time.
+func (a *PasswordReset) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
+
+// newResetToken returns new random password reset token.
+func newResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns hex encoded SHA-256 hash of given token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
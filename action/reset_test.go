@@ -0,0 +1,136 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+// mailerMock is a mock that imitates Mailer behavior.
+type mailerMock struct {
+	behavior string
+	to       string
+	body     string
+}
+
+// Send imitates Mailer Send method behavior depending on one defined.
+func (m *mailerMock) Send(
+	ctx context.Context, to string, subject string, body string) error {
+	if m.behavior != "ok" {
+		return errors.New("some error")
+	}
+	m.to, m.body = to, body
+	return nil
+}
+
+// unlockerMock is a mock that records unlocked users.
+type unlockerMock struct {
+	unlocked string
+	actor    string
+}
+
+// Unlock records name of unlocked user.
+func (m *unlockerMock) Unlock(
+	ctx context.Context, actorName string, username string) {
+	m.unlocked = username
+	m.actor = actorName
+}
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	newAction := func() (*PasswordReset, *mailerMock) {
+		userRepo := repository.NewUsersRepository()
+		userRepo.Create(&entity.User{Name: "test user",
+			Password: "test password", Email: "test@example.com"})
+		userRepo.Add("no mail user", "test password", 0)
+		m := &mailerMock{behavior: "ok"}
+		return &PasswordReset{
+			UserRepo:    userRepo,
+			ResetRepo:   repository.NewResetsRepository(),
+			RefreshRepo: repository.NewRefreshTokensRepository(),
+			Mailer:      m,
+			LoginAction: &unlockerMock{},
+			TTL:         time.Hour,
+			Now:         func() time.Time { return now },
+		}, m
+	}
+	// token extracts reset token from link in given mail body.
+	token := func(body string) string {
+		i := strings.Index(body, "http://test/reset/")
+		So(i, ShouldBeGreaterThanOrEqualTo, 0)
+		return strings.Fields(body[i+len("http://test/reset/"):])[0]
+	}
+
+	Convey("Sends reset link to user found by name", t, func() {
+		a, m := newAction()
+		err := a.Request(ctx, "test user", "http://test/reset/")
+
+		So(err, ShouldBeNil)
+		So(m.to, ShouldEqual, "test@example.com")
+		tok := token(m.body)
+		reset, err := a.Verify(tok)
+		So(err, ShouldBeNil)
+		So(reset.UserName, ShouldEqual, "test user")
+
+		Convey("Sets new password once", func() {
+			a.RefreshRepo.Add(&entity.RefreshToken{
+				TokenHash: "test hash", UserName: "test user"})
+			So(a.Reset(ctx, tok, "new password"), ShouldBeNil)
+			user, _ := a.UserRepo.Get("test user")
+			So(user.ValidPassword("new password"), ShouldBeTrue)
+			So(a.LoginAction.(*unlockerMock).unlocked, ShouldEqual, "test user")
+			_, err := a.RefreshRepo.Use("test hash")
+			So(err, ShouldNotBeNil)
+
+			err = a.Reset(ctx, tok, "other password")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "password reset link is invalid")
+		})
+
+		Convey("Keeps token if password is too short", func() {
+			So(a.Reset(ctx, tok, "short"), ShouldNotBeNil)
+			So(a.Reset(ctx, tok, "new password"), ShouldBeNil)
+		})
+
+		Convey("Refuses expired token", func() {
+			a.Now = func() time.Time { return now.Add(time.Hour) }
+			err := a.Reset(ctx, tok, "new password")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "password reset link expired")
+		})
+
+		Convey("Invalidates previous token by new request", func() {
+			So(a.Request(ctx, "test@example.com", "http://test/reset/"),
+				ShouldBeNil)
+			_, err := a.Verify(tok)
+
+			So(err, ShouldNotBeNil)
+			_, err = a.Verify(token(m.body))
+			So(err, ShouldBeNil)
+		})
+	})
+
+	Convey("Sends nothing to unknown user", t, func() {
+		a, m := newAction()
+
+		So(a.Request(ctx, "wrong user", "http://test/reset/"), ShouldBeNil)
+		So(a.Request(ctx, "no mail user", "http://test/reset/"), ShouldBeNil)
+		So(m.to, ShouldBeEmpty)
+	})
+
+	Convey("Does not return mailer error", t, func() {
+		a, m := newAction()
+		m.behavior = "failure"
+
+		So(a.Request(ctx, "test user", "http://test/reset/"), ShouldBeNil)
+	})
+}
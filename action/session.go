@@ -1,60 +1,532 @@
 package action
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+	"github.com/flexconstructor/openvidu-tutorial/tracing"
 )
 
 // Session is an action that performs operations with OpenViDu sessions.
 type Session struct {
 	SessionRepo entity.Sessions
 	UserRepo    entity.Users
+
+	// GuestRepo stores guests of sessions. Guests can not join sessions if
+	// it is nil.
+	GuestRepo entity.Guests
+
+	// InvitationRepo stores invitations to sessions. Users can not join
+	// sessions by invitation if it is nil.
+	InvitationRepo entity.Invitations
+
+	// MeetingRepo stores scheduled meetings. Sessions of scheduled meetings
+	// can be joined only within their time window. Scheduling is disabled if
+	// it is nil.
+	MeetingRepo entity.Meetings
+
+	// EarlyJoin is a margin before meeting start when participants are
+	// allowed to join meeting.
+	EarlyJoin time.Duration
+
+	// AuditSink records refused joins and failed leaves of sessions;
+	// successful changes are recorded by AuditSessions subscriber of
+	// Events. Audit log is disabled if nil.
+	AuditSink entity.AuditSink
+
+	// Events receives lifecycle events of sessions. Events are not
+	// published if nil.
+	Events entity.SessionEvents
+
+	// Recorder starts and stops recording of sessions on OpenViDu server.
+	// Recording is disabled if nil.
+	Recorder interface {
+		StartRecording(ctx context.Context, sessionID string) (string, error)
+		StopRecording(ctx context.Context, recordingID string) error
+	}
+
+	// Now returns current time. time.Now is used if nil.
+	Now func() time.Time
 }
 
-// Add adds new session with owner data but without participants.
+// Add adds new session with owner data and given access policy but without
+// participants, or adds participant to existing session if access to it is
+// granted. New session is stored with its policy at once, so nobody can join
+// it before the policy is in force.
 //
 // parameters:
-//  sessionID   string   session ID that was returned from OpenViDu server.
-//  sessionName string   The name of session that was returned from browser.
-//  userName    string   Logged user name.
-func (a *Session) Add(
-	sessionID string, sessionName string, userName string) error {
-	user, err := a.UserRepo.Get(userName)
+//  ctx         context.Context        Context of request.
+//  sessionID   string                 session ID that was returned from
+//                                     OpenViDu server.
+//  sessionName string                 The name of session that was returned
+//                                     from browser.
+//  userName    string                 Logged user name.
+//  passcode    string                 Passcode of protected session, may be
+//                                     empty.
+//  policy      *entity.SessionPolicy  Access policy of new session, nil for
+//                                     public session closed for guests.
+//                                     Ignored if session exists.
+func (a *Session) Add(ctx context.Context, sessionID string,
+	sessionName string, userName string, passcode string,
+	policy *entity.SessionPolicy) (err error) {
+	ctx, span := tracing.Start(ctx, "Session.Add",
+		sessionAttributes(sessionName, userName)...)
+	defer func() { tracing.End(span, err) }()
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"session": sessionName,
+		"user":    userName,
+	})
+	created, err := a.add(
+		ctx, sessionID, sessionName, userName, passcode, policy)
 	if err != nil {
+		log.WithError(err).Warn("join refused")
+		a.audit(ctx, entity.AuditSessionJoin, sessionName, userName, err)
 		return err
 	}
-
-	if a.IsExists(sessionName) {
-		_, err = a.addParticipant(sessionName, userName)
+	if created {
+		log.Info("session created")
+		a.publish(ctx, entity.SessionCreated, sessionID, sessionName, userName)
 	} else {
-		_, err = a.SessionRepo.Add(sessionID, sessionName, user)
+		log.Info("participant joined")
+		a.publish(ctx,
+			entity.ParticipantJoined, sessionID, sessionName, userName)
+	}
+	return nil
+}
+
+// AddInvited adds participant to session given invitation is issued for and
+// spends one use of the invitation. The use is spent only if participant is
+// added; the user is also added to allow list of session, so it can join
+// the session again without invitation.
+//
+// parameters:
+//  ctx         context.Context     Context of request.
+//  userName    string              Logged user name.
+//  invitation  *entity.Invitation  Verified invitation, see Invitation
+//                                  action.
+func (a *Session) AddInvited(ctx context.Context, userName string,
+	invitation *entity.Invitation) (err error) {
+	sessionName := invitation.SessionName
+	ctx, span := tracing.Start(ctx, "Session.AddInvited",
+		sessionAttributes(sessionName, userName)...)
+	defer func() { tracing.End(span, err) }()
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"session":    sessionName,
+		"user":       userName,
+		"invitation": invitation.ID,
+	})
+	sessionID, err := a.addInvited(userName, invitation)
+	if err != nil {
+		log.WithError(err).Warn("join refused")
+		a.audit(ctx, entity.AuditSessionJoin, sessionName, userName, err)
+		return err
+	}
+	log.Info("participant joined")
+	a.publish(ctx, entity.ParticipantJoined, sessionID, sessionName, userName)
+	return nil
+}
+
+// addInvited adds participant to session by given invitation and returns
+// ID of the session. Use of invitation is spent under the repository lock,
+// so it is spent only if participant is added.
+func (a *Session) addInvited(
+	userName string, invitation *entity.Invitation) (string, error) {
+	if a.InvitationRepo == nil {
+		return "", errors.New("invitations are disabled")
+	}
+	sessionName := invitation.SessionName
+	user, err := a.joiner(sessionName, userName)
+	if err != nil {
+		return "", err
+	}
+	if _, err = a.meeting(sessionName); err != nil {
+		return "", err
+	}
+	var sessionID string
+	err = a.SessionRepo.Modify(sessionName,
+		func(session *entity.Session) error {
+			if session.ID != invitation.SessionID {
+				return fmt.Errorf("session %s is closed", sessionName)
+			}
+			if err := checkNewParticipant(session, userName); err != nil {
+				return err
+			}
+			if _, err := a.InvitationRepo.Use(
+				invitation.ID, a.now()); err != nil {
+				return err
+			}
+			session.AllowList[userName] = true
+			session.AddParticipant(user)
+			sessionID = session.ID
+			return nil
+		})
+	return sessionID, err
+}
+
+// joiner returns user with given name that is about to join session with
+// given name. Guests can join only their own session.
+func (a *Session) joiner(
+	sessionName string, userName string) (*entity.User, error) {
+	user, err := a.user(userName)
+	if err != nil {
+		return nil, err
+	}
+	if user.Guest {
+		guest, err := a.GuestRepo.Get(userName)
+		if err != nil || guest.SessionName != sessionName {
+			return nil, fmt.Errorf(
+				"guest %s can not join session %s", userName, sessionName)
+		}
+	}
+	return user, nil
+}
+
+// add adds new session or adds participant to existing one and returns true
+// if session was created.
+func (a *Session) add(ctx context.Context, sessionID string,
+	sessionName string, userName string, passcode string,
+	policy *entity.SessionPolicy) (bool, error) {
+	user, err := a.joiner(sessionName, userName)
+	if err != nil {
+		return false, err
+	}
+
+	meeting, err := a.meeting(sessionName)
+	if err != nil {
+		return false, err
+	}
+
+	if a.IsExists(ctx, sessionName) {
+		_, err = a.addParticipant(sessionName, userName, passcode)
+		return false, err
+	}
+	if meeting != nil && !meeting.IsInvited(userName) {
+		return false, fmt.Errorf(
+			"user %s is not invited to meeting %s", userName, sessionName)
+	}
+	session := entity.NewSession()
+	session.ID = sessionID
+	session.Name = sessionName
+	session.Owner = user
+	session.CreatedAt = a.now()
+	if policy != nil {
+		if err = policy.Validate(); err != nil {
+			return false, err
+		}
+		policy.Apply(session)
 	}
-	return err
+	if meeting != nil {
+		session.Access = entity.AccessPrivate
+		session.AllowList[meeting.Owner.Name] = true
+		for _, name := range meeting.Invited {
+			session.AllowList[name] = true
+		}
+	}
+	if err = a.SessionRepo.Create(session); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 //  Delete delete participant of session i given userName is not name of
 //  session`s owner or remove the session otherwise.
-func (a *Session) Delete(sessionName string, userName string) error {
-	user, err := a.UserRepo.Get(userName)
+func (a *Session) Delete(
+	ctx context.Context, sessionName string, userName string) (err error) {
+	ctx, span := tracing.Start(ctx, "Session.Delete",
+		sessionAttributes(sessionName, userName)...)
+	defer func() { tracing.End(span, err) }()
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"session": sessionName,
+		"user":    userName,
+	})
+	sessionID, closed, err := a.delete(sessionName, userName)
 	if err != nil {
+		log.WithError(err).Warn("leave failed")
+		a.audit(ctx, entity.AuditSessionLeave, sessionName, userName, err)
 		return err
 	}
+	if closed {
+		log.Info("session closed")
+		a.publish(ctx, entity.SessionClosed, sessionID, sessionName, userName)
+	} else {
+		log.Info("participant left")
+		a.publish(ctx, entity.ParticipantLeft, sessionID, sessionName, userName)
+	}
+	return nil
+}
 
+// TransferOwnership hands session over to one of its participants that can
+// publish. Only the session owner can hand it over; previous owner stays in
+// session as participant.
+//
+// parameters:
+//  ctx          context.Context  Context of request.
+//  sessionName  string           The name of session.
+//  userName     string           Logged user name.
+//  newOwnerName string           Name of participant to become owner.
+func (a *Session) TransferOwnership(ctx context.Context, sessionName string,
+	userName string, newOwnerName string) (err error) {
+	ctx, span := tracing.Start(ctx, "Session.TransferOwnership", append(
+		sessionAttributes(sessionName, userName),
+		attribute.String("session.new_owner", newOwnerName))...)
+	defer func() { tracing.End(span, err) }()
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"session":   sessionName,
+		"user":      userName,
+		"new_owner": newOwnerName,
+	})
 	session, err := a.SessionRepo.Get(sessionName)
 	if err != nil {
 		return err
 	}
+	if session.Owner.Name != userName {
+		return fmt.Errorf(
+			"user %s is not owner of session %s", userName, sessionName)
+	}
+	owner, ok := session.Subscribers[newOwnerName]
+	if !ok {
+		return fmt.Errorf("user %s is not participant of session %s",
+			newOwnerName, sessionName)
+	}
+	if owner.Guest || owner.Role == entity.Subscriber {
+		return fmt.Errorf(
+			"user %s can not own session %s", newOwnerName, sessionName)
+	}
+	if err = a.SessionRepo.SetOwner(sessionName, owner); err != nil {
+		return err
+	}
+	log.Info("session handed over")
+	a.publishEvent(ctx, &entity.SessionEvent{
+		Type:          entity.OwnerChanged,
+		SessionID:     session.ID,
+		SessionName:   sessionName,
+		User:          newOwnerName,
+		PreviousOwner: userName,
+	})
+	return nil
+}
+
+// audit records failed change of session with given name made by user with
+// given name.
+func (a *Session) audit(ctx context.Context, action entity.AuditAction,
+	sessionName string, userName string, err error) {
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Time:    a.now(),
+		Action:  action,
+		Actor:   userName,
+		Session: sessionName,
+		Error:   errorText(err),
+	})
+}
+
+// publish publishes lifecycle event of given type of session with given ID
+// and name about user with given name.
+func (a *Session) publish(ctx context.Context,
+	eventType entity.SessionEventType,
+	sessionID string, sessionName string, userName string) {
+	a.publishEvent(ctx, &entity.SessionEvent{
+		Type:        eventType,
+		SessionID:   sessionID,
+		SessionName: sessionName,
+		User:        userName,
+	})
+}
+
+// publishEvent publishes given lifecycle event if events are enabled.
+func (a *Session) publishEvent(ctx context.Context, e *entity.SessionEvent) {
+	if a.Events == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = a.now()
+	}
+	a.Events.Publish(ctx, e)
+}
+
+// delete removes participant or whole session and returns ID of session
+// and true if session was removed.
+func (a *Session) delete(
+	sessionName string, userName string) (string, bool, error) {
+	user, err := a.user(userName)
+	if err != nil {
+		return "", false, err
+	}
+
+	session, err := a.SessionRepo.Get(sessionName)
+	if err != nil {
+		return "", false, err
+	}
 
 	if session.Owner.Name == user.Name {
-		return a.SessionRepo.Delete(sessionName)
+		if err = a.SessionRepo.Delete(sessionName); err != nil {
+			return "", false, err
+		}
+		if a.GuestRepo != nil {
+			return session.ID, true,
+				a.GuestRepo.DeleteBySession(sessionName)
+		}
+		return session.ID, true, nil
 	}
 
-	return a.SessionRepo.Leave(sessionName, userName)
+	if err = a.SessionRepo.Leave(sessionName, userName); err != nil {
+		return "", false, err
+	}
+	if user.Guest {
+		return session.ID, false, a.GuestRepo.Delete(userName)
+	}
+	return session.ID, false, nil
+}
+
+// AllowGuests opens session for guests or closes it. Only the session owner
+// can change it.
+func (a *Session) AllowGuests(ctx context.Context,
+	sessionName string, userName string, allow bool) (err error) {
+	_, span := tracing.Start(ctx, "Session.AllowGuests",
+		sessionAttributes(sessionName, userName)...)
+	defer func() { tracing.End(span, err) }()
+	return a.SessionRepo.Modify(sessionName,
+		func(session *entity.Session) error {
+			if session.Owner.Name != userName {
+				return fmt.Errorf("user %s is not owner of session %s",
+					userName, sessionName)
+			}
+			session.AllowGuests = allow
+			return nil
+		})
+}
+
+// SetAccess changes access mode of session. Only the session owner can
+// change it.
+//
+// parameters:
+//  ctx         context.Context       Context of request.
+//  sessionName string                The name of session.
+//  userName    string                Logged user name.
+//  access      entity.SessionAccess  New access mode.
+//  allowList   []string              Names of users to add to allow list
+//                                    of session.
+//  passcode    string                New passcode, empty keeps current one.
+func (a *Session) SetAccess(ctx context.Context, sessionName string,
+	userName string, access entity.SessionAccess, allowList []string,
+	passcode string) (err error) {
+	_, span := tracing.Start(ctx, "Session.SetAccess", append(
+		sessionAttributes(sessionName, userName),
+		attribute.String("session.access", access.String()))...)
+	defer func() { tracing.End(span, err) }()
+	return a.SessionRepo.Modify(sessionName,
+		func(session *entity.Session) error {
+			if session.Owner.Name != userName {
+				return fmt.Errorf("user %s is not owner of session %s",
+					userName, sessionName)
+			}
+			if access == entity.AccessPasscode && passcode == "" &&
+				session.Access != entity.AccessPasscode {
+				return errors.New("passcode is empty")
+			}
+			if passcode != "" {
+				session.SetPasscode(passcode)
+			}
+			for _, name := range allowList {
+				session.AllowList[name] = true
+			}
+			session.Access = access
+			return nil
+		})
+}
+
+// SetRecording starts or stops recording of session on OpenViDu server. Only
+// the session owner can change it. Nothing is changed if session is already
+// in requested state.
+//
+// parameters:
+//  ctx         context.Context  Context of request.
+//  sessionName string           The name of session.
+//  userName    string           Logged user name.
+//  record      bool             True to start recording, false to stop it.
+func (a *Session) SetRecording(ctx context.Context, sessionName string,
+	userName string, record bool) (err error) {
+	ctx, span := tracing.Start(ctx, "Session.SetRecording", append(
+		sessionAttributes(sessionName, userName),
+		attribute.Bool("session.recording", record))...)
+	defer func() { tracing.End(span, err) }()
+	if a.Recorder == nil {
+		return errors.New("recording is disabled")
+	}
+	session, err := a.SessionRepo.Get(sessionName)
+	if err != nil {
+		return err
+	}
+	if session.Owner.Name != userName {
+		return fmt.Errorf(
+			"user %s is not owner of session %s", userName, sessionName)
+	}
+	if record == (session.RecordingID != "") {
+		return nil
+	}
+	eventType := entity.RecordingStarted
+	if record {
+		id, err := a.Recorder.StartRecording(ctx, session.ID)
+		if err != nil {
+			return err
+		}
+		err = a.SessionRepo.Modify(sessionName,
+			func(session *entity.Session) error {
+				if session.RecordingID != "" {
+					return fmt.Errorf(
+						"session %s is already recorded", sessionName)
+				}
+				session.RecordingID = id
+				return nil
+			})
+		if err != nil {
+			// Session was closed or recorded meanwhile, so recording
+			// would be left without session referring it.
+			if stopErr := a.Recorder.StopRecording(ctx, id); stopErr != nil {
+				logging.FromContext(ctx).WithError(stopErr).WithField(
+					"recording", id).Error("can not stop recording")
+			}
+			return err
+		}
+	} else {
+		eventType = entity.RecordingStopped
+		id := session.RecordingID
+		if err = a.Recorder.StopRecording(ctx, id); err != nil {
+			return err
+		}
+		err = a.SessionRepo.Modify(sessionName,
+			func(session *entity.Session) error {
+				if session.RecordingID == id {
+					session.RecordingID = ""
+				}
+				return nil
+			})
+		if err != nil {
+			return err
+		}
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"session":   sessionName,
+		"user":      userName,
+		"recording": record,
+	}).Info("session recording changed")
+	a.publish(ctx, eventType, session.ID, sessionName, userName)
+	return nil
 }
 
 // GetID returns session ID by given session name.
-func (a *Session) GetID(sessionName string) (string, error) {
+func (a *Session) GetID(
+	ctx context.Context, sessionName string) (id string, err error) {
+	_, span := tracing.Start(ctx, "Session.GetID",
+		attribute.String("session.name", sessionName))
+	defer func() { tracing.End(span, err) }()
 	s, err := a.SessionRepo.Get(sessionName)
 	if err != nil {
 		return "", err
@@ -62,8 +534,20 @@ func (a *Session) GetID(sessionName string) (string, error) {
 	return s.ID, nil
 }
 
+// Get returns session by given session name.
+func (a *Session) Get(ctx context.Context,
+	sessionName string) (s *entity.Session, err error) {
+	_, span := tracing.Start(ctx, "Session.Get",
+		attribute.String("session.name", sessionName))
+	defer func() { tracing.End(span, err) }()
+	return a.SessionRepo.Get(sessionName)
+}
+
 // IsExists returns true if session is exists or false otherwise.
-func (a *Session) IsExists(sessionName string) bool {
+func (a *Session) IsExists(ctx context.Context, sessionName string) bool {
+	_, span := tracing.Start(ctx, "Session.IsExists",
+		attribute.String("session.name", sessionName))
+	defer span.End()
 	_, err := a.SessionRepo.Get(sessionName)
 	if err != nil {
 		return false
@@ -71,28 +555,112 @@ func (a *Session) IsExists(sessionName string) bool {
 	return true
 }
 
-// addParticipant adds new participant to existed session.
-func (a *Session) addParticipant(
-	sessionName string, userName string) (string, error) {
-	user, err := a.UserRepo.Get(userName)
+// List returns sessions that match given filter along with total number of
+// matched sessions.
+func (a *Session) List(ctx context.Context,
+	filter entity.SessionFilter) ([]*entity.Session, int, error) {
+	_, span := tracing.Start(ctx, "Session.List",
+		attribute.String("session.owner", filter.Owner),
+		attribute.String("session.query", filter.Name))
+	list, total, err := a.SessionRepo.List(filter)
+	tracing.End(span, err)
+	return list, total, err
+}
+
+// sessionAttributes returns span attributes of session with given name and
+// user with given name.
+func sessionAttributes(
+	sessionName string, userName string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("session.name", sessionName),
+		attribute.String("user.name", userName),
+	}
+}
+
+// meeting returns scheduled meeting of session with given name or nil if
+// session is not scheduled. Returns an error if meeting can not be joined
+// at the moment.
+func (a *Session) meeting(sessionName string) (*entity.Meeting, error) {
+	if a.MeetingRepo == nil {
+		return nil, nil
+	}
+	meeting, err := a.MeetingRepo.Get(sessionName)
 	if err != nil {
-		return "", err
+		return nil, nil
 	}
+	if err = meeting.CheckWindow(a.now(), a.EarlyJoin); err != nil {
+		return nil, err
+	}
+	return meeting, nil
+}
 
-	session, err := a.SessionRepo.Get(sessionName)
+// now returns current time.
+func (a *Session) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
+
+// addParticipant adds new participant to existed session. Access is
+// checked under the repository lock, so participant can not slip in while
+// the owner changes access mode.
+func (a *Session) addParticipant(sessionName string,
+	userName string, passcode string) (string, error) {
+	user, err := a.user(userName)
 	if err != nil {
 		return "", err
 	}
 
+	var sessionID string
+	err = a.SessionRepo.Modify(sessionName,
+		func(session *entity.Session) error {
+			if err := checkNewParticipant(session, userName); err != nil {
+				return err
+			}
+			if user.Guest {
+				// Guest may have expired or session may have been closed
+				// for guests since guest was registered.
+				if _, err := a.GuestRepo.Get(userName); err != nil {
+					return err
+				}
+				if !session.AllowGuests {
+					return fmt.Errorf(
+						"session %s is not open for guests", session.Name)
+				}
+			} else if err := session.Authorize(
+				userName, passcode); err != nil {
+				return err
+			}
+			session.AddParticipant(user)
+			sessionID = session.ID
+			return nil
+		})
+	return sessionID, err
+}
+
+// checkNewParticipant returns an error if user with given name is owner or
+// participant of given session already.
+func checkNewParticipant(session *entity.Session, userName string) error {
 	if session.Owner.Name == userName {
-		return "", fmt.Errorf(
-			"owner %s can not subscribe session %s", userName, session.Name)
+		return fmt.Errorf("owner %s can not subscribe session %s",
+			userName, session.Name)
 	}
-
 	if _, ok := session.Subscribers[userName]; ok {
-		return "", fmt.Errorf(
-			"user %s already subscribed to the session %s", userName, sessionName)
+		return fmt.Errorf("user %s already subscribed to the session %s",
+			userName, session.Name)
+	}
+	return nil
+}
+
+// user returns registered user or guest by given user name.
+func (a *Session) user(userName string) (*entity.User, error) {
+	if a.GuestRepo != nil && strings.HasPrefix(userName, entity.GuestPrefix) {
+		guest, err := a.GuestRepo.Get(userName)
+		if err != nil {
+			return nil, err
+		}
+		return guest.User, nil
 	}
-	session.AddParticipant(user)
-	return session.ID, nil
+	return a.UserRepo.Get(userName)
 }
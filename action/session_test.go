@@ -1,7 +1,10 @@
 package action
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 
@@ -17,7 +20,7 @@ func TestSession_Add(t *testing.T) {
 		}
 		a.UserRepo.Add("test user", "test password", 1)
 		a.UserRepo.Add("test participant", "test password", 0)
-		err := a.Add("test session id", "test session name", "test user")
+		err := a.Add(context.Background(), "test session id", "test session name", "test user", "", nil)
 		So(err, ShouldBeNil)
 
 		Convey("New Session was be added to repository", func() {
@@ -30,8 +33,8 @@ func TestSession_Add(t *testing.T) {
 		})
 
 		Convey("Add user to subscribers", func() {
-			err := a.Add("test session id", "test session name",
-				"test participant")
+			err := a.Add(context.Background(), "test session id", "test session name",
+				"test participant", "", nil)
 			So(err, ShouldBeNil)
 			s, _ := a.SessionRepo.Get("test session name")
 			So(s.Subscribers, ShouldNotBeEmpty)
@@ -40,12 +43,203 @@ func TestSession_Add(t *testing.T) {
 		})
 
 		Convey("Returns an user error", func() {
-			err := a.Add("test session id", "test session name", "wrong user")
+			err := a.Add(context.Background(), "test session id", "test session name", "wrong user", "", nil)
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring, "login incorrect")
 		})
 
 	})
+
+	Convey("Creates session with its access policy", t, func() {
+		ctx := context.Background()
+		a := Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.UserRepo.Add("test participant", "test password", 0)
+		err := a.Add(ctx, "test id", "test session name", "test user", "",
+			&entity.SessionPolicy{
+				Access:      entity.AccessPasscode,
+				AllowList:   []string{"test friend"},
+				Passcode:    "test passcode",
+				AllowGuests: true,
+			})
+		So(err, ShouldBeNil)
+		s, _ := a.SessionRepo.Get("test session name")
+		So(s.Access, ShouldEqual, entity.AccessPasscode)
+		So(s.AllowList["test friend"], ShouldBeTrue)
+		So(s.AllowGuests, ShouldBeTrue)
+
+		So(a.Add(ctx, "test id", "test session name", "test participant",
+			"wrong", nil), ShouldNotBeNil)
+		So(a.Add(ctx, "test id", "test session name", "test participant",
+			"test passcode", nil), ShouldBeNil)
+	})
+
+	Convey("Refuses invalid access policy", t, func() {
+		a := Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		err := a.Add(context.Background(), "test id", "test session name",
+			"test user", "", &entity.SessionPolicy{
+				Access: entity.AccessPasscode,
+			})
+
+		So(err, ShouldNotBeNil)
+		So(a.IsExists(context.Background(), "test session name"),
+			ShouldBeFalse)
+	})
+}
+
+func TestSession_AddInvited(t *testing.T) {
+	newAction := func() (*Session, *entity.Invitation) {
+		a := &Session{
+			SessionRepo:    repository.NewSessionsRepository(),
+			UserRepo:       repository.NewUsersRepository(),
+			InvitationRepo: repository.NewInvitationsRepository(),
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.UserRepo.Add("invited user", "test password", 0)
+		a.Add(context.Background(), "test id", "test session name",
+			"test user", "", &entity.SessionPolicy{
+				Access: entity.AccessPrivate,
+			})
+		invitation := &entity.Invitation{
+			ID:          "test invitation",
+			SessionName: "test session name",
+			SessionID:   "test id",
+			ExpiresAt:   time.Now().Add(time.Hour),
+			MaxUses:     1,
+		}
+		a.InvitationRepo.Add(invitation)
+		return a, invitation
+	}
+
+	Convey("Adds invited user to private session", t, func() {
+		a, invitation := newAction()
+		err := a.AddInvited(context.Background(), "invited user", invitation)
+
+		So(err, ShouldBeNil)
+		s, _ := a.SessionRepo.Get("test session name")
+		So(s.Subscribers, ShouldContainKey, "invited user")
+		So(s.AllowList["invited user"], ShouldBeTrue)
+		So(invitation.Uses, ShouldEqual, 1)
+
+		Convey("Used up invitation admits nobody else", func() {
+			a.UserRepo.Add("other user", "test password", 0)
+			err := a.AddInvited(context.Background(), "other user", invitation)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "invitation already used")
+		})
+	})
+
+	Convey("Spends no use if user is not added", t, func() {
+		a, invitation := newAction()
+
+		So(a.AddInvited(context.Background(), "test user", invitation),
+			ShouldNotBeNil)
+		So(a.AddInvited(context.Background(), "wrong user", invitation),
+			ShouldNotBeNil)
+		So(invitation.Uses, ShouldEqual, 0)
+	})
+
+	Convey("Refuses invitation to other session of the same name", t,
+		func() {
+			a, invitation := newAction()
+			a.Delete(context.Background(), "test session name", "test user")
+			a.Add(context.Background(), "new id", "test session name",
+				"test user", "", nil)
+			err := a.AddInvited(context.Background(), "invited user",
+				invitation)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "is closed")
+			So(invitation.Uses, ShouldEqual, 0)
+		})
+
+	Convey("Returns an error if invitations are disabled", t, func() {
+		a, invitation := newAction()
+		a.InvitationRepo = nil
+
+		So(a.AddInvited(context.Background(), "invited user", invitation),
+			ShouldNotBeNil)
+	})
+}
+
+func TestSession_Meeting(t *testing.T) {
+	start := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	newAction := func(now time.Time) *Session {
+		a := &Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+			MeetingRepo: repository.NewMeetingsRepository(),
+			EarlyJoin:   10 * time.Minute,
+			Now: func() time.Time {
+				return now
+			},
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.UserRepo.Add("test participant", "test password", 0)
+		a.UserRepo.Add("test stranger", "test password", 1)
+		owner, _ := a.UserRepo.Get("test user")
+		a.MeetingRepo.Add(&entity.Meeting{
+			SessionName: "test meeting",
+			Owner:       owner,
+			Start:       start,
+			End:         start.Add(time.Hour),
+			Invited:     []string{"test participant"},
+		})
+		return a
+	}
+
+	Convey("Starts private session of meeting within window", t, func() {
+		a := newAction(start.Add(-5 * time.Minute))
+		err := a.Add(context.Background(), "test id", "test meeting", "test user", "", nil)
+
+		So(err, ShouldBeNil)
+		s, _ := a.SessionRepo.Get("test meeting")
+		So(s.Access, ShouldEqual, entity.AccessPrivate)
+		So(s.AllowList["test participant"], ShouldBeTrue)
+
+		Convey("Invited user joins meeting", func() {
+			err := a.Add(context.Background(), "test id", "test meeting", "test participant", "", nil)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Not invited user can not join meeting", func() {
+			err := a.Add(context.Background(), "test id", "test meeting", "test stranger", "", nil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Not invited user can not start meeting", t, func() {
+		a := newAction(start)
+		err := a.Add(context.Background(), "test id", "test meeting", "test stranger", "", nil)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"user test stranger is not invited to meeting test meeting")
+	})
+
+	Convey("Refuses join before window", t, func() {
+		a := newAction(start.Add(-11 * time.Minute))
+		err := a.Add(context.Background(), "test id", "test meeting", "test user", "", nil)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"meeting test meeting starts at")
+	})
+
+	Convey("Refuses join after window", t, func() {
+		a := newAction(start.Add(time.Hour))
+		err := a.Add(context.Background(), "test id", "test meeting", "test user", "", nil)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "meeting test meeting is over")
+	})
 }
 
 func TestSession_Delete(t *testing.T) {
@@ -59,7 +253,7 @@ func TestSession_Delete(t *testing.T) {
 		a.SessionRepo.Add("test session id", "test session name",
 			&entity.User{Name: "test user"})
 
-		err := a.Delete("test session name", "test user")
+		err := a.Delete(context.Background(), "test session name", "test user")
 		So(err, ShouldBeNil)
 
 		Convey("test session was be removed from repository", func() {
@@ -68,32 +262,88 @@ func TestSession_Delete(t *testing.T) {
 		})
 
 		Convey("Remove participant from session", func() {
-			s, _ := a.SessionRepo.Add("test session id", "test session name",
+			a.SessionRepo.Add("test session id", "test session name",
 				&entity.User{Name: "test user"})
-			s.AddParticipant(&entity.User{Name: "test participant"})
-			err := a.Delete("test session name", "test participant")
+			a.SessionRepo.Join("test session name",
+				&entity.User{Name: "test participant"})
+			err := a.Delete(context.Background(), "test session name", "test participant")
 			So(err, ShouldBeNil)
 
 			Convey("Session subscribers should be empty", func() {
+				s, _ := a.SessionRepo.Get("test session name")
 				So(s.Subscribers, ShouldBeEmpty)
 			})
 		})
 
 		Convey("Returns a session error", func() {
-			err := a.Delete("wrong session name", "test user")
+			err := a.Delete(context.Background(), "wrong session name", "test user")
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring,
 				"session wrong session name does not exists")
 		})
 
 		Convey("Returns a user error", func() {
-			err := a.Delete("test session name", "wrong user")
+			err := a.Delete(context.Background(), "test session name", "wrong user")
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring, "login incorrect")
 		})
 	})
 }
 
+func TestSession_Audit(t *testing.T) {
+	Convey("Records session events", t, func() {
+		ctx := context.Background()
+		sink := &auditSinkMock{behavior: "ok"}
+		a := Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+			AuditSink:   sink,
+			Events:      sessionEventsFunc(AuditSessions(sink)),
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.UserRepo.Add("test participant", "test password", 0)
+
+		a.Add(ctx, "test session id", "test session name", "test user", "", nil)
+		a.Add(ctx, "test session id", "test session name",
+			"test participant", "", nil)
+		a.Add(ctx, "test session id", "test session name", "wrong user", "", nil)
+		a.Delete(ctx, "test session name", "test participant")
+		a.Delete(ctx, "test session name", "test user")
+
+		So(sink.actions(), ShouldResemble, []entity.AuditAction{
+			entity.AuditSessionCreate, entity.AuditSessionJoin,
+			entity.AuditSessionJoin, entity.AuditSessionLeave,
+			entity.AuditSessionClose,
+		})
+		So(sink.events[1].Actor, ShouldEqual, "test participant")
+		So(sink.events[1].Session, ShouldEqual, "test session name")
+		So(sink.events[1].Error, ShouldBeEmpty)
+		So(sink.events[2].Error, ShouldContainSubstring, "login incorrect")
+	})
+}
+
+func TestSession_Get(t *testing.T) {
+	Convey("Returns session", t, func() {
+		a := Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.Add(context.Background(),
+			"test session id", "test session name", "test user", "", nil)
+		s, err := a.Get(context.Background(), "test session name")
+
+		So(err, ShouldBeNil)
+		So(s.ID, ShouldEqual, "test session id")
+		So(s.Owner.Name, ShouldEqual, "test user")
+
+		Convey("Returns an error", func() {
+			_, err := a.Get(context.Background(), "wrong session name")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
 func TestSession_GetID(t *testing.T) {
 	Convey("Returns session ID", t, func() {
 		a := Session{
@@ -101,9 +351,9 @@ func TestSession_GetID(t *testing.T) {
 			UserRepo:    repository.NewUsersRepository(),
 		}
 		a.UserRepo.Add("test user", "test password", 1)
-		a.Add("test session id", "test session name", "test user")
+		a.Add(context.Background(), "test session id", "test session name", "test user", "", nil)
 
-		sID, err := a.GetID("test session name")
+		sID, err := a.GetID(context.Background(), "test session name")
 		Convey("Returns no errors", func() {
 			So(err, ShouldBeNil)
 		})
@@ -113,7 +363,7 @@ func TestSession_GetID(t *testing.T) {
 		})
 
 		Convey("Returns an error", func() {
-			_, err := a.GetID("wrong session name")
+			_, err := a.GetID(context.Background(), "wrong session name")
 			So(err, ShouldNotBeNil)
 			So(err.Error(), ShouldContainSubstring,
 				"session wrong session name does not exists")
@@ -127,23 +377,23 @@ func TestSession_addParticipant(t *testing.T) {
 		UserRepo:    repository.NewUsersRepository(),
 	}
 	a.UserRepo.Add("test user", "test password", 1)
-	a.Add("test session id", "test session name", "test user")
+	a.Add(context.Background(), "test session id", "test session name", "test user", "", nil)
 
 	Convey("Returns user error", t, func() {
-		_, err := a.addParticipant("test session id", "wrong user name")
+		_, err := a.addParticipant("test session id", "wrong user name", "")
 		So(err, ShouldNotBeNil)
 		So(err.Error(), ShouldContainSubstring, "login incorrect")
 	})
 
 	Convey("Returns session error", t, func() {
-		_, err := a.addParticipant("wrong session id", "test user")
+		_, err := a.addParticipant("wrong session id", "test user", "")
 		So(err, ShouldNotBeNil)
 		So(err.Error(), ShouldContainSubstring,
 			"session wrong session id does not exists")
 	})
 
 	Convey("Session owner cannot be added as subscriber", t, func() {
-		_, err := a.addParticipant("test session name", "test user")
+		_, err := a.addParticipant("test session name", "test user", "")
 		So(err, ShouldNotBeNil)
 		So(err.Error(), ShouldContainSubstring,
 			"owner test user can not subscribe session test session name")
@@ -151,11 +401,410 @@ func TestSession_addParticipant(t *testing.T) {
 
 	Convey("Duplicate of participant is impossible", t, func() {
 		a.UserRepo.Add("test participant", "test password", 0)
-		a.Add("test session id", "test session name", "test participant")
-		err := a.Add("test session id", "test session name", "test participant")
+		a.Add(context.Background(), "test session id", "test session name", "test participant", "", nil)
+		err := a.Add(context.Background(), "test session id", "test session name",
+			"test participant", "", nil)
 		So(err, ShouldNotBeNil)
 		So(err.Error(), ShouldContainSubstring,
 			"user test participant already subscribed to the session test session name")
 
 	})
 }
+
+func TestSession_List(t *testing.T) {
+	Convey("Returns list of sessions", t, func() {
+		a := Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.UserRepo.Add("other user", "test password", 1)
+		a.Add(context.Background(), "test session id", "test session name", "test user", "", nil)
+		a.Add(context.Background(), "other session id", "other session name", "other user", "", nil)
+
+		list, total, err := a.List(context.Background(), entity.SessionFilter{Owner: "test user"})
+
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 1)
+		So(list, ShouldHaveLength, 1)
+		So(list[0].Name, ShouldEqual, "test session name")
+	})
+}
+
+func TestSession_Guests(t *testing.T) {
+	Convey("Guest joins session", t, func() {
+		a := Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+			GuestRepo:   repository.NewGuestsRepository(),
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.Add(context.Background(), "test session id", "test session name", "test user", "",
+			&entity.SessionPolicy{AllowGuests: true})
+		a.GuestRepo.Add(entity.NewGuest("1", "test guest", "test session name", 0))
+		err := a.Add(context.Background(), "test session id", "test session name",
+			"guest:test guest#1", "", nil)
+
+		So(err, ShouldBeNil)
+		s, _ := a.SessionRepo.Get("test session name")
+		So(s.Subscribers["guest:test guest#1"], ShouldNotBeNil)
+
+		Convey("Guest is removed when leaves session", func() {
+			err := a.Delete(context.Background(), "test session name", "guest:test guest#1")
+			So(err, ShouldBeNil)
+			s, _ := a.SessionRepo.Get("test session name")
+			So(s.Subscribers, ShouldBeEmpty)
+			_, err = a.GuestRepo.Get("guest:test guest#1")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Guests are removed when owner closes session", func() {
+			err := a.Delete(context.Background(), "test session name", "test user")
+			So(err, ShouldBeNil)
+			_, err = a.GuestRepo.Get("guest:test guest#1")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Guest can not join other session", func() {
+			a.Add(context.Background(), "other session id", "other session name", "test user", "", nil)
+			err := a.Add(context.Background(), "other session id", "other session name",
+				"guest:test guest#1", "", nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"guest guest:test guest#1 can not join session other session name")
+		})
+
+		Convey("Returns unknown guest error", func() {
+			err := a.Add(context.Background(), "test session id", "test session name",
+				"guest:wrong", "", nil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+	Convey("Guest can not join session closed for guests", t, func() {
+		a := Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+			GuestRepo:   repository.NewGuestsRepository(),
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.Add(context.Background(), "test session id", "test session name", "test user", "", nil)
+		a.GuestRepo.Add(entity.NewGuest("1", "test guest", "test session name", 0))
+		err := a.Add(context.Background(), "test session id", "test session name",
+			"guest:test guest#1", "", nil)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"session test session name is not open for guests")
+	})
+}
+
+// sessionEventsMock is a mock that remembers published session events.
+type sessionEventsMock struct {
+	events []*entity.SessionEvent
+}
+
+// Publish remembers given event.
+func (m *sessionEventsMock) Publish(
+	ctx context.Context, event *entity.SessionEvent) {
+	m.events = append(m.events, event)
+}
+
+// types returns types of remembered events.
+func (m *sessionEventsMock) types() []entity.SessionEventType {
+	var list []entity.SessionEventType
+	for _, e := range m.events {
+		list = append(list, e.Type)
+	}
+	return list
+}
+
+func TestSession_Events(t *testing.T) {
+	Convey("Publishes lifecycle events of session", t, func() {
+		ctx := context.Background()
+		events := &sessionEventsMock{}
+		a := Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+			Events:      events,
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.UserRepo.Add("test participant", "test password", 0)
+
+		a.Add(ctx, "test session id", "test session name", "test user", "", nil)
+		a.Add(ctx, "test session id", "test session name",
+			"test participant", "", nil)
+		a.Add(ctx, "test session id", "test session name", "wrong user", "", nil)
+		a.Delete(ctx, "test session name", "test participant")
+		a.Delete(ctx, "test session name", "test user")
+
+		So(events.types(), ShouldResemble, []entity.SessionEventType{
+			entity.SessionCreated, entity.ParticipantJoined,
+			entity.ParticipantLeft, entity.SessionClosed,
+		})
+		So(events.events[1].User, ShouldEqual, "test participant")
+		So(events.events[1].SessionName, ShouldEqual, "test session name")
+		So(events.events[3].SessionID, ShouldEqual, "test session id")
+		So(events.events[3].Time.IsZero(), ShouldBeFalse)
+	})
+}
+
+func TestSession_TransferOwnership(t *testing.T) {
+	newAction := func(events *sessionEventsMock) *Session {
+		ctx := context.Background()
+		a := &Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+			Events:      events,
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.UserRepo.Add("test publisher", "test password", 1)
+		a.UserRepo.Add("test subscriber", "test password", 0)
+		a.Add(ctx, "test session id", "test session name", "test user", "", nil)
+		a.Add(ctx, "test session id", "test session name",
+			"test publisher", "", nil)
+		a.Add(ctx, "test session id", "test session name",
+			"test subscriber", "", nil)
+		events.events = nil
+		return a
+	}
+
+	Convey("Hands session over to participant", t, func() {
+		events := &sessionEventsMock{}
+		a := newAction(events)
+		err := a.TransferOwnership(context.Background(),
+			"test session name", "test user", "test publisher")
+
+		So(err, ShouldBeNil)
+		s, _ := a.SessionRepo.Get("test session name")
+		So(s.Owner.Name, ShouldEqual, "test publisher")
+		So(s.Subscribers, ShouldContainKey, "test user")
+		So(events.types(), ShouldResemble,
+			[]entity.SessionEventType{entity.OwnerChanged})
+		So(events.events[0].User, ShouldEqual, "test publisher")
+		So(events.events[0].PreviousOwner, ShouldEqual, "test user")
+
+		Convey("Previous owner leaves session as participant", func() {
+			So(a.Delete(context.Background(),
+				"test session name", "test user"), ShouldBeNil)
+			So(a.IsExists(context.Background(), "test session name"),
+				ShouldBeTrue)
+		})
+	})
+
+	Convey("Returns an error", t, func() {
+		events := &sessionEventsMock{}
+		a := newAction(events)
+		for _, c := range []struct {
+			session, user, owner, err string
+		}{
+			{"wrong session", "test user", "test publisher",
+				"does not exists"},
+			{"test session name", "test publisher", "test publisher",
+				"is not owner"},
+			{"test session name", "test user", "other user",
+				"is not participant"},
+			{"test session name", "test user", "test subscriber",
+				"can not own"},
+		} {
+			err := a.TransferOwnership(
+				context.Background(), c.session, c.user, c.owner)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, c.err)
+		}
+		So(events.events, ShouldBeEmpty)
+	})
+}
+
+func TestSession_AllowGuests(t *testing.T) {
+	Convey("Opens session for guests", t, func() {
+		a := Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.Add(context.Background(), "test session id", "test session name", "test user", "", nil)
+		err := a.AllowGuests(context.Background(), "test session name", "test user", true)
+
+		So(err, ShouldBeNil)
+		s, _ := a.SessionRepo.Get("test session name")
+		So(s.AllowGuests, ShouldBeTrue)
+
+		Convey("Returns an owner error", func() {
+			err := a.AllowGuests(context.Background(), "test session name", "other user", false)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"user other user is not owner of session test session name")
+		})
+
+		Convey("Returns a session error", func() {
+			err := a.AllowGuests(context.Background(), "wrong session", "test user", false)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSession_SetAccess(t *testing.T) {
+	Convey("Changes access mode of session", t, func() {
+		a := Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.UserRepo.Add("allowed user", "test password", 0)
+		a.UserRepo.Add("test participant", "test password", 0)
+		a.Add(context.Background(), "test session id", "test session name", "test user", "", nil)
+
+		Convey("to private", func() {
+			err := a.SetAccess(context.Background(), "test session name", "test user",
+				entity.AccessPrivate, []string{"allowed user"}, "")
+			So(err, ShouldBeNil)
+			s, _ := a.SessionRepo.Get("test session name")
+			So(s.Access, ShouldEqual, entity.AccessPrivate)
+
+			Convey("allowed user can join", func() {
+				err := a.Add(context.Background(), "test session id", "test session name",
+					"allowed user", "", nil)
+				So(err, ShouldBeNil)
+			})
+
+			Convey("other user can not join", func() {
+				err := a.Add(context.Background(), "test session id", "test session name",
+					"test participant", "", nil)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring,
+					"session test session name is private")
+			})
+		})
+
+		Convey("to passcode protected", func() {
+			err := a.SetAccess(context.Background(), "test session name", "test user",
+				entity.AccessPasscode, nil, "test passcode")
+			So(err, ShouldBeNil)
+
+			Convey("user with passcode can join", func() {
+				err := a.Add(context.Background(), "test session id", "test session name",
+					"test participant", "test passcode", nil)
+				So(err, ShouldBeNil)
+			})
+
+			Convey("user with wrong passcode can not join", func() {
+				err := a.Add(context.Background(), "test session id", "test session name",
+					"test participant", "wrong passcode", nil)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "passcode incorrect")
+			})
+
+			Convey("passcode is kept if not changed", func() {
+				err := a.SetAccess(context.Background(), "test session name", "test user",
+					entity.AccessPasscode, nil, "")
+				So(err, ShouldBeNil)
+				s, _ := a.SessionRepo.Get("test session name")
+				So(s.Authorize("test participant", "test passcode"),
+					ShouldBeNil)
+			})
+		})
+
+		Convey("Returns an empty passcode error", func() {
+			err := a.SetAccess(context.Background(), "test session name", "test user",
+				entity.AccessPasscode, nil, "")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "passcode is empty")
+		})
+
+		Convey("Returns an owner error", func() {
+			err := a.SetAccess(context.Background(), "test session name", "test participant",
+				entity.AccessPrivate, nil, "")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Returns a session error", func() {
+			err := a.SetAccess(context.Background(), "wrong session", "test user",
+				entity.AccessPrivate, nil, "")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+// recorderMock is a mock that imitates recording of sessions on OpenViDu
+// server and remembers stopped recordings.
+type recorderMock struct {
+	behavior string
+	stopped  []string
+}
+
+// StartRecording returns ID of recording or an error depending on behavior.
+func (m *recorderMock) StartRecording(
+	ctx context.Context, sessionID string) (string, error) {
+	if m.behavior != "ok" {
+		return "", errors.New("some error")
+	}
+	return "rec_" + sessionID, nil
+}
+
+// StopRecording remembers given recording ID.
+func (m *recorderMock) StopRecording(
+	ctx context.Context, recordingID string) error {
+	m.stopped = append(m.stopped, recordingID)
+	return nil
+}
+
+func TestSession_SetRecording(t *testing.T) {
+	newAction := func(recorder *recorderMock,
+		events *sessionEventsMock) *Session {
+		a := &Session{
+			SessionRepo: repository.NewSessionsRepository(),
+			UserRepo:    repository.NewUsersRepository(),
+			Recorder:    recorder,
+			Events:      events,
+		}
+		a.UserRepo.Add("test user", "test password", 1)
+		a.Add(context.Background(),
+			"test id", "test session name", "test user", "", nil)
+		events.events = nil
+		return a
+	}
+
+	Convey("Starts and stops recording of session", t, func() {
+		ctx := context.Background()
+		recorder := &recorderMock{behavior: "ok"}
+		events := &sessionEventsMock{}
+		a := newAction(recorder, events)
+
+		So(a.SetRecording(ctx, "test session name", "test user", true),
+			ShouldBeNil)
+		s, _ := a.SessionRepo.Get("test session name")
+		So(s.RecordingID, ShouldEqual, "rec_test id")
+
+		So(a.SetRecording(ctx, "test session name", "test user", true),
+			ShouldBeNil)
+		So(a.SetRecording(ctx, "test session name", "test user", false),
+			ShouldBeNil)
+		s, _ = a.SessionRepo.Get("test session name")
+		So(s.RecordingID, ShouldBeEmpty)
+		So(recorder.stopped, ShouldResemble, []string{"rec_test id"})
+		So(events.types(), ShouldResemble, []entity.SessionEventType{
+			entity.RecordingStarted, entity.RecordingStopped,
+		})
+		So(events.events[0].User, ShouldEqual, "test user")
+	})
+
+	Convey("Returns an error", t, func() {
+		ctx := context.Background()
+		events := &sessionEventsMock{}
+		a := newAction(&recorderMock{behavior: "ok"}, events)
+
+		So(a.SetRecording(ctx, "test session name", "other user", true),
+			ShouldNotBeNil)
+		So(a.SetRecording(ctx, "wrong session", "test user", true),
+			ShouldNotBeNil)
+
+		a.Recorder = &recorderMock{behavior: "failure"}
+		So(a.SetRecording(ctx, "test session name", "test user", true),
+			ShouldNotBeNil)
+
+		a.Recorder = nil
+		So(a.SetRecording(ctx, "test session name", "test user", true),
+			ShouldNotBeNil)
+		So(events.events, ShouldBeEmpty)
+	})
+}
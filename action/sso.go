@@ -0,0 +1,165 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+	"github.com/flexconstructor/openvidu-tutorial/metrics"
+)
+
+// SSO is an action that logs in users authenticated by OpenID Connect
+// identity provider. Claims of ID token are mapped to user and its role.
+type SSO struct {
+	UserRepo entity.Users
+
+	// UsernameClaim is a claim that holds user name, e.g.
+	// "preferred_username". Verified "email" and then "sub" claims are used
+	// if it is empty.
+	UsernameClaim string
+
+	// RoleClaim is a claim that holds string or list of strings mapped to
+	// user role by Roles, e.g. "groups".
+	RoleClaim string
+
+	// Roles maps values of RoleClaim to user roles. The highest role wins.
+	Roles map[string]entity.UserRole
+
+	// DefaultRole is a role of user none of whose claim values is mapped.
+	DefaultRole entity.UserRole
+
+	// Provision creates accounts for unknown users. Only existing accounts
+	// can log in if it is false.
+	Provision bool
+
+	// AuditSink records logins and failures. Audit log is disabled if nil.
+	AuditSink entity.AuditSink
+
+	// Now returns current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// Login returns user mapped from given ID token claims of user logging in
+// from given client IP. The user is created if it does not exist, otherwise
+// its role and profile are updated from claims.
+//
+// Local account with the same name is never taken over: login is refused
+// unless the account was created by the same identity provider subject.
+func (a *SSO) Login(ctx context.Context,
+	ip string, claims map[string]interface{}) (*entity.User, error) {
+	issuer, _ := claims["iss"].(string)
+	subject, _ := claims["sub"].(string)
+	if issuer == "" || subject == "" {
+		err := errors.New("ID token has no issuer or subject")
+		a.Fail(ctx, ip, err)
+		return nil, err
+	}
+	mapped := &entity.User{
+		Name:        a.userName(claims),
+		Role:        a.role(claims),
+		DisplayName: claimString(claims, "name"),
+		Email:       verifiedEmail(claims),
+		Subject:     issuer + " " + subject,
+	}
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":    mapped.Name,
+		"subject": mapped.Subject,
+	})
+	user, err := storeExternalUser(a.UserRepo, mapped, a.Provision)
+	if err != nil {
+		log.WithError(err).Warn("single sign-on refused")
+		metrics.CountLogin(false)
+		a.audit(ctx, entity.AuditLoginFailed, ip, mapped.Name, err)
+		return nil, err
+	}
+	log.WithField("role", user.Role.String()).
+		Info("user logged in with single sign-on")
+	metrics.CountLogin(true)
+	a.audit(ctx, entity.AuditLogin, ip, user.Name, nil)
+	return user, nil
+}
+
+// Fail records single sign-on from given client IP that failed with given
+// error before user is known, e.g. at identity provider.
+func (a *SSO) Fail(ctx context.Context, ip string, err error) {
+	logging.FromContext(ctx).WithError(err).WithField("ip", ip).
+		Warn("single sign-on failed")
+	metrics.CountLogin(false)
+	a.audit(ctx, entity.AuditLoginFailed, ip, "", err)
+}
+
+// audit records single sign-on event of given user made from given IP with
+// given error.
+func (a *SSO) audit(ctx context.Context, action entity.AuditAction,
+	ip string, username string, err error) {
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Time:    a.now(),
+		Action:  action,
+		Actor:   username,
+		IP:      ip,
+		Error:   errorText(err),
+		Details: "single sign-on",
+	})
+}
+
+// now returns current time.
+func (a *SSO) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
+
+// userName returns user name mapped from given claims.
+func (a *SSO) userName(claims map[string]interface{}) string {
+	if a.UsernameClaim != "" {
+		if name := claimString(claims, a.UsernameClaim); name != "" {
+			return name
+		}
+	}
+	if email := verifiedEmail(claims); email != "" {
+		return email
+	}
+	return claimString(claims, "sub")
+}
+
+// role returns the highest user role mapped from values of role claim.
+func (a *SSO) role(claims map[string]interface{}) entity.UserRole {
+	var values []string
+	switch v := claims[a.RoleClaim].(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, value := range v {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return entity.HighestRole(values, a.Roles, a.DefaultRole)
+}
+
+// claimString returns trimmed string claim with given name, or an empty
+// string if there is no such claim.
+func claimString(claims map[string]interface{}, name string) string {
+	s, _ := claims[name].(string)
+	return strings.TrimSpace(s)
+}
+
+// verifiedEmail returns mail address from claims if identity provider has
+// verified it, or an empty string otherwise.
+func verifiedEmail(claims map[string]interface{}) string {
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		return ""
+	}
+	email, err := parseEmail(claimString(claims, "email"))
+	if err != nil {
+		return ""
+	}
+	return email
+}
@@ -0,0 +1,153 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+func newSSOAction() *SSO {
+	userRepo := repository.NewUsersRepository()
+	userRepo.Add("test user", "test password", 1)
+	return &SSO{
+		UserRepo:      userRepo,
+		UsernameClaim: "preferred_username",
+		RoleClaim:     "groups",
+		Roles: map[string]entity.UserRole{
+			"staff":  entity.Publisher,
+			"admins": entity.Moderator,
+		},
+		DefaultRole: entity.Subscriber,
+		Provision:   true,
+	}
+}
+
+func TestSSO_Login(t *testing.T) {
+	ctx := context.Background()
+	claims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":                "https://idp.example.com",
+			"sub":                "42",
+			"preferred_username": "sso user",
+			"name":               "SSO User",
+			"email":              "sso@example.com",
+			"email_verified":     true,
+			"groups":             []interface{}{"staff", "admins", "other"},
+		}
+	}
+
+	Convey("Provisions user mapped from claims", t, func() {
+		a := newSSOAction()
+		user, err := a.Login(ctx, "127.0.0.1", claims())
+
+		So(err, ShouldBeNil)
+		So(user.Name, ShouldEqual, "sso user")
+		So(user.DisplayName, ShouldEqual, "SSO User")
+		So(user.Email, ShouldEqual, "sso@example.com")
+		So(user.Role, ShouldEqual, entity.Moderator)
+		So(user.Subject, ShouldEqual, "https://idp.example.com 42")
+		So(user.Password, ShouldNotBeEmpty)
+		stored, _ := a.UserRepo.Get("sso user")
+		So(stored, ShouldEqual, user)
+
+		Convey("Updates role on next login", func() {
+			c := claims()
+			c["groups"] = "staff"
+			delete(c, "name")
+			user, err := a.Login(ctx, "127.0.0.1", c)
+
+			So(err, ShouldBeNil)
+			So(user.Role, ShouldEqual, entity.Publisher)
+			So(user.DisplayName, ShouldEqual, "SSO User")
+			stored, _ := a.UserRepo.Get("sso user")
+			So(stored.Role, ShouldEqual, entity.Publisher)
+		})
+
+		Convey("Returns an error if other subject has the name", func() {
+			c := claims()
+			c["sub"] = "43"
+			_, err := a.Login(ctx, "127.0.0.1", c)
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Records logins and failures", t, func() {
+		a := newSSOAction()
+		sink := &auditSinkMock{behavior: "ok"}
+		a.AuditSink = sink
+		a.Login(ctx, "127.0.0.1", claims())
+		c := claims()
+		c["preferred_username"] = "test user"
+		a.Login(ctx, "127.0.0.1", c)
+		a.Fail(ctx, "127.0.0.1", errors.New("access denied"))
+
+		So(sink.actions(), ShouldResemble, []entity.AuditAction{
+			entity.AuditLogin, entity.AuditLoginFailed,
+			entity.AuditLoginFailed,
+		})
+		So(sink.events[0].Actor, ShouldEqual, "sso user")
+		So(sink.events[0].IP, ShouldEqual, "127.0.0.1")
+		So(sink.events[1].Actor, ShouldEqual, "test user")
+		So(sink.events[1].Error, ShouldNotBeEmpty)
+		So(sink.events[2].Error, ShouldEqual, "access denied")
+	})
+
+	Convey("Maps unverified user to fallback name and default role", t,
+		func() {
+			a := newSSOAction()
+			c := claims()
+			delete(c, "preferred_username")
+			delete(c, "groups")
+			c["email_verified"] = false
+			user, err := a.Login(ctx, "127.0.0.1", c)
+
+			So(err, ShouldBeNil)
+			So(user.Name, ShouldEqual, "42")
+			So(user.Email, ShouldBeEmpty)
+			So(user.Role, ShouldEqual, entity.Subscriber)
+		})
+
+	Convey("Returns an error", t, func() {
+		a := newSSOAction()
+
+		Convey("if local account has the name", func() {
+			c := claims()
+			c["preferred_username"] = "test user"
+			_, err := a.Login(ctx, "127.0.0.1", c)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual,
+				"user test user is not linked to identity provider")
+		})
+
+		Convey("if user is unknown and provisioning is disabled", func() {
+			a.Provision = false
+			_, err := a.Login(ctx, "127.0.0.1", claims())
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "user sso user is not registered")
+		})
+
+		Convey("if user name looks like guest", func() {
+			c := claims()
+			c["preferred_username"] = entity.GuestPrefix + "x"
+			_, err := a.Login(ctx, "127.0.0.1", c)
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("if subject is missing", func() {
+			c := claims()
+			delete(c, "sub")
+			_, err := a.Login(ctx, "127.0.0.1", c)
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
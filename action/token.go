@@ -0,0 +1,189 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// tokenIssuer is an issuer of JWT access tokens.
+const tokenIssuer = "openvidu-tutorial"
+
+// errAccessToken is returned for any access token that is not accepted.
+var errAccessToken = errors.New("access token is invalid")
+
+// Token is an action that issues short-lived JWT access tokens with
+// rotating refresh tokens to clients that do not use HTTP session.
+type Token struct {
+	UserRepo    entity.Users
+	RefreshRepo entity.RefreshTokens
+
+	// LoginAction checks credentials exactly like the login form does.
+	LoginAction interface {
+		Do(ctx context.Context,
+			ip string, username string, password string) error
+	}
+
+	// TwoFactorAction asks users with two-factor authentication for
+	// one-time password. Password is the only factor if it is nil.
+	TwoFactorAction interface {
+		Enabled(userName string) bool
+		Verify(ctx context.Context,
+			ip string, userName string, code string) error
+	}
+
+	// Secret is a key access tokens are signed with.
+	Secret []byte
+
+	// AccessTTL and RefreshTTL are lifetimes of access and refresh tokens.
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+
+	// Now returns current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// Issue returns new token pair for user with given credentials.
+//
+// parameters:
+//  ctx       context.Context  Context of request.
+//  ip        string           Client IP address.
+//  username  string           Login of user.
+//  password  string           Password of user.
+//  code      string           One-time password or recovery code of user
+//                             with two-factor authentication.
+func (a *Token) Issue(ctx context.Context, ip string, username string,
+	password string, code string) (*entity.TokenPair, error) {
+	if err := a.LoginAction.Do(ctx, ip, username, password); err != nil {
+		return nil, err
+	}
+	if a.TwoFactorAction != nil && a.TwoFactorAction.Enabled(username) {
+		if code == "" {
+			return nil, errors.New("one-time password required")
+		}
+		if err := a.TwoFactorAction.Verify(
+			ctx, ip, username, code); err != nil {
+			return nil, err
+		}
+	}
+	family, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	pair, err := a.newPair(username, family)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user": username,
+		"ip":   ip,
+	}).Info("access token issued")
+	return pair, nil
+}
+
+// Refresh exchanges given refresh token for new token pair. Presenting
+// used refresh token again revokes all tokens rotated from the same login,
+// as the token has leaked.
+func (a *Token) Refresh(
+	ctx context.Context, refreshToken string) (*entity.TokenPair, error) {
+	token, err := a.RefreshRepo.Use(hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	log := logging.FromContext(ctx).WithField("user", token.UserName)
+	if err = token.Check(a.now()); err != nil {
+		if token.Used {
+			log.WithField("audit", true).
+				Warn("used refresh token presented, revoking its family")
+			if e := a.RefreshRepo.DeleteFamily(token.Family); e != nil {
+				log.WithError(e).Error("refresh tokens revocation failed")
+			}
+		}
+		return nil, err
+	}
+	user, err := a.UserRepo.Get(token.UserName)
+	if err != nil || user.Pending {
+		return nil, fmt.Errorf("user %s can not log in", token.UserName)
+	}
+	return a.newPair(user.Name, token.Family)
+}
+
+// Revoke revokes given refresh token and all tokens rotated from the same
+// login. Issued access tokens stay valid until they expire.
+func (a *Token) Revoke(ctx context.Context, refreshToken string) error {
+	token, err := a.RefreshRepo.Use(hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	return a.RefreshRepo.DeleteFamily(token.Family)
+}
+
+// Verify returns user given access token is issued to.
+func (a *Token) Verify(
+	ctx context.Context, accessToken string) (*entity.User, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(accessToken, claims,
+		func(*jwt.Token) (interface{}, error) { return a.Secret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(a.now))
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).Debug("access token refused")
+		return nil, errAccessToken
+	}
+	user, err := a.UserRepo.Get(claims.Subject)
+	if err != nil || user.Pending {
+		return nil, errAccessToken
+	}
+	return user, nil
+}
+
+// newPair returns new token pair of user with given name and stores its
+// refresh token in given family.
+func (a *Token) newPair(
+	userName string, family string) (*entity.TokenPair, error) {
+	now := a.now()
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256,
+		jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   userName,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.AccessTTL)),
+		}).SignedString(a.Secret)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := newResetToken()
+	if err != nil {
+		return nil, err
+	}
+	if err = a.RefreshRepo.Add(&entity.RefreshToken{
+		TokenHash: hashToken(refresh),
+		UserName:  userName,
+		Family:    family,
+		ExpiresAt: now.Add(a.RefreshTTL),
+	}); err != nil {
+		return nil, err
+	}
+	return &entity.TokenPair{
+		AccessToken:  access,
+		ExpiresIn:    a.AccessTTL,
+		RefreshToken: refresh,
+	}, nil
+}
+
+// now returns current time.
+func (a *Token) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
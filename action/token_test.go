@@ -0,0 +1,157 @@
+package action
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+func TestToken(t *testing.T) {
+	ctx := context.Background()
+	newAction := func(now *time.Time) *Token {
+		userRepo := repository.NewUsersRepository()
+		userRepo.Add("test user", "test password", 1)
+		return &Token{
+			UserRepo:    userRepo,
+			RefreshRepo: repository.NewRefreshTokensRepository(),
+			LoginAction: &Login{UserRepo: userRepo},
+			Secret:      []byte("test secret of at least 32 bytes"),
+			AccessTTL:   15 * time.Minute,
+			RefreshTTL:  time.Hour,
+			Now:         func() time.Time { return *now },
+		}
+	}
+
+	Convey("Issues token pair", t, func() {
+		now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+		a := newAction(&now)
+		pair, err := a.Issue(
+			ctx, "127.0.0.1", "test user", "test password", "")
+
+		So(err, ShouldBeNil)
+		So(pair.ExpiresIn, ShouldEqual, 15*time.Minute)
+
+		Convey("with access token of the user", func() {
+			user, err := a.Verify(ctx, pair.AccessToken)
+
+			So(err, ShouldBeNil)
+			So(user.Name, ShouldEqual, "test user")
+		})
+
+		Convey("with access token that expires", func() {
+			now = now.Add(16 * time.Minute)
+			_, err := a.Verify(ctx, pair.AccessToken)
+
+			So(err, ShouldEqual, errAccessToken)
+		})
+
+		Convey("with refresh token that rotates", func() {
+			now = now.Add(30 * time.Minute)
+			next, err := a.Refresh(ctx, pair.RefreshToken)
+
+			So(err, ShouldBeNil)
+			So(next.RefreshToken, ShouldNotEqual, pair.RefreshToken)
+			_, err = a.Verify(ctx, next.AccessToken)
+			So(err, ShouldBeNil)
+
+			Convey("and revokes its family if used again", func() {
+				_, err := a.Refresh(ctx, pair.RefreshToken)
+				So(err, ShouldNotBeNil)
+
+				_, err = a.Refresh(ctx, next.RefreshToken)
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("with refresh token that expires", func() {
+			now = now.Add(2 * time.Hour)
+			_, err := a.Refresh(ctx, pair.RefreshToken)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "expired")
+		})
+
+		Convey("with refresh token that is revoked", func() {
+			So(a.Revoke(ctx, pair.RefreshToken), ShouldBeNil)
+			_, err := a.Refresh(ctx, pair.RefreshToken)
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Returns an error on issue", t, func() {
+		now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+		a := newAction(&now)
+
+		Convey("if password is wrong", func() {
+			_, err := a.Issue(ctx, "127.0.0.1", "test user", "wrong", "")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("if one-time password is missing", func() {
+			twoFactor := newTwoFactorAction(&now)
+			twoFactor.UserRepo = a.UserRepo
+			a.TwoFactorAction = twoFactor
+			a.UserRepo.Modify("test user", func(user *entity.User) error {
+				user.TOTPSecret = "JBSWY3DPEHPK3PXP"
+				return nil
+			})
+			_, err := a.Issue(
+				ctx, "127.0.0.1", "test user", "test password", "")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "one-time password")
+		})
+	})
+
+	Convey("Refuses access token", t, func() {
+		now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+		a := newAction(&now)
+		sign := func(method jwt.SigningMethod, key interface{},
+			claims jwt.RegisteredClaims) string {
+			token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+			if err != nil {
+				panic(err)
+			}
+			return token
+		}
+		claims := jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   "test user",
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}
+
+		Convey("signed with other key", func() {
+			_, err := a.Verify(ctx, sign(jwt.SigningMethodHS256,
+				[]byte("other secret of at least 32 bytes"), claims))
+			So(err, ShouldEqual, errAccessToken)
+		})
+
+		Convey("without signature", func() {
+			_, err := a.Verify(ctx, sign(jwt.SigningMethodNone,
+				jwt.UnsafeAllowNoneSignatureType, claims))
+			So(err, ShouldEqual, errAccessToken)
+		})
+
+		Convey("of unknown user", func() {
+			claims.Subject = "unknown user"
+			_, err := a.Verify(ctx,
+				sign(jwt.SigningMethodHS256, a.Secret, claims))
+			So(err, ShouldEqual, errAccessToken)
+		})
+
+		Convey("of pending user", func() {
+			a.UserRepo.Create(&entity.User{Name: "pending user", Pending: true})
+			claims.Subject = "pending user"
+			_, err := a.Verify(ctx,
+				sign(jwt.SigningMethodHS256, a.Secret, claims))
+			So(err, ShouldEqual, errAccessToken)
+		})
+	})
+}
@@ -0,0 +1,299 @@
+package action
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+const (
+	// recoveryCodesCount is a number of recovery codes given on
+	// enrollment.
+	recoveryCodesCount = 10
+
+	// totpPeriod is a validity period of one-time password in seconds.
+	totpPeriod = 30
+)
+
+// errOneTimePassword is returned when neither one-time password nor
+// recovery code is correct.
+var errOneTimePassword = errors.New("one-time password incorrect")
+
+// TwoFactor is an action that manages two-factor authentication with
+// time-based one-time passwords (TOTP) and recovery codes.
+type TwoFactor struct {
+	UserRepo entity.Users
+
+	// Issuer is a name of the application shown by authenticator apps.
+	Issuer string
+
+	// LoginAction throttles guessing of one-time passwords together with
+	// passwords. Guesses are not limited if it is nil.
+	LoginAction interface {
+		Begin(ip string, username string) error
+		Fail(ctx context.Context, ip string, username string)
+		Succeed(ip string, username string)
+	}
+
+	// AuditSink records enabling and disabling of two-factor
+	// authentication. Audit log is disabled if nil.
+	AuditSink entity.AuditSink
+
+	// Now returns current time. time.Now is used if nil.
+	Now func() time.Time
+}
+
+// Enabled returns true if user with given name has two-factor
+// authentication enabled.
+func (a *TwoFactor) Enabled(userName string) bool {
+	user, err := a.UserRepo.Get(userName)
+	return err == nil && user.TwoFactor()
+}
+
+// Enroll returns new provisioning URI of one-time passwords for user with
+// given name. The URI is shown as QR code to be scanned by authenticator
+// app and is activated by Activate.
+func (a *TwoFactor) Enroll(userName string) (string, error) {
+	user, err := a.UserRepo.Get(userName)
+	if err != nil {
+		return "", err
+	}
+	if user.TwoFactor() {
+		return "", errors.New("two-factor authentication is already enabled")
+	}
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      a.Issuer,
+		AccountName: userName,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return "", err
+	}
+	return key.URL(), nil
+}
+
+// Activate enables two-factor authentication of user with given name if
+// given one-time password is generated by given provisioning URI. Returns
+// recovery codes that are shown to the user once.
+//
+// parameters:
+//  ctx       context.Context  Context of request.
+//  userName  string           Name of enrolled user.
+//  uri       string           Provisioning URI returned by Enroll.
+//  code      string           One-time password from authenticator app.
+func (a *TwoFactor) Activate(ctx context.Context,
+	userName string, uri string, code string) ([]string, error) {
+	key, err := otp.NewKeyFromURL(uri)
+	if err != nil || key.AccountName() != userName {
+		return nil, errors.New("enrollment is invalid, start again")
+	}
+	if _, err = a.UserRepo.Get(userName); err != nil {
+		return nil, err
+	}
+	counter, ok := a.validate(key.Secret(), 0, code)
+	if !ok {
+		return nil, errOneTimePassword
+	}
+	codes, hashes := newRecoveryCodes()
+	err = a.UserRepo.Modify(userName, func(user *entity.User) error {
+		user.TOTPSecret = key.Secret()
+		user.TOTPCounter = counter
+		user.RecoveryCodes = hashes
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":  userName,
+		"audit": true,
+	}).Info("two-factor authentication enabled")
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action: entity.AuditTwoFactorEnable,
+		Actor:  userName,
+	})
+	return codes, nil
+}
+
+// Verify returns an error unless given code is current one-time password
+// or unused recovery code of user with given name. Used recovery code and
+// one-time password can not be used again.
+func (a *TwoFactor) Verify(ctx context.Context,
+	ip string, userName string, code string) error {
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user": userName,
+		"ip":   ip,
+	})
+	if a.LoginAction != nil {
+		if err := a.LoginAction.Begin(ip, userName); err != nil {
+			log.WithError(err).Warn("second factor refused")
+			return err
+		}
+	}
+	err := a.verify(userName, code, log)
+	if err != nil {
+		log.WithError(err).Warn("second factor failed")
+	}
+	a.end(ctx, ip, userName, err)
+	return err
+}
+
+// Disable turns off two-factor authentication of user with given name if
+// given code is its one-time password or recovery code. Guesses made from
+// given client IP are throttled like second login step.
+func (a *TwoFactor) Disable(ctx context.Context,
+	ip string, userName string, code string) error {
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"user":  userName,
+		"ip":    ip,
+		"audit": true,
+	})
+	if a.LoginAction != nil {
+		if err := a.LoginAction.Begin(ip, userName); err != nil {
+			log.WithError(err).Warn("two-factor disabling refused")
+			return err
+		}
+	}
+	err := a.UserRepo.Modify(userName, func(user *entity.User) error {
+		if err := a.consume(user, code, log); err != nil {
+			return err
+		}
+		user.TOTPSecret = ""
+		user.TOTPCounter = 0
+		user.RecoveryCodes = nil
+		return nil
+	})
+	a.end(ctx, ip, userName, err)
+	audit(ctx, a.AuditSink, entity.AuditEvent{
+		Action: entity.AuditTwoFactorDisable,
+		Actor:  userName,
+		IP:     ip,
+		Error:  errorText(err),
+	})
+	if err != nil {
+		log.WithError(err).Warn("two-factor disabling failed")
+		return err
+	}
+	log.Info("two-factor authentication disabled")
+	return nil
+}
+
+// verify checks given code of user with given name and consumes it.
+func (a *TwoFactor) verify(
+	userName string, code string, log *logrus.Entry) error {
+	return a.UserRepo.Modify(userName, func(user *entity.User) error {
+		return a.consume(user, code, log)
+	})
+}
+
+// end ends attempt of user with given name from given IP that failed with
+// given error or succeeded if it is nil.
+func (a *TwoFactor) end(
+	ctx context.Context, ip string, userName string, err error) {
+	switch {
+	case a.LoginAction == nil:
+	case err != nil:
+		a.LoginAction.Fail(ctx, ip, userName)
+	default:
+		a.LoginAction.Succeed(ip, userName)
+	}
+}
+
+// consume checks given code of given user and marks it used: one-time
+// password of the same or earlier time step and used recovery code are not
+// accepted again.
+func (a *TwoFactor) consume(
+	user *entity.User, code string, log *logrus.Entry) error {
+	if !user.TwoFactor() {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if counter, ok := a.validate(
+		user.TOTPSecret, user.TOTPCounter, code); ok {
+		user.TOTPCounter = counter
+		return nil
+	}
+	hash := hashToken(normalizeRecoveryCode(code))
+	for i, h := range user.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) != 1 {
+			continue
+		}
+		user.RecoveryCodes = append(append([]string{},
+			user.RecoveryCodes[:i]...), user.RecoveryCodes[i+1:]...)
+		log.WithField("left", len(user.RecoveryCodes)).
+			Warn("recovery code used")
+		return nil
+	}
+	return errOneTimePassword
+}
+
+// validate returns time step of given one-time password if it is
+// generated by given secret for current, previous or next time step that
+// is later than given last accepted one.
+func (a *TwoFactor) validate(
+	secret string, last uint64, code string) (uint64, bool) {
+	code = strings.TrimSpace(code)
+	now := a.now()
+	for _, skew := range []int{0, -1, 1} {
+		t := now.Add(time.Duration(skew*totpPeriod) * time.Second)
+		counter := uint64(t.Unix() / totpPeriod)
+		if counter <= last {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(secret, t,
+			totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare(
+			[]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// now returns current time.
+func (a *TwoFactor) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
+
+// newRecoveryCodes returns new random recovery codes and their hashes.
+func newRecoveryCodes() ([]string, []string) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes
+}
+
+// normalizeRecoveryCode returns given recovery code in lower case without
+// separators.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,192 @@
+package action
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+// newTwoFactorAction returns two-factor action at given time with test user
+// and login throttling.
+func newTwoFactorAction(now *time.Time) *TwoFactor {
+	userRepo := repository.NewUsersRepository()
+	userRepo.Add("test user", "test password", 1)
+	return &TwoFactor{
+		UserRepo: userRepo,
+		Issuer:   "Test",
+		LoginAction: &Login{
+			UserRepo:    userRepo,
+			AttemptRepo: repository.NewAttemptsRepository(time.Minute),
+			MaxFailures: 3,
+			Lockout:     time.Minute,
+			Now:         func() time.Time { return *now },
+		},
+		AuditSink: &auditSinkMock{behavior: "ok"},
+		Now:       func() time.Time { return *now },
+	}
+}
+
+// oneTimePassword returns one-time password of given provisioning URI at
+// given time.
+func oneTimePassword(uri string, t time.Time) string {
+	key, err := otp.NewKeyFromURL(uri)
+	if err != nil {
+		panic(err)
+	}
+	code, err := totp.GenerateCodeCustom(key.Secret(), t,
+		totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix})
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+func TestTwoFactor(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Enrolls user", t, func() {
+		now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+		a := newTwoFactorAction(&now)
+		uri, err := a.Enroll("test user")
+
+		So(err, ShouldBeNil)
+		u, _ := url.Parse(uri)
+		So(u.Scheme, ShouldEqual, "otpauth")
+		So(u.Query().Get("issuer"), ShouldEqual, "Test")
+		So(a.Enabled("test user"), ShouldBeFalse)
+
+		codes, err := a.Activate(
+			ctx, "test user", uri, oneTimePassword(uri, now))
+
+		So(err, ShouldBeNil)
+		So(codes, ShouldHaveLength, recoveryCodesCount)
+		So(a.Enabled("test user"), ShouldBeTrue)
+		sink := a.AuditSink.(*auditSinkMock)
+		So(sink.actions(), ShouldResemble,
+			[]entity.AuditAction{entity.AuditTwoFactorEnable})
+
+		Convey("Refuses to enroll again", func() {
+			_, err := a.Enroll("test user")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Verifies next one-time password once", func() {
+			now = now.Add(totpPeriod * time.Second)
+			code := oneTimePassword(uri, now)
+
+			So(a.Verify(ctx, "127.0.0.1", "test user", code), ShouldBeNil)
+			So(a.Verify(ctx, "127.0.0.1", "test user", code),
+				ShouldEqual, errOneTimePassword)
+		})
+
+		Convey("Accepts one-time password of adjacent time step", func() {
+			code := oneTimePassword(uri, now.Add(totpPeriod*time.Second))
+			now = now.Add(2 * totpPeriod * time.Second)
+
+			So(a.Verify(ctx, "127.0.0.1", "test user", code), ShouldBeNil)
+		})
+
+		Convey("Refuses one-time password used on activation", func() {
+			err := a.Verify(
+				ctx, "127.0.0.1", "test user", oneTimePassword(uri, now))
+
+			So(err, ShouldEqual, errOneTimePassword)
+		})
+
+		Convey("Verifies recovery code once", func() {
+			So(a.Verify(ctx, "127.0.0.1", "test user",
+				" "+codes[0]+" "), ShouldBeNil)
+			So(a.Verify(ctx, "127.0.0.1", "test user", codes[0]),
+				ShouldEqual, errOneTimePassword)
+			user, _ := a.UserRepo.Get("test user")
+			So(user.RecoveryCodes, ShouldHaveLength, recoveryCodesCount-1)
+		})
+
+		Convey("Locks account after failed guesses", func() {
+			for i := 0; i < 3; i++ {
+				a.Verify(ctx, "127.0.0.1", "test user", "000000")
+			}
+			err := a.Verify(ctx, "127.0.0.1", "test user", codes[1])
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "too many failed")
+		})
+
+		Convey("Disables two-factor authentication", func() {
+			So(a.Disable(ctx, "127.0.0.1", "test user", codes[2]),
+				ShouldBeNil)
+			So(a.Enabled("test user"), ShouldBeFalse)
+			user, _ := a.UserRepo.Get("test user")
+			So(user.RecoveryCodes, ShouldBeEmpty)
+			So(sink.actions(), ShouldResemble, []entity.AuditAction{
+				entity.AuditTwoFactorEnable, entity.AuditTwoFactorDisable})
+			So(sink.events[1].IP, ShouldEqual, "127.0.0.1")
+			So(sink.events[1].Error, ShouldBeEmpty)
+		})
+
+		Convey("Throttles guesses on disabling", func() {
+			for i := 0; i < 3; i++ {
+				a.Disable(ctx, "127.0.0.1", "test user", "000000")
+			}
+			err := a.Disable(ctx, "127.0.0.1", "test user", codes[2])
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "too many failed")
+			So(a.Enabled("test user"), ShouldBeTrue)
+			So(sink.events, ShouldHaveLength, 4)
+			So(sink.events[3].Error, ShouldNotBeEmpty)
+		})
+
+		Convey("Accepts code once in parallel requests", func() {
+			a.LoginAction = nil
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			accepted := 0
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if a.Verify(ctx, "127.0.0.1", "test user",
+						codes[3]) == nil {
+						mu.Lock()
+						accepted++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			So(accepted, ShouldEqual, 1)
+		})
+	})
+
+	Convey("Returns an error on activation", t, func() {
+		now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+		a := newTwoFactorAction(&now)
+		uri, _ := a.Enroll("test user")
+
+		Convey("if one-time password is wrong", func() {
+			code := oneTimePassword(uri, now.Add(time.Hour))
+			_, err := a.Activate(ctx, "test user", uri, code)
+
+			So(err, ShouldEqual, errOneTimePassword)
+			So(a.Enabled("test user"), ShouldBeFalse)
+		})
+
+		Convey("if URI is enrolled for other user", func() {
+			_, err := a.Activate(
+				ctx, "other user", uri, oneTimePassword(uri, now))
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
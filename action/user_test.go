@@ -25,7 +25,7 @@ func TestUser_Get(t *testing.T) {
 
 			Convey("with correct user data", func() {
 				So(user.Name, ShouldEqual, "test user")
-				So(user.Password, ShouldEqual, "test user password")
+				So(user.ValidPassword("test user password"), ShouldBeTrue)
 				So(user.Role, ShouldEqual, 1)
 			})
 		})
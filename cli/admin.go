@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Usage describes admin commands.
+const Usage = `Admin commands:
+  users list                  list users
+  users add <name> <role>     add user with password read from stdin
+  users remove <name>         remove user and close sessions it owns
+  users role <name> <role>    change role of user
+  users passwd <name>         reset password of user to one read from stdin
+  sessions list               list active sessions
+  sessions close <name>       close session on OpenViDu server and remove it
+  export [--credentials]      write users and sessions to stdout as JSON,
+                              with password hashes and 2FA secrets if asked
+  import                      read users and sessions from stdin as JSON
+
+Roles are SUBSCRIBER, PUBLISHER and MODERATOR.
+`
+
+// ErrUsage is returned for unknown command or wrong number of arguments.
+var ErrUsage = errors.New("invalid command, see usage")
+
+// Admin is a command-line controller that runs admin commands.
+type Admin struct {
+	AdminAction interface {
+		Users() ([]*entity.User, error)
+		AddUser(ctx context.Context, userName string,
+			role entity.UserRole, password string) error
+		RemoveUser(ctx context.Context, userName string) error
+		SetRole(ctx context.Context,
+			userName string, role entity.UserRole) error
+		ResetPassword(ctx context.Context,
+			userName string, password string) error
+		Sessions() ([]*entity.Session, error)
+		CloseSession(ctx context.Context, sessionName string) error
+		Export(w io.Writer, credentials bool) error
+		Import(ctx context.Context, r io.Reader) (int, int, error)
+	}
+
+	// In is a source of passwords and imported data.
+	In io.Reader
+
+	// Out is a destination of command results.
+	Out io.Writer
+}
+
+// Run runs admin command given by its arguments.
+//
+// parameters:
+//  ctx   context.Context  Context of command.
+//  args  []string         Command name followed by its arguments, e.g.
+//                         "users", "add", "alice", "PUBLISHER".
+func (c *Admin) Run(ctx context.Context, args []string) error {
+	switch command(args, 2) {
+	case "users list":
+		return c.listUsers()
+	case "users add":
+		if len(args) != 4 {
+			return ErrUsage
+		}
+		role, err := entity.ParseUserRole(strings.ToUpper(args[3]))
+		if err != nil {
+			return err
+		}
+		password, err := c.readPassword()
+		if err != nil {
+			return err
+		}
+		return c.AdminAction.AddUser(ctx, args[2], role, password)
+	case "users remove":
+		if len(args) != 3 {
+			return ErrUsage
+		}
+		return c.AdminAction.RemoveUser(ctx, args[2])
+	case "users role":
+		if len(args) != 4 {
+			return ErrUsage
+		}
+		role, err := entity.ParseUserRole(strings.ToUpper(args[3]))
+		if err != nil {
+			return err
+		}
+		return c.AdminAction.SetRole(ctx, args[2], role)
+	case "users passwd":
+		if len(args) != 3 {
+			return ErrUsage
+		}
+		password, err := c.readPassword()
+		if err != nil {
+			return err
+		}
+		return c.AdminAction.ResetPassword(ctx, args[2], password)
+	case "sessions list":
+		return c.listSessions()
+	case "sessions close":
+		if len(args) != 3 {
+			return ErrUsage
+		}
+		return c.AdminAction.CloseSession(ctx, args[2])
+	}
+	switch command(args, 1) {
+	case "export":
+		switch {
+		case len(args) == 1:
+			return c.AdminAction.Export(c.Out, false)
+		case len(args) == 2 && args[1] == "--credentials":
+			return c.AdminAction.Export(c.Out, true)
+		}
+		return ErrUsage
+	case "import":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+		users, sessions, err := c.AdminAction.Import(ctx, c.In)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(c.Out,
+			"imported %d users and %d sessions\n", users, sessions)
+		return err
+	}
+	return ErrUsage
+}
+
+// listUsers writes table of all users.
+func (c *Admin) listUsers() error {
+	list, err := c.AdminAction.Users()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(c.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tROLE\tSTATUS")
+	for _, user := range list {
+		status := make([]string, 0, 3)
+		if user.Pending {
+			status = append(status, "pending")
+		}
+		if user.TwoFactor() {
+			status = append(status, "2fa")
+		}
+		if user.Subject != "" {
+			status = append(status, "external")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n",
+			user.Name, user.Role, strings.Join(status, ","))
+	}
+	return w.Flush()
+}
+
+// listSessions writes table of all active sessions.
+func (c *Admin) listSessions() error {
+	list, err := c.AdminAction.Sessions()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(c.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tID\tOWNER\tPARTICIPANTS\tCREATED")
+	for _, s := range list {
+		owner := ""
+		if s.Owner != nil {
+			owner = s.Owner.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", s.Name, s.ID, owner,
+			len(s.Subscribers), s.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+// readPassword returns first line of input, so passwords do not appear in
+// command line.
+func (c *Admin) readPassword() (string, error) {
+	line, err := bufio.NewReader(c.In).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password is not given on stdin")
+	}
+	return password, nil
+}
+
+// command returns first n arguments joined with space.
+func command(args []string, n int) string {
+	if len(args) < n {
+		return ""
+	}
+	return strings.Join(args[:n], " ")
+}
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+type adminActionMock struct {
+	behavior string
+	calls    []string
+}
+
+func (m *adminActionMock) Users() ([]*entity.User, error) {
+	if m.behavior == "failure" {
+		return nil, errors.New("test error")
+	}
+	return []*entity.User{
+		{Name: "first", Role: entity.Moderator, TOTPSecret: "secret"},
+		{Name: "second", Pending: true},
+	}, nil
+}
+
+func (m *adminActionMock) AddUser(ctx context.Context, userName string,
+	role entity.UserRole, password string) error {
+	return m.call("add " + userName + " " + role.String() + " " + password)
+}
+
+func (m *adminActionMock) RemoveUser(
+	ctx context.Context, userName string) error {
+	return m.call("remove " + userName)
+}
+
+func (m *adminActionMock) SetRole(ctx context.Context,
+	userName string, role entity.UserRole) error {
+	return m.call("role " + userName + " " + role.String())
+}
+
+func (m *adminActionMock) ResetPassword(ctx context.Context,
+	userName string, password string) error {
+	return m.call("passwd " + userName + " " + password)
+}
+
+func (m *adminActionMock) Sessions() ([]*entity.Session, error) {
+	if m.behavior == "failure" {
+		return nil, errors.New("test error")
+	}
+	s := entity.NewSession()
+	s.ID = "test id"
+	s.Name = "test session"
+	s.Owner = &entity.User{Name: "first"}
+	s.CreatedAt = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.AddParticipant(&entity.User{Name: "second"})
+	return []*entity.Session{s}, nil
+}
+
+func (m *adminActionMock) CloseSession(
+	ctx context.Context, sessionName string) error {
+	return m.call("close " + sessionName)
+}
+
+func (m *adminActionMock) Export(w io.Writer, credentials bool) error {
+	if err := m.call(fmt.Sprintf("export %t", credentials)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "test data")
+	return err
+}
+
+func (m *adminActionMock) Import(
+	ctx context.Context, r io.Reader) (int, int, error) {
+	data, _ := ioutil.ReadAll(r)
+	if err := m.call("import " + string(data)); err != nil {
+		return 0, 0, err
+	}
+	return 2, 1, nil
+}
+
+func (m *adminActionMock) call(call string) error {
+	m.calls = append(m.calls, call)
+	if m.behavior == "failure" {
+		return errors.New("test error")
+	}
+	return nil
+}
+
+func TestAdmin_Run(t *testing.T) {
+	Convey("Runs user commands", t, func() {
+		mock := &adminActionMock{behavior: "ok"}
+		c, _ := newAdminCommand(mock, "new password\nignored\n")
+		ctx := context.Background()
+
+		So(c.Run(ctx, []string{"users", "add", "alice", "publisher"}),
+			ShouldBeNil)
+		So(c.Run(ctx, []string{"users", "role", "alice", "MODERATOR"}),
+			ShouldBeNil)
+		So(c.Run(ctx, []string{"users", "remove", "alice"}), ShouldBeNil)
+		So(mock.calls, ShouldResemble, []string{
+			"add alice PUBLISHER new password",
+			"role alice MODERATOR",
+			"remove alice",
+		})
+	})
+
+	Convey("Reads password from input", t, func() {
+		mock := &adminActionMock{behavior: "ok"}
+		c, _ := newAdminCommand(mock, "new password")
+
+		So(c.Run(context.Background(), []string{"users", "passwd", "alice"}),
+			ShouldBeNil)
+		So(mock.calls, ShouldResemble, []string{"passwd alice new password"})
+	})
+
+	Convey("Returns an error without password", t, func() {
+		mock := &adminActionMock{behavior: "ok"}
+		c, _ := newAdminCommand(mock, "")
+
+		So(c.Run(context.Background(), []string{"users", "passwd", "alice"}),
+			ShouldNotBeNil)
+		So(mock.calls, ShouldBeEmpty)
+	})
+
+	Convey("Lists users", t, func() {
+		c, out := newAdminCommand(&adminActionMock{behavior: "ok"}, "")
+
+		So(c.Run(context.Background(), []string{"users", "list"}),
+			ShouldBeNil)
+		So(out.String(), ShouldContainSubstring, "first   MODERATOR   2fa")
+		So(out.String(), ShouldContainSubstring, "second  SUBSCRIBER  pending")
+	})
+
+	Convey("Lists and closes sessions", t, func() {
+		mock := &adminActionMock{behavior: "ok"}
+		c, out := newAdminCommand(mock, "")
+		ctx := context.Background()
+
+		So(c.Run(ctx, []string{"sessions", "list"}), ShouldBeNil)
+		So(out.String(), ShouldContainSubstring,
+			"test session  test id  first  1             2026-01-02T03:04:05Z")
+		So(c.Run(ctx, []string{"sessions", "close", "test session"}),
+			ShouldBeNil)
+		So(mock.calls, ShouldResemble, []string{"close test session"})
+	})
+
+	Convey("Exports and imports data", t, func() {
+		mock := &adminActionMock{behavior: "ok"}
+		c, out := newAdminCommand(mock, "imported data")
+		ctx := context.Background()
+
+		So(c.Run(ctx, []string{"export"}), ShouldBeNil)
+		So(c.Run(ctx, []string{"export", "--credentials"}), ShouldBeNil)
+		So(c.Run(ctx, []string{"import"}), ShouldBeNil)
+		So(mock.calls, ShouldResemble, []string{
+			"export false", "export true", "import imported data"})
+		So(out.String(), ShouldEqual,
+			"test datatest dataimported 2 users and 1 sessions\n")
+	})
+
+	Convey("Returns action errors", t, func() {
+		c, _ := newAdminCommand(&adminActionMock{behavior: "failure"}, "pass")
+		ctx := context.Background()
+
+		So(c.Run(ctx, []string{"users", "list"}), ShouldNotBeNil)
+		So(c.Run(ctx, []string{"sessions", "list"}), ShouldNotBeNil)
+		So(c.Run(ctx, []string{"sessions", "close", "s"}), ShouldNotBeNil)
+		So(c.Run(ctx, []string{"import"}), ShouldNotBeNil)
+	})
+
+	Convey("Returns an error for invalid command", t, func() {
+		mock := &adminActionMock{behavior: "ok"}
+		c, _ := newAdminCommand(mock, "new password")
+		ctx := context.Background()
+
+		So(c.Run(ctx, nil), ShouldEqual, ErrUsage)
+		So(c.Run(ctx, []string{"users"}), ShouldEqual, ErrUsage)
+		So(c.Run(ctx, []string{"users", "add", "alice"}), ShouldEqual,
+			ErrUsage)
+		So(c.Run(ctx, []string{"export", "file"}), ShouldEqual, ErrUsage)
+		So(c.Run(ctx, []string{"users", "role", "alice", "ADMIN"}),
+			ShouldNotBeNil)
+		So(mock.calls, ShouldBeEmpty)
+	})
+}
+
+// newAdminCommand returns admin command with given action and input, and
+// its output.
+func newAdminCommand(
+	mock *adminActionMock, in string) (*Admin, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &Admin{
+		AdminAction: mock,
+		In:          strings.NewReader(in),
+		Out:         out,
+	}, out
+}
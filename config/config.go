@@ -0,0 +1,625 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Config is a configuration of the example application.
+type Config struct {
+	// OpenViDuURL is an URL of OpenViDu server.
+	OpenViDuURL string
+
+	// OpenViDuLogin is a login of OpenViDu server API.
+	OpenViDuLogin string
+
+	// OpenViDuSecret is a secret of OpenViDu server API.
+	OpenViDuSecret string
+
+	// EarlyJoin is a period before start of scheduled meeting when its
+	// participants can join it.
+	EarlyJoin time.Duration
+
+	// LogLevel is a minimal level of log records, e.g. "debug" or "info".
+	LogLevel string
+
+	// TracingExporter is an exporter of tracing spans: "none", "stdout" or
+	// "otlp".
+	TracingExporter string
+
+	// Addr is a TCP address that HTTP server listens on.
+	Addr string
+
+	// PublicURL is a base URL users reach the application at. Links sent
+	// by mail point to it rather than to Host header of request.
+	PublicURL string
+
+	// TrustedProxies are IPs and CIDR ranges of reverse proxies whose
+	// X-Forwarded-For header gives client IP. Header is ignored if empty.
+	TrustedProxies []string
+
+	// DrainPeriod is a time between shutdown signal and stop of HTTP server
+	// when new joins are refused but participants can leave sessions.
+	DrainPeriod time.Duration
+
+	// ShutdownTimeout is a time given to in-flight requests to complete
+	// after HTTP server stops accepting connections.
+	ShutdownTimeout time.Duration
+
+	// ShutdownPolicy defines what happens with active sessions on exit.
+	ShutdownPolicy ShutdownPolicy
+
+	// StateFile is a file where sessions are persisted on exit with
+	// PersistSessions policy and restored from on start.
+	StateFile string
+
+	// UsersFile is a JSON file where users are stored, so they survive
+	// restart and can be managed with admin commands. Users are kept in
+	// memory only if it is empty.
+	UsersFile string
+
+	// AuditSink is a storage of audit log: "file" appends events to
+	// AuditFile, "database" inserts them into AuditDatabaseURL database,
+	// "none" disables audit log.
+	AuditSink string
+
+	// AuditFile is a JSON lines file of "file" audit sink.
+	AuditFile string
+
+	// AuditDatabaseURL is a PostgreSQL connection URL of "database" audit
+	// sink.
+	AuditDatabaseURL string
+
+	// SessionKeys are keys of HTTP session cookies. First pair signs and
+	// encrypts new cookies, the rest only decode cookies issued before
+	// keys rotation. Random keys are used if none is configured.
+	SessionKeys []SessionKey
+
+	// SessionStore is a storage of HTTP session values: "cookie" keeps them
+	// in cookie itself, "filesystem" keeps them on server in SessionDir.
+	SessionStore string
+
+	// SessionDir is a directory of "filesystem" session store. Temporary
+	// directory is used if empty.
+	SessionDir string
+
+	// SessionMaxAge is a lifetime of HTTP session.
+	SessionMaxAge time.Duration
+
+	// SessionSecure restricts HTTP session cookie to HTTPS requests.
+	SessionSecure bool
+
+	// SessionHTTPOnly hides HTTP session cookie from scripts.
+	SessionHTTPOnly bool
+
+	// SessionSameSite is a SameSite attribute of HTTP session cookie.
+	SessionSameSite http.SameSite
+
+	// LoginMaxFailures is a number of consecutive failed logins after which
+	// account is locked.
+	LoginMaxFailures int
+
+	// LoginMaxIPFailures is a number of consecutive failed logins after
+	// which client IP is locked.
+	LoginMaxIPFailures int
+
+	// LoginLockout is a period of account or client IP lockout.
+	LoginLockout time.Duration
+
+	// LoginDelay is a time next login is refused after first failure. It
+	// doubles with every next failure.
+	LoginDelay time.Duration
+
+	// Signup allows visitors to register accounts.
+	Signup bool
+
+	// SignupRole is a role of signed up users.
+	SignupRole entity.UserRole
+
+	// SignupApproval requires signed up users to be approved by moderator
+	// before they can log in.
+	SignupApproval bool
+
+	// GuestRole is a role of guests that join public session without
+	// invitation. Guests can not be moderators.
+	GuestRole entity.UserRole
+
+	// GuestJoinTimeout is a period after which guest that has not joined
+	// its session is removed.
+	GuestJoinTimeout time.Duration
+
+	// Mailer is a sender of mail: "file" writes messages to MailFile,
+	// "smtp" sends them through SMTPAddr server.
+	Mailer string
+
+	// MailFrom is a sender address of mail messages.
+	MailFrom string
+
+	// MailFile is a file "file" mailer appends messages to. Messages are
+	// discarded if it is empty.
+	MailFile string
+
+	// SMTPAddr is a "host:port" address of SMTP server.
+	SMTPAddr string
+
+	// SMTPUsername and SMTPPassword authenticate to SMTP server.
+	SMTPUsername string
+	SMTPPassword string
+
+	// SMTPTimeout is a maximal duration of sending one message through
+	// SMTP server.
+	SMTPTimeout time.Duration
+
+	// PasswordResetTTL is a period of password reset link validity.
+	PasswordResetTTL time.Duration
+
+	// OIDCIssuer is an URL of OpenID Connect identity provider. Single
+	// sign-on is disabled if empty.
+	OIDCIssuer string
+
+	// OIDCClientID and OIDCClientSecret identify the application at
+	// identity provider.
+	OIDCClientID     string
+	OIDCClientSecret string
+
+	// OIDCRedirectURL is an URL of single sign-on callback registered at
+	// identity provider.
+	OIDCRedirectURL string
+
+	// OIDCScopes are scopes requested from identity provider.
+	OIDCScopes []string
+
+	// OIDCUsernameClaim is an ID token claim that holds user name.
+	OIDCUsernameClaim string
+
+	// OIDCRoleClaim is an ID token claim whose values are mapped to user
+	// roles by OIDCRoles.
+	OIDCRoleClaim string
+
+	// OIDCRoles maps values of OIDCRoleClaim to user roles.
+	OIDCRoles map[string]entity.UserRole
+
+	// OIDCDefaultRole is a role of user none of whose claim values is
+	// mapped.
+	OIDCDefaultRole entity.UserRole
+
+	// OIDCProvision creates accounts for unknown users logged in through
+	// identity provider.
+	OIDCProvision bool
+
+	// LDAPURL is an URL of directory server users are authenticated
+	// against before local accounts. Directory is not used if empty.
+	LDAPURL string
+
+	// LDAPStartTLS upgrades "ldap://" connection to TLS.
+	LDAPStartTLS bool
+
+	// LDAPBindDN and LDAPBindPassword authenticate service account that
+	// searches users.
+	LDAPBindDN       string
+	LDAPBindPassword string
+
+	// LDAPBaseDN is a base of user search.
+	LDAPBaseDN string
+
+	// LDAPUserFilter is a filter of user search, "%s" is replaced by user
+	// name.
+	LDAPUserFilter string
+
+	// LDAPGroupAttribute is an attribute of user entry that lists its
+	// groups.
+	LDAPGroupAttribute string
+
+	// LDAPRoles maps group DNs to user roles.
+	LDAPRoles map[string]entity.UserRole
+
+	// LDAPDefaultRole is a role of user none of whose groups is mapped.
+	LDAPDefaultRole entity.UserRole
+
+	// LDAPTimeout limits requests to directory server.
+	LDAPTimeout time.Duration
+
+	// TOTPIssuer is a name of the application shown by authenticator apps
+	// next to one-time passwords.
+	TOTPIssuer string
+
+	// JWTSecret is a key JWT access tokens are signed with. Random key is
+	// generated on start if it is empty, so tokens do not survive restart.
+	JWTSecret []byte
+
+	// JWTAccessTTL is a lifetime of JWT access token.
+	JWTAccessTTL time.Duration
+
+	// JWTRefreshTTL is a lifetime of refresh token. Every refresh issues
+	// new refresh token with full lifetime.
+	JWTRefreshTTL time.Duration
+}
+
+// Mailers.
+const (
+	// FileMailer writes mail messages to file or log.
+	FileMailer = "file"
+
+	// SMTPMailer sends mail messages through SMTP server.
+	SMTPMailer = "smtp"
+)
+
+// Audit sinks.
+const (
+	// FileAudit appends audit events to file.
+	FileAudit = "file"
+
+	// DatabaseAudit inserts audit events into database.
+	DatabaseAudit = "database"
+
+	// NoAudit disables audit log.
+	NoAudit = "none"
+)
+
+// SessionKey is a pair of keys of HTTP session cookie.
+type SessionKey struct {
+	// Hash is a key that authenticates cookie value, 32 or 64 bytes long.
+	Hash []byte
+
+	// Encryption is an AES key that encrypts cookie value, 16, 24 or 32
+	// bytes long. Cookie value is not encrypted if empty.
+	Encryption []byte
+}
+
+// Session stores.
+const (
+	// CookieStore keeps HTTP session values in cookie.
+	CookieStore = "cookie"
+
+	// FilesystemStore keeps HTTP session values in files on server, so
+	// cookie only carries session ID.
+	FilesystemStore = "filesystem"
+)
+
+// ShutdownPolicy is a policy of handling active sessions on application
+// exit.
+type ShutdownPolicy string
+
+// Shutdown policies.
+const (
+	// CloseSessions closes all active sessions on OpenViDu server.
+	CloseSessions ShutdownPolicy = "close"
+
+	// PersistSessions saves active sessions to state file, so they are
+	// restored on next start while OpenViDu server keeps them alive.
+	PersistSessions ShutdownPolicy = "persist"
+)
+
+// FromEnv returns configuration read from environment variables. Default
+// value is used for any variable that is not set.
+func FromEnv() (*Config, error) {
+	c := &Config{
+		OpenViDuURL:     env("OPENVIDU_URL", "https://openvidu-server-kms:8443"),
+		OpenViDuLogin:   env("OPENVIDU_LOGIN", "OPENVIDUAPP"),
+		OpenViDuSecret:  env("OPENVIDU_SECRET", "MY_SECRET"),
+		LogLevel:        env("LOG_LEVEL", "info"),
+		TracingExporter: env("TRACING_EXPORTER", "none"),
+		Addr:            ":" + env("PORT", "8080"),
+		ShutdownPolicy:  ShutdownPolicy(env("SHUTDOWN_POLICY", "close")),
+		StateFile:       env("SESSIONS_STATE_FILE", "sessions.json"),
+		SessionStore:    env("SESSION_STORE", CookieStore),
+		SessionDir:      env("SESSION_DIR", ""),
+		Mailer:          env("MAILER", FileMailer),
+		MailFrom:        env("MAIL_FROM", "noreply@localhost"),
+		MailFile:        env("MAIL_FILE", "mail.txt"),
+		SMTPAddr:        env("SMTP_ADDR", "localhost:25"),
+		SMTPUsername:    env("SMTP_USERNAME", ""),
+		SMTPPassword:    env("SMTP_PASSWORD", ""),
+	}
+	c.OIDCIssuer = env("OIDC_ISSUER", "")
+	c.OIDCClientID = env("OIDC_CLIENT_ID", "")
+	c.OIDCClientSecret = env("OIDC_CLIENT_SECRET", "")
+	c.OIDCRedirectURL = env("OIDC_REDIRECT_URL",
+		"http://localhost:8080/login/oidc/callback")
+	c.OIDCScopes = strings.FieldsFunc(
+		env("OIDC_SCOPES", "openid,profile,email"),
+		func(r rune) bool { return r == ',' || r == ' ' })
+	c.OIDCUsernameClaim = env("OIDC_USERNAME_CLAIM", "preferred_username")
+	c.OIDCRoleClaim = env("OIDC_ROLE_CLAIM", "groups")
+	c.LDAPURL = env("LDAP_URL", "")
+	c.LDAPBindDN = env("LDAP_BIND_DN", "")
+	c.LDAPBindPassword = env("LDAP_BIND_PASSWORD", "")
+	c.LDAPBaseDN = env("LDAP_BASE_DN", "")
+	c.LDAPUserFilter = env("LDAP_USER_FILTER", "(uid=%s)")
+	c.LDAPGroupAttribute = env("LDAP_GROUP_ATTRIBUTE", "memberOf")
+	c.TOTPIssuer = env("TOTP_ISSUER", "OpenVidu tutorial")
+	c.PublicURL = strings.TrimRight(
+		env("PUBLIC_URL", "http://localhost:8080"), "/")
+	c.TrustedProxies = strings.FieldsFunc(env("TRUSTED_PROXIES", ""),
+		func(r rune) bool { return r == ',' || r == ' ' })
+	c.UsersFile = env("USERS_FILE", "")
+	c.AuditSink = env("AUDIT_SINK", FileAudit)
+	c.AuditFile = env("AUDIT_FILE", "audit.jsonl")
+	c.AuditDatabaseURL = env("AUDIT_DATABASE_URL", "")
+	var err error
+	if c.EarlyJoin, err = envDuration(
+		"MEETING_EARLY_JOIN", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	if c.DrainPeriod, err = envDuration(
+		"SHUTDOWN_DRAIN", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if c.ShutdownTimeout, err = envDuration(
+		"SHUTDOWN_TIMEOUT", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if c.SMTPTimeout, err = envDuration(
+		"SMTP_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if u, e := url.Parse(c.PublicURL); e != nil || u.Host == "" ||
+		(u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf(
+			"invalid PUBLIC_URL: %s is not an absolute HTTP URL", c.PublicURL)
+	}
+	for _, p := range c.TrustedProxies {
+		if net.ParseIP(p) == nil {
+			if _, _, err = net.ParseCIDR(p); err != nil {
+				return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %s", err)
+			}
+		}
+	}
+	if c.ShutdownPolicy != CloseSessions &&
+		c.ShutdownPolicy != PersistSessions {
+		return nil, fmt.Errorf(
+			"invalid SHUTDOWN_POLICY: %s", c.ShutdownPolicy)
+	}
+	if c.SessionStore != CookieStore && c.SessionStore != FilesystemStore {
+		return nil, fmt.Errorf("invalid SESSION_STORE: %s", c.SessionStore)
+	}
+	if c.SessionKeys, err = parseSessionKeys(
+		env("SESSION_KEYS", "")); err != nil {
+		return nil, fmt.Errorf("invalid SESSION_KEYS: %s", err)
+	}
+	if c.SessionMaxAge, err = envDuration(
+		"SESSION_MAX_AGE", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if c.SessionSecure, err = envBool("SESSION_SECURE", false); err != nil {
+		return nil, err
+	}
+	if c.SessionHTTPOnly, err = envBool(
+		"SESSION_HTTP_ONLY", true); err != nil {
+		return nil, err
+	}
+	if c.LoginMaxFailures, err = envInt("LOGIN_MAX_FAILURES", 5); err != nil {
+		return nil, err
+	}
+	if c.LoginMaxIPFailures, err = envInt(
+		"LOGIN_MAX_IP_FAILURES", 20); err != nil {
+		return nil, err
+	}
+	if c.LoginLockout, err = envDuration(
+		"LOGIN_LOCKOUT", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if c.LoginDelay, err = envDuration("LOGIN_DELAY", time.Second); err != nil {
+		return nil, err
+	}
+	if c.Signup, err = envBool("SIGNUP_ENABLED", false); err != nil {
+		return nil, err
+	}
+	if c.SignupRole, err = entity.ParseUserRole(
+		env("SIGNUP_ROLE", "SUBSCRIBER")); err != nil {
+		return nil, fmt.Errorf("invalid SIGNUP_ROLE: %s", err)
+	}
+	if c.SignupApproval, err = envBool(
+		"SIGNUP_APPROVAL", false); err != nil {
+		return nil, err
+	}
+	if c.GuestRole, err = entity.ParseUserRole(
+		env("GUEST_ROLE", "SUBSCRIBER")); err != nil {
+		return nil, fmt.Errorf("invalid GUEST_ROLE: %s", err)
+	}
+	if c.GuestRole == entity.Moderator {
+		return nil, fmt.Errorf(
+			"invalid GUEST_ROLE: guests can not be %s", c.GuestRole)
+	}
+	if c.GuestJoinTimeout, err = envDuration(
+		"GUEST_JOIN_TIMEOUT", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	if c.Mailer != FileMailer && c.Mailer != SMTPMailer {
+		return nil, fmt.Errorf("invalid MAILER: %s", c.Mailer)
+	}
+	switch c.AuditSink {
+	case FileAudit, NoAudit:
+	case DatabaseAudit:
+		if c.AuditDatabaseURL == "" {
+			return nil, fmt.Errorf(
+				"AUDIT_DATABASE_URL is required with database AUDIT_SINK")
+		}
+	default:
+		return nil, fmt.Errorf("invalid AUDIT_SINK: %s", c.AuditSink)
+	}
+	if c.PasswordResetTTL, err = envDuration(
+		"PASSWORD_RESET_TTL", time.Hour); err != nil {
+		return nil, err
+	}
+	if c.OIDCRoles, err = parseRoles(env("OIDC_ROLES", ""), ","); err != nil {
+		return nil, fmt.Errorf("invalid OIDC_ROLES: %s", err)
+	}
+	if c.OIDCDefaultRole, err = entity.ParseUserRole(
+		env("OIDC_DEFAULT_ROLE", "SUBSCRIBER")); err != nil {
+		return nil, fmt.Errorf("invalid OIDC_DEFAULT_ROLE: %s", err)
+	}
+	if c.OIDCProvision, err = envBool("OIDC_PROVISION", true); err != nil {
+		return nil, err
+	}
+	if c.OIDCIssuer != "" && c.OIDCClientID == "" {
+		return nil, fmt.Errorf("OIDC_CLIENT_ID is required with OIDC_ISSUER")
+	}
+	if c.LDAPStartTLS, err = envBool("LDAP_START_TLS", false); err != nil {
+		return nil, err
+	}
+	if c.LDAPRoles, err = parseRoles(env("LDAP_ROLES", ""), ";"); err != nil {
+		return nil, fmt.Errorf("invalid LDAP_ROLES: %s", err)
+	}
+	if c.LDAPDefaultRole, err = entity.ParseUserRole(
+		env("LDAP_DEFAULT_ROLE", "SUBSCRIBER")); err != nil {
+		return nil, fmt.Errorf("invalid LDAP_DEFAULT_ROLE: %s", err)
+	}
+	if c.LDAPTimeout, err = envDuration(
+		"LDAP_TIMEOUT", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if c.LDAPURL != "" && strings.Count(c.LDAPUserFilter, "%s") != 1 {
+		return nil, fmt.Errorf(
+			"invalid LDAP_USER_FILTER: %s must contain one %%s",
+			c.LDAPUserFilter)
+	}
+	if c.JWTSecret, err = base64.StdEncoding.DecodeString(
+		env("JWT_SECRET", "")); err != nil {
+		return nil, fmt.Errorf("invalid JWT_SECRET: %s", err)
+	}
+	if len(c.JWTSecret) > 0 && len(c.JWTSecret) < 32 {
+		return nil, fmt.Errorf(
+			"invalid JWT_SECRET: must be at least 32 bytes long, got %d",
+			len(c.JWTSecret))
+	}
+	if c.JWTAccessTTL, err = envDuration(
+		"JWT_ACCESS_TTL", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if c.JWTRefreshTTL, err = envDuration(
+		"JWT_REFRESH_TTL", 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	switch v := env("SESSION_SAME_SITE", "lax"); v {
+	case "lax":
+		c.SessionSameSite = http.SameSiteLaxMode
+	case "strict":
+		c.SessionSameSite = http.SameSiteStrictMode
+	case "none":
+		c.SessionSameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("invalid SESSION_SAME_SITE: %s", v)
+	}
+	return c, nil
+}
+
+// parseSessionKeys parses comma separated list of base64 encoded key pairs
+// in "<hash key>[:<encryption key>]" format.
+func parseSessionKeys(v string) ([]SessionKey, error) {
+	var keys []SessionKey
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, ":", 2)
+		var key SessionKey
+		var err error
+		if key.Hash, err = base64.StdEncoding.DecodeString(
+			parts[0]); err != nil {
+			return nil, err
+		}
+		if len(key.Hash) != 32 && len(key.Hash) != 64 {
+			return nil, fmt.Errorf(
+				"hash key must be 32 or 64 bytes long, got %d", len(key.Hash))
+		}
+		if len(parts) == 2 {
+			if key.Encryption, err = base64.StdEncoding.DecodeString(
+				parts[1]); err != nil {
+				return nil, err
+			}
+			switch len(key.Encryption) {
+			case 16, 24, 32:
+			default:
+				return nil, fmt.Errorf(
+					"encryption key must be 16, 24 or 32 bytes long, got %d",
+					len(key.Encryption))
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// parseRoles parses list of "<value>=<role>" pairs separated by given
+// separator. Value is split at the last "=", so it may be a DN.
+func parseRoles(v string, sep string) (map[string]entity.UserRole, error) {
+	roles := make(map[string]entity.UserRole)
+	for _, pair := range strings.Split(v, sep) {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%s is not a <value>=<role> pair", pair)
+		}
+		role, err := entity.ParseUserRole(strings.TrimSpace(pair[i+1:]))
+		if err != nil {
+			return nil, err
+		}
+		roles[strings.TrimSpace(pair[:i])] = role
+	}
+	return roles, nil
+}
+
+// env returns value of environment variable with given name or given
+// default value if variable is not set.
+func env(name string, def string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return def
+}
+
+// envDuration returns duration from environment variable with given name or
+// given default value if variable is not set.
+func envDuration(name string, def time.Duration) (time.Duration, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", name, err)
+	}
+	return d, nil
+}
+
+// envBool returns boolean from environment variable with given name or
+// given default value if variable is not set.
+func envBool(name string, def bool) (bool, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %s", name, err)
+	}
+	return b, nil
+}
+
+// envInt returns integer from environment variable with given name or given
+// default value if variable is not set.
+func envInt(name string, def int) (int, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", name, err)
+	}
+	return i, nil
+}
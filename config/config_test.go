@@ -0,0 +1,302 @@
+package config
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestFromEnv(t *testing.T) {
+	Convey("Returns default configuration", t, func() {
+		os.Unsetenv("OPENVIDU_URL")
+		os.Unsetenv("MEETING_EARLY_JOIN")
+		os.Unsetenv("LOG_LEVEL")
+		os.Unsetenv("TRACING_EXPORTER")
+		os.Unsetenv("PORT")
+		os.Unsetenv("SHUTDOWN_POLICY")
+		c, err := FromEnv()
+
+		So(err, ShouldBeNil)
+		So(c.OpenViDuURL, ShouldEqual, "https://openvidu-server-kms:8443")
+		So(c.OpenViDuLogin, ShouldEqual, "OPENVIDUAPP")
+		So(c.OpenViDuSecret, ShouldEqual, "MY_SECRET")
+		So(c.EarlyJoin, ShouldEqual, 10*time.Minute)
+		So(c.LogLevel, ShouldEqual, "info")
+		So(c.TracingExporter, ShouldEqual, "none")
+		So(c.Addr, ShouldEqual, ":8080")
+		So(c.PublicURL, ShouldEqual, "http://localhost:8080")
+		So(c.TrustedProxies, ShouldBeEmpty)
+		So(c.DrainPeriod, ShouldEqual, 10*time.Second)
+		So(c.ShutdownTimeout, ShouldEqual, 30*time.Second)
+		So(c.SMTPTimeout, ShouldEqual, 10*time.Second)
+		So(c.ShutdownPolicy, ShouldEqual, CloseSessions)
+		So(c.StateFile, ShouldEqual, "sessions.json")
+		So(c.UsersFile, ShouldBeEmpty)
+		So(c.AuditSink, ShouldEqual, FileAudit)
+		So(c.AuditFile, ShouldEqual, "audit.jsonl")
+		So(c.SessionStore, ShouldEqual, CookieStore)
+		So(c.SessionKeys, ShouldBeEmpty)
+		So(c.SessionMaxAge, ShouldEqual, 24*time.Hour)
+		So(c.SessionSecure, ShouldBeFalse)
+		So(c.SessionHTTPOnly, ShouldBeTrue)
+		So(c.SessionSameSite, ShouldEqual, http.SameSiteLaxMode)
+		So(c.LoginMaxFailures, ShouldEqual, 5)
+		So(c.LoginMaxIPFailures, ShouldEqual, 20)
+		So(c.LoginLockout, ShouldEqual, 15*time.Minute)
+		So(c.LoginDelay, ShouldEqual, time.Second)
+		So(c.Signup, ShouldBeFalse)
+		So(c.SignupRole, ShouldEqual, entity.Subscriber)
+		So(c.SignupApproval, ShouldBeFalse)
+		So(c.GuestRole, ShouldEqual, entity.Subscriber)
+		So(c.GuestJoinTimeout, ShouldEqual, 10*time.Minute)
+		So(c.Mailer, ShouldEqual, FileMailer)
+		So(c.MailFrom, ShouldEqual, "noreply@localhost")
+		So(c.MailFile, ShouldEqual, "mail.txt")
+		So(c.PasswordResetTTL, ShouldEqual, time.Hour)
+		So(c.TOTPIssuer, ShouldEqual, "OpenVidu tutorial")
+		So(c.JWTSecret, ShouldBeEmpty)
+		So(c.JWTAccessTTL, ShouldEqual, 15*time.Minute)
+		So(c.JWTRefreshTTL, ShouldEqual, 30*24*time.Hour)
+	})
+
+	Convey("Returns configuration from environment", t, func() {
+		os.Setenv("OPENVIDU_URL", "https://test:8443")
+		os.Setenv("MEETING_EARLY_JOIN", "5m")
+		defer os.Unsetenv("OPENVIDU_URL")
+		defer os.Unsetenv("MEETING_EARLY_JOIN")
+		c, err := FromEnv()
+
+		So(err, ShouldBeNil)
+		So(c.OpenViDuURL, ShouldEqual, "https://test:8443")
+		So(c.EarlyJoin, ShouldEqual, 5*time.Minute)
+	})
+
+	Convey("Returns an error", t, func() {
+		os.Setenv("MEETING_EARLY_JOIN", "wrong")
+		defer os.Unsetenv("MEETING_EARLY_JOIN")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid MEETING_EARLY_JOIN")
+	})
+
+	Convey("Returns login limits error", t, func() {
+		os.Setenv("LOGIN_MAX_FAILURES", "wrong")
+		defer os.Unsetenv("LOGIN_MAX_FAILURES")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid LOGIN_MAX_FAILURES")
+	})
+
+	Convey("Returns public URL without trailing slash", t, func() {
+		os.Setenv("PUBLIC_URL", "https://meet.example.com/")
+		defer os.Unsetenv("PUBLIC_URL")
+		c, err := FromEnv()
+
+		So(err, ShouldBeNil)
+		So(c.PublicURL, ShouldEqual, "https://meet.example.com")
+	})
+
+	Convey("Returns public URL error", t, func() {
+		os.Setenv("PUBLIC_URL", "meet.example.com")
+		defer os.Unsetenv("PUBLIC_URL")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid PUBLIC_URL")
+	})
+
+	Convey("Returns trusted proxies", t, func() {
+		os.Setenv("TRUSTED_PROXIES", "10.0.0.1, 172.16.0.0/12")
+		defer os.Unsetenv("TRUSTED_PROXIES")
+		c, err := FromEnv()
+
+		So(err, ShouldBeNil)
+		So(c.TrustedProxies, ShouldResemble,
+			[]string{"10.0.0.1", "172.16.0.0/12"})
+	})
+
+	Convey("Returns trusted proxies error", t, func() {
+		os.Setenv("TRUSTED_PROXIES", "proxy.local")
+		defer os.Unsetenv("TRUSTED_PROXIES")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid TRUSTED_PROXIES")
+	})
+
+	Convey("Returns signup role error", t, func() {
+		os.Setenv("SIGNUP_ROLE", "wrong")
+		defer os.Unsetenv("SIGNUP_ROLE")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid SIGNUP_ROLE")
+	})
+
+	Convey("Returns guest role error", t, func() {
+		for _, role := range []string{"wrong", "MODERATOR"} {
+			os.Setenv("GUEST_ROLE", role)
+			_, err := FromEnv()
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "invalid GUEST_ROLE")
+		}
+		os.Unsetenv("GUEST_ROLE")
+	})
+
+	Convey("Returns mailer error", t, func() {
+		os.Setenv("MAILER", "wrong")
+		defer os.Unsetenv("MAILER")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid MAILER")
+	})
+
+	Convey("Returns an error of audit sink", t, func() {
+		os.Setenv("AUDIT_SINK", "wrong")
+		defer os.Unsetenv("AUDIT_SINK")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid AUDIT_SINK")
+
+		os.Setenv("AUDIT_SINK", DatabaseAudit)
+		_, err = FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "AUDIT_DATABASE_URL")
+	})
+
+	Convey("Returns single sign-on configuration", t, func() {
+		os.Setenv("OIDC_ISSUER", "https://idp.example.com")
+		os.Setenv("OIDC_CLIENT_ID", "test client")
+		os.Setenv("OIDC_SCOPES", "openid, profile,groups")
+		os.Setenv("OIDC_ROLES", "staff=PUBLISHER, admins=MODERATOR")
+		defer os.Unsetenv("OIDC_ISSUER")
+		defer os.Unsetenv("OIDC_CLIENT_ID")
+		defer os.Unsetenv("OIDC_SCOPES")
+		defer os.Unsetenv("OIDC_ROLES")
+		c, err := FromEnv()
+
+		So(err, ShouldBeNil)
+		So(c.OIDCScopes, ShouldResemble, []string{"openid", "profile", "groups"})
+		So(c.OIDCRoles, ShouldResemble, map[string]entity.UserRole{
+			"staff":  entity.Publisher,
+			"admins": entity.Moderator,
+		})
+		So(c.OIDCProvision, ShouldBeTrue)
+
+		Convey("Returns roles error", func() {
+			os.Setenv("OIDC_ROLES", "staff")
+			_, err := FromEnv()
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "invalid OIDC_ROLES")
+		})
+
+		Convey("Returns client ID error", func() {
+			os.Unsetenv("OIDC_CLIENT_ID")
+			_, err := FromEnv()
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Returns directory configuration", t, func() {
+		os.Setenv("LDAP_URL", "ldaps://ldap.example.com")
+		os.Setenv("LDAP_ROLES", "cn=staff,dc=example,dc=com=PUBLISHER; "+
+			"cn=admins,dc=example,dc=com=MODERATOR")
+		defer os.Unsetenv("LDAP_URL")
+		defer os.Unsetenv("LDAP_ROLES")
+		c, err := FromEnv()
+
+		So(err, ShouldBeNil)
+		So(c.LDAPRoles, ShouldResemble, map[string]entity.UserRole{
+			"cn=staff,dc=example,dc=com":  entity.Publisher,
+			"cn=admins,dc=example,dc=com": entity.Moderator,
+		})
+		So(c.LDAPUserFilter, ShouldEqual, "(uid=%s)")
+		So(c.LDAPTimeout, ShouldEqual, 5*time.Second)
+
+		Convey("Returns user filter error", func() {
+			os.Setenv("LDAP_USER_FILTER", "(uid=test)")
+			defer os.Unsetenv("LDAP_USER_FILTER")
+			_, err := FromEnv()
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "invalid LDAP_USER_FILTER")
+		})
+	})
+
+	Convey("Returns JWT secret error", t, func() {
+		os.Setenv("JWT_SECRET", base64.StdEncoding.EncodeToString(
+			[]byte("short")))
+		defer os.Unsetenv("JWT_SECRET")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid JWT_SECRET")
+	})
+
+	Convey("Returns shutdown policy error", t, func() {
+		os.Setenv("SHUTDOWN_POLICY", "wrong")
+		defer os.Unsetenv("SHUTDOWN_POLICY")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid SHUTDOWN_POLICY")
+	})
+	Convey("Returns session configuration", t, func() {
+		hash := strings.Repeat("h", 32)
+		enc := strings.Repeat("e", 16)
+		os.Setenv("SESSION_KEYS", base64.StdEncoding.EncodeToString(
+			[]byte(hash))+":"+base64.StdEncoding.EncodeToString([]byte(enc))+
+			", "+base64.StdEncoding.EncodeToString([]byte(hash+hash)))
+		os.Setenv("SESSION_STORE", "filesystem")
+		os.Setenv("SESSION_SECURE", "true")
+		os.Setenv("SESSION_SAME_SITE", "strict")
+		defer os.Unsetenv("SESSION_KEYS")
+		defer os.Unsetenv("SESSION_STORE")
+		defer os.Unsetenv("SESSION_SECURE")
+		defer os.Unsetenv("SESSION_SAME_SITE")
+		c, err := FromEnv()
+
+		So(err, ShouldBeNil)
+		So(c.SessionKeys, ShouldResemble, []SessionKey{
+			{Hash: []byte(hash), Encryption: []byte(enc)},
+			{Hash: []byte(hash + hash)},
+		})
+		So(c.SessionStore, ShouldEqual, FilesystemStore)
+		So(c.SessionSecure, ShouldBeTrue)
+		So(c.SessionSameSite, ShouldEqual, http.SameSiteStrictMode)
+	})
+
+	Convey("Returns session keys error", t, func() {
+		os.Setenv("SESSION_KEYS", base64.StdEncoding.EncodeToString(
+			[]byte("short")))
+		defer os.Unsetenv("SESSION_KEYS")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid SESSION_KEYS")
+	})
+
+	Convey("Returns session store error", t, func() {
+		os.Setenv("SESSION_STORE", "wrong")
+		defer os.Unsetenv("SESSION_STORE")
+		_, err := FromEnv()
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid SESSION_STORE")
+	})
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/sessions"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// errPasswordsMismatch is returned when password and its confirmation
+// differ.
+var errPasswordsMismatch = errors.New("passwords do not match")
+
+// Accounts is a HTTP controller that provides signup and management of
+// user accounts.
+type Accounts struct {
+	SessionStore  sessions.Store
+	AccountAction interface {
+		Signup(ctx context.Context, userName string, displayName string,
+			email string, password string) (*entity.User, error)
+		Pending(moderatorName string) ([]*entity.User, error)
+		Approve(ctx context.Context, userName string,
+			moderatorName string) error
+		UpdateProfile(ctx context.Context, userName string,
+			displayName string, email string) error
+		ChangePassword(ctx context.Context, userName string, current string,
+			password string) error
+		Delete(ctx context.Context, userName string, password string) error
+	}
+}
+
+// SignupForm returns signup page.
+func (c *Accounts) SignupForm(ctx *gin.Context) {
+	ctx.Status(http.StatusOK)
+	ctx.Set("template", "signup.tmpl")
+	ctx.Set("parameters", gin.H{})
+}
+
+// Signup registers new user by "user", "display-name", "email" and "pass"
+// form parameters. Approved user is logged in and redirected to dashboard.
+func (c *Accounts) Signup(ctx *gin.Context) {
+	if ctx.PostForm("pass") != ctx.PostForm("pass-confirm") {
+		c.signupPage(ctx, http.StatusBadRequest,
+			gin.H{"error": errPasswordsMismatch.Error()})
+		return
+	}
+	user, err := c.AccountAction.Signup(ctx.Request.Context(),
+		ctx.PostForm("user"), ctx.PostForm("display-name"),
+		ctx.PostForm("email"), ctx.PostForm("pass"))
+	if err != nil {
+		c.signupPage(ctx, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if user.Pending {
+		c.signupPage(ctx, http.StatusOK, gin.H{
+			"message": "Your account is created and awaits approval of " +
+				"moderator.",
+		})
+		return
+	}
+	session, err := c.SessionStore.Get(ctx.Request, SESSION_NAME)
+	if err != nil {
+		ctx.Redirect(http.StatusSeeOther, "/")
+		return
+	}
+	session.Values["error"] = nil
+	session.Values["loggedUser"] = user.Name
+	session.Save(ctx.Request, ctx.Writer)
+	ctx.Redirect(http.StatusSeeOther, "/dashboard")
+}
+
+// Profile returns account page of logged user.
+func (c *Accounts) Profile(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	c.page(ctx, http.StatusOK, user, nil)
+}
+
+// UpdateProfile changes display name and mail address of logged user to
+// "display-name" and "email" form parameters.
+func (c *Accounts) UpdateProfile(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.AccountAction.UpdateProfile(ctx.Request.Context(),
+		user.Name, ctx.PostForm("display-name"),
+		ctx.PostForm("email")); err != nil {
+		c.page(ctx, http.StatusBadRequest, user, err)
+		return
+	}
+	ctx.Redirect(http.StatusSeeOther, "/account")
+}
+
+// ChangePassword changes password of logged user to "pass" form parameter
+// if "current-pass" form parameter is its current password.
+func (c *Accounts) ChangePassword(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	if ctx.PostForm("pass") != ctx.PostForm("pass-confirm") {
+		c.page(ctx, http.StatusBadRequest, user, errPasswordsMismatch)
+		return
+	}
+	if err := c.AccountAction.ChangePassword(ctx.Request.Context(),
+		user.Name, ctx.PostForm("current-pass"),
+		ctx.PostForm("pass")); err != nil {
+		c.page(ctx, http.StatusBadRequest, user, err)
+		return
+	}
+	ctx.Redirect(http.StatusSeeOther, "/account")
+}
+
+// Delete deletes account of logged user confirmed by "current-pass" form
+// parameter, logs the user out and redirects to index page.
+func (c *Accounts) Delete(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.AccountAction.Delete(ctx.Request.Context(),
+		user.Name, ctx.PostForm("current-pass")); err != nil {
+		c.page(ctx, http.StatusBadRequest, user, err)
+		return
+	}
+	if session, err := c.SessionStore.Get(
+		ctx.Request, SESSION_NAME); err == nil {
+		delete(session.Values, "loggedUser")
+		session.Save(ctx.Request, ctx.Writer)
+	}
+	ctx.Redirect(http.StatusSeeOther, "/")
+}
+
+// Approve approves pending user given by "user" form parameter. Only
+// moderators can approve users.
+func (c *Accounts) Approve(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.AccountAction.Approve(ctx.Request.Context(),
+		ctx.PostForm("user"), user.Name); err != nil {
+		c.page(ctx, http.StatusBadRequest, user, err)
+		return
+	}
+	ctx.Redirect(http.StatusSeeOther, "/account")
+}
+
+// signupPage writes signup page with given parameters to context.
+func (c *Accounts) signupPage(
+	ctx *gin.Context, status int, parameters gin.H) {
+	ctx.Status(status)
+	ctx.Set("template", "signup.tmpl")
+	ctx.Set("parameters", parameters)
+}
+
+// page writes account page of given user with given error to context.
+// Moderators also see users awaiting approval.
+func (c *Accounts) page(
+	ctx *gin.Context, status int, user *entity.User, err error) {
+	parameters := gin.H{
+		"userName":    user.Name,
+		"displayName": user.DisplayName,
+		"email":       user.Email,
+		"role":        user.Role.String(),
+		"twoFactor":   user.TwoFactor(),
+		"recovery":    len(user.RecoveryCodes),
+	}
+	if user.Role == entity.Moderator {
+		pending, e := c.AccountAction.Pending(user.Name)
+		if e != nil {
+			ctx.Error(e)
+		}
+		parameters["moderator"] = true
+		parameters["pending"] = pending
+	}
+	if err != nil {
+		parameters["error"] = err.Error()
+	}
+	ctx.Status(status)
+	ctx.Set("template", "account.tmpl")
+	ctx.Set("parameters", parameters)
+}
+
+// registeredUser returns registered user written to context by Session
+// middleware or redirects to index page otherwise.
+func registeredUser(ctx *gin.Context) (*entity.User, bool) {
+	user, ok := ctx.Get("user")
+	if !ok || user.(*entity.User).Guest {
+		ctx.Redirect(http.StatusTemporaryRedirect, "/")
+		ctx.Abort()
+		return nil, false
+	}
+	return user.(*entity.User), true
+}
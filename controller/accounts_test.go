@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/sessions"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// mockAccountAction is a mock that imitates AccountAction behavior.
+// "pending" behavior imitates signup that requires approval.
+type mockAccountAction struct {
+	behavior string
+	approved string
+}
+
+// Signup imitates AccountAction Signup method behavior depending on one
+// defined.
+func (a *mockAccountAction) Signup(ctx context.Context, userName string,
+	displayName string, email string, password string) (*entity.User, error) {
+	if a.behavior == "failure" {
+		return nil, errors.New("some error")
+	}
+	return &entity.User{
+		Name:        userName,
+		DisplayName: displayName,
+		Email:       email,
+		Pending:     a.behavior == "pending",
+	}, nil
+}
+
+// Pending imitates AccountAction Pending method behavior depending on one
+// defined.
+func (a *mockAccountAction) Pending(
+	moderatorName string) ([]*entity.User, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	return []*entity.User{{Name: "pending user", Pending: true}}, nil
+}
+
+// Approve imitates AccountAction Approve method behavior depending on one
+// defined.
+func (a *mockAccountAction) Approve(
+	ctx context.Context, userName string, moderatorName string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	a.approved = userName
+	return nil
+}
+
+// UpdateProfile imitates AccountAction UpdateProfile method behavior
+// depending on one defined.
+func (a *mockAccountAction) UpdateProfile(ctx context.Context,
+	userName string, displayName string, email string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+// ChangePassword imitates AccountAction ChangePassword method behavior
+// depending on one defined.
+func (a *mockAccountAction) ChangePassword(ctx context.Context,
+	userName string, current string, password string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+// Delete imitates AccountAction Delete method behavior depending on one
+// defined.
+func (a *mockAccountAction) Delete(
+	ctx context.Context, userName string, password string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+// newAccounts returns accounts controller with given action behavior and
+// HTTP session saved in its store.
+func newAccounts(behavior string) (*Accounts, *sessions.Session) {
+	store := &storeMock{behavior: "ok"}
+	session := sessions.NewSession(store, SESSION_NAME)
+	session.Values = map[interface{}]interface{}{"loggedUser": "test user"}
+	store.Save(nil, nil, session)
+	return &Accounts{
+		SessionStore:  store,
+		AccountAction: &mockAccountAction{behavior: behavior},
+	}, session
+}
+
+func TestAccounts_Signup(t *testing.T) {
+	form := url.Values{
+		"user":         {"new user"},
+		"display-name": {"New User"},
+		"pass":         {"new password"},
+		"pass-confirm": {"new password"},
+	}
+
+	Convey("Logs in signed up user", t, func() {
+		_, ctx := newFormContext(form)
+		c, session := newAccounts("ok")
+		c.Signup(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+		So(session.Values["loggedUser"], ShouldEqual, "new user")
+	})
+
+	Convey("Shows message to pending user", t, func() {
+		_, ctx := newFormContext(form)
+		c, session := newAccounts("pending")
+		c.Signup(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("template"), ShouldEqual, "signup.tmpl")
+		So(ctx.MustGet("parameters").(gin.H)["message"], ShouldNotBeEmpty)
+		So(session.Values["loggedUser"], ShouldEqual, "test user")
+	})
+
+	Convey("Returns bad request", t, func() {
+		_, ctx := newFormContext(url.Values{
+			"user": {"new user"}, "pass": {"a"}, "pass-confirm": {"b"}})
+		c, _ := newAccounts("ok")
+		c.Signup(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+		So(ctx.MustGet("parameters").(gin.H)["error"],
+			ShouldEqual, "passwords do not match")
+
+		Convey("if action failed", func() {
+			_, ctx := newFormContext(form)
+			c, _ := newAccounts("failure")
+			c.Signup(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+func TestAccounts_Profile(t *testing.T) {
+	Convey("Writes account page to context", t, func() {
+		_, ctx := newFormContext(url.Values{})
+		ctx.Set("user", &entity.User{Name: "test user", DisplayName: "Test"})
+		c, _ := newAccounts("ok")
+		c.Profile(ctx)
+
+		So(ctx.MustGet("template"), ShouldEqual, "account.tmpl")
+		params := ctx.MustGet("parameters").(gin.H)
+		So(params["displayName"], ShouldEqual, "Test")
+		So(params, ShouldNotContainKey, "pending")
+
+		Convey("with pending users for moderator", func() {
+			_, ctx := newFormContext(url.Values{})
+			ctx.Set("user",
+				&entity.User{Name: "test user", Role: entity.Moderator})
+			c.Profile(ctx)
+
+			params := ctx.MustGet("parameters").(gin.H)
+			So(params["pending"], ShouldHaveLength, 1)
+		})
+	})
+
+	Convey("Redirects guest to index page", t, func() {
+		_, ctx := newFormContext(url.Values{})
+		ctx.Set("user", &entity.User{Name: "guest:test", Guest: true})
+		c, _ := newAccounts("ok")
+		c.Profile(ctx)
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+	})
+}
+
+func TestAccounts_Update(t *testing.T) {
+	Convey("Redirects to account page", t, func() {
+		for _, handler := range []func(*Accounts, *gin.Context){
+			(*Accounts).UpdateProfile,
+			(*Accounts).ChangePassword,
+			(*Accounts).Approve,
+		} {
+			_, ctx := newFormContext(url.Values{"user": {"pending user"}})
+			ctx.Set("user", &entity.User{Name: "test user"})
+			c, _ := newAccounts("ok")
+			handler(c, ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+		}
+	})
+
+	Convey("Returns bad request", t, func() {
+		for _, handler := range []func(*Accounts, *gin.Context){
+			(*Accounts).UpdateProfile,
+			(*Accounts).ChangePassword,
+			(*Accounts).Approve,
+			(*Accounts).Delete,
+		} {
+			_, ctx := newFormContext(url.Values{})
+			ctx.Set("user", &entity.User{Name: "test user"})
+			c, _ := newAccounts("failure")
+			handler(c, ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+			So(ctx.MustGet("parameters").(gin.H)["error"], ShouldNotBeEmpty)
+		}
+	})
+}
+
+func TestAccounts_Delete(t *testing.T) {
+	Convey("Logs out deleted user", t, func() {
+		_, ctx := newFormContext(url.Values{"current-pass": {"test"}})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		c, session := newAccounts("ok")
+		c.Delete(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+		So(session.Values, ShouldNotContainKey, "loggedUser")
+	})
+}
@@ -0,0 +1,283 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Admin is a HTTP controller of admin area where moderators oversee users
+// and active sessions. Every change is confirmed on separate page before
+// it is applied.
+type Admin struct {
+	ModerationAction interface {
+		Users(moderatorName string) ([]*entity.User, error)
+		Sessions(moderatorName string) ([]*entity.Session, error)
+		Session(ctx context.Context, moderatorName string,
+			sessionName string) (*entity.Session, []*entity.Connection, error)
+		Kick(ctx context.Context, moderatorName string,
+			sessionName string, connectionID string) error
+		Unpublish(ctx context.Context, moderatorName string,
+			sessionName string, streamID string) error
+		CloseSession(ctx context.Context,
+			moderatorName string, sessionName string) error
+		SetRole(ctx context.Context, moderatorName string,
+			userName string, role entity.UserRole) error
+		Unlock(ctx context.Context,
+			moderatorName string, userName string) error
+		Audit(moderatorName string,
+			filter entity.AuditFilter) ([]*entity.AuditEvent, int, error)
+	}
+}
+
+// auditPageSize is a number of events on page of audit log.
+const auditPageSize = 50
+
+// Moderator is a middleware that lets only moderators into admin area.
+func (c *Admin) Moderator(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	if user.Role != entity.Moderator {
+		ctx.AbortWithStatus(http.StatusForbidden)
+	}
+}
+
+// Index returns page with all users and active sessions.
+func (c *Admin) Index(ctx *gin.Context) {
+	c.index(ctx, http.StatusOK, nil)
+}
+
+// Session returns page of active session given by "name" query parameter
+// with its participants connected to OpenViDu server.
+func (c *Admin) Session(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*entity.User)
+	session, connections, err := c.ModerationAction.Session(
+		ctx.Request.Context(), user.Name, ctx.Query("name"))
+	if session == nil {
+		c.index(ctx, http.StatusNotFound, err)
+		return
+	}
+	parameters := gin.H{
+		"session":     session,
+		"connections": connections,
+	}
+	if err != nil {
+		ctx.Error(err)
+		parameters["error"] = "Participants are not available: " + err.Error()
+	}
+	ctx.Status(http.StatusOK)
+	ctx.Set("template", "admin-session.tmpl")
+	ctx.Set("parameters", parameters)
+}
+
+// Kick disconnects participant given by "connection" form parameter from
+// session given by "session" form parameter.
+func (c *Admin) Kick(ctx *gin.Context) {
+	session := ctx.PostForm("session")
+	c.confirmed(ctx, "Disconnect participant "+ctx.PostForm("participant")+
+		" from session "+session+"?", sessionPage(session),
+		func(moderatorName string) error {
+			return c.ModerationAction.Kick(ctx.Request.Context(),
+				moderatorName, session, ctx.PostForm("connection"))
+		})
+}
+
+// Unpublish stops stream given by "stream" form parameter published to
+// session given by "session" form parameter.
+func (c *Admin) Unpublish(ctx *gin.Context) {
+	session := ctx.PostForm("session")
+	c.confirmed(ctx, "Stop stream of participant "+
+		ctx.PostForm("participant")+" in session "+session+"?",
+		sessionPage(session), func(moderatorName string) error {
+			return c.ModerationAction.Unpublish(ctx.Request.Context(),
+				moderatorName, session, ctx.PostForm("stream"))
+		})
+}
+
+// CloseSession closes session given by "session" form parameter and
+// disconnects all its participants.
+func (c *Admin) CloseSession(ctx *gin.Context) {
+	session := ctx.PostForm("session")
+	c.confirmed(ctx, "Close session "+session+
+		" and disconnect all its participants?", "/admin",
+		func(moderatorName string) error {
+			return c.ModerationAction.CloseSession(
+				ctx.Request.Context(), moderatorName, session)
+		})
+}
+
+// SetRole changes role of user given by "user" form parameter to "role"
+// form parameter.
+func (c *Admin) SetRole(ctx *gin.Context) {
+	role, err := entity.ParseUserRole(ctx.PostForm("role"))
+	if err != nil {
+		c.index(ctx, http.StatusBadRequest, err)
+		return
+	}
+	userName := ctx.PostForm("user")
+	c.confirmed(ctx, "Change role of user "+userName+" to "+role.String()+
+		"?", "/admin", func(moderatorName string) error {
+		return c.ModerationAction.SetRole(
+			ctx.Request.Context(), moderatorName, userName, role)
+	})
+}
+
+// Unlock removes lockout of user given by "user" form parameter caused by
+// failed logins.
+func (c *Admin) Unlock(ctx *gin.Context) {
+	userName := ctx.PostForm("user")
+	c.confirmed(ctx, "Unlock login of user "+userName+"?", "/admin",
+		func(moderatorName string) error {
+			return c.ModerationAction.Unlock(
+				ctx.Request.Context(), moderatorName, userName)
+		})
+}
+
+// Audit returns page of audit log filtered by "user", "session", "from"
+// and "to" query parameters. Time range is given as "2006-01-02T15:04" or
+// "2006-01-02", and date of "to" includes the whole day. Pages are
+// numbered from 1 by "page" query parameter.
+func (c *Admin) Audit(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*entity.User)
+	filter := entity.AuditFilter{
+		User:    ctx.Query("user"),
+		Session: ctx.Query("session"),
+		Limit:   auditPageSize,
+	}
+	from, _, err := parseAuditTime(ctx.Query("from"))
+	if err != nil {
+		c.index(ctx, http.StatusBadRequest, err)
+		return
+	}
+	to, day, err := parseAuditTime(ctx.Query("to"))
+	if err != nil {
+		c.index(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if day {
+		to = to.AddDate(0, 0, 1)
+	}
+	filter.From, filter.To = from, to
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	filter.Offset = (page - 1) * auditPageSize
+
+	events, total, err := c.ModerationAction.Audit(user.Name, filter)
+	if err != nil {
+		c.index(ctx, http.StatusServiceUnavailable, err)
+		return
+	}
+	query := ctx.Request.URL.Query()
+	pageURL := func(n int) string {
+		query.Set("page", strconv.Itoa(n))
+		return "/admin/audit?" + query.Encode()
+	}
+	parameters := gin.H{
+		"events":  events,
+		"total":   total,
+		"page":    page,
+		"user":    filter.User,
+		"session": filter.Session,
+		"from":    ctx.Query("from"),
+		"to":      ctx.Query("to"),
+	}
+	if page > 1 {
+		parameters["prev"] = pageURL(page - 1)
+	}
+	if page*auditPageSize < total {
+		parameters["next"] = pageURL(page + 1)
+	}
+	ctx.Status(http.StatusOK)
+	ctx.Set("template", "admin-audit.tmpl")
+	ctx.Set("parameters", parameters)
+}
+
+// confirmed applies given change and redirects to given page if "confirm"
+// form parameter is set. Otherwise it writes page that asks moderator the
+// given question and posts the same form again with confirmation.
+func (c *Admin) confirmed(ctx *gin.Context,
+	question string, redirect string, change func(string) error) {
+	user := ctx.MustGet("user").(*entity.User)
+	if ctx.PostForm("confirm") == "" {
+		fields := make(map[string]string)
+		for name, values := range ctx.Request.PostForm {
+			if name != "csrf-token" && len(values) > 0 {
+				fields[name] = values[0]
+			}
+		}
+		ctx.Status(http.StatusOK)
+		ctx.Set("template", "admin-confirm.tmpl")
+		ctx.Set("parameters", gin.H{
+			"question": question,
+			"action":   ctx.Request.URL.Path,
+			"fields":   fields,
+			"cancel":   redirect,
+		})
+		return
+	}
+	if err := change(user.Name); err != nil {
+		c.index(ctx, http.StatusBadRequest, err)
+		return
+	}
+	ctx.Redirect(http.StatusSeeOther, redirect)
+}
+
+// index writes admin page with given error to context.
+func (c *Admin) index(ctx *gin.Context, status int, err error) {
+	user := ctx.MustGet("user").(*entity.User)
+	users, e := c.ModerationAction.Users(user.Name)
+	if e != nil {
+		ctx.Error(e)
+	}
+	sessions, e := c.ModerationAction.Sessions(user.Name)
+	if e != nil {
+		ctx.Error(e)
+	}
+	parameters := gin.H{
+		"userName": user.Name,
+		"users":    users,
+		"sessions": sessions,
+		"roles": []entity.UserRole{
+			entity.Subscriber, entity.Publisher, entity.Moderator,
+		},
+	}
+	if err != nil {
+		parameters["error"] = err.Error()
+	}
+	ctx.Status(status)
+	ctx.Set("template", "admin.tmpl")
+	ctx.Set("parameters", parameters)
+}
+
+// parseAuditTime parses time of audit log filter and returns true if only
+// date is given. Zero time is returned for empty value.
+func parseAuditTime(v string) (time.Time, bool, error) {
+	if v == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err := time.ParseInLocation(
+		"2006-01-02T15:04", v, time.Local); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid time: %s", v)
+	}
+	return t, true, nil
+}
+
+// sessionPage returns path of admin page of session with given name.
+func sessionPage(sessionName string) string {
+	return "/admin/session?" + url.Values{"name": {sessionName}}.Encode()
+}
@@ -0,0 +1,352 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// mockModerationAction is a mock that imitates ModerationAction behavior.
+type mockModerationAction struct {
+	behavior string
+	calls    []string
+	filter   entity.AuditFilter
+}
+
+// Users imitates ModerationAction Users method behavior.
+func (a *mockModerationAction) Users(
+	moderatorName string) ([]*entity.User, error) {
+	return []*entity.User{{Name: moderatorName, Role: entity.Moderator}}, nil
+}
+
+// Sessions imitates ModerationAction Sessions method behavior.
+func (a *mockModerationAction) Sessions(
+	moderatorName string) ([]*entity.Session, error) {
+	return []*entity.Session{{Name: "test session"}}, nil
+}
+
+// Session imitates ModerationAction Session method behavior depending on
+// one defined.
+func (a *mockModerationAction) Session(ctx context.Context,
+	moderatorName string, sessionName string) (*entity.Session,
+	[]*entity.Connection, error) {
+	switch a.behavior {
+	case "ok":
+		return &entity.Session{Name: sessionName},
+			[]*entity.Connection{{ID: "con_1"}}, nil
+	case "unavailable":
+		return &entity.Session{Name: sessionName}, nil,
+			errors.New("some error")
+	default:
+		return nil, nil, errors.New("some error")
+	}
+}
+
+// Kick imitates ModerationAction Kick method behavior.
+func (a *mockModerationAction) Kick(ctx context.Context,
+	moderatorName string, sessionName string, connectionID string) error {
+	return a.call("kick " + sessionName + " " + connectionID)
+}
+
+// Unpublish imitates ModerationAction Unpublish method behavior.
+func (a *mockModerationAction) Unpublish(ctx context.Context,
+	moderatorName string, sessionName string, streamID string) error {
+	return a.call("unpublish " + sessionName + " " + streamID)
+}
+
+// CloseSession imitates ModerationAction CloseSession method behavior.
+func (a *mockModerationAction) CloseSession(ctx context.Context,
+	moderatorName string, sessionName string) error {
+	return a.call("close " + sessionName)
+}
+
+// SetRole imitates ModerationAction SetRole method behavior.
+func (a *mockModerationAction) SetRole(ctx context.Context,
+	moderatorName string, userName string, role entity.UserRole) error {
+	return a.call("role " + userName + " " + role.String())
+}
+
+// Unlock imitates ModerationAction Unlock method behavior.
+func (a *mockModerationAction) Unlock(ctx context.Context,
+	moderatorName string, userName string) error {
+	return a.call("unlock " + userName)
+}
+
+// Audit imitates ModerationAction Audit method behavior depending on one
+// defined.
+func (a *mockModerationAction) Audit(moderatorName string,
+	filter entity.AuditFilter) ([]*entity.AuditEvent, int, error) {
+	a.filter = filter
+	if a.behavior != "ok" {
+		return nil, 0, errors.New("some error")
+	}
+	return []*entity.AuditEvent{{Action: entity.AuditLogin}}, 120, nil
+}
+
+// call records given call and returns an error unless behavior is "ok".
+func (a *mockModerationAction) call(call string) error {
+	a.calls = append(a.calls, call)
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+func TestAdmin_Moderator(t *testing.T) {
+	Convey("Lets moderator in", t, func() {
+		_, ctx := newFormContext(url.Values{})
+		ctx.Set("user", &entity.User{Name: "test", Role: entity.Moderator})
+		(&Admin{}).Moderator(ctx)
+
+		So(ctx.IsAborted(), ShouldBeFalse)
+	})
+
+	Convey("Forbids other users", t, func() {
+		_, ctx := newFormContext(url.Values{})
+		ctx.Set("user", &entity.User{Name: "test", Role: entity.Publisher})
+		(&Admin{}).Moderator(ctx)
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("Redirects anonymous visitor to index page", t, func() {
+		_, ctx := newFormContext(url.Values{})
+		(&Admin{}).Moderator(ctx)
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+	})
+}
+
+func TestAdmin_Index(t *testing.T) {
+	Convey("Writes admin page to context", t, func() {
+		_, ctx := newModeratorContext(url.Values{})
+		(&Admin{ModerationAction: &mockModerationAction{behavior: "ok"}}).
+			Index(ctx)
+
+		So(ctx.MustGet("template"), ShouldEqual, "admin.tmpl")
+		parameters := ctx.MustGet("parameters").(gin.H)
+		So(parameters["users"], ShouldHaveLength, 1)
+		So(parameters["sessions"], ShouldHaveLength, 1)
+	})
+}
+
+func TestAdmin_Session(t *testing.T) {
+	Convey("Writes session page with participants", t, func() {
+		_, ctx := newModeratorContext(url.Values{})
+		ctx.Request.URL.RawQuery = "name=test+session"
+		(&Admin{ModerationAction: &mockModerationAction{behavior: "ok"}}).
+			Session(ctx)
+
+		So(ctx.MustGet("template"), ShouldEqual, "admin-session.tmpl")
+		parameters := ctx.MustGet("parameters").(gin.H)
+		So(parameters["session"].(*entity.Session).Name, ShouldEqual,
+			"test session")
+		So(parameters["connections"], ShouldHaveLength, 1)
+	})
+
+	Convey("Writes session page with OpenViDu error", t, func() {
+		_, ctx := newModeratorContext(url.Values{})
+		(&Admin{ModerationAction: &mockModerationAction{
+			behavior: "unavailable"}}).Session(ctx)
+
+		So(ctx.MustGet("template"), ShouldEqual, "admin-session.tmpl")
+		So(ctx.MustGet("parameters").(gin.H)["error"], ShouldNotBeEmpty)
+	})
+
+	Convey("Writes admin page for unknown session", t, func() {
+		_, ctx := newModeratorContext(url.Values{})
+		(&Admin{ModerationAction: &mockModerationAction{behavior: "wrong"}}).
+			Session(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusNotFound)
+		So(ctx.MustGet("template"), ShouldEqual, "admin.tmpl")
+	})
+}
+
+func TestAdmin_Kick(t *testing.T) {
+	Convey("Asks for confirmation", t, func() {
+		_, ctx := newModeratorContext(url.Values{
+			"csrf-token":  {"test token"},
+			"session":     {"test session"},
+			"participant": {"alice"},
+			"connection":  {"con_1"},
+		})
+		a := &mockModerationAction{behavior: "ok"}
+		(&Admin{ModerationAction: a}).Kick(ctx)
+
+		So(a.calls, ShouldBeEmpty)
+		So(ctx.MustGet("template"), ShouldEqual, "admin-confirm.tmpl")
+		parameters := ctx.MustGet("parameters").(gin.H)
+		So(parameters["question"], ShouldEqual,
+			"Disconnect participant alice from session test session?")
+		So(parameters["action"], ShouldEqual, "/test")
+		So(parameters["fields"], ShouldResemble, map[string]string{
+			"session":     "test session",
+			"participant": "alice",
+			"connection":  "con_1",
+		})
+	})
+
+	Convey("Disconnects participant once confirmed", t, func() {
+		w, ctx := newModeratorContext(url.Values{
+			"session":    {"test session"},
+			"connection": {"con_1"},
+			"confirm":    {"yes"},
+		})
+		a := &mockModerationAction{behavior: "ok"}
+		(&Admin{ModerationAction: a}).Kick(ctx)
+
+		So(a.calls, ShouldResemble, []string{"kick test session con_1"})
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+		So(w.Header().Get("Location"), ShouldEqual,
+			"/admin/session?name=test+session")
+	})
+
+	Convey("Writes admin page with error", t, func() {
+		_, ctx := newModeratorContext(url.Values{
+			"session":    {"test session"},
+			"connection": {"con_1"},
+			"confirm":    {"yes"},
+		})
+		(&Admin{ModerationAction: &mockModerationAction{behavior: "wrong"}}).
+			Kick(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+		So(ctx.MustGet("template"), ShouldEqual, "admin.tmpl")
+		So(ctx.MustGet("parameters").(gin.H)["error"], ShouldEqual,
+			"some error")
+	})
+}
+
+func TestAdmin_Unpublish(t *testing.T) {
+	Convey("Stops stream once confirmed", t, func() {
+		_, ctx := newModeratorContext(url.Values{
+			"session": {"test session"},
+			"stream":  {"str_1"},
+			"confirm": {"yes"},
+		})
+		a := &mockModerationAction{behavior: "ok"}
+		(&Admin{ModerationAction: a}).Unpublish(ctx)
+
+		So(a.calls, ShouldResemble, []string{"unpublish test session str_1"})
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+	})
+}
+
+func TestAdmin_CloseSession(t *testing.T) {
+	Convey("Closes session once confirmed", t, func() {
+		w, ctx := newModeratorContext(url.Values{
+			"session": {"test session"},
+			"confirm": {"yes"},
+		})
+		a := &mockModerationAction{behavior: "ok"}
+		(&Admin{ModerationAction: a}).CloseSession(ctx)
+
+		So(a.calls, ShouldResemble, []string{"close test session"})
+		So(w.Header().Get("Location"), ShouldEqual, "/admin")
+	})
+}
+
+func TestAdmin_SetRole(t *testing.T) {
+	Convey("Changes role once confirmed", t, func() {
+		w, ctx := newModeratorContext(url.Values{
+			"user":    {"alice"},
+			"role":    {"PUBLISHER"},
+			"confirm": {"yes"},
+		})
+		a := &mockModerationAction{behavior: "ok"}
+		(&Admin{ModerationAction: a}).SetRole(ctx)
+
+		So(a.calls, ShouldResemble, []string{"role alice PUBLISHER"})
+		So(w.Header().Get("Location"), ShouldEqual, "/admin")
+	})
+
+	Convey("Writes admin page for unknown role", t, func() {
+		_, ctx := newModeratorContext(url.Values{
+			"user": {"alice"},
+			"role": {"ADMIN"},
+		})
+		a := &mockModerationAction{behavior: "ok"}
+		(&Admin{ModerationAction: a}).SetRole(ctx)
+
+		So(a.calls, ShouldBeEmpty)
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+		So(ctx.MustGet("template"), ShouldEqual, "admin.tmpl")
+	})
+}
+
+func TestAdmin_Unlock(t *testing.T) {
+	Convey("Unlocks user once confirmed", t, func() {
+		w, ctx := newModeratorContext(url.Values{
+			"user":    {"alice"},
+			"confirm": {"yes"},
+		})
+		a := &mockModerationAction{behavior: "ok"}
+		(&Admin{ModerationAction: a}).Unlock(ctx)
+
+		So(a.calls, ShouldResemble, []string{"unlock alice"})
+		So(w.Header().Get("Location"), ShouldEqual, "/admin")
+	})
+}
+
+func TestAdmin_Audit(t *testing.T) {
+	Convey("Writes page of audit log", t, func() {
+		_, ctx := newModeratorContext(url.Values{})
+		ctx.Request.URL.RawQuery = "user=alice&session=room&" +
+			"from=2020-01-02T10:30&to=2020-01-03&page=2"
+		a := &mockModerationAction{behavior: "ok"}
+		(&Admin{ModerationAction: a}).Audit(ctx)
+
+		So(ctx.MustGet("template"), ShouldEqual, "admin-audit.tmpl")
+		So(a.filter.User, ShouldEqual, "alice")
+		So(a.filter.Session, ShouldEqual, "room")
+		So(a.filter.From, ShouldResemble,
+			time.Date(2020, 1, 2, 10, 30, 0, 0, time.Local))
+		So(a.filter.To, ShouldResemble,
+			time.Date(2020, 1, 4, 0, 0, 0, 0, time.Local))
+		So(a.filter.Offset, ShouldEqual, 50)
+		So(a.filter.Limit, ShouldEqual, 50)
+		parameters := ctx.MustGet("parameters").(gin.H)
+		So(parameters["events"], ShouldHaveLength, 1)
+		So(parameters["prev"], ShouldEqual, "/admin/audit?from=2020-01-02T10"+
+			"%3A30&page=1&session=room&to=2020-01-03&user=alice")
+		So(parameters["next"], ShouldContainSubstring, "page=3")
+	})
+
+	Convey("Writes admin page with error", t, func() {
+		_, ctx := newModeratorContext(url.Values{})
+		ctx.Request.URL.RawQuery = "from=yesterday"
+		(&Admin{ModerationAction: &mockModerationAction{behavior: "ok"}}).
+			Audit(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+		So(ctx.MustGet("template"), ShouldEqual, "admin.tmpl")
+
+		_, ctx = newModeratorContext(url.Values{})
+		(&Admin{ModerationAction: &mockModerationAction{behavior: "wrong"}}).
+			Audit(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusServiceUnavailable)
+		So(ctx.MustGet("template"), ShouldEqual, "admin.tmpl")
+	})
+}
+
+// newModeratorContext returns form context of logged moderator.
+func newModeratorContext(
+	form url.Values) (*httptest.ResponseRecorder, *gin.Context) {
+	w, ctx := newFormContext(form)
+	ctx.Set("user", &entity.User{Name: "test moderator",
+		Role: entity.Moderator})
+	return w, ctx
+}
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/service"
+)
+
+// Default and maximal number of sessions returned by API at once.
+const (
+	defaultAPILimit = 20
+	maxAPILimit     = 100
+)
+
+// API is a HTTP controller that provides JSON API of the example for API
+// clients.
+type API struct {
+	OpenViDuService service.OpenViDu
+	SessionAction   interface {
+		Add(ctx context.Context, sessionID string, sessionName string,
+			ownerName string, passcode string,
+			policy *entity.SessionPolicy) error
+		Get(ctx context.Context, sessionName string) (*entity.Session, error)
+		IsExists(ctx context.Context, sessionName string) bool
+		List(ctx context.Context,
+			filter entity.SessionFilter) ([]*entity.Session, int, error)
+	}
+}
+
+// sessionInfo is a public representation of active OpenViDu session.
+type sessionInfo struct {
+	Name         string    `json:"name"`
+	Owner        string    `json:"owner"`
+	Participants int       `json:"participants"`
+	Access       string    `json:"access"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Age          string    `json:"age"`
+}
+
+// newSessionsInfo converts given sessions to their public representation.
+func newSessionsInfo(list []*entity.Session) []sessionInfo {
+	now := time.Now()
+	info := make([]sessionInfo, 0, len(list))
+	for _, s := range list {
+		i := sessionInfo{
+			Name:         s.Name,
+			Participants: s.ParticipantsCount(),
+			Access:       s.Access.String(),
+			CreatedAt:    s.CreatedAt,
+			Age:          now.Sub(s.CreatedAt).Truncate(time.Second).String(),
+		}
+		if s.Owner != nil {
+			i.Owner = s.Owner.Name
+		}
+		info = append(info, i)
+	}
+	return info
+}
+
+// Sessions writes list of active sessions as JSON.
+//
+// Supports "owner", "q", "offset" and "limit" query parameters.
+func (c *API) Sessions(ctx *gin.Context) {
+	if _, ok := loggedUser(ctx); !ok {
+		return
+	}
+
+	filter := entity.SessionFilter{
+		Owner: ctx.Query("owner"),
+		Name:  ctx.Query("q"),
+		Limit: defaultAPILimit,
+	}
+	if offset, err := strconv.Atoi(ctx.Query("offset")); err == nil &&
+		offset > 0 {
+		filter.Offset = offset
+	}
+	if limit, err := strconv.Atoi(ctx.Query("limit")); err == nil &&
+		limit > 0 {
+		filter.Limit = limit
+	}
+	if filter.Limit > maxAPILimit {
+		filter.Limit = maxAPILimit
+	}
+
+	list, total, err := c.SessionAction.List(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"sessions": newSessionsInfo(list),
+		"total":    total,
+		"offset":   filter.Offset,
+		"limit":    filter.Limit,
+	})
+}
+
+// CreateSession creates OpenViDu session named by "session-name" form
+// parameter and owned by logged user, and writes it as JSON.
+func (c *API) CreateSession(ctx *gin.Context) {
+	user, ok := loggedUser(ctx)
+	if !ok {
+		return
+	}
+	name := strings.TrimSpace(ctx.PostForm("session-name"))
+	if name == "" {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "session name is required"})
+		return
+	}
+	if user.Role == entity.Subscriber {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("user %s can not publish", user.Name)})
+		return
+	}
+	if c.SessionAction.IsExists(ctx.Request.Context(), name) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("session %s already exists", name)})
+		return
+	}
+	id, err := c.OpenViDuService.GetMediaSession(ctx.Request.Context(), name)
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if err = c.SessionAction.Add(
+		ctx.Request.Context(), id, name, user.Name, "", nil); err != nil {
+		// Media session of refused session must not be left on OpenViDu
+		// server.
+		c.OpenViDuService.CloseSession(ctx.Request.Context(), id)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"name": name, "id": id})
+}
+
+// Token writes OpenViDu token to join session given by "name" path
+// parameter as JSON. Only the session owner gets tokens, e.g. for its
+// backend to hand them out to participants.
+//
+// Reads "role" and "data" form parameters. Role is PUBLISHER by default
+// and can not exceed role of logged user.
+func (c *API) Token(ctx *gin.Context) {
+	user, ok := loggedUser(ctx)
+	if !ok {
+		return
+	}
+	session, err := c.SessionAction.Get(
+		ctx.Request.Context(), ctx.Param("name"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if session.Owner == nil || session.Owner.Name != user.Name {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf(
+			"user %s is not owner of session %s", user.Name, session.Name)})
+		return
+	}
+	role, err := entity.ParseUserRole(
+		ctx.DefaultPostForm("role", entity.Publisher.String()))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if role > user.Role {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf(
+			"user %s can not grant role %s", user.Name, role)})
+		return
+	}
+	data, err := json.Marshal(
+		map[string]string{"serverData": ctx.PostForm("data")})
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	token, err := c.OpenViDuService.GetToken(ctx.Request.Context(),
+		map[string]interface{}{
+			"session": session.ID,
+			"role":    role.String(),
+			"data":    string(data),
+		})
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"session": session.Name,
+		"token":   token["token"],
+		"role":    role.String(),
+	})
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestAPI_Sessions(t *testing.T) {
+	Convey("Writes list of sessions as JSON", t, func() {
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet,
+			"/api/sessions?offset=5&limit=1000", nil)
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&API{SessionAction: &mockSessionAction{"ok"}}).Sessions(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+
+		var body struct {
+			Sessions []sessionInfo
+			Total    int
+			Offset   int
+			Limit    int
+		}
+		So(json.Unmarshal(w.Body.Bytes(), &body), ShouldBeNil)
+
+		Convey("with correct sessions data", func() {
+			So(body.Sessions, ShouldHaveLength, 1)
+			So(body.Sessions[0].Name, ShouldEqual, "test session name")
+			So(body.Sessions[0].Owner, ShouldEqual, "test user")
+			So(body.Sessions[0].Participants, ShouldEqual, 1)
+		})
+
+		Convey("with correct pagination", func() {
+			So(body.Offset, ShouldEqual, 5)
+			So(body.Limit, ShouldEqual, maxAPILimit)
+			So(body.Total, ShouldEqual, 5+sessionsPerPage+1)
+		})
+	})
+
+	Convey("Returns unauthorized if user is not logged", t, func() {
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+		(&API{SessionAction: &mockSessionAction{"ok"}}).Sessions(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Returns list error", t, func() {
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&API{SessionAction: &mockSessionAction{"failure"}}).Sessions(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusInternalServerError)
+	})
+}
+
+func TestAPI_CreateSession(t *testing.T) {
+	form := url.Values{"session-name": {"new session"}}
+
+	Convey("Creates session and writes it as JSON", t, func() {
+		w, ctx := newFormContext(form)
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		(&API{
+			OpenViDuService: &mockOpenViDu{"ok"},
+			SessionAction:   &mockSessionAction{"new"},
+		}).CreateSession(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusCreated)
+		var body map[string]string
+		So(json.Unmarshal(w.Body.Bytes(), &body), ShouldBeNil)
+		So(body["name"], ShouldEqual, "new session")
+	})
+
+	Convey("Returns conflict if session exists", t, func() {
+		w, ctx := newFormContext(form)
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		(&API{
+			OpenViDuService: &mockOpenViDu{"ok"},
+			SessionAction:   &mockSessionAction{"ok"},
+		}).CreateSession(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusConflict)
+	})
+
+	Convey("Returns forbidden if user can not publish", t, func() {
+		w, ctx := newFormContext(form)
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&API{
+			OpenViDuService: &mockOpenViDu{"ok"},
+			SessionAction:   &mockSessionAction{"new"},
+		}).CreateSession(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("Closes media session if session is refused", t, func() {
+		w, ctx := newFormContext(form)
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		openViDu := &mediaSessionsOpenViDu{mockOpenViDu: mockOpenViDu{"ok"}}
+		(&API{
+			OpenViDuService: openViDu,
+			SessionAction:   &mockSessionAction{"failure"},
+		}).CreateSession(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(openViDu.closed, ShouldResemble, []string{"new session"})
+	})
+
+	Convey("Returns bad gateway if OpenViDu fails", t, func() {
+		w, ctx := newFormContext(form)
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		(&API{
+			OpenViDuService: &mockOpenViDu{"failure"},
+			SessionAction:   &mockSessionAction{"new"},
+		}).CreateSession(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusBadGateway)
+	})
+}
+
+func TestAPI_Token(t *testing.T) {
+	newContext := func(form url.Values,
+		user *entity.User) (*httptest.ResponseRecorder, *gin.Context) {
+		w, ctx := newFormContext(form)
+		ctx.Params = gin.Params{{Key: "name", Value: "test session"}}
+		ctx.Set("user", user)
+		return w, ctx
+	}
+	owner := &entity.User{Name: "test user", Role: entity.Publisher}
+
+	Convey("Writes token as JSON", t, func() {
+		w, ctx := newContext(url.Values{"data": {"Alice"}}, owner)
+		(&API{
+			OpenViDuService: &mockOpenViDu{"ok"},
+			SessionAction:   &mockSessionAction{"ok"},
+		}).Token(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		var body map[string]string
+		So(json.Unmarshal(w.Body.Bytes(), &body), ShouldBeNil)
+		So(body["token"], ShouldEqual, "test token")
+		So(body["role"], ShouldEqual, "PUBLISHER")
+	})
+
+	Convey("Returns forbidden", t, func() {
+		c := &API{
+			OpenViDuService: &mockOpenViDu{"ok"},
+			SessionAction:   &mockSessionAction{"ok"},
+		}
+
+		Convey("if user is not owner", func() {
+			w, ctx := newContext(url.Values{},
+				&entity.User{Name: "other user", Role: entity.Moderator})
+			c.Token(ctx)
+
+			So(w.Code, ShouldEqual, http.StatusForbidden)
+		})
+
+		Convey("if role exceeds role of user", func() {
+			w, ctx := newContext(url.Values{"role": {"MODERATOR"}}, owner)
+			c.Token(ctx)
+
+			So(w.Code, ShouldEqual, http.StatusForbidden)
+		})
+	})
+
+	Convey("Returns not found if session does not exist", t, func() {
+		w, ctx := newContext(url.Values{}, owner)
+		(&API{
+			OpenViDuService: &mockOpenViDu{"ok"},
+			SessionAction:   &mockSessionAction{"failure"},
+		}).Token(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// APIKeys is a HTTP controller that lets logged user manage API keys of
+// its machine clients.
+type APIKeys struct {
+	APIKeyAction interface {
+		Create(ctx context.Context, userName string, name string,
+			scopes []entity.APIScope) (string, *entity.APIKey, error)
+		List(userName string) ([]*entity.APIKey, error)
+		Revoke(ctx context.Context, userName string, id string) error
+	}
+}
+
+// List returns page with API keys of logged user.
+func (c *APIKeys) List(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	c.page(ctx, http.StatusOK, user, gin.H{})
+}
+
+// Create issues new API key of logged user named by "name" form parameter
+// with scopes of "scope" form parameters, and shows the key once.
+func (c *APIKeys) Create(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	scopes := make([]entity.APIScope, 0, len(entity.APIScopes))
+	for _, s := range ctx.PostFormArray("scope") {
+		scope, err := entity.ParseAPIScope(s)
+		if err != nil {
+			c.page(ctx, http.StatusBadRequest, user, gin.H{"error": err.Error()})
+			return
+		}
+		scopes = append(scopes, scope)
+	}
+	token, _, err := c.APIKeyAction.Create(
+		ctx.Request.Context(), user.Name, ctx.PostForm("name"), scopes)
+	if err != nil {
+		c.page(ctx, http.StatusBadRequest, user, gin.H{"error": err.Error()})
+		return
+	}
+	c.page(ctx, http.StatusOK, user, gin.H{"token": token})
+}
+
+// Revoke revokes API key of logged user given by "id" form parameter.
+func (c *APIKeys) Revoke(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.APIKeyAction.Revoke(
+		ctx.Request.Context(), user.Name, ctx.PostForm("id")); err != nil {
+		c.page(ctx, http.StatusBadRequest, user, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Redirect(http.StatusSeeOther, "/account/api-keys")
+}
+
+// page writes API keys page of given user with given parameters to
+// context.
+func (c *APIKeys) page(ctx *gin.Context,
+	status int, user *entity.User, parameters gin.H) {
+	keys, err := c.APIKeyAction.List(user.Name)
+	if err != nil {
+		ctx.Error(err)
+	}
+	parameters["keys"] = keys
+	parameters["scopes"] = entity.APIScopes
+	ctx.Status(status)
+	ctx.Set("template", "account-keys.tmpl")
+	ctx.Set("parameters", parameters)
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// mockAPIKeyAction is a mock that imitates APIKeyAction behavior.
+type mockAPIKeyAction struct {
+	behavior string
+	scopes   []entity.APIScope
+}
+
+// Create imitates APIKeyAction Create method behavior depending on one
+// defined.
+func (a *mockAPIKeyAction) Create(ctx context.Context, userName string,
+	name string, scopes []entity.APIScope) (string, *entity.APIKey, error) {
+	if a.behavior != "ok" {
+		return "", nil, errors.New("some error")
+	}
+	a.scopes = scopes
+	return "ovk_test", &entity.APIKey{ID: "test key", Name: name}, nil
+}
+
+// List imitates APIKeyAction List method behavior depending on one
+// defined.
+func (a *mockAPIKeyAction) List(userName string) ([]*entity.APIKey, error) {
+	return []*entity.APIKey{{ID: "test key", UserName: userName}}, nil
+}
+
+// Revoke imitates APIKeyAction Revoke method behavior depending on one
+// defined.
+func (a *mockAPIKeyAction) Revoke(
+	ctx context.Context, userName string, id string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+func TestAPIKeys_List(t *testing.T) {
+	Convey("Writes API keys page to context", t, func() {
+		_, ctx := newFormContext(url.Values{})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&APIKeys{APIKeyAction: &mockAPIKeyAction{behavior: "ok"}}).List(ctx)
+
+		So(ctx.MustGet("template"), ShouldEqual, "account-keys.tmpl")
+		So(ctx.MustGet("parameters").(gin.H)["keys"], ShouldHaveLength, 1)
+	})
+
+	Convey("Redirects user of API key to index page", t, func() {
+		_, ctx := newFormContext(url.Values{})
+		ctx.Set(apiKeyKey, &entity.APIKey{ID: "test key"})
+		(&APIKeys{APIKeyAction: &mockAPIKeyAction{behavior: "ok"}}).List(ctx)
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+	})
+}
+
+func TestAPIKeys_Create(t *testing.T) {
+	Convey("Shows created API key once", t, func() {
+		_, ctx := newFormContext(url.Values{
+			"name":  {"backend"},
+			"scope": {"create-session", "get-token"},
+		})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		a := &mockAPIKeyAction{behavior: "ok"}
+		(&APIKeys{APIKeyAction: a}).Create(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("parameters").(gin.H)["token"], ShouldEqual, "ovk_test")
+		So(a.scopes, ShouldResemble, []entity.APIScope{
+			entity.ScopeCreateSession, entity.ScopeGetToken})
+	})
+
+	Convey("Returns bad request", t, func() {
+		Convey("if scope is unknown", func() {
+			_, ctx := newFormContext(url.Values{
+				"name": {"backend"}, "scope": {"wrong"}})
+			ctx.Set("user", &entity.User{Name: "test user"})
+			(&APIKeys{APIKeyAction: &mockAPIKeyAction{behavior: "ok"}}).
+				Create(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+		})
+
+		Convey("if action failed", func() {
+			_, ctx := newFormContext(url.Values{"name": {"backend"}})
+			ctx.Set("user", &entity.User{Name: "test user"})
+			(&APIKeys{APIKeyAction: &mockAPIKeyAction{behavior: "failure"}}).
+				Create(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+func TestAPIKeys_Revoke(t *testing.T) {
+	Convey("Redirects to API keys page", t, func() {
+		_, ctx := newFormContext(url.Values{"id": {"test key"}})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&APIKeys{APIKeyAction: &mockAPIKeyAction{behavior: "ok"}}).
+			Revoke(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+	})
+
+	Convey("Returns bad request if action failed", t, func() {
+		_, ctx := newFormContext(url.Values{"id": {"test key"}})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&APIKeys{APIKeyAction: &mockAPIKeyAction{behavior: "failure"}}).
+			Revoke(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+	})
+}
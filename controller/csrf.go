@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/sessions"
+)
+
+const (
+	// CSRFField is a name of form field that carries CSRF token.
+	CSRFField = "csrf-token"
+
+	// CSRFHeader is a name of HTTP header that carries CSRF token in
+	// requests made by scripts.
+	CSRFHeader = "X-CSRF-Token"
+
+	// csrfKey is a key of CSRF token in HTTP session and gin context.
+	csrfKey = "csrfToken"
+)
+
+// errCSRF is returned when request has no valid CSRF token.
+var errCSRF = errors.New("CSRF token is invalid, reload page and try again")
+
+// CSRF is a middleware that protects state changing requests from cross
+// site request forgery.
+//
+// Token is stored in HTTP session and written to context as "csrfToken", so
+// it is rendered into forms. Token is created only when a page is rendered,
+// so requests of probes, metrics scrapers or event streams never create
+// HTTP sessions. Requests authorized by bearer token are exempt, as browsers
+// never send such credentials automatically.
+type CSRF struct {
+	Store sessions.Store
+
+	// Exempt lists routes of requests that carry their own credentials in
+	// body and set no cookies, e.g. token endpoint.
+	Exempt []string
+}
+
+// Protect writes CSRF token to context, aborts unsafe requests that carry
+// no valid token with 403 status and creates token for rendered page if
+// HTTP session has none.
+func (mw *CSRF) Protect(ctx *gin.Context) {
+	if hasBearerAuth(ctx.Request) || mw.exempt(ctx.FullPath()) {
+		return
+	}
+	token := mw.token(ctx)
+	if token != "" {
+		ctx.Set(csrfKey, token)
+	}
+	if !isSafeMethod(ctx.Request.Method) && !validCSRF(ctx, token) {
+		ctx.Error(errCSRF)
+		ctx.Status(http.StatusForbidden)
+		if strings.Contains(ctx.GetHeader("Accept"), "application/json") ||
+			ctx.GetHeader(CSRFHeader) != "" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": errCSRF.Error()})
+		} else {
+			ctx.Set("template", "index.tmpl")
+			ctx.Set("parameters", gin.H{"error": errCSRF.Error()})
+		}
+		ctx.Abort()
+	} else {
+		ctx.Next()
+	}
+	if _, ok := ctx.Get("template"); ok && token == "" {
+		mw.issue(ctx)
+	}
+}
+
+// token returns CSRF token stored in HTTP session of request or empty
+// string if there is none.
+func (mw *CSRF) token(ctx *gin.Context) string {
+	session, err := mw.Store.Get(ctx.Request, SESSION_NAME)
+	if err != nil {
+		return ""
+	}
+	token, _ := session.Values[csrfKey].(string)
+	return token
+}
+
+// issue creates new CSRF token, stores it in HTTP session and writes it to
+// context. Page is rendered without token if it can not be stored.
+func (mw *CSRF) issue(ctx *gin.Context) {
+	session, err := mw.Store.Get(ctx.Request, SESSION_NAME)
+	if err != nil {
+		if session, err = mw.Store.New(ctx.Request, SESSION_NAME); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+	token, err := newCSRFToken()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	session.Values[csrfKey] = token
+	if err = session.Save(ctx.Request, ctx.Writer); err != nil {
+		ctx.Error(err)
+		return
+	}
+	ctx.Set(csrfKey, token)
+}
+
+// validCSRF returns true if request carries given non-empty token in header
+// or form.
+func validCSRF(ctx *gin.Context, token string) bool {
+	if token == "" {
+		return false
+	}
+	sent := ctx.GetHeader(CSRFHeader)
+	if sent == "" {
+		sent = ctx.PostForm(CSRFField)
+	}
+	return subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1
+}
+
+// exempt returns true if requests of given route are not protected.
+func (mw *CSRF) exempt(route string) bool {
+	for _, r := range mw.Exempt {
+		if r == route {
+			return true
+		}
+	}
+	return false
+}
+
+// newCSRFToken returns new random CSRF token.
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// isSafeMethod returns true for HTTP methods that do not change state.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// hasBearerAuth returns true if request is authorized by bearer token.
+func hasBearerAuth(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/sessions"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCSRF_Protect(t *testing.T) {
+	newCSRF := func() (*CSRF, *sessions.Session) {
+		store := &storeMock{behavior: "ok"}
+		session := sessions.NewSession(store, SESSION_NAME)
+		session.Values = map[interface{}]interface{}{}
+		store.Save(nil, nil, session)
+		return &CSRF{Store: store}, session
+	}
+
+	// serve passes GET request of given path through given middleware to
+	// handler that renders page for "/" path only, and returns token
+	// written to context.
+	serve := func(mw *CSRF, path string) interface{} {
+		var token interface{}
+		router := gin.New()
+		router.Use(func(ctx *gin.Context) {
+			ctx.Next()
+			token, _ = ctx.Get(csrfKey)
+		}, mw.Protect)
+		router.GET("/", func(ctx *gin.Context) {
+			ctx.Set("template", "index.tmpl")
+		})
+		router.GET("/healthz", func(ctx *gin.Context) {
+			ctx.Status(http.StatusOK)
+		})
+		router.ServeHTTP(httptest.NewRecorder(),
+			httptest.NewRequest(http.MethodGet, path, nil))
+		return token
+	}
+
+	Convey("Writes new token of rendered page to session and context", t,
+		func() {
+			mw, session := newCSRF()
+			token := serve(mw, "/")
+
+			So(session.Values[csrfKey], ShouldNotBeEmpty)
+			So(token, ShouldEqual, session.Values[csrfKey])
+
+			Convey("and keeps it for next requests", func() {
+				So(serve(mw, "/"), ShouldEqual, token)
+				So(serve(mw, "/healthz"), ShouldEqual, token)
+			})
+		})
+
+	Convey("Creates no token for request without page", t, func() {
+		mw, session := newCSRF()
+
+		So(serve(mw, "/healthz"), ShouldBeNil)
+		So(session.Values, ShouldNotContainKey, csrfKey)
+	})
+
+	Convey("Aborts POST request without token", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/session", nil)
+		ctx.Request.PostForm = url.Values{}
+		mw, _ := newCSRF()
+		mw.Protect(ctx)
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusForbidden)
+		So(ctx.MustGet("template"), ShouldEqual, "index.tmpl")
+		So(ctx.MustGet("parameters").(gin.H)["error"],
+			ShouldEqual, errCSRF.Error())
+		So(ctx.MustGet(csrfKey), ShouldNotBeEmpty)
+	})
+
+	Convey("Aborts POST request with wrong token", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/session", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add(CSRFField, "wrong token")
+		mw, session := newCSRF()
+		session.Values[csrfKey] = "test token"
+		mw.Protect(ctx)
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("Passes POST request with valid form token", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/session", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add(CSRFField, "test token")
+		mw, session := newCSRF()
+		session.Values[csrfKey] = "test token"
+		mw.Protect(ctx)
+
+		So(ctx.IsAborted(), ShouldBeFalse)
+	})
+
+	Convey("Passes POST request with valid header token", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/api/session", nil)
+		ctx.Request.Header.Set(CSRFHeader, "test token")
+		mw, session := newCSRF()
+		session.Values[csrfKey] = "test token"
+		mw.Protect(ctx)
+
+		So(ctx.IsAborted(), ShouldBeFalse)
+	})
+
+	Convey("Responds with JSON error to script request", t, func() {
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/api/session", nil)
+		ctx.Request.Header.Set(CSRFHeader, "wrong token")
+		mw, session := newCSRF()
+		session.Values[csrfKey] = "test token"
+		mw.Protect(ctx)
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(w.Body.String(), ShouldContainSubstring, errCSRF.Error())
+		_, ok := ctx.Get("template")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Does not check request authorized by bearer token", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/api/session",
+			strings.NewReader("{}"))
+		ctx.Request.Header.Set("Authorization", "Bearer test")
+		mw, session := newCSRF()
+		mw.Protect(ctx)
+
+		So(ctx.IsAborted(), ShouldBeFalse)
+		So(session.Values, ShouldNotContainKey, csrfKey)
+	})
+
+	Convey("Does not check request to exempt route", t, func() {
+		mw, _ := newCSRF()
+		mw.Exempt = []string{"/api/token"}
+		router := gin.New()
+		router.Use(mw.Protect)
+		router.POST("/api/token", func(ctx *gin.Context) {
+			ctx.Status(http.StatusOK)
+		})
+		router.POST("/session", func(ctx *gin.Context) {
+			ctx.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(
+			http.MethodPost, "/api/token", nil))
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(
+			http.MethodPost, "/session", nil))
+
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("Renders page without token if session cannot be saved", t,
+		func() {
+			So(serve(&CSRF{Store: &storeMock{behavior: "failure"}}, "/"),
+				ShouldBeNil)
+		})
+}
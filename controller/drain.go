@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errDraining is returned while application stops accepting new joins.
+var errDraining = errors.New("server is shutting down, try again later")
+
+// Drain is a HTTP middleware that refuses new joins to sessions when
+// application is going to stop.
+type Drain struct {
+	draining int32
+}
+
+// Start stops accepting of new joins.
+func (c *Drain) Start() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+// IsDraining returns true if new joins are not accepted.
+func (c *Drain) IsDraining() bool {
+	return atomic.LoadInt32(&c.draining) == 1
+}
+
+// RefuseJoins writes index page with error and aborts request if
+// application is draining.
+func (c *Drain) RefuseJoins(ctx *gin.Context) {
+	if !c.IsDraining() {
+		return
+	}
+	ctx.Status(http.StatusServiceUnavailable)
+	ctx.Set("template", "index.tmpl")
+	ctx.Set("parameters", gin.H{"error": errDraining.Error()})
+	ctx.Abort()
+}
+
+// Check returns an error if application is draining. Is used as readiness
+// check.
+func (c *Drain) Check(ctx context.Context) error {
+	if c.IsDraining() {
+		return errDraining
+	}
+	return nil
+}
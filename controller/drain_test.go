@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDrain_RefuseJoins(t *testing.T) {
+	Convey("Passes request through", t, func() {
+		_, ctx := newTestContext()
+		d := &Drain{}
+		d.RefuseJoins(ctx)
+
+		So(ctx.IsAborted(), ShouldBeFalse)
+		So(d.Check(context.Background()), ShouldBeNil)
+	})
+
+	Convey("Refuses request while draining", t, func() {
+		_, ctx := newTestContext()
+		d := &Drain{}
+		d.Start()
+		d.RefuseJoins(ctx)
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusServiceUnavailable)
+		So(ctx.MustGet("template"), ShouldEqual, "index.tmpl")
+		So(d.Check(context.Background()), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// RoomEvents is a HTTP controller that pushes changes of session state to
+// its participants as Server-Sent Events.
+type RoomEvents struct {
+	SessionAction interface {
+		Get(ctx context.Context, sessionName string) (*entity.Session, error)
+	}
+
+	Events interface {
+		Subscribe(sessionName string) (<-chan *entity.RoomEvent, func())
+	}
+
+	// KeepAlive is a period of comments sent to idle stream, so proxies do
+	// not drop it. Default period is used if zero.
+	KeepAlive time.Duration
+}
+
+// defaultKeepAlive is a default period of comments sent to idle stream.
+const defaultKeepAlive = 30 * time.Second
+
+// Stream streams events of session given by "session-name" query parameter
+// to its owner or participant. Current state of session is sent first,
+// stream ends when session is closed.
+func (c *RoomEvents) Stream(ctx *gin.Context) {
+	value, ok := ctx.Get("user")
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	user := value.(*entity.User)
+	sessionName := ctx.Query("session-name")
+	session, err := c.SessionAction.Get(ctx.Request.Context(), sessionName)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !isParticipant(session, user.Name) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "user " + user.Name +
+			" is not participant of session " + sessionName})
+		return
+	}
+
+	events, cancel := c.Events.Subscribe(sessionName)
+	defer cancel()
+	keepAlive := time.NewTicker(c.keepAlive())
+	defer keepAlive.Stop()
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	c.send(ctx, entity.NewRoomEvent(entity.RoomParticipants, session))
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			c.send(ctx, e)
+			if e.Type == entity.RoomClosed {
+				return
+			}
+		case <-keepAlive.C:
+			io.WriteString(ctx.Writer, ": keep-alive\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// send writes given event to stream.
+func (c *RoomEvents) send(ctx *gin.Context, e *entity.RoomEvent) {
+	ctx.SSEvent(string(e.Type), e)
+	ctx.Writer.Flush()
+}
+
+// keepAlive returns period of comments sent to idle stream.
+func (c *RoomEvents) keepAlive() time.Duration {
+	if c.KeepAlive > 0 {
+		return c.KeepAlive
+	}
+	return defaultKeepAlive
+}
+
+// isParticipant returns true if user with given name is owner or
+// participant of given session.
+func isParticipant(session *entity.Session, userName string) bool {
+	if session.Owner != nil && session.Owner.Name == userName {
+		return true
+	}
+	_, ok := session.Subscribers[userName]
+	return ok
+}
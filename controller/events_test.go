@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/event"
+)
+
+// mockRoomSessionAction is a mock that imitates SessionAction behavior of
+// RoomEvents controller.
+type mockRoomSessionAction struct{}
+
+// Get returns session owned by "owner" with participant "alice".
+func (a *mockRoomSessionAction) Get(ctx context.Context,
+	sessionName string) (*entity.Session, error) {
+	if sessionName != "test session" {
+		return nil, errors.New("session does not exist")
+	}
+	s := entity.NewSession()
+	s.Name = sessionName
+	s.Owner = &entity.User{Name: "owner"}
+	s.AddParticipant(&entity.User{Name: "alice"})
+	return s, nil
+}
+
+func TestRoomEvents_Stream(t *testing.T) {
+	Convey("Streams events until session is closed", t, func() {
+		hub := event.NewHub()
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet,
+			"/session/events?session-name=test+session", nil)
+		ctx.Set("user", &entity.User{Name: "alice"})
+		c := &RoomEvents{
+			SessionAction: &mockRoomSessionAction{},
+			Events:        hub,
+			KeepAlive:     time.Millisecond,
+		}
+		done := make(chan struct{})
+		go func() {
+			c.Stream(ctx)
+			close(done)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		hub.Publish(&entity.RoomEvent{
+			Type: entity.RoomClosed, Session: "test session"})
+		<-done
+
+		body := w.Body.String()
+		So(body, ShouldStartWith, "event:participants\n")
+		So(body, ShouldContainSubstring, `"participants":["alice"]`)
+		So(body, ShouldContainSubstring, ": keep-alive\n\n")
+		So(strings.HasSuffix(body, "event:closed\n"+
+			`data:{"type":"closed","session":"test session",`+
+			`"participants":null,"recording":false,`+
+			`"time":"0001-01-01T00:00:00Z"}`+"\n\n"),
+			ShouldBeTrue)
+	})
+
+	Convey("Ends stream when client goes away", t, func() {
+		_, ctx := newTestContext()
+		reqCtx, cancel := context.WithCancel(context.Background())
+		ctx.Request = httptest.NewRequest(http.MethodGet,
+			"/session/events?session-name=test+session", nil).
+			WithContext(reqCtx)
+		ctx.Set("user", &entity.User{Name: "owner"})
+		cancel()
+
+		(&RoomEvents{SessionAction: &mockRoomSessionAction{},
+			Events: event.NewHub()}).Stream(ctx)
+	})
+
+	Convey("Returns an error", t, func() {
+		c := &RoomEvents{
+			SessionAction: &mockRoomSessionAction{},
+			Events:        event.NewHub(),
+		}
+		for user, status := range map[string]int{
+			"":         http.StatusUnauthorized,
+			"stranger": http.StatusForbidden,
+		} {
+			_, ctx := newTestContext()
+			ctx.Request = httptest.NewRequest(http.MethodGet,
+				"/session/events?session-name=test+session", nil)
+			if user != "" {
+				ctx.Set("user", &entity.User{Name: user})
+			}
+			c.Stream(ctx)
+			So(ctx.Writer.Status(), ShouldEqual, status)
+		}
+
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet,
+			"/session/events?session-name=unknown", nil)
+		ctx.Set("user", &entity.User{Name: "owner"})
+		c.Stream(ctx)
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultHealthTimeout is a time given to all readiness checks if one is not
+// specified.
+const defaultHealthTimeout = 5 * time.Second
+
+// Health is a HTTP controller that reports whether application is alive and
+// ready to serve requests.
+type Health struct {
+	// Checks are readiness checks of application dependencies by their
+	// names. A check returns an error if its dependency is not usable.
+	Checks map[string]func(ctx context.Context) error
+
+	// Timeout is a time given to all readiness checks.
+	Timeout time.Duration
+}
+
+// checkStatus is a result of one readiness check.
+type checkStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Live writes that application process is alive.
+func (c *Health) Live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready runs all readiness checks and writes their statuses as JSON. Response
+// status is 503 if any check fails.
+func (c *Health) Ready(ctx *gin.Context) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+	checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	checks := make(map[string]checkStatus, len(c.Checks))
+	for name, check := range c.Checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+			status := checkStatus{Status: "ok"}
+			if err := check(checkCtx); err != nil {
+				status = checkStatus{Status: "error", Error: err.Error()}
+			}
+			mu.Lock()
+			checks[name] = status
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	code, status := http.StatusOK, "ok"
+	for _, s := range checks {
+		if s.Status != "ok" {
+			code, status = http.StatusServiceUnavailable, "unavailable"
+			break
+		}
+	}
+	ctx.JSON(code, gin.H{"status": status, "checks": checks})
+}
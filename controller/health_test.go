@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHealth_Live(t *testing.T) {
+	Convey("Writes ok status", t, func() {
+		w, ctx := newTestContext()
+		(&Health{}).Live(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"status":"ok"`)
+	})
+}
+
+func TestHealth_Ready(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	failed := func(ctx context.Context) error { return errors.New("some error") }
+
+	Convey("Writes statuses of checks", t, func() {
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		(&Health{Checks: map[string]func(context.Context) error{
+			"first":  ok,
+			"second": ok,
+		}}).Ready(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		var body struct {
+			Status string
+			Checks map[string]checkStatus
+		}
+		So(json.Unmarshal(w.Body.Bytes(), &body), ShouldBeNil)
+		So(body.Status, ShouldEqual, "ok")
+		So(body.Checks, ShouldHaveLength, 2)
+		So(body.Checks["first"].Status, ShouldEqual, "ok")
+	})
+
+	Convey("Returns service unavailable", t, func() {
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		(&Health{Checks: map[string]func(context.Context) error{
+			"first":  ok,
+			"second": failed,
+		}}).Ready(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+		So(w.Body.String(), ShouldContainSubstring, `"status":"unavailable"`)
+		So(w.Body.String(), ShouldContainSubstring, `"error":"some error"`)
+	})
+}
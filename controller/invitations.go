@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// defaultInvitationTTL is a lifetime of invitation if one is not specified.
+const defaultInvitationTTL = 24 * time.Hour
+
+// Invitations is a HTTP controller that provides operations with invitation
+// links to OpenViDu sessions.
+type Invitations struct {
+	InvitationAction interface {
+		Create(sessionName string, userName string, role entity.UserRole,
+			maxUses int, ttl time.Duration) (string, *entity.Invitation, error)
+		Verify(token string) (*entity.Invitation, error)
+		Revoke(id string, userName string) error
+		List(sessionName string,
+			userName string) ([]*entity.Invitation, error)
+	}
+}
+
+// invitationInfo is a public representation of invitation.
+type invitationInfo struct {
+	ID        string    `json:"id"`
+	Link      string    `json:"link,omitempty"`
+	Role      string    `json:"role"`
+	MaxUses   int       `json:"maxUses"`
+	Uses      int       `json:"uses"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Revoked   bool      `json:"revoked"`
+}
+
+// newInvitationInfo converts given invitation to its public representation.
+func newInvitationInfo(i *entity.Invitation) invitationInfo {
+	return invitationInfo{
+		ID:        i.ID,
+		Role:      i.Role.String(),
+		MaxUses:   i.MaxUses,
+		Uses:      i.Uses,
+		ExpiresAt: i.ExpiresAt,
+		Revoked:   i.Revoked,
+	}
+}
+
+// Create issues new invitation link and writes it as JSON.
+//
+// Reads "session-name", "role", "max-uses" and "ttl" (in minutes) form
+// parameters.
+func (c *Invitations) Create(ctx *gin.Context) {
+	user, ok := loggedUser(ctx)
+	if !ok {
+		return
+	}
+	role, err := entity.ParseUserRole(ctx.DefaultPostForm("role", "SUBSCRIBER"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	maxUses, err := strconv.Atoi(ctx.DefaultPostForm("max-uses", "0"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid max-uses"})
+		return
+	}
+	ttl := defaultInvitationTTL
+	if minutes := ctx.PostForm("ttl"); minutes != "" {
+		m, err := strconv.Atoi(minutes)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid ttl"})
+			return
+		}
+		ttl = time.Duration(m) * time.Minute
+	}
+
+	token, invitation, err := c.InvitationAction.Create(
+		ctx.PostForm("session-name"), user.Name, role, maxUses, ttl)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	info := newInvitationInfo(invitation)
+	info.Link = joinLink(ctx.Request, token)
+	ctx.JSON(http.StatusOK, info)
+}
+
+// List writes invitations of session given by "session-name" query
+// parameter as JSON.
+func (c *Invitations) List(ctx *gin.Context) {
+	user, ok := loggedUser(ctx)
+	if !ok {
+		return
+	}
+	list, err := c.InvitationAction.List(ctx.Query("session-name"), user.Name)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	info := make([]invitationInfo, 0, len(list))
+	for _, i := range list {
+		info = append(info, newInvitationInfo(i))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"invitations": info})
+}
+
+// Revoke revokes invitation given by "id" form parameter.
+func (c *Invitations) Revoke(ctx *gin.Context) {
+	user, ok := loggedUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.InvitationAction.Revoke(
+		ctx.PostForm("id"), user.Name); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"revoked": true})
+}
+
+// Join returns join page of invitation given by "token" URL parameter.
+func (c *Invitations) Join(ctx *gin.Context) {
+	token := ctx.Param("token")
+	invitation, err := c.InvitationAction.Verify(token)
+	if err != nil {
+		ctx.Status(http.StatusNotFound)
+		ctx.Set("template", "join.tmpl")
+		ctx.Set("parameters", gin.H{"error": err.Error()})
+		return
+	}
+	joinPage(ctx, invitation, token)
+}
+
+// joinPage writes join page of given invitation to context.
+func joinPage(ctx *gin.Context, invitation *entity.Invitation, token string) {
+	user, logged := ctx.Get("user")
+	if logged {
+		logged = !user.(*entity.User).Guest
+	}
+	ctx.Status(http.StatusOK)
+	ctx.Set("template", "join.tmpl")
+	ctx.Set("parameters", gin.H{
+		"logged":      logged,
+		"sessionName": invitation.SessionName,
+		"role":        invitation.Role.String(),
+		"invitation":  token,
+	})
+}
+
+// joinLink returns absolute link to join page of given invitation token.
+func joinLink(r *http.Request, token string) string {
+	return absoluteURL(r, "/join/"+token)
+}
+
+// absoluteURL returns absolute URL of given path on host of given request.
+func absoluteURL(r *http.Request, path string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}
+
+// loggedUser returns registered user written to context by Session
+// middleware or writes unauthorized JSON response otherwise.
+func loggedUser(ctx *gin.Context) (*entity.User, bool) {
+	user, ok := ctx.Get("user")
+	if !ok || user.(*entity.User).Guest {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+	return user.(*entity.User), true
+}
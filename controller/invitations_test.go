@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// mockInvitationAction is a mock that imitates InvitationAction behavior.
+type mockInvitationAction struct {
+	behavior string
+}
+
+// invitation returns invitation used by mockInvitationAction.
+func (a *mockInvitationAction) invitation() *entity.Invitation {
+	return &entity.Invitation{
+		ID:          "test ID",
+		SessionName: "invited session",
+		Role:        1,
+		MaxUses:     1,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+}
+
+// Create imitates InvitationAction Create method behavior depending on one
+// defined.
+func (a *mockInvitationAction) Create(
+	sessionName string, userName string, role entity.UserRole,
+	maxUses int, ttl time.Duration) (string, *entity.Invitation, error) {
+	if a.behavior != "ok" {
+		return "", nil, errors.New("some error")
+	}
+	return "test token", a.invitation(), nil
+}
+
+// Verify imitates InvitationAction Verify method behavior depending on one
+// defined.
+func (a *mockInvitationAction) Verify(
+	token string) (*entity.Invitation, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	return a.invitation(), nil
+}
+
+// Revoke imitates InvitationAction Revoke method behavior depending on one
+// defined.
+func (a *mockInvitationAction) Revoke(id string, userName string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+// List imitates InvitationAction List method behavior depending on one
+// defined.
+func (a *mockInvitationAction) List(
+	sessionName string, userName string) ([]*entity.Invitation, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	return []*entity.Invitation{a.invitation()}, nil
+}
+
+// newFormContext returns test context with POST request of given form.
+func newFormContext(form url.Values) (
+	*httptest.ResponseRecorder, *gin.Context) {
+	w, ctx := newTestContext()
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+	ctx.Request.PostForm = form
+	return w, ctx
+}
+
+func TestInvitations_Create(t *testing.T) {
+	Convey("Writes invitation link as JSON", t, func() {
+		w, ctx := newFormContext(url.Values{
+			"session-name": {"test session"},
+			"role":         {"PUBLISHER"},
+			"max-uses":     {"1"},
+			"ttl":          {"60"},
+		})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&Invitations{&mockInvitationAction{"ok"}}).Create(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+
+		var info invitationInfo
+		So(json.Unmarshal(w.Body.Bytes(), &info), ShouldBeNil)
+		So(info.ID, ShouldEqual, "test ID")
+		So(info.Role, ShouldEqual, "PUBLISHER")
+		So(info.Link, ShouldEqual, "http://example.com/join/test token")
+	})
+
+	Convey("Returns unauthorized", t, func() {
+		w, ctx := newFormContext(url.Values{})
+		(&Invitations{&mockInvitationAction{"ok"}}).Create(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Returns unauthorized for guest", t, func() {
+		w, ctx := newFormContext(url.Values{})
+		ctx.Set("user", entity.NewGuest("1", "test guest", "test session", 0).User)
+		(&Invitations{&mockInvitationAction{"ok"}}).Create(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Returns bad request", t, func() {
+		for _, form := range []url.Values{
+			{"role": {"wrong"}},
+			{"max-uses": {"wrong"}},
+			{"ttl": {"wrong"}},
+			{},
+		} {
+			behavior := "ok"
+			if len(form) == 0 {
+				behavior = "failure"
+			}
+			w, ctx := newFormContext(form)
+			ctx.Set("user", &entity.User{Name: "test user"})
+			(&Invitations{&mockInvitationAction{behavior}}).Create(ctx)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestInvitations_List(t *testing.T) {
+	Convey("Writes invitations as JSON", t, func() {
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet,
+			"/invitations?session-name=invited+session", nil)
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&Invitations{&mockInvitationAction{"ok"}}).List(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"id":"test ID"`)
+	})
+
+	Convey("Returns bad request", t, func() {
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/invitations", nil)
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&Invitations{&mockInvitationAction{"failure"}}).List(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+	})
+}
+
+func TestInvitations_Revoke(t *testing.T) {
+	Convey("Revokes invitation", t, func() {
+		w, ctx := newFormContext(url.Values{"id": {"test ID"}})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&Invitations{&mockInvitationAction{"ok"}}).Revoke(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("Returns bad request", t, func() {
+		w, ctx := newFormContext(url.Values{"id": {"test ID"}})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&Invitations{&mockInvitationAction{"failure"}}).Revoke(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+	})
+}
+
+func TestInvitations_Join(t *testing.T) {
+	Convey("Writes join page to context", t, func() {
+		_, ctx := newTestContext()
+		ctx.Params = gin.Params{{Key: "token", Value: "test token"}}
+		(&Invitations{&mockInvitationAction{"ok"}}).Join(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("template"), ShouldEqual, "join.tmpl")
+
+		Convey("with invitation parameters", func() {
+			params := ctx.MustGet("parameters").(gin.H)
+			So(params["sessionName"], ShouldEqual, "invited session")
+			So(params["role"], ShouldEqual, "PUBLISHER")
+			So(params["invitation"], ShouldEqual, "test token")
+			So(params["logged"], ShouldBeFalse)
+		})
+	})
+
+	Convey("Writes invitation error to context", t, func() {
+		_, ctx := newTestContext()
+		ctx.Params = gin.Params{{Key: "token", Value: "test token"}}
+		(&Invitations{&mockInvitationAction{"failure"}}).Join(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusNotFound)
+		So(ctx.MustGet("parameters").(gin.H)["error"], ShouldEqual,
+			"some error")
+	})
+}
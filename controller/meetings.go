@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// meetingTimeLayout is a layout of meeting start and end times sent by
+// "datetime-local" inputs.
+const meetingTimeLayout = "2006-01-02T15:04"
+
+// Meetings is a HTTP controller that provides scheduling of meetings.
+type Meetings struct {
+	MeetingAction interface {
+		Schedule(userName string, sessionName string, title string,
+			start time.Time, end time.Time,
+			invited []string) (*entity.Meeting, error)
+		List(userName string) ([]*entity.Meeting, error)
+		Cancel(ctx context.Context, sessionName string, userName string) error
+	}
+}
+
+// meetingInfo is a public representation of meeting.
+type meetingInfo struct {
+	SessionName string
+	Title       string
+	Owner       string
+	Start       string
+	End         string
+	TimeZone    string
+	Invited     string
+	Own         bool
+}
+
+// newMeetingsInfo converts given meetings to their public representation
+// for user with given name.
+func newMeetingsInfo(list []*entity.Meeting, userName string) []meetingInfo {
+	info := make([]meetingInfo, 0, len(list))
+	for _, m := range list {
+		loc := m.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+		info = append(info, meetingInfo{
+			SessionName: m.SessionName,
+			Title:       m.Title,
+			Owner:       m.Owner.Name,
+			Start:       m.Start.In(loc).Format(time.RFC1123),
+			End:         m.End.In(loc).Format(time.RFC1123),
+			TimeZone:    loc.String(),
+			Invited:     strings.Join(m.Invited, ", "),
+			Own:         m.Owner.Name == userName,
+		})
+	}
+	return info
+}
+
+// List returns page with meetings of logged user.
+func (c *Meetings) List(ctx *gin.Context) {
+	user, ok := c.user(ctx)
+	if !ok {
+		return
+	}
+	c.page(ctx, http.StatusOK, user, nil)
+}
+
+// Schedule schedules new meeting and redirects to meetings page.
+//
+// Reads "session-name", "title", "start", "end", "timezone" and "invited"
+// form parameters. Start and end are local times in given time zone.
+func (c *Meetings) Schedule(ctx *gin.Context) {
+	user, ok := c.user(ctx)
+	if !ok {
+		return
+	}
+	loc, err := time.LoadLocation(ctx.DefaultPostForm("timezone", "UTC"))
+	if err != nil {
+		c.page(ctx, http.StatusBadRequest, user, err)
+		return
+	}
+	start, err := time.ParseInLocation(
+		meetingTimeLayout, ctx.PostForm("start"), loc)
+	if err != nil {
+		c.page(ctx, http.StatusBadRequest, user, err)
+		return
+	}
+	end, err := time.ParseInLocation(
+		meetingTimeLayout, ctx.PostForm("end"), loc)
+	if err != nil {
+		c.page(ctx, http.StatusBadRequest, user, err)
+		return
+	}
+	invited := strings.FieldsFunc(ctx.PostForm("invited"),
+		func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+
+	if _, err = c.MeetingAction.Schedule(user.Name,
+		ctx.PostForm("session-name"), ctx.PostForm("title"),
+		start, end, invited); err != nil {
+		c.page(ctx, http.StatusBadRequest, user, err)
+		return
+	}
+	ctx.Redirect(http.StatusSeeOther, "/meetings")
+}
+
+// Cancel cancels meeting given by "session-name" form parameter and
+// redirects to meetings page.
+func (c *Meetings) Cancel(ctx *gin.Context) {
+	user, ok := c.user(ctx)
+	if !ok {
+		return
+	}
+	if err := c.MeetingAction.Cancel(ctx.Request.Context(),
+		ctx.PostForm("session-name"), user.Name); err != nil {
+		c.page(ctx, http.StatusBadRequest, user, err)
+		return
+	}
+	ctx.Redirect(http.StatusSeeOther, "/meetings")
+}
+
+// page writes meetings page of given user with given error to context.
+func (c *Meetings) page(
+	ctx *gin.Context, status int, user *entity.User, err error) {
+	list, e := c.MeetingAction.List(user.Name)
+	if e != nil {
+		ctx.Error(e)
+	}
+	parameters := gin.H{
+		"meetings":  newMeetingsInfo(list, user.Name),
+		"userName":  user.Name,
+		"publisher": user.Role > 0,
+	}
+	if err != nil {
+		parameters["error"] = err.Error()
+	}
+	ctx.Status(status)
+	ctx.Set("template", "meetings.tmpl")
+	ctx.Set("parameters", parameters)
+}
+
+// user returns registered user written to context by Session middleware
+// or redirects to index page otherwise.
+func (c *Meetings) user(ctx *gin.Context) (*entity.User, bool) {
+	user, ok := ctx.Get("user")
+	if !ok || user.(*entity.User).Guest {
+		ctx.Redirect(http.StatusTemporaryRedirect, "/")
+		ctx.Abort()
+		return nil, false
+	}
+	return user.(*entity.User), true
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// mockMeetingAction is a mock that imitates MeetingAction behavior.
+type mockMeetingAction struct {
+	behavior  string
+	scheduled *entity.Meeting
+}
+
+// Schedule imitates MeetingAction Schedule method behavior depending on one
+// defined.
+func (a *mockMeetingAction) Schedule(userName string, sessionName string,
+	title string, start time.Time, end time.Time,
+	invited []string) (*entity.Meeting, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	a.scheduled = &entity.Meeting{
+		SessionName: sessionName,
+		Title:       title,
+		Owner:       &entity.User{Name: userName},
+		Start:       start,
+		End:         end,
+		Location:    start.Location(),
+		Invited:     invited,
+	}
+	return a.scheduled, nil
+}
+
+// List imitates MeetingAction List method behavior depending on one
+// defined.
+func (a *mockMeetingAction) List(userName string) ([]*entity.Meeting, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	start := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	return []*entity.Meeting{{
+		SessionName: "test meeting",
+		Owner:       &entity.User{Name: "test user"},
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Invited:     []string{"first", "second"},
+	}}, nil
+}
+
+// Cancel imitates MeetingAction Cancel method behavior depending on one
+// defined.
+func (a *mockMeetingAction) Cancel(
+	ctx context.Context, sessionName string, userName string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+func TestMeetings_List(t *testing.T) {
+	Convey("Writes meetings page to context", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/meetings", nil)
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		(&Meetings{&mockMeetingAction{behavior: "ok"}}).List(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("template"), ShouldEqual, "meetings.tmpl")
+
+		params := ctx.MustGet("parameters").(gin.H)
+		So(params["publisher"], ShouldBeTrue)
+		info := params["meetings"].([]meetingInfo)
+		So(info, ShouldHaveLength, 1)
+		So(info[0].Own, ShouldBeTrue)
+		So(info[0].Invited, ShouldEqual, "first, second")
+		So(info[0].TimeZone, ShouldEqual, "UTC")
+	})
+
+	Convey("Redirects anonymous user", t, func() {
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/meetings", nil)
+		(&Meetings{&mockMeetingAction{behavior: "ok"}}).List(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusTemporaryRedirect)
+	})
+}
+
+func TestMeetings_Schedule(t *testing.T) {
+	Convey("Schedules meeting in given time zone", t, func() {
+		a := &mockMeetingAction{behavior: "ok"}
+		_, ctx := newFormContext(url.Values{
+			"session-name": {"test meeting"},
+			"title":        {"test title"},
+			"start":        {"2018-01-01T10:00"},
+			"end":          {"2018-01-01T11:00"},
+			"timezone":     {"Europe/Berlin"},
+			"invited":      {"first, second"},
+		})
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		(&Meetings{a}).Schedule(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+		So(a.scheduled.Start.UTC(), ShouldResemble,
+			time.Date(2018, 1, 1, 9, 0, 0, 0, time.UTC))
+		So(a.scheduled.Invited, ShouldResemble, []string{"first", "second"})
+	})
+
+	Convey("Returns bad request", t, func() {
+		for _, form := range []url.Values{
+			{"timezone": {"Wrong/Zone"}},
+			{"start": {"wrong"}},
+			{"start": {"2018-01-01T10:00"}, "end": {"wrong"}},
+		} {
+			_, ctx := newFormContext(form)
+			ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+			(&Meetings{&mockMeetingAction{behavior: "ok"}}).Schedule(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+			params := ctx.MustGet("parameters").(gin.H)
+			So(params["error"], ShouldNotBeEmpty)
+		}
+	})
+
+	Convey("Returns action error", t, func() {
+		_, ctx := newFormContext(url.Values{
+			"start": {"2018-01-01T10:00"},
+			"end":   {"2018-01-01T11:00"},
+		})
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		(&Meetings{&mockMeetingAction{behavior: "failure"}}).Schedule(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+	})
+}
+
+func TestMeetings_Cancel(t *testing.T) {
+	Convey("Cancels meeting", t, func() {
+		_, ctx := newFormContext(url.Values{"session-name": {"test meeting"}})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&Meetings{&mockMeetingAction{behavior: "ok"}}).Cancel(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+	})
+
+	Convey("Returns bad request", t, func() {
+		_, ctx := newFormContext(url.Values{"session-name": {"test meeting"}})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&Meetings{&mockMeetingAction{behavior: "failure"}}).Cancel(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+	})
+}
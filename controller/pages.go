@@ -1,8 +1,14 @@
 package controller
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/sessions"
@@ -17,26 +23,76 @@ type Pages struct {
 	SessionStore    sessions.Store
 	OpenViDuService service.OpenViDu
 	LoginAction     interface {
-		Do(username string, password string) error
+		Do(ctx context.Context,
+			ip string, username string, password string) error
 	}
 
 	SessionAction interface {
-		Add(sessionID string, sessionName string, ownerName string) error
-		Delete(sessionName string, userName string) error
-		GetID(sessionName string) (string, error)
-		IsExists(sessionName string) bool
+		Add(ctx context.Context, sessionID string, sessionName string,
+			ownerName string, passcode string,
+			policy *entity.SessionPolicy) error
+		AddInvited(ctx context.Context, userName string,
+			invitation *entity.Invitation) error
+		Delete(ctx context.Context, sessionName string, userName string) error
+		GetID(ctx context.Context, sessionName string) (string, error)
+		IsExists(ctx context.Context, sessionName string) bool
+		List(ctx context.Context,
+			filter entity.SessionFilter) ([]*entity.Session, int, error)
+		SetAccess(ctx context.Context, sessionName string, userName string,
+			access entity.SessionAccess, allowList []string,
+			passcode string) error
+		TransferOwnership(ctx context.Context, sessionName string,
+			userName string, newOwnerName string) error
+		SetRecording(ctx context.Context, sessionName string,
+			userName string, record bool) error
 	}
+
+	GuestAction interface {
+		Join(displayName string, sessionName string,
+			token string) (*entity.User, error)
+	}
+
+	InvitationAction interface {
+		Verify(token string) (*entity.Invitation, error)
+	}
+
+	// TwoFactorAction tells users that enter one-time password after
+	// password check. Password is the only factor if it is nil.
+	TwoFactorAction interface {
+		Enabled(userName string) bool
+	}
+
+	// Signup shows link to signup page on index page.
+	Signup bool
+
+	// SSO shows link to single sign-on on index page.
+	SSO bool
+
+	// DemoUsers shows demo users and their password on index page.
+	DemoUsers bool
 }
 
+// sessionsPerPage is a number of sessions shown on one dashboard page.
+const sessionsPerPage = 10
+
 // Index returns index page.
 func (c *Pages) Index(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 	ctx.Set("template", "index.tmpl")
 	if ctx.Errors != nil && ctx.Request.Method == http.MethodPost {
-		ctx.Set("parameters", gin.H{"error": ctx.Errors.String()})
+		ctx.Set("parameters", gin.H{
+			"error":  ctx.Errors.String(),
+			"signup":    c.Signup,
+			"sso":       c.SSO,
+			"demoUsers": c.DemoUsers,
+		})
 		return
 	}
-	ctx.Set("parameters", gin.H{})
+	ctx.Set("parameters", gin.H{
+		"signup":    c.Signup,
+		"sso":       c.SSO,
+		"demoUsers": c.DemoUsers,
+	})
 }
 
 //Dashboard returns dashboard page.
@@ -51,7 +107,8 @@ func (c *Pages) Dashboard(ctx *gin.Context) {
 	login := ctx.PostForm("user")
 	password := ctx.PostForm("pass")
 
-	err = c.LoginAction.Do(login, password)
+	err = c.LoginAction.Do(
+		ctx.Request.Context(), ctx.ClientIP(), login, password)
 	if err != nil {
 		session.Values["error"] = err.Error()
 		session.Save(ctx.Request, ctx.Writer)
@@ -60,12 +117,74 @@ func (c *Pages) Dashboard(ctx *gin.Context) {
 	}
 
 	session.Values["error"] = nil
+	if c.TwoFactorAction != nil && c.TwoFactorAction.Enabled(login) {
+		session.Values[pendingUserKey] = login
+		session.Values[pendingSinceKey] = time.Now().Unix()
+		session.Values[pendingInvitationKey] = ctx.PostForm("invitation")
+		session.Save(ctx.Request, ctx.Writer)
+		ctx.Redirect(http.StatusSeeOther, "/login/2fa")
+		return
+	}
 	session.Values["loggedUser"] = login
 	session.Save(ctx.Request, ctx.Writer)
 
+	if token := ctx.PostForm("invitation"); token != "" {
+		invitation, err := c.InvitationAction.Verify(token)
+		if err == nil {
+			ctx.Set("user", &entity.User{Name: login})
+			joinPage(ctx, invitation, token)
+			return
+		}
+		ctx.Error(err)
+	}
+	c.dashboard(ctx)
+}
+
+// Rooms returns dashboard page for already logged user.
+func (c *Pages) Rooms(ctx *gin.Context) {
+	if user, ok := ctx.Get("user"); !ok || user.(*entity.User).Guest {
+		ctx.Redirect(http.StatusTemporaryRedirect, "/")
+		ctx.Abort()
+		return
+	}
+	c.dashboard(ctx)
+}
+
+// dashboard writes dashboard page with the page of active sessions, that
+// is requested by query parameters, to context.
+func (c *Pages) dashboard(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	filter := entity.SessionFilter{
+		Owner:  ctx.Query("owner"),
+		Name:   ctx.Query("q"),
+		Offset: (page - 1) * sessionsPerPage,
+		Limit:  sessionsPerPage,
+	}
+	list, total, err := c.SessionAction.List(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.Error(err)
+	}
+
+	parameters := gin.H{
+		"sessions": newSessionsInfo(list),
+		"query":    filter.Name,
+		"owner":    filter.Owner,
+		"page":     page,
+		"total":    total,
+	}
+	if page > 1 {
+		parameters["prevPage"] = page - 1
+	}
+	if page*sessionsPerPage < total {
+		parameters["nextPage"] = page + 1
+	}
+
 	ctx.Status(http.StatusOK)
 	ctx.Set("template", "dashboard.tmpl")
-	ctx.Set("parameters", gin.H{})
+	ctx.Set("parameters", parameters)
 }
 
 // Session returns session page.
@@ -75,15 +194,77 @@ func (c *Pages) Session(ctx *gin.Context) {
 		ctx.Abort()
 		return
 	}
+	c.join(ctx, ctx.MustGet("user").(*entity.User), ctx.PostForm("data"))
+}
+
+// GuestForm returns page where anonymous visitor chooses display name to join
+// session given by "session-name" query parameter as guest.
+func (c *Pages) GuestForm(ctx *gin.Context) {
+	ctx.Status(http.StatusOK)
+	ctx.Set("template", "guest.tmpl")
+	ctx.Set("parameters", gin.H{"sessionName": ctx.Query("session-name")})
+}
+
+// Guest registers anonymous visitor as guest and returns session page.
+func (c *Pages) Guest(ctx *gin.Context) {
+	session, err := c.SessionStore.Get(ctx.Request, SESSION_NAME)
+	if err != nil {
+		ctx.Redirect(http.StatusTemporaryRedirect, "/")
+		ctx.Abort()
+		return
+	}
+	displayName := ctx.PostForm("name")
+	user, err := c.GuestAction.Join(displayName,
+		ctx.PostForm("session-name"), ctx.PostForm("invitation"))
+	if err != nil {
+		ctx.Error(err)
+		ctx.Redirect(http.StatusTemporaryRedirect, "/")
+		ctx.Abort()
+		return
+	}
+
+	session.Values["guestUser"] = user.Name
+	session.Save(ctx.Request, ctx.Writer)
+
+	ctx.Set("user", user)
+	c.join(ctx, user, displayName)
+}
+
+// join joins given user to session requested by form and writes session
+// page to context.
+func (c *Pages) join(ctx *gin.Context, user *entity.User, participant string) {
 	sessionName := ctx.PostForm("session-name")
-	participant := ctx.PostForm("data")
-	user := ctx.MustGet("user").(*entity.User)
+	role := user.Role
 	var session string
+	var created bool
 	var err error
-	if c.SessionAction.IsExists(sessionName) {
-		session, err = c.SessionAction.GetID(sessionName)
-	} else if user.Role > 0 {
-		session, err = c.OpenViDuService.GetMediaSession(sessionName)
+	// Invitation is only verified here; its use is spent when user joins
+	// session.
+	var invitation *entity.Invitation
+	if token := ctx.PostForm("invitation"); token != "" {
+		invitation, err = c.InvitationAction.Verify(token)
+		if err != nil {
+			ctx.Error(err)
+			ctx.Redirect(http.StatusTemporaryRedirect, "/")
+			ctx.Abort()
+			return
+		}
+		sessionName, role = invitation.SessionName, invitation.Role
+	}
+
+	var policy *entity.SessionPolicy
+	if c.SessionAction.IsExists(ctx.Request.Context(), sessionName) {
+		session, err = c.SessionAction.GetID(ctx.Request.Context(), sessionName)
+	} else if invitation != nil {
+		err = fmt.Errorf("session %s is closed", sessionName)
+	} else if user.Role > 0 && !user.Guest {
+		// Policy is checked before media session is created, so invalid
+		// form leaves nothing behind.
+		if policy, err = sessionPolicy(ctx); err == nil {
+			session, err = c.OpenViDuService.GetMediaSession(
+				ctx.Request.Context(), sessionName)
+			created = true
+		}
 	} else {
 		err = fmt.Errorf("user %s can not publish", participant)
 	}
@@ -95,21 +276,41 @@ func (c *Pages) Session(ctx *gin.Context) {
 		return
 	}
 
-	tokenOptions := make(map[string]interface{})
-	tokenOptions["session"] = session
-	tokenOptions["role"] = user.Role.String()
-	tokenOptions["data"] = "{\"serverData\": \"" + participant + "\"}"
-	tokenMap, err := c.OpenViDuService.GetToken(tokenOptions)
+	// Login name is given next to nickname, so participant of connection
+	// can be told even if its nickname differs.
+	data, err := json.Marshal(map[string]string{
+		"serverData": participant,
+		"userName":   user.Name,
+	})
 	if err != nil {
 		ctx.Error(err)
 		ctx.Redirect(http.StatusTemporaryRedirect, "/")
 		ctx.Abort()
 		return
 	}
-
-	err = c.SessionAction.Add(session, sessionName, user.Name)
+	tokenOptions := make(map[string]interface{})
+	tokenOptions["session"] = session
+	tokenOptions["role"] = role.String()
+	tokenOptions["data"] = string(data)
+	tokenMap, err := c.OpenViDuService.GetToken(
+		ctx.Request.Context(), tokenOptions)
+	if err == nil && invitation != nil {
+		err = c.SessionAction.AddInvited(
+			ctx.Request.Context(), user.Name, invitation)
+	} else if err == nil {
+		err = c.SessionAction.Add(ctx.Request.Context(), session,
+			sessionName, user.Name, ctx.PostForm("passcode"), policy)
+	}
 	if err != nil {
 		ctx.Error(err)
+		if created {
+			// Session was refused, e.g. out of its meeting window, so
+			// media session must not be left on OpenViDu server.
+			if err = c.OpenViDuService.CloseSession(
+				ctx.Request.Context(), session); err != nil {
+				ctx.Error(err)
+			}
+		}
 		ctx.Redirect(http.StatusTemporaryRedirect, "/")
 		ctx.Abort()
 		return
@@ -122,20 +323,137 @@ func (c *Pages) Session(ctx *gin.Context) {
 		"nickName":    participant,
 		"userName":    user.Name,
 		"sessionName": sessionName,
+		"role":        role.String(),
+		"owner":       created,
+		"allowGuests": created && ctx.PostForm("allow-guests") != "",
+		"access":      ctx.DefaultPostForm("access", "PUBLIC"),
 	})
 }
 
+// Access changes access mode of session by its owner and writes result as
+// JSON.
+//
+// Reads "session-name", "access", "allow-list" and "session-passcode" form
+// parameters.
+func (c *Pages) Access(ctx *gin.Context) {
+	user, ok := loggedUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.setAccess(
+		ctx, ctx.PostForm("session-name"), user.Name); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"access": ctx.PostForm("access")})
+}
+
+// Owner hands session over to one of its participants by its owner and
+// writes result as JSON.
+//
+// Reads "session-name" and "user" form parameters.
+func (c *Pages) Owner(ctx *gin.Context) {
+	user, ok := loggedUser(ctx)
+	if !ok {
+		return
+	}
+	owner := ctx.PostForm("user")
+	if err := c.SessionAction.TransferOwnership(ctx.Request.Context(),
+		ctx.PostForm("session-name"), user.Name, owner); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"owner": owner})
+}
+
+// Recording starts or stops recording of session by its owner and writes
+// result as JSON.
+//
+// Reads "session-name" and "recording" form parameters. Recording is
+// started if "recording" is "start" and stopped if it is "stop".
+func (c *Pages) Recording(ctx *gin.Context) {
+	user, ok := loggedUser(ctx)
+	if !ok {
+		return
+	}
+	recording := ctx.PostForm("recording")
+	if recording != "start" && recording != "stop" {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "recording must be start or stop"})
+		return
+	}
+	if err := c.SessionAction.SetRecording(ctx.Request.Context(),
+		ctx.PostForm("session-name"), user.Name,
+		recording == "start"); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"recording": recording == "start"})
+}
+
+// setAccess changes access mode of session with given name to one requested
+// by form. Access mode is not changed if form does not contain it.
+func (c *Pages) setAccess(
+	ctx *gin.Context, sessionName string, userName string) error {
+	mode := ctx.PostForm("access")
+	if mode == "" {
+		return nil
+	}
+	access, err := entity.ParseSessionAccess(mode)
+	if err != nil {
+		return err
+	}
+	return c.SessionAction.SetAccess(
+		ctx.Request.Context(), sessionName, userName, access,
+		allowList(ctx), ctx.PostForm("session-passcode"))
+}
+
+// sessionPolicy returns access policy of new session requested by form.
+// Session is public if form contains no access mode.
+func sessionPolicy(ctx *gin.Context) (*entity.SessionPolicy, error) {
+	policy := &entity.SessionPolicy{
+		AllowList:   allowList(ctx),
+		Passcode:    ctx.PostForm("session-passcode"),
+		AllowGuests: ctx.PostForm("allow-guests") != "",
+	}
+	if mode := ctx.PostForm("access"); mode != "" {
+		access, err := entity.ParseSessionAccess(mode)
+		if err != nil {
+			return nil, err
+		}
+		policy.Access = access
+	}
+	return policy, policy.Validate()
+}
+
+// allowList returns names of users given by "allow-list" form parameter
+// separated by commas or spaces.
+func allowList(ctx *gin.Context) []string {
+	return strings.FieldsFunc(ctx.PostForm("allow-list"),
+		func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+}
+
 // Leave the controller command that removes user from the OpenViDu session, or
 // removes session if user is owner.
 func (c *Pages) Leave(ctx *gin.Context) {
 	ovdSession := ctx.PostForm("session-name")
 	user := ctx.MustGet("user").(*entity.User)
-	err := c.SessionAction.Delete(ovdSession, user.Name)
+	err := c.SessionAction.Delete(
+		ctx.Request.Context(), ovdSession, user.Name)
 	if err != nil {
 		ctx.Error(err)
 		ctx.Redirect(http.StatusTemporaryRedirect, "/")
 		ctx.Abort()
 		return
 	}
+	if user.Guest {
+		if session, err := c.SessionStore.Get(
+			ctx.Request, SESSION_NAME); err == nil {
+			delete(session.Values, "guestUser")
+			session.Save(ctx.Request, ctx.Writer)
+		}
+	}
 	ctx.Redirect(http.StatusTemporaryRedirect, "/")
 }
@@ -1,11 +1,14 @@
 package controller
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/sessions"
@@ -23,7 +26,8 @@ type mockOpenViDu struct {
 // depending on one defined.
 //
 // Implements service.OpenViDu interface.
-func (s *mockOpenViDu) GetMediaSession(sessionName string) (string, error) {
+func (s *mockOpenViDu) GetMediaSession(
+	ctx context.Context, sessionName string) (string, error) {
 	if s.behavior == "ok" {
 		return sessionName, nil
 	}
@@ -34,7 +38,7 @@ func (s *mockOpenViDu) GetMediaSession(sessionName string) (string, error) {
 // one  defined.
 //
 // Implements service.OpenViDu interface.
-func (s *mockOpenViDu) GetToken(
+func (s *mockOpenViDu) GetToken(ctx context.Context,
 	params map[string]interface{}) (map[string]interface{}, error) {
 	if s.behavior == "ok" {
 		params["token"] = "test token"
@@ -43,6 +47,62 @@ func (s *mockOpenViDu) GetToken(
 	return nil, errors.New("some error")
 }
 
+// tokenParamsOpenViDu is a mock that remembers parameters of requested
+// token.
+type tokenParamsOpenViDu struct {
+	mockOpenViDu
+	params map[string]interface{}
+}
+
+// GetToken remembers given parameters and imitates mockOpenViDu behavior.
+//
+// Implements service.OpenViDu interface.
+func (s *tokenParamsOpenViDu) GetToken(ctx context.Context,
+	params map[string]interface{}) (map[string]interface{}, error) {
+	s.params = params
+	return s.mockOpenViDu.GetToken(ctx, params)
+}
+
+// mediaSessionsOpenViDu is a mock that remembers names of created media
+// sessions and IDs of closed ones.
+type mediaSessionsOpenViDu struct {
+	mockOpenViDu
+	created []string
+	closed  []string
+}
+
+// GetMediaSession remembers given session name and imitates mockOpenViDu
+// behavior.
+//
+// Implements service.OpenViDu interface.
+func (s *mediaSessionsOpenViDu) GetMediaSession(
+	ctx context.Context, sessionName string) (string, error) {
+	s.created = append(s.created, sessionName)
+	return s.mockOpenViDu.GetMediaSession(ctx, sessionName)
+}
+
+// CloseSession remembers given session ID and imitates mockOpenViDu
+// behavior.
+//
+// Implements service.OpenViDu interface.
+func (s *mediaSessionsOpenViDu) CloseSession(
+	ctx context.Context, sessionID string) error {
+	s.closed = append(s.closed, sessionID)
+	return s.mockOpenViDu.CloseSession(ctx, sessionID)
+}
+
+// CloseSession imitates OpenViDu HTTP Client CloseSession method behavior
+// depending on one defined.
+//
+// Implements service.OpenViDu interface.
+func (s *mockOpenViDu) CloseSession(
+	ctx context.Context, sessionID string) error {
+	if s.behavior == "ok" {
+		return nil
+	}
+	return errors.New("some error")
+}
+
 // mockLoginAction is a mock that imitates the LoginAction behavior.
 type mockLoginAction struct {
 	behavior string
@@ -50,7 +110,8 @@ type mockLoginAction struct {
 
 // Do imitates LoginAction Do method behavior depending on one
 // defined.
-func (a *mockLoginAction) Do(username string, password string) error {
+func (a *mockLoginAction) Do(ctx context.Context,
+	ip string, username string, password string) error {
 	if a.behavior != "ok" {
 		return errors.New("some error")
 	}
@@ -58,23 +119,52 @@ func (a *mockLoginAction) Do(username string, password string) error {
 }
 
 // mockSessionAction is a mock that imitates SessionAction behavior.
+// "new" behavior imitates successful actions with not existing session.
 type mockSessionAction struct {
 	behavior string
 }
 
 // Add imitates SessionAction Add method behavior depending on one
 // defined.
-func (a *mockSessionAction) Add(
-	sessionID string, sessionName string, ownerName string) error {
+func (a *mockSessionAction) Add(ctx context.Context,
+	sessionID string, sessionName string, ownerName string,
+	passcode string, policy *entity.SessionPolicy) error {
+	if a.behavior == "ok" || a.behavior == "new" {
+		return nil
+	}
+	return errors.New("some error")
+}
+
+// AddInvited imitates SessionAction AddInvited method behavior depending on
+// one defined.
+func (a *mockSessionAction) AddInvited(ctx context.Context,
+	userName string, invitation *entity.Invitation) error {
 	if a.behavior == "ok" {
 		return nil
 	}
 	return errors.New("some error")
 }
 
+// policySessionAction is a mock that remembers access policy of added
+// session.
+type policySessionAction struct {
+	mockSessionAction
+	policy *entity.SessionPolicy
+}
+
+// Add remembers given policy and imitates mockSessionAction behavior.
+func (a *policySessionAction) Add(ctx context.Context,
+	sessionID string, sessionName string, ownerName string,
+	passcode string, policy *entity.SessionPolicy) error {
+	a.policy = policy
+	return a.mockSessionAction.Add(
+		ctx, sessionID, sessionName, ownerName, passcode, policy)
+}
+
 // Delete imitates SessionAction Delete method behavior depending on one
 // defined.
-func (a *mockSessionAction) Delete(sessionName string, userName string) error {
+func (a *mockSessionAction) Delete(
+	ctx context.Context, sessionName string, userName string) error {
 	if a.behavior == "ok" {
 		return nil
 	}
@@ -83,7 +173,8 @@ func (a *mockSessionAction) Delete(sessionName string, userName string) error {
 
 // GetID imitates SessionAction GetID method behavior depending on one
 // defined.
-func (a *mockSessionAction) GetID(sessionName string) (string, error) {
+func (a *mockSessionAction) GetID(
+	ctx context.Context, sessionName string) (string, error) {
 	if a.behavior == "ok" {
 		return "test session ID", nil
 	}
@@ -92,11 +183,83 @@ func (a *mockSessionAction) GetID(sessionName string) (string, error) {
 
 // IsExists imitates SessionAction IsExists method behavior depending on one
 // defined.
-func (a *mockSessionAction) IsExists(sessionName string) bool {
+func (a *mockSessionAction) IsExists(
+	ctx context.Context, sessionName string) bool {
 
 	return a.behavior == "ok"
 }
 
+// SetAccess imitates SessionAction SetAccess method behavior depending on
+// one defined.
+func (a *mockSessionAction) SetAccess(ctx context.Context,
+	sessionName string, userName string,
+	access entity.SessionAccess, allowList []string, passcode string) error {
+	if a.behavior == "ok" || a.behavior == "new" {
+		return nil
+	}
+	return errors.New("some error")
+}
+
+// TransferOwnership imitates SessionAction TransferOwnership method behavior
+// depending on one defined.
+func (a *mockSessionAction) TransferOwnership(ctx context.Context,
+	sessionName string, userName string, newOwnerName string) error {
+	if a.behavior == "ok" || a.behavior == "new" {
+		return nil
+	}
+	return errors.New("some error")
+}
+
+// SetRecording imitates SessionAction SetRecording method behavior
+// depending on one defined.
+func (a *mockSessionAction) SetRecording(ctx context.Context,
+	sessionName string, userName string, record bool) error {
+	if a.behavior == "ok" || a.behavior == "new" {
+		return nil
+	}
+	return errors.New("some error")
+}
+
+// mockGuestAction is a mock that imitates GuestAction behavior.
+type mockGuestAction struct {
+	behavior string
+}
+
+// Join imitates GuestAction Join method behavior depending on one defined.
+func (a *mockGuestAction) Join(displayName string, sessionName string,
+	token string) (*entity.User, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	return entity.NewGuest("1", displayName, sessionName, 0).User, nil
+}
+
+// List imitates SessionAction List method behavior depending on one defined.
+func (a *mockSessionAction) List(ctx context.Context,
+	filter entity.SessionFilter) ([]*entity.Session, int, error) {
+	if a.behavior != "ok" {
+		return nil, 0, errors.New("some error")
+	}
+	s := entity.NewSession()
+	s.Name = "test session name"
+	s.Owner = &entity.User{Name: "test user"}
+	s.CreatedAt = time.Now()
+	return []*entity.Session{s}, filter.Offset + sessionsPerPage + 1, nil
+}
+
+// Get imitates SessionAction Get method behavior depending on one defined.
+func (a *mockSessionAction) Get(
+	ctx context.Context, sessionName string) (*entity.Session, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	s := entity.NewSession()
+	s.ID = "test session ID"
+	s.Name = sessionName
+	s.Owner = &entity.User{Name: "test user", Role: entity.Publisher}
+	return s, nil
+}
+
 func TestPages_Index(t *testing.T) {
 	Convey("Writes index page to context", t, func() {
 		_, ctx := newTestContext()
@@ -105,6 +268,16 @@ func TestPages_Index(t *testing.T) {
 		So(ctx.MustGet("template").(string), ShouldEqual, "index.tmpl")
 	})
 
+	Convey("Writes index page with signup link to context", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		(&Pages{Signup: true, SSO: true, DemoUsers: true}).Index(ctx)
+
+		So(ctx.MustGet("parameters").(gin.H)["signup"], ShouldBeTrue)
+		So(ctx.MustGet("parameters").(gin.H)["sso"], ShouldBeTrue)
+		So(ctx.MustGet("parameters").(gin.H)["demoUsers"], ShouldBeTrue)
+	})
+
 	Convey("Writes index page to context with error", t, func() {
 		_, ctx := newTestContext()
 		ctx.Request = httptest.NewRequest(http.MethodPost, "/", nil)
@@ -117,6 +290,15 @@ func TestPages_Index(t *testing.T) {
 	})
 }
 
+// mockTwoFactorEnabled is a mock of TwoFactorAction of users with enabled
+// two-factor authentication.
+type mockTwoFactorEnabled struct{}
+
+// Enabled always returns true.
+func (a *mockTwoFactorEnabled) Enabled(userName string) bool {
+	return true
+}
+
 func TestPages_Dashboard(t *testing.T) {
 	Convey("Writes dashboard page to context", t, func() {
 		_, ctx := newTestContext()
@@ -128,6 +310,7 @@ func TestPages_Dashboard(t *testing.T) {
 			SessionStore:    &storeMock{behavior: "ok"},
 			OpenViDuService: &mockOpenViDu{"ok"},
 			LoginAction:     &mockLoginAction{"ok"},
+			SessionAction:   &mockSessionAction{"ok"},
 		}
 		session := sessions.NewSession(c.SessionStore, SESSION_NAME)
 		session.Values = map[interface{}]interface{}{}
@@ -137,6 +320,17 @@ func TestPages_Dashboard(t *testing.T) {
 		So(ctx.MustGet("template").(string), ShouldEqual, "dashboard.tmpl")
 		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
 
+		Convey("with list of active sessions", func() {
+			params := ctx.MustGet("parameters").(gin.H)
+			list := params["sessions"].([]sessionInfo)
+			So(list, ShouldHaveLength, 1)
+			So(list[0].Name, ShouldEqual, "test session name")
+			So(list[0].Owner, ShouldEqual, "test user")
+			So(list[0].Participants, ShouldEqual, 1)
+			So(params["nextPage"], ShouldEqual, 2)
+			So(params, ShouldNotContainKey, "prevPage")
+		})
+
 		Convey("writes user login to HTTP session", func() {
 			So(session.Values["loggedUser"], ShouldEqual, "test user")
 		})
@@ -146,6 +340,51 @@ func TestPages_Dashboard(t *testing.T) {
 		})
 	})
 
+	Convey("Writes join page if user accepts invitation", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add("user", "test user")
+		ctx.Request.PostForm.Add("pass", "test password")
+		ctx.Request.PostForm.Add("invitation", "test token")
+		c := Pages{
+			SessionStore:     &storeMock{behavior: "ok"},
+			LoginAction:      &mockLoginAction{"ok"},
+			InvitationAction: &mockInvitationAction{"ok"},
+		}
+		session := sessions.NewSession(c.SessionStore, SESSION_NAME)
+		session.Values = map[interface{}]interface{}{}
+		c.SessionStore.Save(nil, nil, session)
+		c.Dashboard(ctx)
+
+		So(ctx.MustGet("template").(string), ShouldEqual, "join.tmpl")
+		So(ctx.MustGet("parameters").(gin.H)["logged"], ShouldBeTrue)
+	})
+
+	Convey("Asks for one-time password if two-factor is enabled", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add("user", "test user")
+		ctx.Request.PostForm.Add("pass", "test password")
+		ctx.Request.PostForm.Add("invitation", "test token")
+		c := Pages{
+			SessionStore:    &storeMock{behavior: "ok"},
+			LoginAction:     &mockLoginAction{"ok"},
+			TwoFactorAction: &mockTwoFactorEnabled{},
+		}
+		session := sessions.NewSession(c.SessionStore, SESSION_NAME)
+		session.Values = map[interface{}]interface{}{}
+		c.SessionStore.Save(nil, nil, session)
+		c.Dashboard(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+		So(ctx.Writer.Header().Get("Location"), ShouldEqual, "/login/2fa")
+		So(session.Values, ShouldNotContainKey, "loggedUser")
+		So(session.Values[pendingUserKey], ShouldEqual, "test user")
+		So(session.Values[pendingInvitationKey], ShouldEqual, "test token")
+	})
+
 	Convey("Redirect to index", t, func() {
 		_, ctx := newTestContext()
 		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
@@ -185,6 +424,54 @@ func TestPages_Dashboard(t *testing.T) {
 	})
 }
 
+func TestPages_Rooms(t *testing.T) {
+	Convey("Writes dashboard page to context", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet,
+			"/dashboard?page=2&q=test", nil)
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&Pages{SessionAction: &mockSessionAction{"ok"}}).Rooms(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("template").(string), ShouldEqual, "dashboard.tmpl")
+
+		Convey("with requested page", func() {
+			params := ctx.MustGet("parameters").(gin.H)
+			So(params["page"], ShouldEqual, 2)
+			So(params["query"], ShouldEqual, "test")
+			So(params["prevPage"], ShouldEqual, 1)
+			So(params["nextPage"], ShouldEqual, 3)
+		})
+	})
+
+	Convey("Redirects to index if user is not logged", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+		(&Pages{SessionAction: &mockSessionAction{"ok"}}).Rooms(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+	})
+
+	Convey("Redirects guest to index", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+		ctx.Set("user", entity.NewGuest("1", "test guest", "test session", 0).User)
+		(&Pages{SessionAction: &mockSessionAction{"ok"}}).Rooms(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+	})
+
+	Convey("Writes list error to context", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+		ctx.Set("user", &entity.User{Name: "test user"})
+		(&Pages{SessionAction: &mockSessionAction{"failure"}}).Rooms(ctx)
+
+		So(ctx.Errors, ShouldNotBeEmpty)
+		So(ctx.MustGet("template").(string), ShouldEqual, "dashboard.tmpl")
+	})
+}
+
 func TestPages_Session(t *testing.T) {
 	Convey("Writes session data to context", t, func() {
 		_, ctx := newTestContext()
@@ -217,6 +504,116 @@ func TestPages_Session(t *testing.T) {
 		})
 	})
 
+	Convey("Joins session by invitation", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add("session-name", "test session name")
+		ctx.Request.PostForm.Add("data", "test session data")
+		ctx.Request.PostForm.Add("invitation", "test token")
+		ctx.Set("user", &entity.User{Name: "test user name",
+			Password: "test password", Role: 0})
+		(&Pages{SessionAction: &mockSessionAction{"ok"},
+			InvitationAction: &mockInvitationAction{"ok"},
+			OpenViDuService:  &mockOpenViDu{"ok"}}).Session(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+
+		Convey("with granted session and role", func() {
+			params := ctx.MustGet("parameters").(gin.H)
+			So(params["sessionName"], ShouldEqual, "invited session")
+			So(params["role"], ShouldEqual, "PUBLISHER")
+			So(params["owner"], ShouldBeFalse)
+		})
+	})
+
+	Convey("If invitation is not valid", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add("invitation", "test token")
+		ctx.Set("user", &entity.User{Name: "test user name", Role: 1})
+		(&Pages{SessionAction: &mockSessionAction{"ok"},
+			InvitationAction: &mockInvitationAction{"failure"},
+			OpenViDuService:  &mockOpenViDu{"ok"}}).Session(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+		So(ctx.Errors, ShouldNotBeEmpty)
+	})
+
+	Convey("If invited session is closed", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add("invitation", "test token")
+		ctx.Set("user", &entity.User{Name: "test user name", Role: 1})
+		(&Pages{SessionAction: &mockSessionAction{"failure"},
+			InvitationAction: &mockInvitationAction{"ok"},
+			OpenViDuService:  &mockOpenViDu{"ok"}}).Session(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+		So(ctx.Errors.String(), ShouldContainSubstring,
+			"session invited session is closed")
+	})
+
+	Convey("Creates new session with access mode", t, func() {
+		_, ctx := newFormContext(url.Values{
+			"session-name":     {"test session name"},
+			"data":             {"test session data"},
+			"access":           {"PRIVATE"},
+			"allow-list":       {"first, second"},
+			"session-passcode": {""},
+			"allow-guests":     {"on"},
+		})
+		ctx.Set("user", &entity.User{Name: "test user name", Role: 1})
+		sessionAction := &policySessionAction{
+			mockSessionAction: mockSessionAction{"new"}}
+		(&Pages{SessionAction: sessionAction,
+			OpenViDuService: &mockOpenViDu{"ok"}}).Session(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		params := ctx.MustGet("parameters").(gin.H)
+		So(params["owner"], ShouldBeTrue)
+		So(params["access"], ShouldEqual, "PRIVATE")
+		So(sessionAction.policy, ShouldResemble, &entity.SessionPolicy{
+			Access:      entity.AccessPrivate,
+			AllowList:   []string{"first", "second"},
+			AllowGuests: true,
+		})
+	})
+
+	Convey("If new session is refused", t, func() {
+		_, ctx := newFormContext(url.Values{
+			"session-name": {"test session name"},
+		})
+		ctx.Set("user", &entity.User{Name: "test user name", Role: 1})
+		openViDu := &mediaSessionsOpenViDu{mockOpenViDu: mockOpenViDu{"ok"}}
+		(&Pages{SessionAction: &mockSessionAction{"failure"},
+			OpenViDuService: openViDu}).Session(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+		So(ctx.Errors, ShouldNotBeEmpty)
+		So(openViDu.closed, ShouldResemble, []string{"test session name"})
+	})
+
+	Convey("If access policy is wrong", t, func() {
+		for _, form := range []url.Values{
+			{"access": {"wrong"}},
+			{"access": {"PASSCODE"}, "session-passcode": {""}},
+		} {
+			form.Set("session-name", "test session name")
+			_, ctx := newFormContext(form)
+			ctx.Set("user", &entity.User{Name: "test user name", Role: 1})
+			openViDu := &mediaSessionsOpenViDu{mockOpenViDu: mockOpenViDu{"ok"}}
+			(&Pages{SessionAction: &mockSessionAction{"new"},
+				OpenViDuService: openViDu}).Session(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+			So(ctx.Errors, ShouldNotBeEmpty)
+			So(openViDu.created, ShouldBeEmpty)
+		}
+	})
+
 	Convey("If context has error", t, func() {
 		_, ctx := newTestContext()
 		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
@@ -272,6 +669,224 @@ func TestPages_Session(t *testing.T) {
 	})
 }
 
+func TestPages_Access(t *testing.T) {
+	Convey("Changes access mode of session", t, func() {
+		w, ctx := newFormContext(url.Values{
+			"session-name":     {"test session name"},
+			"access":           {"PASSCODE"},
+			"session-passcode": {"test passcode"},
+		})
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		(&Pages{SessionAction: &mockSessionAction{"ok"}}).Access(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"access":"PASSCODE"`)
+	})
+
+	Convey("Returns bad request", t, func() {
+		for _, form := range []url.Values{
+			{"access": {"wrong"}},
+			{"access": {"PRIVATE"}, "behavior": {"failure"}},
+		} {
+			w, ctx := newFormContext(form)
+			ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+			(&Pages{SessionAction: &mockSessionAction{
+				form.Get("behavior")}}).Access(ctx)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		}
+	})
+
+	Convey("Returns unauthorized", t, func() {
+		w, ctx := newFormContext(url.Values{"access": {"PRIVATE"}})
+		(&Pages{SessionAction: &mockSessionAction{"ok"}}).Access(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusUnauthorized)
+	})
+}
+
+func TestPages_Owner(t *testing.T) {
+	Convey("Hands session over to participant", t, func() {
+		w, ctx := newFormContext(url.Values{
+			"session-name": {"test session name"},
+			"user":         {"test participant"},
+		})
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		(&Pages{SessionAction: &mockSessionAction{"ok"}}).Owner(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring,
+			`"owner":"test participant"`)
+	})
+
+	Convey("Returns bad request", t, func() {
+		w, ctx := newFormContext(url.Values{"user": {"test participant"}})
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		(&Pages{SessionAction: &mockSessionAction{"failure"}}).Owner(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Returns unauthorized", t, func() {
+		w, ctx := newFormContext(url.Values{"user": {"test participant"}})
+		(&Pages{SessionAction: &mockSessionAction{"ok"}}).Owner(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusUnauthorized)
+	})
+}
+
+func TestPages_Recording(t *testing.T) {
+	Convey("Starts recording of session", t, func() {
+		w, ctx := newFormContext(url.Values{
+			"session-name": {"test session name"},
+			"recording":    {"start"},
+		})
+		ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+		(&Pages{SessionAction: &mockSessionAction{"ok"}}).Recording(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"recording":true`)
+	})
+
+	Convey("Returns bad request", t, func() {
+		for _, c := range []struct{ recording, behavior string }{
+			{"start", "failure"},
+			{"pause", "ok"},
+		} {
+			w, ctx := newFormContext(url.Values{
+				"session-name": {"test session name"},
+				"recording":    {c.recording},
+			})
+			ctx.Set("user", &entity.User{Name: "test user", Role: 1})
+			(&Pages{SessionAction: &mockSessionAction{c.behavior}}).
+				Recording(ctx)
+
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+		}
+	})
+
+	Convey("Returns unauthorized", t, func() {
+		w, ctx := newFormContext(url.Values{"recording": {"stop"}})
+		(&Pages{SessionAction: &mockSessionAction{"ok"}}).Recording(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusUnauthorized)
+	})
+}
+
+func TestPages_GuestForm(t *testing.T) {
+	Convey("Writes guest page to context", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet,
+			"/guest?session-name=test+session", nil)
+		(&Pages{}).GuestForm(ctx)
+
+		So(ctx.MustGet("template"), ShouldEqual, "guest.tmpl")
+		So(ctx.MustGet("parameters").(gin.H)["sessionName"], ShouldEqual,
+			"test session")
+	})
+}
+
+func TestPages_Guest(t *testing.T) {
+	Convey("Joins guest to session", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add("name", "test guest")
+		ctx.Request.PostForm.Add("session-name", "test session name")
+		c := Pages{
+			SessionStore:    &storeMock{behavior: "ok"},
+			SessionAction:   &mockSessionAction{"ok"},
+			GuestAction:     &mockGuestAction{"ok"},
+			OpenViDuService: &mockOpenViDu{"ok"},
+		}
+		session := sessions.NewSession(c.SessionStore, SESSION_NAME)
+		session.Values = map[interface{}]interface{}{}
+		c.SessionStore.Save(nil, nil, session)
+		c.Guest(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("template"), ShouldEqual, "session.tmpl")
+
+		Convey("writes guest to HTTP session", func() {
+			So(session.Values["guestUser"], ShouldEqual, "guest:test guest#1")
+		})
+
+		Convey("with guest parameters", func() {
+			params := ctx.MustGet("parameters").(gin.H)
+			So(params["userName"], ShouldEqual, "guest:test guest#1")
+			So(params["nickName"], ShouldEqual, "test guest")
+			So(params["role"], ShouldEqual, "SUBSCRIBER")
+		})
+	})
+
+	Convey("Escapes display name in connection data", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add("name", `test", "admin": "true`)
+		ctx.Request.PostForm.Add("session-name", "test session name")
+		openViDu := &tokenParamsOpenViDu{mockOpenViDu: mockOpenViDu{"ok"}}
+		c := Pages{
+			SessionStore:    &storeMock{behavior: "ok"},
+			SessionAction:   &mockSessionAction{"ok"},
+			GuestAction:     &mockGuestAction{"ok"},
+			OpenViDuService: openViDu,
+		}
+		session := sessions.NewSession(c.SessionStore, SESSION_NAME)
+		session.Values = map[interface{}]interface{}{}
+		c.SessionStore.Save(nil, nil, session)
+		c.Guest(ctx)
+
+		var data map[string]string
+		So(json.Unmarshal(
+			[]byte(openViDu.params["data"].(string)), &data), ShouldBeNil)
+		So(data, ShouldResemble, map[string]string{
+			"serverData": `test", "admin": "true`,
+			"userName":   `guest:test", "admin": "true#1`,
+		})
+	})
+
+	Convey("If guest can not join", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		ctx.Request.PostForm = url.Values{}
+		(&Pages{
+			SessionStore: &storeMock{behavior: "ok"},
+			GuestAction:  &mockGuestAction{"failure"},
+		}).Guest(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+		So(ctx.Errors, ShouldNotBeEmpty)
+	})
+
+	Convey("Guest can not create session", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add("name", "test guest")
+		c := Pages{
+			SessionStore:    &storeMock{behavior: "ok"},
+			SessionAction:   &mockSessionAction{"failure"},
+			GuestAction:     &mockGuestAction{"ok"},
+			OpenViDuService: &mockOpenViDu{"ok"},
+		}
+		c.SessionStore.Save(nil, nil,
+			sessions.NewSession(c.SessionStore, SESSION_NAME))
+		c.Guest(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+		So(ctx.Errors, ShouldNotBeEmpty)
+	})
+
+	Convey("If HTTP session is not available", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		(&Pages{SessionStore: &storeMock{behavior: "failure"}}).Guest(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+	})
+}
+
 func TestPages_Leave(t *testing.T) {
 	Convey("Leaves session", t, func() {
 		_, ctx := newTestContext()
@@ -286,6 +901,30 @@ func TestPages_Leave(t *testing.T) {
 		So(ctx.Errors, ShouldBeEmpty)
 	})
 
+	Convey("Guest leaves session", t, func() {
+		_, ctx := newTestContext()
+		ctx.Set("user", entity.NewGuest("1", "test guest", "test session", 0).User)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/test", nil)
+		ctx.Request.PostForm = url.Values{}
+		ctx.Request.PostForm.Add("session-name", "test session name")
+		c := Pages{
+			SessionStore:  &storeMock{behavior: "ok"},
+			SessionAction: &mockSessionAction{"ok"},
+		}
+		session := sessions.NewSession(c.SessionStore, SESSION_NAME)
+		session.Values = map[interface{}]interface{}{
+			"guestUser": "guest:test guest",
+		}
+		c.SessionStore.Save(nil, nil, session)
+		c.Leave(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusTemporaryRedirect)
+
+		Convey("Guest is removed from HTTP session", func() {
+			So(session.Values, ShouldNotContainKey, "guestUser")
+		})
+	})
+
 	Convey("If leave session failed", t, func() {
 		_, ctx := newTestContext()
 		ctx.Set("user", &entity.User{Name: "test user name",
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// PasswordReset is a HTTP controller that provides recovery of forgotten
+// passwords.
+type PasswordReset struct {
+	ResetAction interface {
+		Request(ctx context.Context, login string, linkPrefix string) error
+		Verify(token string) (*entity.PasswordReset, error)
+		Reset(ctx context.Context, token string, password string) error
+	}
+
+	// PublicURL is a base URL of the application that reset links point
+	// to, so they can not be redirected by forged Host header.
+	PublicURL string
+}
+
+// RequestForm returns page where user requests password reset link.
+func (c *PasswordReset) RequestForm(ctx *gin.Context) {
+	c.page(ctx, http.StatusOK, gin.H{})
+}
+
+// Request sends password reset link to user given by "user" form
+// parameter, that is user name or mail address. The response is the same
+// whether user exists or not and whether the link is sent or not.
+func (c *PasswordReset) Request(ctx *gin.Context) {
+	if err := c.ResetAction.Request(ctx.Request.Context(),
+		ctx.PostForm("user"), c.PublicURL+"/reset/"); err != nil {
+		ctx.Error(err)
+	}
+	c.page(ctx, http.StatusOK, gin.H{
+		"message": "If the account exists and has an email, a password " +
+			"reset link has been sent to it.",
+	})
+}
+
+// ResetForm returns page where user sets new password with token given by
+// "token" path parameter.
+func (c *PasswordReset) ResetForm(ctx *gin.Context) {
+	token := ctx.Param("token")
+	if _, err := c.ResetAction.Verify(token); err != nil {
+		c.page(ctx, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.page(ctx, http.StatusOK, gin.H{"token": token})
+}
+
+// Reset sets password given by "pass" form parameter with token given by
+// "token" path parameter.
+func (c *PasswordReset) Reset(ctx *gin.Context) {
+	token := ctx.Param("token")
+	if ctx.PostForm("pass") != ctx.PostForm("pass-confirm") {
+		c.page(ctx, http.StatusBadRequest, gin.H{
+			"token": token,
+			"error": errPasswordsMismatch.Error(),
+		})
+		return
+	}
+	if err := c.ResetAction.Reset(ctx.Request.Context(),
+		token, ctx.PostForm("pass")); err != nil {
+		c.page(ctx, http.StatusBadRequest, gin.H{
+			"token": token,
+			"error": err.Error(),
+		})
+		return
+	}
+	c.page(ctx, http.StatusOK, gin.H{
+		"done":    true,
+		"message": "Your password has been changed, you can log in now.",
+	})
+}
+
+// page writes password reset page with given parameters to context.
+func (c *PasswordReset) page(
+	ctx *gin.Context, status int, parameters gin.H) {
+	ctx.Status(status)
+	ctx.Set("template", "reset.tmpl")
+	ctx.Set("parameters", parameters)
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// mockResetAction is a mock that imitates ResetAction behavior.
+type mockResetAction struct {
+	behavior   string
+	linkPrefix string
+}
+
+// Request imitates ResetAction Request method behavior depending on one
+// defined.
+func (a *mockResetAction) Request(
+	ctx context.Context, login string, linkPrefix string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	a.linkPrefix = linkPrefix
+	return nil
+}
+
+// Verify imitates ResetAction Verify method behavior depending on one
+// defined.
+func (a *mockResetAction) Verify(token string) (*entity.PasswordReset, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("password reset link is invalid")
+	}
+	return &entity.PasswordReset{UserName: "test user"}, nil
+}
+
+// Reset imitates ResetAction Reset method behavior depending on one
+// defined.
+func (a *mockResetAction) Reset(
+	ctx context.Context, token string, password string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+func TestPasswordReset_Request(t *testing.T) {
+	Convey("Sends reset link", t, func() {
+		_, ctx := newFormContext(url.Values{"user": {"test user"}})
+		ctx.Request.Host = "attacker.test"
+		a := &mockResetAction{behavior: "ok"}
+		(&PasswordReset{ResetAction: a,
+			PublicURL: "https://meet.test"}).Request(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("template"), ShouldEqual, "reset.tmpl")
+		So(ctx.MustGet("parameters").(gin.H)["message"], ShouldNotBeEmpty)
+		So(a.linkPrefix, ShouldEqual, "https://meet.test/reset/")
+	})
+
+	Convey("Returns the same response on failure", t, func() {
+		_, ctx := newFormContext(url.Values{"user": {"test user"}})
+		(&PasswordReset{ResetAction: &mockResetAction{
+			behavior: "failure"}}).Request(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("parameters").(gin.H)["message"], ShouldNotBeEmpty)
+		So(ctx.MustGet("parameters").(gin.H)["error"], ShouldBeNil)
+		So(ctx.Errors, ShouldNotBeEmpty)
+	})
+}
+
+func TestPasswordReset_ResetForm(t *testing.T) {
+	Convey("Writes new password form to context", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/reset/abc", nil)
+		ctx.Params = gin.Params{{Key: "token", Value: "abc"}}
+		(&PasswordReset{ResetAction: &mockResetAction{behavior: "ok"}}).ResetForm(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("parameters").(gin.H)["token"], ShouldEqual, "abc")
+	})
+
+	Convey("Returns bad request for invalid token", t, func() {
+		_, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/reset/abc", nil)
+		ctx.Params = gin.Params{{Key: "token", Value: "abc"}}
+		(&PasswordReset{ResetAction: &mockResetAction{behavior: "failure"}}).ResetForm(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+		So(ctx.MustGet("parameters").(gin.H)["error"],
+			ShouldEqual, "password reset link is invalid")
+	})
+}
+
+func TestPasswordReset_Reset(t *testing.T) {
+	form := url.Values{"pass": {"new password"}, "pass-confirm": {"new password"}}
+
+	Convey("Sets new password", t, func() {
+		_, ctx := newFormContext(form)
+		ctx.Params = gin.Params{{Key: "token", Value: "abc"}}
+		(&PasswordReset{ResetAction: &mockResetAction{behavior: "ok"}}).Reset(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("parameters").(gin.H)["done"], ShouldBeTrue)
+	})
+
+	Convey("Returns bad request", t, func() {
+		_, ctx := newFormContext(url.Values{
+			"pass": {"new password"}, "pass-confirm": {"other"}})
+		(&PasswordReset{ResetAction: &mockResetAction{behavior: "ok"}}).Reset(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+
+		Convey("if action failed", func() {
+			_, ctx := newFormContext(form)
+			(&PasswordReset{ResetAction: &mockResetAction{behavior: "failure"}}).Reset(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
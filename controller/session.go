@@ -1,7 +1,11 @@
 package controller
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/sessions"
@@ -11,16 +15,57 @@ import (
 
 const SESSION_NAME = "user_session"
 
+// Context keys of request authenticated by API key.
+const (
+	// apiKeyKey is a context key of API key request is authenticated by.
+	apiKeyKey = "apiKey"
+
+	// apiUserKey is a context key of user API key belongs to. The user is
+	// set as "user" only by Scope middleware.
+	apiUserKey = "apiKeyUser"
+)
+
 // Session is a middleware that perform check in user data in HTTP session.
 type Session struct {
 	Store      sessions.Store
 	UserAction interface {
 		Get(username string) (*entity.User, error)
 	}
+
+	// GuestAction retrieves guests of OpenViDu sessions. Guests are not
+	// recognized if it is nil.
+	GuestAction interface {
+		Get(name string) (*entity.User, error)
+	}
+
+	// APIKeyAction authenticates machine clients by API keys sent in
+	// "Authorization: Bearer" header. API keys are not accepted if it is
+	// nil.
+	APIKeyAction interface {
+		Authenticate(ctx context.Context,
+			token string) (*entity.User, *entity.APIKey, error)
+	}
+
+	// TokenAction authenticates clients by JWT access tokens sent in
+	// "Authorization: Bearer" header. Access tokens are not accepted if it
+	// is nil.
+	TokenAction interface {
+		Verify(ctx context.Context, accessToken string) (*entity.User, error)
+	}
 }
 
 // Check checks existed session and writes this to context.
 func (mw *Session) Check(ctx *gin.Context) {
+	if token, ok := bearerToken(ctx.Request); ok {
+		switch {
+		case isJWT(token) && mw.TokenAction != nil:
+			mw.checkAccessToken(ctx, token)
+			return
+		case mw.APIKeyAction != nil:
+			mw.checkAPIKey(ctx, token)
+			return
+		}
+	}
 	s, err := mw.Store.Get(ctx.Request, SESSION_NAME)
 	if err != nil {
 		ctx.Error(err)
@@ -35,7 +80,7 @@ func (mw *Session) Check(ctx *gin.Context) {
 	username, ok := s.Values["loggedUser"]
 
 	if !ok {
-		ctx.Error(errors.New("user not found"))
+		mw.checkGuest(ctx, s)
 		return
 	}
 	user, err := mw.UserAction.Get(username.(string))
@@ -45,3 +90,88 @@ func (mw *Session) Check(ctx *gin.Context) {
 	}
 	ctx.Set("user", user)
 }
+
+// checkGuest writes guest, stored in given HTTP session, to context.
+func (mw *Session) checkGuest(ctx *gin.Context, s *sessions.Session) {
+	name, ok := s.Values["guestUser"]
+	if !ok || mw.GuestAction == nil {
+		ctx.Error(errors.New("user not found"))
+		return
+	}
+	user, err := mw.GuestAction.Get(name.(string))
+	if err != nil {
+		ctx.Error(errors.New("user not found"))
+		return
+	}
+	ctx.Set("user", user)
+}
+
+// checkAccessToken writes user given JWT access token is issued to to
+// context, or aborts request with unauthorized status if the token is not
+// accepted.
+func (mw *Session) checkAccessToken(ctx *gin.Context, token string) {
+	user, err := mw.TokenAction.Verify(ctx.Request.Context(), token)
+	if err != nil {
+		invalidToken(ctx, err)
+		return
+	}
+	ctx.Set("user", user)
+}
+
+// checkAPIKey writes API key given by token and its user to context, or
+// aborts request with unauthorized status if the key is not accepted.
+func (mw *Session) checkAPIKey(ctx *gin.Context, token string) {
+	user, key, err := mw.APIKeyAction.Authenticate(
+		ctx.Request.Context(), token)
+	if err != nil {
+		invalidToken(ctx, err)
+		return
+	}
+	ctx.Set(apiKeyKey, key)
+	ctx.Set(apiUserKey, user)
+}
+
+// invalidToken aborts request with unauthorized status because of given
+// bearer token error.
+func invalidToken(ctx *gin.Context, err error) {
+	ctx.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	ctx.AbortWithStatusJSON(http.StatusUnauthorized,
+		gin.H{"error": err.Error()})
+}
+
+// Scope returns middleware that lets request authenticated by API key
+// through only if the key has given scope. Requests authenticated by HTTP
+// session are not affected.
+//
+// Users of API keys are unknown to handlers without this middleware, so
+// API keys are refused everywhere else.
+func Scope(scope entity.APIScope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key, ok := ctx.Get(apiKeyKey)
+		if !ok {
+			return
+		}
+		if !key.(*entity.APIKey).Allows(scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": fmt.Sprintf("API key is not allowed to %s", scope),
+			})
+			return
+		}
+		ctx.Set("user", ctx.MustGet(apiUserKey))
+	}
+}
+
+// bearerToken returns token of "Authorization: Bearer" header of given
+// request.
+func bearerToken(r *http.Request) (string, bool) {
+	if !hasBearerAuth(r) {
+		return "", false
+	}
+	return strings.TrimSpace(
+		strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")), true
+}
+
+// isJWT returns true if given bearer token has JWT compact form.
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -67,6 +68,133 @@ func (a *userActionMock) Get(username string) (*entity.User, error) {
 	return nil, errors.New("some error")
 }
 
+// apiKeyActionMock is a mock that imitates APIKeyAction behavior.
+type apiKeyActionMock struct {
+	behavior string
+}
+
+// Authenticate imitates APIKeyAction Authenticate method behavior depending
+// on one defined.
+func (a *apiKeyActionMock) Authenticate(ctx context.Context,
+	token string) (*entity.User, *entity.APIKey, error) {
+	if a.behavior != "ok" {
+		return nil, nil, errors.New("API key is invalid")
+	}
+	return &entity.User{Name: "test user", Role: 1},
+		&entity.APIKey{ID: "test key",
+			Scopes: []entity.APIScope{entity.ScopeGetToken}}, nil
+}
+
+// newBearerContext returns context of request authenticated by API key
+// checked by Session middleware with given API key action behavior.
+func newBearerContext(
+	behavior string) (*httptest.ResponseRecorder, *gin.Context) {
+	w, ctx := newTestContext()
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+	ctx.Request.Header.Set("Authorization", "Bearer ovk_test")
+	c := &Session{
+		Store:        &storeMock{behavior: "failure"},
+		UserAction:   &userActionMock{"failure"},
+		APIKeyAction: &apiKeyActionMock{behavior},
+	}
+	c.Check(ctx)
+	return w, ctx
+}
+
+func TestSession_CheckAPIKey(t *testing.T) {
+	Convey("Writes API key to context", t, func() {
+		_, ctx := newBearerContext("ok")
+
+		So(ctx.IsAborted(), ShouldBeFalse)
+		So(ctx.MustGet(apiKeyKey).(*entity.APIKey).ID, ShouldEqual, "test key")
+
+		Convey("but not its user", func() {
+			_, ok := ctx.Get("user")
+			So(ok, ShouldBeFalse)
+		})
+	})
+
+	Convey("Returns unauthorized if API key is invalid", t, func() {
+		w, ctx := newBearerContext("failure")
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+		So(w.Code, ShouldEqual, http.StatusUnauthorized)
+		So(w.Header().Get("WWW-Authenticate"), ShouldContainSubstring,
+			"invalid_token")
+	})
+}
+
+// tokenActionMock is a mock that imitates TokenAction behavior.
+type tokenActionMock struct {
+	behavior string
+}
+
+// Verify imitates TokenAction Verify method behavior depending on one
+// defined.
+func (a *tokenActionMock) Verify(
+	ctx context.Context, accessToken string) (*entity.User, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("access token is invalid")
+	}
+	return &entity.User{Name: "test user", Role: 1}, nil
+}
+
+func TestSession_CheckAccessToken(t *testing.T) {
+	newContext := func(
+		behavior string) (*httptest.ResponseRecorder, *gin.Context) {
+		w, ctx := newTestContext()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+		ctx.Request.Header.Set("Authorization", "Bearer a.b.c")
+		c := &Session{
+			Store:        &storeMock{behavior: "failure"},
+			UserAction:   &userActionMock{"failure"},
+			APIKeyAction: &apiKeyActionMock{"failure"},
+			TokenAction:  &tokenActionMock{behavior},
+		}
+		c.Check(ctx)
+		return w, ctx
+	}
+
+	Convey("Writes user of access token to context", t, func() {
+		_, ctx := newContext("ok")
+
+		So(ctx.IsAborted(), ShouldBeFalse)
+		So(ctx.MustGet("user").(*entity.User).Name, ShouldEqual, "test user")
+	})
+
+	Convey("Returns unauthorized if access token is invalid", t, func() {
+		w, ctx := newContext("failure")
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+		So(w.Code, ShouldEqual, http.StatusUnauthorized)
+	})
+}
+
+func TestScope(t *testing.T) {
+	Convey("Writes user of API key with scope to context", t, func() {
+		_, ctx := newBearerContext("ok")
+		Scope(entity.ScopeGetToken)(ctx)
+
+		So(ctx.IsAborted(), ShouldBeFalse)
+		So(ctx.MustGet("user").(*entity.User).Name, ShouldEqual, "test user")
+	})
+
+	Convey("Returns forbidden if API key has no scope", t, func() {
+		w, ctx := newBearerContext("ok")
+		Scope(entity.ScopeCreateSession)(ctx)
+
+		So(ctx.IsAborted(), ShouldBeTrue)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("Does not affect request without API key", t, func() {
+		_, ctx := newTestContext()
+		Scope(entity.ScopeCreateSession)(ctx)
+
+		So(ctx.IsAborted(), ShouldBeFalse)
+	})
+}
+
 func TestSession_Check(t *testing.T) {
 	Convey("Writes user to context", t, func() {
 		c := &Session{
@@ -134,6 +262,39 @@ func TestSession_Check(t *testing.T) {
 		So(ctx.Errors, ShouldNotBeEmpty)
 	})
 
+	Convey("Writes guest to context", t, func() {
+		c := &Session{
+			Store:       &storeMock{behavior: "ok"},
+			UserAction:  &userActionMock{"failure"},
+			GuestAction: &userActionMock{"ok"},
+		}
+		c.Store.Save(nil, nil, &sessions.Session{
+			Values: map[interface{}]interface{}{
+				"guestUser": "guest:test guest",
+			},
+		})
+		_, ctx := runMiddlware(c.Check)
+
+		So(ctx.Errors, ShouldBeEmpty)
+		So(func() { ctx.MustGet("user") }, ShouldNotPanic)
+	})
+
+	Convey("If guest not found", t, func() {
+		c := &Session{
+			Store:       &storeMock{behavior: "ok"},
+			UserAction:  &userActionMock{"ok"},
+			GuestAction: &userActionMock{"failure"},
+		}
+		c.Store.Save(nil, nil, &sessions.Session{
+			Values: map[interface{}]interface{}{
+				"guestUser": "guest:test guest",
+			},
+		})
+		_, ctx := runMiddlware(c.Check)
+
+		So(ctx.Errors, ShouldNotBeEmpty)
+	})
+
 	Convey("If user not found", t, func() {
 		c := &Session{
 			Store:      &storeMock{behavior: "ok"},
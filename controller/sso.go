@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/sessions"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Keys of single sign-on request values in HTTP session.
+const (
+	ssoStateKey    = "ssoState"
+	ssoNonceKey    = "ssoNonce"
+	ssoVerifierKey = "ssoVerifier"
+)
+
+// errSSOState is returned when identity provider returns user with state
+// that was not issued to the user.
+var errSSOState = errors.New("single sign-on request is invalid, try again")
+
+// SSO is a HTTP controller that logs users in through OpenID Connect
+// identity provider.
+type SSO struct {
+	SessionStore sessions.Store
+
+	// Provider is an identity provider users authenticate at.
+	Provider interface {
+		AuthCodeURL(ctx context.Context, state string, nonce string,
+			verifier string) (string, error)
+		Exchange(ctx context.Context, code string, verifier string,
+			nonce string) (map[string]interface{}, error)
+	}
+
+	SSOAction interface {
+		Login(ctx context.Context, ip string,
+			claims map[string]interface{}) (*entity.User, error)
+		Fail(ctx context.Context, ip string, err error)
+	}
+
+	// TwoFactorAction tells users that enter one-time password after
+	// identity provider login. Identity provider is the only factor if it
+	// is nil.
+	TwoFactorAction interface {
+		Enabled(userName string) bool
+	}
+}
+
+// Login redirects user to identity provider. State, nonce and PKCE code
+// verifier of the request are kept in HTTP session until callback.
+func (c *SSO) Login(ctx *gin.Context) {
+	session, err := c.SessionStore.Get(ctx.Request, SESSION_NAME)
+	if err != nil {
+		ctx.Redirect(http.StatusTemporaryRedirect, "/")
+		ctx.Abort()
+		return
+	}
+	values := make(map[string]string)
+	for _, key := range []string{ssoStateKey, ssoNonceKey, ssoVerifierKey} {
+		if values[key], err = newCSRFToken(); err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		session.Values[key] = values[key]
+	}
+	authURL, err := c.Provider.AuthCodeURL(ctx.Request.Context(),
+		values[ssoStateKey], values[ssoNonceKey], values[ssoVerifierKey])
+	if err != nil {
+		ctx.Error(err)
+		c.page(ctx, http.StatusBadGateway,
+			"Identity provider is unavailable, try again later.")
+		return
+	}
+	if err = session.Save(ctx.Request, ctx.Writer); err != nil {
+		ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// Callback logs in user returned by identity provider with "code" and
+// "state" query parameters and redirects the user to dashboard, or to the
+// second login step if the user has two-factor authentication enabled.
+func (c *SSO) Callback(ctx *gin.Context) {
+	session, err := c.SessionStore.Get(ctx.Request, SESSION_NAME)
+	if err != nil {
+		ctx.Redirect(http.StatusTemporaryRedirect, "/")
+		ctx.Abort()
+		return
+	}
+	state, _ := session.Values[ssoStateKey].(string)
+	nonce, _ := session.Values[ssoNonceKey].(string)
+	verifier, _ := session.Values[ssoVerifierKey].(string)
+	delete(session.Values, ssoStateKey)
+	delete(session.Values, ssoNonceKey)
+	delete(session.Values, ssoVerifierKey)
+
+	if e := ctx.Query("error"); e != "" {
+		if d := ctx.Query("error_description"); d != "" {
+			e = d
+		}
+		c.SSOAction.Fail(ctx.Request.Context(), ctx.ClientIP(), errors.New(e))
+		session.Save(ctx.Request, ctx.Writer)
+		c.page(ctx, http.StatusUnauthorized, e)
+		return
+	}
+	if state == "" || subtle.ConstantTimeCompare(
+		[]byte(ctx.Query("state")), []byte(state)) != 1 {
+		c.SSOAction.Fail(ctx.Request.Context(), ctx.ClientIP(), errSSOState)
+		session.Save(ctx.Request, ctx.Writer)
+		c.page(ctx, http.StatusBadRequest, errSSOState.Error())
+		return
+	}
+	claims, err := c.Provider.Exchange(
+		ctx.Request.Context(), ctx.Query("code"), verifier, nonce)
+	if err != nil {
+		ctx.Error(err)
+		c.SSOAction.Fail(ctx.Request.Context(), ctx.ClientIP(), err)
+		session.Save(ctx.Request, ctx.Writer)
+		c.page(ctx, http.StatusUnauthorized,
+			"Identity provider login failed, try again.")
+		return
+	}
+	user, err := c.SSOAction.Login(
+		ctx.Request.Context(), ctx.ClientIP(), claims)
+	if err != nil {
+		session.Save(ctx.Request, ctx.Writer)
+		c.page(ctx, http.StatusForbidden, err.Error())
+		return
+	}
+	session.Values["error"] = nil
+	if c.TwoFactorAction != nil && c.TwoFactorAction.Enabled(user.Name) {
+		session.Values[pendingUserKey] = user.Name
+		session.Values[pendingSinceKey] = time.Now().Unix()
+		session.Values[pendingInvitationKey] = ""
+		session.Save(ctx.Request, ctx.Writer)
+		ctx.Redirect(http.StatusFound, "/login/2fa")
+		return
+	}
+	session.Values["loggedUser"] = user.Name
+	session.Save(ctx.Request, ctx.Writer)
+	ctx.Redirect(http.StatusFound, "/dashboard")
+}
+
+// page writes index page with given error to context.
+func (c *SSO) page(ctx *gin.Context, status int, message string) {
+	ctx.Status(status)
+	ctx.Set("template", "index.tmpl")
+	ctx.Set("parameters", gin.H{"error": message, "sso": true})
+}
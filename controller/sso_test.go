@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/sessions"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// mockProvider is a mock that imitates identity provider behavior.
+type mockProvider struct {
+	behavior string
+	verifier string
+	nonce    string
+}
+
+// AuthCodeURL imitates Provider AuthCodeURL method behavior depending on
+// one defined.
+func (p *mockProvider) AuthCodeURL(ctx context.Context,
+	state string, nonce string, verifier string) (string, error) {
+	if p.behavior == "failure" {
+		return "", errors.New("some error")
+	}
+	return "https://idp.example.com/auth?state=" + url.QueryEscape(state),
+		nil
+}
+
+// Exchange imitates Provider Exchange method behavior depending on one
+// defined.
+func (p *mockProvider) Exchange(ctx context.Context, code string,
+	verifier string, nonce string) (map[string]interface{}, error) {
+	if p.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	p.verifier, p.nonce = verifier, nonce
+	return map[string]interface{}{"sub": code}, nil
+}
+
+// mockSSOAction is a mock that imitates SSOAction behavior.
+type mockSSOAction struct {
+	behavior string
+	failures []error
+}
+
+// Login imitates SSOAction Login method behavior depending on one defined.
+func (a *mockSSOAction) Login(ctx context.Context, ip string,
+	claims map[string]interface{}) (*entity.User, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	return &entity.User{Name: claims["sub"].(string)}, nil
+}
+
+// Fail records given error.
+func (a *mockSSOAction) Fail(ctx context.Context, ip string, err error) {
+	a.failures = append(a.failures, err)
+}
+
+// newSSO returns single sign-on controller with given behaviors and HTTP
+// session saved in its store.
+func newSSO(provider string, action string) (*SSO, *sessions.Session) {
+	store := &storeMock{behavior: "ok"}
+	session := sessions.NewSession(store, SESSION_NAME)
+	session.Values = map[interface{}]interface{}{}
+	store.Save(nil, nil, session)
+	return &SSO{
+		SessionStore: store,
+		Provider:     &mockProvider{behavior: provider},
+		SSOAction:    &mockSSOAction{behavior: action},
+	}, session
+}
+
+// newCallbackContext returns context of callback request with given query.
+func newCallbackContext(query string) (
+	*httptest.ResponseRecorder, *gin.Context) {
+	w, ctx := newTestContext()
+	ctx.Request = httptest.NewRequest(
+		http.MethodGet, "/login/oidc/callback?"+query, nil)
+	return w, ctx
+}
+
+func TestSSO_Login(t *testing.T) {
+	Convey("Redirects to identity provider", t, func() {
+		w, ctx := newCallbackContext("")
+		c, session := newSSO("ok", "ok")
+		c.Login(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusFound)
+		state := session.Values[ssoStateKey].(string)
+		So(state, ShouldNotBeEmpty)
+		So(session.Values[ssoNonceKey], ShouldNotEqual, state)
+		So(w.Header().Get("Location"), ShouldEndWith, "state="+state)
+	})
+
+	Convey("Returns bad gateway if provider is unavailable", t, func() {
+		_, ctx := newCallbackContext("")
+		c, _ := newSSO("failure", "ok")
+		c.Login(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadGateway)
+		So(ctx.MustGet("template"), ShouldEqual, "index.tmpl")
+	})
+}
+
+func TestSSO_Callback(t *testing.T) {
+	start := func(c *SSO, session *sessions.Session) string {
+		_, ctx := newCallbackContext("")
+		c.Login(ctx)
+		return session.Values[ssoStateKey].(string)
+	}
+
+	Convey("Logs in user returned by identity provider", t, func() {
+		c, session := newSSO("ok", "ok")
+		state := start(c, session)
+		nonce := session.Values[ssoNonceKey].(string)
+		w, ctx := newCallbackContext("code=test+user&state=" + state)
+		c.Callback(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusFound)
+		So(w.Header().Get("Location"), ShouldEqual, "/dashboard")
+		So(session.Values["loggedUser"], ShouldEqual, "test user")
+		So(session.Values, ShouldNotContainKey, ssoStateKey)
+		provider := c.Provider.(*mockProvider)
+		So(provider.nonce, ShouldEqual, nonce)
+		So(provider.verifier, ShouldNotBeEmpty)
+
+		Convey("Asks for one-time password if 2FA is enabled", func() {
+			c, session := newSSO("ok", "ok")
+			c.TwoFactorAction = &mockTwoFactorEnabled{}
+			state := start(c, session)
+			w, ctx := newCallbackContext("code=test+user&state=" + state)
+			c.Callback(ctx)
+
+			So(w.Header().Get("Location"), ShouldEqual, "/login/2fa")
+			So(session.Values[pendingUserKey], ShouldEqual, "test user")
+			So(session.Values, ShouldNotContainKey, "loggedUser")
+		})
+
+		Convey("Refuses to reuse state", func() {
+			_, ctx := newCallbackContext("code=other&state=" + state)
+			c.Callback(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+			So(session.Values["loggedUser"], ShouldEqual, "test user")
+		})
+	})
+
+	Convey("Returns an error page", t, func() {
+		Convey("if state does not match", func() {
+			c, session := newSSO("ok", "ok")
+			start(c, session)
+			_, ctx := newCallbackContext("code=test+user&state=wrong")
+			c.Callback(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+			So(ctx.MustGet("parameters").(gin.H)["error"],
+				ShouldEqual, errSSOState.Error())
+			So(session.Values, ShouldNotContainKey, "loggedUser")
+			So(c.SSOAction.(*mockSSOAction).failures, ShouldResemble,
+				[]error{errSSOState})
+		})
+
+		Convey("if identity provider returned an error", func() {
+			c, session := newSSO("ok", "ok")
+			state := start(c, session)
+			_, ctx := newCallbackContext(
+				"error=access_denied&error_description=denied&state=" + state)
+			c.Callback(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusUnauthorized)
+			So(ctx.MustGet("parameters").(gin.H)["error"], ShouldEqual, "denied")
+			So(c.SSOAction.(*mockSSOAction).failures, ShouldHaveLength, 1)
+		})
+
+		Convey("if code exchange failed", func() {
+			c, session := newSSO("ok", "ok")
+			state := start(c, session)
+			c.Provider.(*mockProvider).behavior = "failure"
+			_, ctx := newCallbackContext("code=test+user&state=" + state)
+			c.Callback(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusUnauthorized)
+			So(session.Values, ShouldNotContainKey, "loggedUser")
+		})
+
+		Convey("if action refused user", func() {
+			c, session := newSSO("ok", "failure")
+			state := start(c, session)
+			_, ctx := newCallbackContext("code=test+user&state=" + state)
+			c.Callback(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusForbidden)
+			So(session.Values, ShouldNotContainKey, "loggedUser")
+		})
+	})
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Token is a HTTP controller that issues JWT access tokens to single-page
+// and mobile clients that do not use HTTP session.
+type Token struct {
+	TokenAction interface {
+		Issue(ctx context.Context, ip string, username string,
+			password string, code string) (*entity.TokenPair, error)
+		Refresh(ctx context.Context,
+			refreshToken string) (*entity.TokenPair, error)
+		Revoke(ctx context.Context, refreshToken string) error
+	}
+}
+
+// Issue writes new token pair as JSON.
+//
+// "grant_type" form parameter is "password" to log in with "user", "pass"
+// and, for users with two-factor authentication, "code" form parameters,
+// or "refresh_token" to exchange "refresh_token" form parameter.
+func (c *Token) Issue(ctx *gin.Context) {
+	var pair *entity.TokenPair
+	var err error
+	switch ctx.PostForm("grant_type") {
+	case "password":
+		pair, err = c.TokenAction.Issue(ctx.Request.Context(),
+			ctx.ClientIP(), ctx.PostForm("user"), ctx.PostForm("pass"),
+			ctx.PostForm("code"))
+	case "refresh_token":
+		pair, err = c.TokenAction.Refresh(
+			ctx.Request.Context(), ctx.PostForm("refresh_token"))
+	default:
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "unsupported grant type"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, gin.H{
+		"access_token":  pair.AccessToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(pair.ExpiresIn.Seconds()),
+		"refresh_token": pair.RefreshToken,
+	})
+}
+
+// Revoke revokes refresh token given by "refresh_token" form parameter,
+// e.g. on logout. Responds with no content even for unknown token, so the
+// result does not disclose which tokens exist.
+func (c *Token) Revoke(ctx *gin.Context) {
+	if err := c.TokenAction.Revoke(ctx.Request.Context(),
+		ctx.PostForm("refresh_token")); err != nil {
+		ctx.Error(err)
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// mockTokenAction is a mock that imitates TokenAction behavior.
+type mockTokenAction struct {
+	behavior string
+}
+
+// Issue imitates TokenAction Issue method behavior depending on one
+// defined.
+func (a *mockTokenAction) Issue(ctx context.Context, ip string,
+	username string, password string, code string) (*entity.TokenPair, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	return &entity.TokenPair{AccessToken: "access", ExpiresIn: time.Minute,
+		RefreshToken: "refresh"}, nil
+}
+
+// Refresh imitates TokenAction Refresh method behavior depending on one
+// defined.
+func (a *mockTokenAction) Refresh(
+	ctx context.Context, refreshToken string) (*entity.TokenPair, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	return &entity.TokenPair{AccessToken: "next access",
+		ExpiresIn: time.Minute, RefreshToken: "next refresh"}, nil
+}
+
+// Revoke imitates TokenAction Revoke method behavior depending on one
+// defined.
+func (a *mockTokenAction) Revoke(
+	ctx context.Context, refreshToken string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+func TestToken_Issue(t *testing.T) {
+	Convey("Writes token pair as JSON", t, func() {
+		w, ctx := newFormContext(url.Values{"grant_type": {"password"},
+			"user": {"test user"}, "pass": {"test password"}})
+		(&Token{TokenAction: &mockTokenAction{"ok"}}).Issue(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("Cache-Control"), ShouldEqual, "no-store")
+		var body map[string]interface{}
+		So(json.Unmarshal(w.Body.Bytes(), &body), ShouldBeNil)
+		So(body["access_token"], ShouldEqual, "access")
+		So(body["token_type"], ShouldEqual, "Bearer")
+		So(body["expires_in"], ShouldEqual, 60)
+		So(body["refresh_token"], ShouldEqual, "refresh")
+
+		Convey("for refresh token", func() {
+			w, ctx := newFormContext(url.Values{
+				"grant_type": {"refresh_token"}, "refresh_token": {"refresh"}})
+			(&Token{TokenAction: &mockTokenAction{"ok"}}).Issue(ctx)
+
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldContainSubstring, "next refresh")
+		})
+	})
+
+	Convey("Returns unauthorized if action failed", t, func() {
+		w, ctx := newFormContext(url.Values{"grant_type": {"password"}})
+		(&Token{TokenAction: &mockTokenAction{"failure"}}).Issue(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Returns bad request for unknown grant type", t, func() {
+		w, ctx := newFormContext(url.Values{"grant_type": {"wrong"}})
+		(&Token{TokenAction: &mockTokenAction{"ok"}}).Issue(ctx)
+
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+	})
+}
+
+func TestToken_Revoke(t *testing.T) {
+	Convey("Responds with no content", t, func() {
+		for _, behavior := range []string{"ok", "failure"} {
+			_, ctx := newFormContext(url.Values{"refresh_token": {"refresh"}})
+			(&Token{TokenAction: &mockTokenAction{behavior}}).Revoke(ctx)
+
+			So(ctx.Writer.Status(), ShouldEqual, http.StatusNoContent)
+		}
+	})
+}
@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"html/template"
+	"image/png"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/sessions"
+	"github.com/pquerna/otp"
+)
+
+// Keys of two-factor authentication values in HTTP session.
+const (
+	// pendingUserKey is a key of user that passed password check and has
+	// to enter one-time password.
+	pendingUserKey = "pendingUser"
+
+	// pendingSinceKey is a key of Unix time password check passed at.
+	pendingSinceKey = "pendingSince"
+
+	// pendingInvitationKey is a key of invitation token user logged in
+	// with.
+	pendingInvitationKey = "pendingInvitation"
+
+	// enrollmentKey is a key of provisioning URI being enrolled.
+	enrollmentKey = "totpEnrollment"
+)
+
+// pendingTTL is a time user has to enter one-time password after password
+// check.
+const pendingTTL = 5 * time.Minute
+
+// TwoFactor is a HTTP controller that provides second login step and
+// enrollment of two-factor authentication.
+type TwoFactor struct {
+	SessionStore    sessions.Store
+	TwoFactorAction interface {
+		Enroll(userName string) (string, error)
+		Activate(ctx context.Context, userName string, uri string,
+			code string) ([]string, error)
+		Verify(ctx context.Context, ip string, userName string,
+			code string) error
+		Disable(ctx context.Context, ip string, userName string,
+			code string) error
+	}
+}
+
+// Form returns page where user that passed password check enters one-time
+// password.
+func (c *TwoFactor) Form(ctx *gin.Context) {
+	if _, _, ok := c.pending(ctx); !ok {
+		ctx.Redirect(http.StatusTemporaryRedirect, "/")
+		ctx.Abort()
+		return
+	}
+	c.loginPage(ctx, http.StatusOK, nil)
+}
+
+// Verify logs in user that passed password check if "code" form parameter
+// is its one-time password or recovery code.
+func (c *TwoFactor) Verify(ctx *gin.Context) {
+	session, userName, ok := c.pending(ctx)
+	if !ok {
+		ctx.Redirect(http.StatusSeeOther, "/")
+		return
+	}
+	if err := c.TwoFactorAction.Verify(ctx.Request.Context(),
+		ctx.ClientIP(), userName, ctx.PostForm("code")); err != nil {
+		c.loginPage(ctx, http.StatusUnauthorized, err)
+		return
+	}
+	invitation, _ := session.Values[pendingInvitationKey].(string)
+	delete(session.Values, pendingUserKey)
+	delete(session.Values, pendingSinceKey)
+	delete(session.Values, pendingInvitationKey)
+	session.Values["error"] = nil
+	session.Values["loggedUser"] = userName
+	session.Save(ctx.Request, ctx.Writer)
+	if invitation != "" {
+		ctx.Redirect(http.StatusSeeOther, "/join/"+invitation)
+		return
+	}
+	ctx.Redirect(http.StatusSeeOther, "/dashboard")
+}
+
+// Setup returns page where logged user enrolls two-factor authentication
+// by scanning QR code, or disables it if it is enabled.
+func (c *TwoFactor) Setup(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	if user.TwoFactor() {
+		c.setupPage(ctx, http.StatusOK, gin.H{"enabled": true})
+		return
+	}
+	session, err := c.SessionStore.Get(ctx.Request, SESSION_NAME)
+	if err != nil {
+		ctx.Redirect(http.StatusTemporaryRedirect, "/")
+		ctx.Abort()
+		return
+	}
+	uri, err := c.TwoFactorAction.Enroll(user.Name)
+	if err != nil {
+		c.setupPage(ctx, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session.Values[enrollmentKey] = uri
+	session.Save(ctx.Request, ctx.Writer)
+	c.enrollmentPage(ctx, http.StatusOK, uri, nil)
+}
+
+// Activate enables two-factor authentication of logged user if "code"
+// form parameter is one-time password of enrolled QR code, and shows
+// recovery codes.
+func (c *TwoFactor) Activate(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	session, err := c.SessionStore.Get(ctx.Request, SESSION_NAME)
+	if err != nil {
+		ctx.Redirect(http.StatusSeeOther, "/")
+		return
+	}
+	uri, _ := session.Values[enrollmentKey].(string)
+	codes, err := c.TwoFactorAction.Activate(
+		ctx.Request.Context(), user.Name, uri, ctx.PostForm("code"))
+	if err != nil {
+		c.enrollmentPage(ctx, http.StatusBadRequest, uri, err)
+		return
+	}
+	delete(session.Values, enrollmentKey)
+	session.Save(ctx.Request, ctx.Writer)
+	c.setupPage(ctx, http.StatusOK, gin.H{
+		"enabled":       true,
+		"recoveryCodes": codes,
+	})
+}
+
+// Disable turns off two-factor authentication of logged user confirmed by
+// "code" form parameter.
+func (c *TwoFactor) Disable(ctx *gin.Context) {
+	user, ok := registeredUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.TwoFactorAction.Disable(ctx.Request.Context(),
+		ctx.ClientIP(), user.Name, ctx.PostForm("code")); err != nil {
+		c.setupPage(ctx, http.StatusBadRequest, gin.H{
+			"enabled": true,
+			"error":   err.Error(),
+		})
+		return
+	}
+	ctx.Redirect(http.StatusSeeOther, "/account")
+}
+
+// pending returns HTTP session and name of user that passed password check
+// and has not entered one-time password yet.
+func (c *TwoFactor) pending(
+	ctx *gin.Context) (*sessions.Session, string, bool) {
+	session, err := c.SessionStore.Get(ctx.Request, SESSION_NAME)
+	if err != nil {
+		return nil, "", false
+	}
+	userName, _ := session.Values[pendingUserKey].(string)
+	since, _ := session.Values[pendingSinceKey].(int64)
+	if userName == "" ||
+		time.Since(time.Unix(since, 0)) > pendingTTL {
+		return nil, "", false
+	}
+	return session, userName, true
+}
+
+// loginPage writes second login step page with given error to context.
+func (c *TwoFactor) loginPage(ctx *gin.Context, status int, err error) {
+	parameters := gin.H{}
+	if err != nil {
+		parameters["error"] = err.Error()
+	}
+	ctx.Status(status)
+	ctx.Set("template", "login-2fa.tmpl")
+	ctx.Set("parameters", parameters)
+}
+
+// enrollmentPage writes page with QR code of given provisioning URI and
+// given error to context.
+func (c *TwoFactor) enrollmentPage(
+	ctx *gin.Context, status int, uri string, err error) {
+	parameters := gin.H{}
+	if err != nil {
+		parameters["error"] = err.Error()
+	}
+	key, e := otp.NewKeyFromURL(uri)
+	if e != nil {
+		parameters["error"] = "Enrollment is invalid, start again."
+		c.setupPage(ctx, http.StatusBadRequest, parameters)
+		return
+	}
+	parameters["secret"] = key.Secret()
+	parameters["uri"] = uri
+	if qr, e := qrCode(key); e == nil {
+		parameters["qr"] = qr
+	} else {
+		ctx.Error(e)
+	}
+	c.setupPage(ctx, status, parameters)
+}
+
+// setupPage writes two-factor authentication page with given parameters
+// to context.
+func (c *TwoFactor) setupPage(
+	ctx *gin.Context, status int, parameters gin.H) {
+	ctx.Status(status)
+	ctx.Set("template", "account-2fa.tmpl")
+	ctx.Set("parameters", parameters)
+}
+
+// qrCode returns data URL of PNG image with QR code of given key.
+func qrCode(key *otp.Key) (template.URL, error) {
+	img, err := key.Image(200, 200)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," +
+		base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
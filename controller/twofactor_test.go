@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/sessions"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// testProvisioningURI is a provisioning URI returned by mockTwoFactorAction.
+const testProvisioningURI = "otpauth://totp/Test:test%20user" +
+	"?issuer=Test&secret=JBSWY3DPEHPK3PXP"
+
+// mockTwoFactorAction is a mock that imitates TwoFactorAction behavior.
+type mockTwoFactorAction struct {
+	behavior string
+}
+
+// Enroll imitates TwoFactorAction Enroll method behavior depending on one
+// defined.
+func (a *mockTwoFactorAction) Enroll(userName string) (string, error) {
+	if a.behavior != "ok" {
+		return "", errors.New("some error")
+	}
+	return testProvisioningURI, nil
+}
+
+// Activate imitates TwoFactorAction Activate method behavior depending on
+// one defined.
+func (a *mockTwoFactorAction) Activate(ctx context.Context,
+	userName string, uri string, code string) ([]string, error) {
+	if a.behavior != "ok" {
+		return nil, errors.New("some error")
+	}
+	return []string{"abcd-efgh"}, nil
+}
+
+// Verify imitates TwoFactorAction Verify method behavior depending on one
+// defined.
+func (a *mockTwoFactorAction) Verify(ctx context.Context,
+	ip string, userName string, code string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+// Disable imitates TwoFactorAction Disable method behavior depending on one
+// defined.
+func (a *mockTwoFactorAction) Disable(ctx context.Context,
+	ip string, userName string, code string) error {
+	if a.behavior != "ok" {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+// newTwoFactor returns two-factor controller with given action behavior
+// and HTTP session of user that passed password check at given time.
+func newTwoFactor(
+	behavior string, since time.Time) (*TwoFactor, *sessions.Session) {
+	store := &storeMock{behavior: "ok"}
+	session := sessions.NewSession(store, SESSION_NAME)
+	session.Values = map[interface{}]interface{}{
+		pendingUserKey:       "test user",
+		pendingSinceKey:      since.Unix(),
+		pendingInvitationKey: "",
+	}
+	store.Save(nil, nil, session)
+	return &TwoFactor{
+		SessionStore:    store,
+		TwoFactorAction: &mockTwoFactorAction{behavior: behavior},
+	}, session
+}
+
+func TestTwoFactor_Verify(t *testing.T) {
+	Convey("Logs in user with one-time password", t, func() {
+		_, ctx := newFormContext(url.Values{"code": {"123456"}})
+		c, session := newTwoFactor("ok", time.Now())
+		c.Verify(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+		So(session.Values["loggedUser"], ShouldEqual, "test user")
+		So(session.Values, ShouldNotContainKey, pendingUserKey)
+
+		Convey("and redirects to invitation", func() {
+			_, ctx := newFormContext(url.Values{"code": {"123456"}})
+			c, session := newTwoFactor("ok", time.Now())
+			session.Values[pendingInvitationKey] = "test-token"
+			c.Verify(ctx)
+
+			So(ctx.Writer.Header().Get("Location"),
+				ShouldEqual, "/join/test-token")
+		})
+	})
+
+	Convey("Returns unauthorized if code is wrong", t, func() {
+		_, ctx := newFormContext(url.Values{"code": {"000000"}})
+		c, session := newTwoFactor("failure", time.Now())
+		c.Verify(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusUnauthorized)
+		So(ctx.MustGet("template"), ShouldEqual, "login-2fa.tmpl")
+		So(session.Values, ShouldNotContainKey, "loggedUser")
+	})
+
+	Convey("Redirects to index page if password check expired", t, func() {
+		_, ctx := newFormContext(url.Values{"code": {"123456"}})
+		c, session := newTwoFactor("ok", time.Now().Add(-2*pendingTTL))
+		c.Verify(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+		So(ctx.Writer.Header().Get("Location"), ShouldEqual, "/")
+		So(session.Values, ShouldNotContainKey, "loggedUser")
+	})
+}
+
+func TestTwoFactor_Setup(t *testing.T) {
+	Convey("Writes QR code of enrollment to context", t, func() {
+		_, ctx := newFormContext(url.Values{})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		c, session := newTwoFactor("ok", time.Now())
+		c.Setup(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		So(ctx.MustGet("template"), ShouldEqual, "account-2fa.tmpl")
+		params := ctx.MustGet("parameters").(gin.H)
+		So(params["secret"], ShouldEqual, "JBSWY3DPEHPK3PXP")
+		So(string(params["qr"].(template.URL)),
+			ShouldStartWith, "data:image/png;base64,")
+		So(session.Values[enrollmentKey], ShouldEqual, testProvisioningURI)
+	})
+
+	Convey("Shows enabled two-factor authentication", t, func() {
+		_, ctx := newFormContext(url.Values{})
+		ctx.Set("user", &entity.User{Name: "test user", TOTPSecret: "S"})
+		c, _ := newTwoFactor("ok", time.Now())
+		c.Setup(ctx)
+
+		So(ctx.MustGet("parameters").(gin.H)["enabled"], ShouldBeTrue)
+	})
+}
+
+func TestTwoFactor_Activate(t *testing.T) {
+	Convey("Shows recovery codes", t, func() {
+		_, ctx := newFormContext(url.Values{"code": {"123456"}})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		c, session := newTwoFactor("ok", time.Now())
+		session.Values[enrollmentKey] = testProvisioningURI
+		c.Activate(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusOK)
+		params := ctx.MustGet("parameters").(gin.H)
+		So(params["recoveryCodes"], ShouldResemble, []string{"abcd-efgh"})
+		So(session.Values, ShouldNotContainKey, enrollmentKey)
+	})
+
+	Convey("Returns bad request if code is wrong", t, func() {
+		_, ctx := newFormContext(url.Values{"code": {"000000"}})
+		ctx.Set("user", &entity.User{Name: "test user"})
+		c, session := newTwoFactor("failure", time.Now())
+		session.Values[enrollmentKey] = testProvisioningURI
+		c.Activate(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+		So(ctx.MustGet("parameters").(gin.H)["error"], ShouldNotBeEmpty)
+	})
+}
+
+func TestTwoFactor_Disable(t *testing.T) {
+	Convey("Redirects to account page", t, func() {
+		_, ctx := newFormContext(url.Values{"code": {"123456"}})
+		ctx.Set("user", &entity.User{Name: "test user", TOTPSecret: "S"})
+		c, _ := newTwoFactor("ok", time.Now())
+		c.Disable(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusSeeOther)
+	})
+
+	Convey("Returns bad request if code is wrong", t, func() {
+		_, ctx := newFormContext(url.Values{"code": {"000000"}})
+		ctx.Set("user", &entity.User{Name: "test user", TOTPSecret: "S"})
+		c, _ := newTwoFactor("failure", time.Now())
+		c.Disable(ctx)
+
+		So(ctx.Writer.Status(), ShouldEqual, http.StatusBadRequest)
+	})
+}
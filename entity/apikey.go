@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// APIScope is an operation API key is allowed to perform.
+type APIScope string
+
+// API key scopes.
+const (
+	// ScopeListSessions allows to list active sessions.
+	ScopeListSessions APIScope = "list-sessions"
+
+	// ScopeCreateSession allows to create OpenViDu sessions.
+	ScopeCreateSession APIScope = "create-session"
+
+	// ScopeGetToken allows to get tokens to join OpenViDu sessions.
+	ScopeGetToken APIScope = "get-token"
+)
+
+// APIScopes is a list of all API key scopes.
+var APIScopes = []APIScope{
+	ScopeListSessions, ScopeCreateSession, ScopeGetToken,
+}
+
+// ParseAPIScope returns API key scope by given string representation.
+func ParseAPIScope(scope string) (APIScope, error) {
+	for _, s := range APIScopes {
+		if string(s) == scope {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown API key scope %s", scope)
+}
+
+// APIKey is a credential that machine clients send in "Authorization:
+// Bearer" header to act on behalf of user.
+type APIKey struct {
+	ID       string
+	UserName string
+
+	// Name describes the client that uses the key.
+	Name string
+
+	// TokenHash is a hex encoded SHA-256 hash of the key. The key itself
+	// is shown to the user once on creation.
+	TokenHash string
+
+	Scopes    []APIScope
+	CreatedAt time.Time
+
+	// LastUsedAt is a time the key was accepted last. Zero if the key has
+	// not been used yet.
+	LastUsedAt time.Time
+}
+
+// Allows returns true if API key is allowed to perform given operation.
+func (k *APIKey) Allows(scope APIScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeys is a repository that stores API keys.
+type APIKeys interface {
+	// Add adds new API key to repository.
+	Add(key *APIKey) error
+
+	// Get returns API key by given token hash.
+	Get(tokenHash string) (*APIKey, error)
+
+	// Touch sets time API key with given token hash was used at.
+	Touch(tokenHash string, at time.Time) error
+
+	// List returns API keys of user with given name sorted by creation
+	// time.
+	List(userName string) ([]*APIKey, error)
+
+	// Delete removes API key with given ID of user with given name.
+	Delete(userName string, id string) error
+
+	// DeleteByUser removes all API keys of user with given name.
+	DeleteByUser(userName string) error
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseAPIScope(t *testing.T) {
+	Convey("Returns API key scope", t, func() {
+		scope, err := ParseAPIScope("get-token")
+
+		So(err, ShouldBeNil)
+		So(scope, ShouldEqual, ScopeGetToken)
+	})
+
+	Convey("Returns an error for unknown scope", t, func() {
+		_, err := ParseAPIScope("wrong")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "unknown API key scope")
+	})
+}
+
+func TestAPIKey_Allows(t *testing.T) {
+	Convey("Allows only granted scopes", t, func() {
+		k := &APIKey{Scopes: []APIScope{ScopeGetToken}}
+
+		So(k.Allows(ScopeGetToken), ShouldBeTrue)
+		So(k.Allows(ScopeCreateSession), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,122 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginFailures is a record of failed login attempts made by some user or
+// from some client IP.
+type LoginFailures struct {
+	// Count is a number of consecutive failures.
+	Count int
+
+	// Last is a time of last failure.
+	Last time.Time
+
+	// LockedUntil is a time when lockout ends. Zero if not locked.
+	LockedUntil time.Time
+}
+
+// Check returns an error if login attempt made at given time must be
+// refused: while attempts are locked or until progressive delay after last
+// failure passes. Delay starts with given one and doubles with every next
+// failure up to lockout period.
+func (f *LoginFailures) Check(
+	now time.Time, delay time.Duration, lockout time.Duration) error {
+	if now.Before(f.LockedUntil) {
+		return throttled(f.LockedUntil.Sub(now))
+	}
+	if f.Count == 0 || now.Sub(f.Last) > lockout {
+		return nil
+	}
+	wait := delay
+	for i := 1; i < f.Count && wait < lockout; i++ {
+		wait *= 2
+	}
+	if wait > lockout {
+		wait = lockout
+	}
+	if next := f.Last.Add(wait); now.Before(next) {
+		return throttled(next.Sub(now))
+	}
+	return nil
+}
+
+// Begin registers login attempt made at given time unless Check refuses
+// it or given limit of failures is reached by attempts in progress. Attempt
+// is counted as failure in advance, so parallel attempts are throttled like
+// consecutive ones; attempt that does not fail is uncounted by Undo.
+// Failures older than lockout period or than expired lock are forgotten.
+// Zero limit never refuses.
+func (f *LoginFailures) Begin(now time.Time,
+	limit int, delay time.Duration, lockout time.Duration) error {
+	if err := f.Check(now, delay, lockout); err != nil {
+		return err
+	}
+	if now.Sub(f.Last) > lockout || !f.LockedUntil.IsZero() {
+		f.Count, f.LockedUntil = 0, time.Time{}
+	}
+	if limit > 0 && f.Count >= limit {
+		return throttled(lockout)
+	}
+	f.Count++
+	f.Last = now
+	return nil
+}
+
+// Lock locks attempts for lockout period from given time if given limit of
+// failures is reached. Returns true if attempts are locked. Zero limit never
+// locks.
+func (f *LoginFailures) Lock(
+	now time.Time, limit int, lockout time.Duration) bool {
+	if limit <= 0 || f.Count < limit {
+		return false
+	}
+	f.LockedUntil = now.Add(lockout)
+	return true
+}
+
+// Undo uncounts attempt registered by Begin that has not failed.
+func (f *LoginFailures) Undo() {
+	if f.Count > 0 {
+		f.Count--
+	}
+}
+
+// Expired returns true if failures are forgotten at given time: attempts
+// are not locked and there are no failures within lockout period.
+func (f *LoginFailures) Expired(now time.Time, lockout time.Duration) bool {
+	if now.Before(f.LockedUntil) {
+		return false
+	}
+	return f.Count == 0 || now.Sub(f.Last) > lockout
+}
+
+// throttled returns an error of refused login attempt that can be retried
+// after given time.
+func throttled(wait time.Duration) error {
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return fmt.Errorf("too many failed login attempts, try again in %s",
+		wait.Round(time.Second))
+}
+
+// LoginAttempts is a repository interface that stores failed login attempts
+// by key, e.g. user name or client IP.
+type LoginAttempts interface {
+	// Get retrieves failures by given key. Returns zero value if there
+	// are none.
+	Get(key string) LoginFailures
+
+	// Update applies given function to failures by given key and stores
+	// the result unless the function returns an error, which is returned.
+	// Check and change of failures is atomic. Failures expired at given
+	// time are removed.
+	Update(key string, now time.Time,
+		update func(failures *LoginFailures) error) error
+
+	// Delete removes failures by given key.
+	Delete(key string)
+}
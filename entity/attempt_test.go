@@ -0,0 +1,137 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoginFailures_Begin(t *testing.T) {
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	Convey("Counts attempts in advance", t, func() {
+		f := &LoginFailures{}
+
+		So(f.Begin(now, 0, time.Second, time.Minute), ShouldBeNil)
+		So(f.Count, ShouldEqual, 1)
+		So(f.Last, ShouldResemble, now)
+
+		Convey("Refuses parallel attempt", func() {
+			So(f.Begin(now, 0, time.Second, time.Minute), ShouldNotBeNil)
+			So(f.Count, ShouldEqual, 1)
+		})
+
+		Convey("Uncounts attempt that has not failed", func() {
+			f.Undo()
+			So(f.Count, ShouldEqual, 0)
+			So(f.Begin(now, 0, time.Second, time.Minute), ShouldBeNil)
+		})
+	})
+
+	Convey("Forgets failures older than lockout period", t, func() {
+		f := &LoginFailures{Count: 2, Last: now}
+
+		So(f.Begin(now.Add(2*time.Minute), 0, time.Second, time.Minute),
+			ShouldBeNil)
+		So(f.Count, ShouldEqual, 1)
+	})
+
+	Convey("Refuses attempts in progress over limit", t, func() {
+		f := &LoginFailures{}
+
+		So(f.Begin(now, 2, 0, time.Minute), ShouldBeNil)
+		So(f.Begin(now, 2, 0, time.Minute), ShouldBeNil)
+		So(f.Begin(now, 2, 0, time.Minute), ShouldNotBeNil)
+		So(f.Count, ShouldEqual, 2)
+	})
+
+	Convey("Forgets failures after lock expires", t, func() {
+		f := &LoginFailures{Count: 3, Last: now,
+			LockedUntil: now.Add(time.Minute)}
+
+		So(f.Begin(now.Add(time.Minute), 3, 0, time.Minute), ShouldBeNil)
+		So(f.Count, ShouldEqual, 1)
+		So(f.LockedUntil.IsZero(), ShouldBeTrue)
+	})
+}
+
+func TestLoginFailures_Lock(t *testing.T) {
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	Convey("Locks when limit is reached", t, func() {
+		f := &LoginFailures{Count: 2, Last: now}
+
+		So(f.Lock(now, 3, time.Minute), ShouldBeFalse)
+		f.Count++
+		So(f.Lock(now, 3, time.Minute), ShouldBeTrue)
+		So(f.LockedUntil, ShouldResemble, now.Add(time.Minute))
+	})
+
+	Convey("Never locks with zero limit", t, func() {
+		f := &LoginFailures{Count: 100, Last: now}
+
+		So(f.Lock(now, 0, time.Minute), ShouldBeFalse)
+		So(f.LockedUntil.IsZero(), ShouldBeTrue)
+	})
+}
+
+func TestLoginFailures_Expired(t *testing.T) {
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	Convey("Expires without recent failures", t, func() {
+		So((&LoginFailures{}).Expired(now, time.Minute), ShouldBeTrue)
+		So((&LoginFailures{Count: 1, Last: now}).Expired(
+			now.Add(2*time.Minute), time.Minute), ShouldBeTrue)
+		So((&LoginFailures{Count: 1, Last: now}).Expired(
+			now.Add(time.Second), time.Minute), ShouldBeFalse)
+	})
+
+	Convey("Does not expire while locked", t, func() {
+		f := &LoginFailures{Count: 3, Last: now.Add(-time.Hour),
+			LockedUntil: now.Add(time.Minute)}
+
+		So(f.Expired(now, time.Minute), ShouldBeFalse)
+	})
+}
+
+func TestLoginFailures_Check(t *testing.T) {
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	Convey("Returns no error without failures", t, func() {
+		So((&LoginFailures{}).Check(now, time.Second, time.Minute), ShouldBeNil)
+	})
+
+	Convey("Returns an error while locked", t, func() {
+		f := &LoginFailures{Count: 3, Last: now,
+			LockedUntil: now.Add(time.Minute)}
+		err := f.Check(now.Add(30*time.Second), time.Second, time.Minute)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual,
+			"too many failed login attempts, try again in 30s")
+
+		Convey("Returns no error after lockout", func() {
+			So(f.Check(now.Add(2*time.Minute), time.Second, time.Minute),
+				ShouldBeNil)
+		})
+	})
+
+	Convey("Returns an error until progressive delay passes", t, func() {
+		f := &LoginFailures{Count: 3, Last: now}
+
+		So(f.Check(now.Add(3*time.Second), time.Second, time.Minute),
+			ShouldNotBeNil)
+		So(f.Check(now.Add(4*time.Second), time.Second, time.Minute),
+			ShouldBeNil)
+	})
+
+	Convey("Limits delay by lockout period", t, func() {
+		f := &LoginFailures{Count: 30, Last: now}
+
+		So(f.Check(now.Add(59*time.Second), time.Second, time.Minute),
+			ShouldNotBeNil)
+		So(f.Check(now.Add(time.Minute), time.Second, time.Minute),
+			ShouldBeNil)
+	})
+}
@@ -0,0 +1,156 @@
+package entity
+
+import "time"
+
+// AuditAction is a kind of audited event.
+type AuditAction string
+
+// Audited events.
+const (
+	// AuditLogin is a successful login of user.
+	AuditLogin AuditAction = "login"
+
+	// AuditLoginFailed is a failed or refused login of user.
+	AuditLoginFailed AuditAction = "login-failed"
+
+	// AuditLockout is a lockout of account or client IP after failed
+	// logins.
+	AuditLockout AuditAction = "lockout"
+
+	// AuditUnlock is a removal of account lockout.
+	AuditUnlock AuditAction = "unlock"
+
+	// AuditSessionCreate is a creation of session by its owner.
+	AuditSessionCreate AuditAction = "session-create"
+
+	// AuditSessionJoin is a join of participant to session.
+	AuditSessionJoin AuditAction = "session-join"
+
+	// AuditSessionLeave is a leave of participant from session.
+	AuditSessionLeave AuditAction = "session-leave"
+
+	// AuditSessionClose is a close of session by its owner, moderator or
+	// admin.
+	AuditSessionClose AuditAction = "session-close"
+
+	// AuditOwnerChange is a handover of session to another owner.
+	AuditOwnerChange AuditAction = "owner-change"
+
+	// AuditRecordingStart is a start of session recording by its owner.
+	AuditRecordingStart AuditAction = "recording-start"
+
+	// AuditRecordingStop is a stop of session recording by its owner.
+	AuditRecordingStop AuditAction = "recording-stop"
+
+	// AuditKick is a disconnection of participant by moderator.
+	AuditKick AuditAction = "kick"
+
+	// AuditUnpublish is a stop of participant stream by moderator.
+	AuditUnpublish AuditAction = "unpublish"
+
+	// AuditRoleChange is a change of user role.
+	AuditRoleChange AuditAction = "role-change"
+
+	// AuditUserAdd is a creation of user by admin.
+	AuditUserAdd AuditAction = "user-add"
+
+	// AuditUserRemove is a removal of user by admin.
+	AuditUserRemove AuditAction = "user-remove"
+
+	// AuditPasswordReset is a reset of user password by admin.
+	AuditPasswordReset AuditAction = "password-reset"
+
+	// AuditImport is an import of users and sessions by admin.
+	AuditImport AuditAction = "import"
+
+	// AuditApprove is an approval of signed up account by moderator.
+	AuditApprove AuditAction = "approve"
+
+	// AuditTwoFactorEnable is an activation of two-factor authentication.
+	AuditTwoFactorEnable AuditAction = "2fa-enable"
+
+	// AuditTwoFactorDisable is a deactivation of two-factor
+	// authentication.
+	AuditTwoFactorDisable AuditAction = "2fa-disable"
+
+	// AuditAPIKeyCreate is a creation of API key.
+	AuditAPIKeyCreate AuditAction = "api-key-create"
+
+	// AuditAPIKeyRevoke is a revocation of API key.
+	AuditAPIKeyRevoke AuditAction = "api-key-revoke"
+)
+
+// AuditEvent is a record of security or session event: who did what to
+// whom and when.
+type AuditEvent struct {
+	Time   time.Time   `json:"time"`
+	Action AuditAction `json:"action"`
+
+	// Actor is a name of user that performed the action. Empty for
+	// actions of admin commands.
+	Actor string `json:"actor,omitempty"`
+
+	// Session is a name of session the action applies to.
+	Session string `json:"session,omitempty"`
+
+	// Target is a user, client IP, connection or stream the action applies
+	// to.
+	Target string `json:"target,omitempty"`
+
+	IP        string `json:"ip,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// Details describe the action, e.g. old and new role of user.
+	Details string `json:"details,omitempty"`
+
+	// Error is a reason the action failed. Empty if it succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditFilter is a set of criteria used for querying of audit events.
+//
+// Zero value of any field means that criteria is not applied.
+type AuditFilter struct {
+	// User matches events performed by or applied to user with given name.
+	User string
+
+	// Session matches events of session with given name.
+	Session string
+
+	// From and To limit time of events to [From, To) range.
+	From time.Time
+	To   time.Time
+
+	// Offset is a number of newest matching events to skip.
+	Offset int
+
+	// Limit is a maximal number of returned events.
+	Limit int
+}
+
+// Matches returns true if given event satisfies filter criteria.
+func (f AuditFilter) Matches(e *AuditEvent) bool {
+	switch {
+	case f.User != "" && e.Actor != f.User && e.Target != f.User:
+		return false
+	case f.Session != "" && e.Session != f.Session:
+		return false
+	case !f.From.IsZero() && e.Time.Before(f.From):
+		return false
+	case !f.To.IsZero() && !e.Time.Before(f.To):
+		return false
+	default:
+		return true
+	}
+}
+
+// AuditSink is an append-only storage of audit events. Events can not be
+// changed or removed once recorded.
+type AuditSink interface {
+	// Record appends given event to audit log.
+	Record(event *AuditEvent) error
+
+	// Query returns events matching given filter, newest first, and total
+	// number of matching events.
+	Query(filter AuditFilter) ([]*AuditEvent, int, error)
+}
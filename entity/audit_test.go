@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAuditFilter_Matches(t *testing.T) {
+	at := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	e := &AuditEvent{
+		Time:    at,
+		Action:  AuditKick,
+		Actor:   "moderator",
+		Session: "test session",
+		Target:  "alice",
+	}
+
+	Convey("Matches event by any criteria", t, func() {
+		So(AuditFilter{}.Matches(e), ShouldBeTrue)
+		So(AuditFilter{User: "moderator"}.Matches(e), ShouldBeTrue)
+		So(AuditFilter{User: "alice"}.Matches(e), ShouldBeTrue)
+		So(AuditFilter{Session: "test session"}.Matches(e), ShouldBeTrue)
+		So(AuditFilter{From: at, To: at.Add(time.Second)}.Matches(e),
+			ShouldBeTrue)
+	})
+
+	Convey("Does not match event out of criteria", t, func() {
+		So(AuditFilter{User: "bob"}.Matches(e), ShouldBeFalse)
+		So(AuditFilter{Session: "other"}.Matches(e), ShouldBeFalse)
+		So(AuditFilter{From: at.Add(time.Second)}.Matches(e), ShouldBeFalse)
+		So(AuditFilter{To: at}.Matches(e), ShouldBeFalse)
+	})
+}
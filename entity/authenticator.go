@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrUnknownUser is returned by Authenticator that does not know user,
+	// so the next authenticator of a chain is tried.
+	ErrUnknownUser = errors.New("login incorrect")
+
+	// ErrAuthUnavailable is returned by Authenticator whose backend can not
+	// be reached. Such failures do not count as failed login attempts.
+	ErrAuthUnavailable = errors.New("authentication backend unavailable")
+)
+
+// Authenticator verifies user credentials against a user store, e.g.
+// repository of local accounts or directory server.
+type Authenticator interface {
+	// Authenticate returns user with given name if given password is
+	// correct. Users of external stores have non-empty Subject.
+	Authenticate(
+		ctx context.Context, username string, password string) (*User, error)
+}
+
+// HighestRole returns the highest role given values are mapped to by given
+// roles, or given default role if it is higher or no value is mapped.
+func HighestRole(values []string,
+	roles map[string]UserRole, def UserRole) UserRole {
+	role := def
+	for _, v := range values {
+		if r, ok := roles[v]; ok && r > role {
+			role = r
+		}
+	}
+	return role
+}
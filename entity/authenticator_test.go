@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHighestRole(t *testing.T) {
+	roles := map[string]UserRole{"staff": Publisher, "admins": Moderator}
+
+	Convey("Returns the highest mapped role", t, func() {
+		So(HighestRole([]string{"staff", "admins", "other"}, roles,
+			Subscriber), ShouldEqual, Moderator)
+		So(HighestRole([]string{"staff"}, roles, Subscriber),
+			ShouldEqual, Publisher)
+	})
+
+	Convey("Returns default role", t, func() {
+		So(HighestRole([]string{"other"}, roles, Subscriber),
+			ShouldEqual, Subscriber)
+		So(HighestRole([]string{"staff"}, roles, Moderator),
+			ShouldEqual, Moderator)
+	})
+}
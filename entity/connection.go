@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// Connection is a participant connected to OpenViDu session.
+type Connection struct {
+	ID string
+
+	// Participant is a nickname participant joined session with, taken
+	// from server data of its token.
+	Participant string
+
+	// UserName is a login name of user that joined session, taken from
+	// server data of its token. Empty for connections with tokens issued
+	// by API, which do not belong to users of session.
+	UserName string
+
+	Role      UserRole
+	CreatedAt time.Time
+
+	// Streams are IDs of media streams participant publishes.
+	Streams []string
+}
+
+// Publishing returns true if participant publishes any media stream.
+func (c *Connection) Publishing() bool {
+	return len(c.Streams) > 0
+}
@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConnection_Publishing(t *testing.T) {
+	Convey("Returns true if participant publishes streams", t, func() {
+		So((&Connection{Streams: []string{"test stream"}}).Publishing(),
+			ShouldBeTrue)
+		So((&Connection{}).Publishing(), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,53 @@
+package entity
+
+import "time"
+
+// GuestPrefix is a prefix of names of guest users, that separates them from
+// names of registered users.
+const GuestPrefix = "guest:"
+
+// Guest is an anonymous visitor that joins OpenViDu session without
+// pre-provisioned account.
+type Guest struct {
+	User        *User
+	SessionName string
+
+	// CreatedAt is a time when guest was registered. Guests that do not
+	// join their session in time are removed.
+	CreatedAt time.Time
+}
+
+// NewGuest returns new guest of session with given name, display name and
+// role. Given ID is appended to user name, so guests with the same display
+// name do not collide.
+func NewGuest(id string, displayName string, sessionName string,
+	role UserRole) *Guest {
+	return &Guest{
+		User: &User{
+			Name:        GuestPrefix + displayName + "#" + id,
+			DisplayName: displayName,
+			Role:        role,
+			Guest:       true,
+		},
+		SessionName: sessionName,
+	}
+}
+
+// Guests is a repository that stores guests of OpenViDu sessions.
+type Guests interface {
+	// Add adds new guest to repository.
+	Add(guest *Guest) error
+
+	// Get returns guest by given user name.
+	Get(name string) (*Guest, error)
+
+	// List returns all guests.
+	List() ([]*Guest, error)
+
+	// Delete removes guest with given user name from repository.
+	Delete(name string) error
+
+	// DeleteBySession removes all guests of session with given name from
+	// repository.
+	DeleteBySession(sessionName string) error
+}
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewGuest(t *testing.T) {
+	Convey("Returns new guest", t, func() {
+		g := NewGuest("1", "test guest", "test session", 1)
+
+		So(g.SessionName, ShouldEqual, "test session")
+
+		Convey("with guest user", func() {
+			So(g.User, ShouldNotBeNil)
+			So(g.User.Name, ShouldEqual, "guest:test guest#1")
+			So(g.User.DisplayName, ShouldEqual, "test guest")
+			So(g.User.Role, ShouldEqual, 1)
+			So(g.User.Guest, ShouldBeTrue)
+		})
+	})
+}
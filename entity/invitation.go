@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// Invitation is a grant to join OpenViDu session with given role, that is
+// shared by session owner as a link.
+type Invitation struct {
+	ID          string
+	SessionName string
+
+	// SessionID is an ID of invited session on OpenViDu server, so
+	// invitation does not admit to later session of the same name.
+	SessionID string
+
+	Role        UserRole
+	CreatedBy   string
+	ExpiresAt   time.Time
+
+	// MaxUses is a number of times the invitation can be used.
+	// Zero means unlimited number of uses.
+	MaxUses int
+	Uses    int
+	Revoked bool
+}
+
+// Check returns an error if invitation can not be used at given time.
+func (e *Invitation) Check(now time.Time) error {
+	switch {
+	case e.Revoked:
+		return errors.New("invitation revoked")
+	case !now.Before(e.ExpiresAt):
+		return errors.New("invitation expired")
+	case e.MaxUses > 0 && e.Uses >= e.MaxUses:
+		return errors.New("invitation already used")
+	default:
+		return nil
+	}
+}
+
+// Invitations is a repository that stores invitations to OpenViDu sessions.
+type Invitations interface {
+	// Add adds new invitation to repository.
+	Add(invitation *Invitation) error
+
+	// Get returns invitation by given ID.
+	Get(id string) (*Invitation, error)
+
+	// Use increments number of uses of invitation with given ID if it can
+	// be used at given time.
+	Use(id string, now time.Time) (*Invitation, error)
+
+	// Revoke marks invitation with given ID as revoked.
+	Revoke(id string) error
+
+	// List returns all invitations to session with given name.
+	List(sessionName string) ([]*Invitation, error)
+}
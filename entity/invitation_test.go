@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInvitation_Check(t *testing.T) {
+	now := time.Now()
+
+	Convey("Returns no error for valid invitation", t, func() {
+		i := &Invitation{ExpiresAt: now.Add(time.Minute), MaxUses: 1}
+
+		So(i.Check(now), ShouldBeNil)
+	})
+
+	Convey("Returns revoked error", t, func() {
+		i := &Invitation{ExpiresAt: now.Add(time.Minute), Revoked: true}
+		err := i.Check(now)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invitation revoked")
+	})
+
+	Convey("Returns expired error", t, func() {
+		i := &Invitation{ExpiresAt: now}
+		err := i.Check(now)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invitation expired")
+	})
+
+	Convey("Returns used error", t, func() {
+		i := &Invitation{ExpiresAt: now.Add(time.Minute), MaxUses: 2, Uses: 2}
+		err := i.Check(now)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invitation already used")
+	})
+
+	Convey("Unlimited invitation can be used many times", t, func() {
+		i := &Invitation{ExpiresAt: now.Add(time.Minute), Uses: 100}
+
+		So(i.Check(now), ShouldBeNil)
+	})
+}
@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Meeting is a scheduled OpenViDu session that can be joined only within
+// its time window.
+type Meeting struct {
+	SessionName string
+	Title       string
+	Owner       *User
+	Start       time.Time
+	End         time.Time
+
+	// Location is a time zone the meeting was scheduled in.
+	Location *time.Location
+
+	// Invited is a list of names of users invited to meeting.
+	Invited []string
+}
+
+// IsInvited returns true if user with given name is owner of meeting or is
+// invited to it.
+func (e *Meeting) IsInvited(userName string) bool {
+	if e.Owner != nil && e.Owner.Name == userName {
+		return true
+	}
+	for _, name := range e.Invited {
+		if name == userName {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckWindow returns an error if meeting can not be joined at given time.
+// Participants can join meeting earlier than it starts by given margin.
+func (e *Meeting) CheckWindow(now time.Time, earlyJoin time.Duration) error {
+	if now.Before(e.Start.Add(-earlyJoin)) {
+		return fmt.Errorf("meeting %s starts at %s", e.SessionName,
+			e.Start.In(e.location()).Format(time.RFC1123))
+	}
+	if !now.Before(e.End) {
+		return fmt.Errorf("meeting %s is over", e.SessionName)
+	}
+	return nil
+}
+
+// location returns time zone of meeting.
+func (e *Meeting) location() *time.Location {
+	if e.Location == nil {
+		return time.UTC
+	}
+	return e.Location
+}
+
+// Meetings is a repository that stores scheduled meetings.
+type Meetings interface {
+	// Add adds new meeting to repository.
+	Add(meeting *Meeting) error
+
+	// Get returns meeting by given session name.
+	Get(sessionName string) (*Meeting, error)
+
+	// Delete removes meeting with given session name from repository.
+	Delete(sessionName string) error
+
+	// List returns all meetings ordered by start time.
+	List() ([]*Meeting, error)
+}
@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMeeting_IsInvited(t *testing.T) {
+	Convey("Returns true for owner and invited users", t, func() {
+		m := &Meeting{
+			Owner:   &User{Name: "test owner"},
+			Invited: []string{"test user"},
+		}
+
+		So(m.IsInvited("test owner"), ShouldBeTrue)
+		So(m.IsInvited("test user"), ShouldBeTrue)
+
+		Convey("Returns false for other users", func() {
+			So(m.IsInvited("other user"), ShouldBeFalse)
+		})
+	})
+}
+
+func TestMeeting_CheckWindow(t *testing.T) {
+	start := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	m := &Meeting{
+		SessionName: "test meeting",
+		Start:       start,
+		End:         start.Add(time.Hour),
+	}
+
+	Convey("Returns no error within window", t, func() {
+		So(m.CheckWindow(start, 0), ShouldBeNil)
+		So(m.CheckWindow(start.Add(59*time.Minute), 0), ShouldBeNil)
+	})
+
+	Convey("Returns no error within early join margin", t, func() {
+		So(m.CheckWindow(start.Add(-5*time.Minute), 10*time.Minute),
+			ShouldBeNil)
+	})
+
+	Convey("Returns not started error", t, func() {
+		err := m.CheckWindow(start.Add(-time.Minute), 0)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"meeting test meeting starts at Mon, 01 Jan 2018 10:00:00 UTC")
+	})
+
+	Convey("Returns start time in meeting time zone", t, func() {
+		loc := time.FixedZone("TST", 3*60*60)
+		m := &Meeting{SessionName: "test meeting", Start: start,
+			End: start.Add(time.Hour), Location: loc}
+		err := m.CheckWindow(start.Add(-time.Minute), 0)
+
+		So(err.Error(), ShouldContainSubstring, "13:00:00 TST")
+	})
+
+	Convey("Returns over error", t, func() {
+		err := m.CheckWindow(start.Add(time.Hour), 0)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "meeting test meeting is over")
+	})
+}
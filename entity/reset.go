@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// PasswordReset is a single use grant to set new password of user, that is
+// sent to the user by mail as a link.
+type PasswordReset struct {
+	// TokenHash is a hex encoded SHA-256 hash of reset token. The token
+	// itself is known only to the user.
+	TokenHash string
+	UserName  string
+	ExpiresAt time.Time
+}
+
+// Check returns an error if password reset can not be used at given time.
+func (e *PasswordReset) Check(now time.Time) error {
+	if !now.Before(e.ExpiresAt) {
+		return errors.New("password reset link expired")
+	}
+	return nil
+}
+
+// PasswordResets is a repository that stores password resets.
+type PasswordResets interface {
+	// Add adds new password reset to repository.
+	Add(reset *PasswordReset) error
+
+	// Get returns password reset by given token hash.
+	Get(tokenHash string) (*PasswordReset, error)
+
+	// Take returns password reset by given token hash and removes it from
+	// repository, so it can not be used twice.
+	Take(tokenHash string) (*PasswordReset, error)
+
+	// DeleteByUser removes all password resets of user with given name.
+	DeleteByUser(userName string) error
+}
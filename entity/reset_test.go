@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPasswordReset_Check(t *testing.T) {
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	r := &PasswordReset{ExpiresAt: now.Add(time.Hour)}
+
+	Convey("Returns no error before expiration", t, func() {
+		So(r.Check(now), ShouldBeNil)
+	})
+
+	Convey("Returns an error after expiration", t, func() {
+		err := r.Check(now.Add(time.Hour))
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "password reset link expired")
+	})
+}
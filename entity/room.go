@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"sort"
+	"time"
+)
+
+// RoomEventType is a kind of change of session state pushed to its
+// participants.
+type RoomEventType string
+
+// Types of room events.
+const (
+	// RoomParticipants is a change of session owner or participants.
+	RoomParticipants RoomEventType = "participants"
+
+	// RoomRecording is a start or stop of session recording.
+	RoomRecording RoomEventType = "recording"
+
+	// RoomClosed is a close of session. No events follow it.
+	RoomClosed RoomEventType = "closed"
+)
+
+// RoomEvent is a change of session state. It carries full list of
+// participants, so receiver that missed previous events is up to date.
+type RoomEvent struct {
+	Type    RoomEventType `json:"type"`
+	Session string        `json:"session"`
+	Owner   string        `json:"owner,omitempty"`
+
+	// Participants are names of session subscribers sorted by name.
+	Participants []string `json:"participants"`
+
+	// Recording is true if session is being recorded.
+	Recording bool      `json:"recording"`
+	Time      time.Time `json:"time"`
+}
+
+// NewRoomEvent returns event of given type with current state of given
+// session.
+func NewRoomEvent(t RoomEventType, session *Session) *RoomEvent {
+	e := &RoomEvent{
+		Type:         t,
+		Session:      session.Name,
+		Participants: make([]string, 0, len(session.Subscribers)),
+		Recording:    session.RecordingID != "",
+		Time:         time.Now(),
+	}
+	if session.Owner != nil {
+		e.Owner = session.Owner.Name
+	}
+	for name := range session.Subscribers {
+		e.Participants = append(e.Participants, name)
+	}
+	sort.Strings(e.Participants)
+	return e
+}
+
+// RoomEvents delivers room events to subscribers of sessions.
+type RoomEvents interface {
+	// Publish delivers given event to subscribers of its session without
+	// waiting for them.
+	Publish(event *RoomEvent)
+
+	// Subscribe returns channel of events of session with given name and
+	// function that cancels subscription. Channel is closed after
+	// RoomClosed event or cancellation.
+	Subscribe(sessionName string) (<-chan *RoomEvent, func())
+}
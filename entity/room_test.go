@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewRoomEvent(t *testing.T) {
+	Convey("Returns event with state of session", t, func() {
+		s := NewSession()
+		s.Name = "test session"
+		s.Owner = &User{Name: "owner"}
+		s.AddParticipant(&User{Name: "bob"})
+		s.AddParticipant(&User{Name: "alice"})
+		e := NewRoomEvent(RoomParticipants, s)
+
+		So(e.Type, ShouldEqual, RoomParticipants)
+		So(e.Session, ShouldEqual, "test session")
+		So(e.Owner, ShouldEqual, "owner")
+		So(e.Participants, ShouldResemble, []string{"alice", "bob"})
+		So(e.Recording, ShouldBeFalse)
+		So(e.Time.IsZero(), ShouldBeFalse)
+	})
+
+	Convey("Returns recording status of session", t, func() {
+		s := NewSession()
+		s.RecordingID = "test recording"
+
+		So(NewRoomEvent(RoomRecording, s).Recording, ShouldBeTrue)
+	})
+}
@@ -1,9 +1,50 @@
 package entity
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 )
 
+// SessionAccess is an access mode of session. Can be "PUBLIC", "PRIVATE" or
+// "PASSCODE".
+type SessionAccess uint8
+
+// Access modes of session.
+const (
+	// AccessPublic allows any logged user to join session.
+	AccessPublic SessionAccess = iota
+
+	// AccessPrivate allows only users from session allow list to join it.
+	AccessPrivate
+
+	// AccessPasscode allows users that know passcode to join session.
+	AccessPasscode
+)
+
+// accessModes is a list of string representations of session access modes.
+var accessModes = []string{"PUBLIC", "PRIVATE", "PASSCODE"}
+
+// String defines string representation of session access mode.
+func (a SessionAccess) String() string {
+	return accessModes[uint8(a)]
+}
+
+// ParseSessionAccess returns session access mode by given string
+// representation.
+func ParseSessionAccess(access string) (SessionAccess, error) {
+	for i, a := range accessModes {
+		if a == access {
+			return SessionAccess(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown session access %s", access)
+}
+
 // Session is OpenViDu session value object performed by publisher for
 // subscribers.
 type Session struct {
@@ -11,13 +52,118 @@ type Session struct {
 	Name        string
 	Owner       *User
 	Subscribers map[string]*User
+	CreatedAt   time.Time
+
+	// AllowGuests is true for public session that anonymous guests can join
+	// without invitation.
+	AllowGuests bool
+
+	// Access is a mode of access to session for logged users.
+	Access SessionAccess
+
+	// AllowList is a set of names of users that can join session
+	// regardless of its access mode.
+	AllowList map[string]bool
+
+	// RecordingID is an ID of active recording of session on OpenViDu
+	// server. Empty if session is not recorded.
+	RecordingID string
+
+	// passcodeHash is a SHA-256 hash of session passcode.
+	passcodeHash string
 }
 
 // NewSession returns new OpenViDu session value object.
 func NewSession() *Session {
 	return &Session{
 		Subscribers: make(map[string]*User),
+		AllowList:   make(map[string]bool),
+	}
+}
+
+// Copy returns deep copy of session, so it can be read and changed without
+// affecting the original.
+func (e *Session) Copy() *Session {
+	c := *e
+	c.Owner = copyUser(e.Owner)
+	c.Subscribers = make(map[string]*User, len(e.Subscribers))
+	for name, user := range e.Subscribers {
+		c.Subscribers[name] = copyUser(user)
+	}
+	c.AllowList = make(map[string]bool, len(e.AllowList))
+	for name, allowed := range e.AllowList {
+		c.AllowList[name] = allowed
+	}
+	return &c
+}
+
+// copyUser returns copy of given user or nil if it is nil.
+func copyUser(user *User) *User {
+	if user == nil {
+		return nil
+	}
+	c := *user
+	return &c
+}
+
+// SessionPolicy is an access policy a session is created with.
+type SessionPolicy struct {
+	// Access is a mode of access to session for logged users.
+	Access SessionAccess
+
+	// AllowList are names of users that can join session regardless of its
+	// access mode.
+	AllowList []string
+
+	// Passcode is a passcode required to join session in AccessPasscode
+	// mode.
+	Passcode string
+
+	// AllowGuests opens session for anonymous guests.
+	AllowGuests bool
+}
+
+// Validate returns an error if session can not be created with policy.
+func (p *SessionPolicy) Validate() error {
+	if p.Access == AccessPasscode && p.Passcode == "" {
+		return errors.New("passcode is empty")
+	}
+	return nil
+}
+
+// Apply sets policy to given new session.
+func (p *SessionPolicy) Apply(session *Session) {
+	session.Access = p.Access
+	session.AllowGuests = p.AllowGuests
+	for _, name := range p.AllowList {
+		session.AllowList[name] = true
+	}
+	if p.Passcode != "" {
+		session.SetPasscode(p.Passcode)
+	}
+}
+
+// SetPasscode sets passcode required to join session in AccessPasscode mode.
+func (e *Session) SetPasscode(passcode string) {
+	e.passcodeHash = hashPasscode(passcode)
+}
+
+// Authorize returns an error if user with given name can not join session
+// with given passcode.
+func (e *Session) Authorize(userName string, passcode string) error {
+	if (e.Owner != nil && e.Owner.Name == userName) || e.AllowList[userName] {
+		return nil
+	}
+	switch e.Access {
+	case AccessPrivate:
+		return fmt.Errorf("session %s is private", e.Name)
+	case AccessPasscode:
+		if passcode == "" || subtle.ConstantTimeCompare(
+			[]byte(hashPasscode(passcode)), []byte(e.passcodeHash)) != 1 {
+			return errors.New("passcode incorrect")
+		}
 	}
+	return nil
 }
 
 // AddParticipant adds participant to session subscribers list.
@@ -34,7 +180,122 @@ func (e *Session) RemoveParticipant(user *User) error {
 	return nil
 }
 
-// Sessions is a repository that stores OpenViDu sessions.
+// ParticipantsCount returns number of session participants including the
+// session owner.
+func (e *Session) ParticipantsCount() int {
+	count := len(e.Subscribers)
+	if e.Owner != nil {
+		count++
+	}
+	return count
+}
+
+// sessionRecord is a serialized representation of session. Passwords of
+// participants are never serialized.
+type sessionRecord struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	Owner        *participant    `json:"owner"`
+	Subscribers  []*participant  `json:"subscribers"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	AllowGuests  bool            `json:"allowGuests"`
+	Access       SessionAccess   `json:"access"`
+	AllowList    map[string]bool `json:"allowList"`
+	PasscodeHash string          `json:"passcodeHash,omitempty"`
+	RecordingID  string          `json:"recordingId,omitempty"`
+}
+
+// participant is a serialized representation of session participant.
+type participant struct {
+	Name  string   `json:"name"`
+	Role  UserRole `json:"role"`
+	Guest bool     `json:"guest,omitempty"`
+}
+
+// newParticipant returns serialized representation of given user.
+func newParticipant(user *User) *participant {
+	if user == nil {
+		return nil
+	}
+	return &participant{Name: user.Name, Role: user.Role, Guest: user.Guest}
+}
+
+// user returns user represented by participant.
+func (p *participant) user() *User {
+	if p == nil {
+		return nil
+	}
+	return &User{Name: p.Name, Role: p.Role, Guest: p.Guest}
+}
+
+// MarshalJSON returns JSON representation of session including its passcode
+// hash, so session can be restored after application restart.
+//
+// implements json.Marshaler interface.
+func (e *Session) MarshalJSON() ([]byte, error) {
+	r := sessionRecord{
+		ID:           e.ID,
+		Name:         e.Name,
+		Owner:        newParticipant(e.Owner),
+		Subscribers:  make([]*participant, 0, len(e.Subscribers)),
+		CreatedAt:    e.CreatedAt,
+		AllowGuests:  e.AllowGuests,
+		Access:       e.Access,
+		AllowList:    e.AllowList,
+		PasscodeHash: e.passcodeHash,
+		RecordingID:  e.RecordingID,
+	}
+	for _, u := range e.Subscribers {
+		r.Subscribers = append(r.Subscribers, newParticipant(u))
+	}
+	return json.Marshal(r)
+}
+
+// UnmarshalJSON restores session from its JSON representation.
+//
+// implements json.Unmarshaler interface.
+func (e *Session) UnmarshalJSON(data []byte) error {
+	var r sessionRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	*e = *NewSession()
+	e.ID = r.ID
+	e.Name = r.Name
+	e.Owner = r.Owner.user()
+	e.CreatedAt = r.CreatedAt
+	e.AllowGuests = r.AllowGuests
+	e.Access = r.Access
+	e.passcodeHash = r.PasscodeHash
+	e.RecordingID = r.RecordingID
+	for _, p := range r.Subscribers {
+		e.AddParticipant(p.user())
+	}
+	for name, allowed := range r.AllowList {
+		e.AllowList[name] = allowed
+	}
+	return nil
+}
+
+// SessionFilter is a set of criteria used for listing of sessions.
+//
+// Zero value of any field means that criteria is not applied.
+type SessionFilter struct {
+	// Owner is a name of session owner.
+	Owner string
+
+	// Name is a part of session name to search for, case insensitive.
+	Name string
+
+	// Offset is a number of sessions to skip.
+	Offset int
+
+	// Limit is a maximum number of sessions to return.
+	Limit int
+}
+
+// Sessions is a repository that stores OpenViDu sessions. Sessions it
+// returns are copies, so they are changed only through its methods.
 type Sessions interface {
 
 	// Add new session to repository by given session ID, session name, and
@@ -47,7 +308,36 @@ type Sessions interface {
 	// Get returns session by given session name.
 	Get(sessionName string) (*Session, error)
 
+	// Join adds given user to participants of session by given session
+	// name.
+	Join(sessionName string, user *User) error
+
 	// Leave removes participant from session by given session name and user
 	// name.
 	Leave(sessionName string, userName string) error
+
+	// SetOwner hands session by given session name over to given
+	// participant. Previous owner becomes participant of session.
+	SetOwner(sessionName string, owner *User) error
+
+	// List returns sessions that match given filter, newest first, along
+	// with total number of matched sessions.
+	List(filter SessionFilter) ([]*Session, int, error)
+
+	// Restore puts given previously stored session to repository.
+	Restore(session *Session) error
+
+	// Create puts given new session to repository along with its access
+	// policy, so it can not be joined before the policy is set.
+	Create(session *Session) error
+
+	// Modify applies given change to session by given session name
+	// atomically. Session is not changed if change returns an error.
+	Modify(sessionName string, change func(session *Session) error) error
+}
+
+// hashPasscode returns hex encoded SHA-256 hash of given passcode.
+func hashPasscode(passcode string) string {
+	sum := sha256.Sum256([]byte(passcode))
+	return hex.EncodeToString(sum[:])
 }
@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"encoding/json"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -31,6 +32,26 @@ func TestSession_AddParticipant(t *testing.T) {
 	})
 }
 
+func TestSession_Copy(t *testing.T) {
+	Convey("Returns independent copy of session", t, func() {
+		s := NewSession()
+		s.Owner = &User{Name: "owner"}
+		s.AddParticipant(&User{Name: "test user"})
+		s.AllowList["test user"] = true
+		s.SetPasscode("test passcode")
+		c := s.Copy()
+
+		c.Owner.Name = "other"
+		c.AddParticipant(&User{Name: "other user"})
+		c.AllowList["other user"] = true
+		So(s.Owner.Name, ShouldEqual, "owner")
+		So(s.Subscribers, ShouldHaveLength, 1)
+		So(s.AllowList, ShouldHaveLength, 1)
+		So(c.Subscribers, ShouldHaveLength, 2)
+		So(c.passcodeHash, ShouldEqual, s.passcodeHash)
+	})
+}
+
 func TestSession_RemoveParticipant(t *testing.T) {
 	Convey("Removes session participant", t, func() {
 		user := &User{Name: "test user"}
@@ -52,3 +73,146 @@ func TestSession_RemoveParticipant(t *testing.T) {
 		})
 	})
 }
+
+func TestSession_ParticipantsCount(t *testing.T) {
+	Convey("Returns number of participants", t, func() {
+		s := NewSession()
+
+		So(s.ParticipantsCount(), ShouldEqual, 0)
+
+		Convey("including session owner", func() {
+			s.Owner = &User{Name: "test owner"}
+			s.AddParticipant(&User{Name: "test user"})
+
+			So(s.ParticipantsCount(), ShouldEqual, 2)
+		})
+	})
+}
+
+func TestSession_Authorize(t *testing.T) {
+	Convey("Public session can be joined by anyone", t, func() {
+		s := NewSession()
+
+		So(s.Authorize("test user", ""), ShouldBeNil)
+	})
+
+	Convey("Private session", t, func() {
+		s := NewSession()
+		s.Name = "test session"
+		s.Owner = &User{Name: "test owner"}
+		s.Access = AccessPrivate
+		s.AllowList["allowed user"] = true
+
+		Convey("can be joined by owner and allowed users", func() {
+			So(s.Authorize("test owner", ""), ShouldBeNil)
+			So(s.Authorize("allowed user", ""), ShouldBeNil)
+		})
+
+		Convey("can not be joined by other users", func() {
+			err := s.Authorize("test user", "")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"session test session is private")
+		})
+	})
+
+	Convey("Passcode protected session", t, func() {
+		s := NewSession()
+		s.Access = AccessPasscode
+		s.SetPasscode("test passcode")
+
+		Convey("can be joined with correct passcode", func() {
+			So(s.Authorize("test user", "test passcode"), ShouldBeNil)
+		})
+
+		Convey("can not be joined with wrong passcode", func() {
+			err := s.Authorize("test user", "wrong passcode")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "passcode incorrect")
+		})
+
+		Convey("can not be joined without passcode", func() {
+			So(s.Authorize("test user", ""), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSessionPolicy(t *testing.T) {
+	Convey("Sets access policy to session", t, func() {
+		p := &SessionPolicy{
+			Access:      AccessPasscode,
+			AllowList:   []string{"allowed user"},
+			Passcode:    "test passcode",
+			AllowGuests: true,
+		}
+		s := NewSession()
+
+		So(p.Validate(), ShouldBeNil)
+		p.Apply(s)
+		So(s.Access, ShouldEqual, AccessPasscode)
+		So(s.AllowGuests, ShouldBeTrue)
+		So(s.Authorize("allowed user", ""), ShouldBeNil)
+		So(s.Authorize("test user", "test passcode"), ShouldBeNil)
+		So(s.Authorize("test user", "wrong"), ShouldNotBeNil)
+	})
+
+	Convey("Returns an error if passcode is empty", t, func() {
+		p := &SessionPolicy{Access: AccessPasscode}
+
+		So(p.Validate(), ShouldNotBeNil)
+	})
+}
+
+func TestSession_JSON(t *testing.T) {
+	Convey("Restores session from JSON", t, func() {
+		s := NewSession()
+		s.ID = "test id"
+		s.Name = "test session"
+		s.Owner = &User{Name: "test owner", Password: "secret", Role: 1}
+		s.AddParticipant(&User{Name: "test user", Password: "secret"})
+		s.Access = AccessPasscode
+		s.AllowList["test friend"] = true
+		s.SetPasscode("test passcode")
+		s.RecordingID = "test recording"
+
+		data, err := json.Marshal(s)
+		So(err, ShouldBeNil)
+		So(string(data), ShouldNotContainSubstring, "secret")
+
+		restored := &Session{}
+		So(json.Unmarshal(data, restored), ShouldBeNil)
+		So(restored.ID, ShouldEqual, "test id")
+		So(restored.Owner.Name, ShouldEqual, "test owner")
+		So(restored.Owner.Role, ShouldEqual, 1)
+		So(restored.Subscribers["test user"], ShouldNotBeNil)
+		So(restored.Access, ShouldEqual, AccessPasscode)
+		So(restored.AllowList["test friend"], ShouldBeTrue)
+		So(restored.RecordingID, ShouldEqual, "test recording")
+
+		Convey("with passcode", func() {
+			So(restored.Authorize("other", "test passcode"), ShouldBeNil)
+			So(restored.Authorize("other", "wrong"), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Returns an error", t, func() {
+		So(json.Unmarshal([]byte(`{"id": 1}`), &Session{}), ShouldNotBeNil)
+	})
+}
+
+func TestParseSessionAccess(t *testing.T) {
+	Convey("Returns access mode by its string representation", t, func() {
+		access, err := ParseSessionAccess("PASSCODE")
+
+		So(err, ShouldBeNil)
+		So(access, ShouldEqual, AccessPasscode)
+		So(access.String(), ShouldEqual, "PASSCODE")
+	})
+
+	Convey("Returns an error", t, func() {
+		_, err := ParseSessionAccess("wrong")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "unknown session access wrong")
+	})
+}
@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// SessionEventType is a kind of session lifecycle event.
+type SessionEventType string
+
+// Session lifecycle events.
+const (
+	// SessionCreated is a creation of session by its owner.
+	SessionCreated SessionEventType = "session-created"
+
+	// ParticipantJoined is a join of participant to session.
+	ParticipantJoined SessionEventType = "participant-joined"
+
+	// ParticipantLeft is a leave of participant from session.
+	ParticipantLeft SessionEventType = "participant-left"
+
+	// SessionClosed is a close of session by its owner, moderator or
+	// application, or at the end of its meeting.
+	SessionClosed SessionEventType = "session-closed"
+
+	// OwnerChanged is a handover of session to one of its participants.
+	OwnerChanged SessionEventType = "owner-changed"
+
+	// RecordingStarted is a start of session recording by its owner.
+	RecordingStarted SessionEventType = "recording-started"
+
+	// RecordingStopped is a stop of session recording by its owner.
+	RecordingStopped SessionEventType = "recording-stopped"
+)
+
+// SessionEvent is a domain event of session lifecycle. It is published
+// after the change is made.
+type SessionEvent struct {
+	Type SessionEventType
+	Time time.Time
+
+	// SessionID is an ID of session on OpenViDu server.
+	SessionID string
+
+	// SessionName is a name of session.
+	SessionName string
+
+	// User is a name of user the event is about: owner of created
+	// session, joined or left participant, new owner, or user that closed
+	// session. Empty if session is closed by application, admin command or
+	// end of meeting.
+	User string
+
+	// Details describes circumstances of event, e.g. "meeting ended". May
+	// be empty.
+	Details string
+
+	// PreviousOwner is a name of previous owner of session. Set only for
+	// OwnerChanged event.
+	PreviousOwner string
+}
+
+// SessionEventHandler is a subscriber of session events.
+type SessionEventHandler func(ctx context.Context, event *SessionEvent)
+
+// SessionEvents is a bus of session events.
+type SessionEvents interface {
+	// Publish delivers given event to all subscribers. Given context is a
+	// context of request that made the change.
+	Publish(ctx context.Context, event *SessionEvent)
+}
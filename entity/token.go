@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// TokenPair is a pair of tokens issued to client that does not use HTTP
+// session, e.g. single-page or mobile application.
+type TokenPair struct {
+	// AccessToken is a signed JWT sent in "Authorization: Bearer" header.
+	AccessToken string
+
+	// ExpiresIn is a lifetime of access token.
+	ExpiresIn time.Duration
+
+	// RefreshToken is exchanged for new token pair once the access token
+	// expires. It can be used once.
+	RefreshToken string
+}
+
+// RefreshToken is a single use grant to issue new token pair to user.
+type RefreshToken struct {
+	// TokenHash is a hex encoded SHA-256 hash of refresh token. The token
+	// itself is known only to the client.
+	TokenHash string
+	UserName  string
+
+	// Family identifies chain of refresh tokens rotated from one login.
+	// The whole chain is revoked when used token is presented again.
+	Family string
+
+	ExpiresAt time.Time
+	Used      bool
+}
+
+// Check returns an error if refresh token can not be used at given time.
+func (t *RefreshToken) Check(now time.Time) error {
+	switch {
+	case t.Used:
+		return errors.New("refresh token already used")
+	case !now.Before(t.ExpiresAt):
+		return errors.New("refresh token expired")
+	default:
+		return nil
+	}
+}
+
+// RefreshTokens is a repository that stores refresh tokens.
+type RefreshTokens interface {
+	// Add adds new refresh token to repository.
+	Add(token *RefreshToken) error
+
+	// Use marks refresh token with given hash as used and returns it as
+	// it was before, so the caller can tell if it was used already.
+	Use(tokenHash string) (*RefreshToken, error)
+
+	// DeleteFamily removes all refresh tokens of given family.
+	DeleteFamily(family string) error
+
+	// DeleteByUser removes all refresh tokens of user with given name.
+	DeleteByUser(userName string) error
+}
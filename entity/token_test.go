@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRefreshToken_Check(t *testing.T) {
+	now := time.Now()
+
+	Convey("Returns no error for valid refresh token", t, func() {
+		r := &RefreshToken{ExpiresAt: now.Add(time.Minute)}
+
+		So(r.Check(now), ShouldBeNil)
+	})
+
+	Convey("Returns used error", t, func() {
+		r := &RefreshToken{ExpiresAt: now.Add(time.Minute), Used: true}
+		err := r.Check(now)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "already used")
+	})
+
+	Convey("Returns expired error", t, func() {
+		r := &RefreshToken{ExpiresAt: now}
+		err := r.Check(now)
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "expired")
+	})
+}
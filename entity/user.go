@@ -1,26 +1,164 @@
 package entity
 
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
 // UserRole is a role of user. Can be "SUBSCRIBER", "PUBLISHER" or "MODERATOR".
 type UserRole uint8
 
+// User roles.
+const (
+	// Subscriber receives media.
+	Subscriber UserRole = iota
+
+	// Publisher sends and receives media.
+	Publisher
+
+	// Moderator is a publisher that also approves signed up accounts.
+	Moderator
+)
+
+// roles is a list of string representations of user roles.
+var roles = []string{"SUBSCRIBER", "PUBLISHER", "MODERATOR"}
+
 // String defines string representation of user role.
 func (r UserRole) String() string {
-	roles := []string{"SUBSCRIBER", "PUBLISHER", "MODERATOR"}
 	return roles[uint8(r)]
 }
 
+// ParseUserRole returns user role by given string representation.
+func ParseUserRole(role string) (UserRole, error) {
+	for i, r := range roles {
+		if r == role {
+			return UserRole(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown user role %s", role)
+}
+
 // User is a data of example`s user.
 type User struct {
-	Name     string
+	Name string
+
+	// Password is a bcrypt hash of password, see SetPassword. Users with
+	// empty or malformed hash can not log in with password.
 	Password string
-	Role     UserRole
+
+	Role UserRole
+
+	// DisplayName is a name shown to other users instead of login. Login
+	// is shown if it is empty.
+	DisplayName string
+
+	// Email is an address password reset links are sent to. May be empty.
+	Email string
+
+	// Pending is true for signed up user awaiting approval of moderator.
+	// Pending users can not log in.
+	Pending bool
+
+	// TOTPSecret is a base32 secret of time-based one-time passwords of
+	// the second login step. Two-factor authentication is disabled if
+	// empty.
+	TOTPSecret string
+
+	// TOTPCounter is a time step of the last accepted one-time password,
+	// so the password can not be used again.
+	TOTPCounter uint64
+
+	// RecoveryCodes are SHA-256 hashes of unused recovery codes that
+	// replace one-time password once each.
+	RecoveryCodes []string
+
+	// Subject identifies user of external store, e.g. OpenID Connect
+	// identity provider or directory server, as "<issuer> <subject>".
+	// Empty for local accounts.
+	Subject string
+
+	// Guest is true for anonymous visitor that has no account.
+	Guest bool
+}
+
+// TwoFactor returns true if user logs in with one-time password as second
+// factor.
+func (u *User) TwoFactor() bool {
+	return u.TOTPSecret != ""
+}
+
+// MinPasswordLength is a minimal length of password.
+const MinPasswordLength = 8
+
+// MaxPasswordLength is a maximal length of password in bytes that bcrypt
+// hashes.
+const MaxPasswordLength = 72
+
+// CheckPassword returns an error if given password is too weak or too long
+// to be hashed.
+func CheckPassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters long",
+			MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("password must be at most %d bytes long",
+			MaxPasswordLength)
+	}
+	return nil
+}
+
+// PasswordCost is a bcrypt cost of password hashes set by SetPassword.
+var PasswordCost = bcrypt.DefaultCost
+
+// SetPassword stores bcrypt hash of given password.
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword(
+		[]byte(password), PasswordCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hash)
+	return nil
+}
+
+// ValidPassword returns true if given password matches stored hash.
+func (u *User) ValidPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword(
+		[]byte(u.Password), []byte(password)) == nil
+}
+
+// Title returns name of user shown to other users.
+func (u *User) Title() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return u.Name
 }
 
 // Users is a repository interface that stores user data.
 type Users interface {
-	// Add adds users data to repository
-	Add(username string, password string, role uint8)
+	// Add adds users data to repository. Returns an error if password is
+	// too weak.
+	Add(username string, password string, role uint8) error
 
 	// Get retrieves user from repository.
 	Get(username string) (*User, error)
+
+	// Create adds given user to repository. Returns an error if user with
+	// the same name already exists.
+	Create(user *User) error
+
+	// Modify applies given change to copy of user with given name and
+	// stores the copy unless the change returns an error, which is
+	// returned. No other change of the user happens in between, so the
+	// change may check and consume single use values.
+	Modify(username string, change func(user *User) error) error
+
+	// Delete removes user with given name from repository.
+	Delete(username string) error
+
+	// List returns all users sorted by name.
+	List() ([]*User, error)
 }
@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUserRole_String(t *testing.T) {
+	Convey("Returns string representation of role", t, func() {
+		So(UserRole(0).String(), ShouldEqual, "SUBSCRIBER")
+		So(UserRole(1).String(), ShouldEqual, "PUBLISHER")
+		So(UserRole(2).String(), ShouldEqual, "MODERATOR")
+	})
+}
+
+func TestParseUserRole(t *testing.T) {
+	Convey("Returns role by its string representation", t, func() {
+		role, err := ParseUserRole("PUBLISHER")
+
+		So(err, ShouldBeNil)
+		So(role, ShouldEqual, 1)
+	})
+
+	Convey("Returns an error", t, func() {
+		_, err := ParseUserRole("wrong role")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "unknown user role wrong role")
+	})
+}
+
+func TestUser_Title(t *testing.T) {
+	Convey("Returns display name", t, func() {
+		u := &User{Name: "test", DisplayName: "Test User"}
+
+		So(u.Title(), ShouldEqual, "Test User")
+
+		Convey("Returns login if display name is empty", func() {
+			u.DisplayName = ""
+
+			So(u.Title(), ShouldEqual, "test")
+		})
+	})
+}
+
+func TestCheckPassword(t *testing.T) {
+	Convey("Accepts password", t, func() {
+		So(CheckPassword("test password"), ShouldBeNil)
+	})
+
+	Convey("Returns too short password error", t, func() {
+		err := CheckPassword("pass")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "at least 8 characters")
+	})
+
+	Convey("Returns too long password error", t, func() {
+		err := CheckPassword(strings.Repeat("a", 73))
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "at most 72 bytes")
+	})
+}
+
+func TestUser_SetPassword(t *testing.T) {
+	Convey("Stores hash of password", t, func() {
+		u := &User{Name: "test"}
+
+		So(u.SetPassword("test password"), ShouldBeNil)
+		So(u.Password, ShouldNotEqual, "test password")
+		So(u.ValidPassword("test password"), ShouldBeTrue)
+		So(u.ValidPassword("wrong password"), ShouldBeFalse)
+	})
+
+	Convey("Does not accept password that is not hashed", t, func() {
+		u := &User{Name: "test", Password: "test password"}
+
+		So(u.ValidPassword("test password"), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,142 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// asyncBuffer is a number of session events queued for asynchronous
+// subscriber.
+const asyncBuffer = 256
+
+// Bus is an in-process bus of session events.
+//
+// Synchronous subscribers are called by Publish in order of subscription,
+// so they must be fast. Every asynchronous subscriber gets events in order
+// of publishing from its own goroutine, so slow subscriber delays neither
+// actions nor other subscribers; events it can not keep up with are
+// dropped. Panic of subscriber is logged and never breaks the action that
+// published the event.
+//
+// implements entity.SessionEvents interface.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []entity.SessionEventHandler
+	queues   []chan queuedEvent
+	wg       sync.WaitGroup
+	closed   bool
+}
+
+// queuedEvent is a session event queued for asynchronous subscriber along
+// with context it is delivered with.
+type queuedEvent struct {
+	ctx   context.Context
+	event *entity.SessionEvent
+}
+
+// NewBus returns new bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds synchronous subscriber.
+func (b *Bus) Subscribe(handler entity.SessionEventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// SubscribeAsync adds asynchronous subscriber. Subscriber is not added to
+// closed bus.
+func (b *Bus) SubscribeAsync(handler entity.SessionEventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	queue := make(chan queuedEvent, asyncBuffer)
+	b.queues = append(b.queues, queue)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for q := range queue {
+			call(q.ctx, handler, q.event)
+		}
+	}()
+}
+
+// Publish queues given event for asynchronous subscribers and calls
+// synchronous ones. Time of event is set to current time if it is zero.
+// Asynchronous subscribers get context that keeps log entry and request ID
+// of given one but is never canceled. Events are not delivered by closed
+// bus.
+//
+// implements entity.SessionEvents interface.
+func (b *Bus) Publish(ctx context.Context, event *entity.SessionEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return
+	}
+	handlers := b.handlers
+	if len(b.queues) > 0 {
+		queued := queuedEvent{ctx: detach(ctx), event: event}
+		for _, queue := range b.queues {
+			select {
+			case queue <- queued:
+			default:
+				logging.FromContext(ctx).
+					WithField("event", string(event.Type)).
+					Warn("session event dropped for slow subscriber")
+			}
+		}
+	}
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		call(ctx, handler, event)
+	}
+}
+
+// Close stops delivery of events and waits until asynchronous subscribers
+// handle events queued before.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, queue := range b.queues {
+		close(queue)
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
+
+// call calls given subscriber and logs its panic.
+func call(ctx context.Context,
+	handler entity.SessionEventHandler, event *entity.SessionEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.FromContext(ctx).
+				WithField("event", string(event.Type)).
+				Errorf("session event subscriber panicked: %v", r)
+		}
+	}()
+	handler(ctx, event)
+}
+
+// detach returns context with log entry and request ID of given one that
+// is never canceled.
+func detach(ctx context.Context) context.Context {
+	detached := logging.WithRequestID(
+		context.Background(), logging.RequestID(ctx))
+	return logging.NewContext(detached, logging.FromContext(ctx))
+}
@@ -0,0 +1,102 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+func TestBus(t *testing.T) {
+	Convey("Delivers events to all subscribers in order", t, func() {
+		b := NewBus()
+		var calls []string
+		b.Subscribe(func(ctx context.Context, e *entity.SessionEvent) {
+			calls = append(calls, "first "+e.User)
+		})
+		b.Subscribe(func(ctx context.Context, e *entity.SessionEvent) {
+			calls = append(calls, "second "+e.User)
+		})
+		var async []string
+		var requestIDs []string
+		b.SubscribeAsync(func(ctx context.Context, e *entity.SessionEvent) {
+			async = append(async, e.User)
+			requestIDs = append(requestIDs, logging.RequestID(ctx))
+		})
+
+		ctx, cancel := context.WithCancel(
+			logging.WithRequestID(context.Background(), "test id"))
+		joined := &entity.SessionEvent{
+			Type: entity.ParticipantJoined, User: "alice"}
+		b.Publish(ctx, joined)
+		cancel()
+		b.Publish(ctx, &entity.SessionEvent{
+			Type: entity.ParticipantLeft, User: "bob"})
+		b.Close()
+
+		So(calls, ShouldResemble, []string{
+			"first alice", "second alice", "first bob", "second bob"})
+		So(async, ShouldResemble, []string{"alice", "bob"})
+		So(requestIDs, ShouldResemble, []string{"test id", "test id"})
+		So(joined.Time, ShouldNotBeZeroValue)
+	})
+
+	Convey("Survives panic of subscriber", t, func() {
+		b := NewBus()
+		b.Subscribe(func(ctx context.Context, e *entity.SessionEvent) {
+			panic("test panic")
+		})
+		b.SubscribeAsync(func(ctx context.Context, e *entity.SessionEvent) {
+			panic("test panic")
+		})
+		called := 0
+		b.Subscribe(func(ctx context.Context, e *entity.SessionEvent) {
+			called++
+		})
+
+		So(func() {
+			b.Publish(context.Background(), &entity.SessionEvent{})
+			b.Close()
+		}, ShouldNotPanic)
+		So(called, ShouldEqual, 1)
+	})
+
+	Convey("Drops events for slow subscriber", t, func() {
+		b := NewBus()
+		var mu sync.Mutex
+		mu.Lock()
+		received := 0
+		b.SubscribeAsync(func(ctx context.Context, e *entity.SessionEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			received++
+		})
+		for i := 0; i < asyncBuffer+10; i++ {
+			b.Publish(context.Background(), &entity.SessionEvent{})
+		}
+		mu.Unlock()
+		b.Close()
+
+		So(received, ShouldBeBetweenOrEqual, asyncBuffer, asyncBuffer+1)
+	})
+
+	Convey("Delivers nothing after close", t, func() {
+		b := NewBus()
+		called := 0
+		b.Subscribe(func(ctx context.Context, e *entity.SessionEvent) {
+			called++
+		})
+		b.Close()
+		b.Close()
+		b.SubscribeAsync(func(ctx context.Context, e *entity.SessionEvent) {
+			called++
+		})
+
+		b.Publish(context.Background(), &entity.SessionEvent{})
+		So(called, ShouldEqual, 0)
+	})
+}
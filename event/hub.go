@@ -0,0 +1,114 @@
+// Package event provides in-process delivery of events of the example
+// application.
+package event
+
+import (
+	"sync"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// hubBuffer is a number of room events kept for slow subscriber.
+const hubBuffer = 16
+
+// Hub delivers room events to subscribers of sessions.
+//
+// implements entity.RoomEvents interface.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan *entity.RoomEvent]struct{}
+	closed      bool
+}
+
+// NewHub returns new hub without subscribers.
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[string]map[chan *entity.RoomEvent]struct{}),
+	}
+}
+
+// Publish delivers given event to subscribers of its session. Oldest
+// pending event of slow subscriber is dropped, as every event carries full
+// state of session. Subscriptions end with RoomClosed event.
+//
+// implements entity.RoomEvents interface.
+func (h *Hub) Publish(event *entity.RoomEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[event.Session] {
+		deliver(ch, event)
+		if event.Type == entity.RoomClosed {
+			close(ch)
+		}
+	}
+	if event.Type == entity.RoomClosed {
+		delete(h.subscribers, event.Session)
+	}
+}
+
+// Subscribe returns channel of events of session with given name and
+// function that cancels subscription. Channel of closed hub is closed
+// immediately.
+//
+// implements entity.RoomEvents interface.
+func (h *Hub) Subscribe(
+	sessionName string) (<-chan *entity.RoomEvent, func()) {
+	ch := make(chan *entity.RoomEvent, hubBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subscribers[sessionName] == nil {
+		h.subscribers[sessionName] = make(map[chan *entity.RoomEvent]struct{})
+	}
+	h.subscribers[sessionName][ch] = struct{}{}
+	return ch, func() { h.unsubscribe(sessionName, ch) }
+}
+
+// Close ends all subscriptions and refuses new ones, so streams of events
+// do not hold HTTP server shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, set := range h.subscribers {
+		for ch := range set {
+			close(ch)
+		}
+	}
+	h.subscribers = make(map[string]map[chan *entity.RoomEvent]struct{})
+}
+
+// unsubscribe removes and closes given channel of session with given name
+// unless it is removed already.
+func (h *Hub) unsubscribe(sessionName string, ch chan *entity.RoomEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	set := h.subscribers[sessionName]
+	if _, ok := set[ch]; !ok {
+		return
+	}
+	delete(set, ch)
+	close(ch)
+	if len(set) == 0 {
+		delete(h.subscribers, sessionName)
+	}
+}
+
+// deliver sends given event to given channel without blocking, dropping
+// oldest pending events if channel is full.
+func deliver(ch chan *entity.RoomEvent, event *entity.RoomEvent) {
+	for {
+		select {
+		case ch <- event:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
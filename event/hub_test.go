@@ -0,0 +1,72 @@
+package event
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestHub(t *testing.T) {
+	Convey("Delivers events to subscribers of session", t, func() {
+		h := NewHub()
+		first, cancelFirst := h.Subscribe("test session")
+		second, _ := h.Subscribe("test session")
+		other, cancelOther := h.Subscribe("other session")
+		defer cancelOther()
+
+		h.Publish(&entity.RoomEvent{
+			Type: entity.RoomParticipants, Session: "test session"})
+
+		So((<-first).Type, ShouldEqual, entity.RoomParticipants)
+		So((<-second).Type, ShouldEqual, entity.RoomParticipants)
+		So(other, ShouldBeEmpty)
+
+		Convey("Ends subscription on cancel", func() {
+			cancelFirst()
+			cancelFirst()
+			_, ok := <-first
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Ends subscriptions on close of session", func() {
+			h.Publish(&entity.RoomEvent{
+				Type: entity.RoomClosed, Session: "test session"})
+
+			So((<-first).Type, ShouldEqual, entity.RoomClosed)
+			_, ok := <-first
+			So(ok, ShouldBeFalse)
+			cancelFirst()
+		})
+	})
+
+	Convey("Keeps newest events for slow subscriber", t, func() {
+		h := NewHub()
+		events, cancel := h.Subscribe("test session")
+		defer cancel()
+		for i := 0; i < hubBuffer+5; i++ {
+			h.Publish(&entity.RoomEvent{
+				Type:         entity.RoomParticipants,
+				Session:      "test session",
+				Participants: make([]string, i),
+			})
+		}
+
+		So(events, ShouldHaveLength, hubBuffer)
+		So((<-events).Participants, ShouldHaveLength, 5)
+	})
+
+	Convey("Ends all subscriptions on close", t, func() {
+		h := NewHub()
+		events, cancel := h.Subscribe("test session")
+		h.Close()
+		cancel()
+
+		_, ok := <-events
+		So(ok, ShouldBeFalse)
+		events, _ = h.Subscribe("test session")
+		_, ok = <-events
+		So(ok, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,45 @@
+package event
+
+import (
+	"context"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Rooms is a subscriber of session events that publishes state of changed
+// sessions as room events, so streams of rooms follow the same events as
+// audit and metrics.
+type Rooms struct {
+	// SessionRepo is a repository state of changed sessions is read from.
+	SessionRepo entity.Sessions
+
+	// Events receives room events of changed sessions.
+	Events entity.RoomEvents
+}
+
+// Handle publishes close of session, its recording status after start or
+// stop of recording, or its current owner and participants after other
+// session event. It must be subscribed synchronously, so room
+// events follow changes in order.
+func (r *Rooms) Handle(ctx context.Context, e *entity.SessionEvent) {
+	if e.Type == entity.SessionClosed {
+		r.Events.Publish(&entity.RoomEvent{
+			Type:         entity.RoomClosed,
+			Session:      e.SessionName,
+			Participants: []string{},
+			Time:         e.Time,
+		})
+		return
+	}
+	session, err := r.SessionRepo.Get(e.SessionName)
+	if err != nil {
+		return
+	}
+	t := entity.RoomParticipants
+	if e.Type == entity.RecordingStarted || e.Type == entity.RecordingStopped {
+		t = entity.RoomRecording
+	}
+	room := entity.NewRoomEvent(t, session)
+	room.Time = e.Time
+	r.Events.Publish(room)
+}
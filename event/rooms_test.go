@@ -0,0 +1,73 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+func TestRooms_Handle(t *testing.T) {
+	Convey("Publishes state of changed session", t, func() {
+		h := NewHub()
+		events, cancel := h.Subscribe("test session")
+		defer cancel()
+		sessionRepo := repository.NewSessionsRepository()
+		bus := NewBus()
+		bus.Subscribe((&Rooms{SessionRepo: sessionRepo, Events: h}).Handle)
+		publish := func(t entity.SessionEventType) {
+			bus.Publish(context.Background(), &entity.SessionEvent{
+				Type:        t,
+				SessionName: "test session",
+			})
+		}
+
+		sessionRepo.Add("test id", "test session", &entity.User{Name: "owner"})
+		publish(entity.SessionCreated)
+		So((<-events).Owner, ShouldEqual, "owner")
+
+		sessionRepo.Join("test session", &entity.User{Name: "alice"})
+		publish(entity.ParticipantJoined)
+		e := <-events
+		So(e.Type, ShouldEqual, entity.RoomParticipants)
+		So(e.Participants, ShouldResemble, []string{"alice"})
+		So(e.Time.IsZero(), ShouldBeFalse)
+
+		sessionRepo.SetOwner("test session", &entity.User{Name: "alice"})
+		publish(entity.OwnerChanged)
+		e = <-events
+		So(e.Owner, ShouldEqual, "alice")
+		So(e.Participants, ShouldResemble, []string{"owner"})
+
+		sessionRepo.Modify("test session", func(s *entity.Session) error {
+			s.RecordingID = "test recording"
+			return nil
+		})
+		publish(entity.RecordingStarted)
+		e = <-events
+		So(e.Type, ShouldEqual, entity.RoomRecording)
+		So(e.Recording, ShouldBeTrue)
+
+		sessionRepo.Delete("test session")
+		publish(entity.SessionClosed)
+		e = <-events
+		So(e.Type, ShouldEqual, entity.RoomClosed)
+		So(e.Participants, ShouldBeEmpty)
+	})
+
+	Convey("Publishes nothing for unknown session", t, func() {
+		h := NewHub()
+		events, cancel := h.Subscribe("test session")
+		defer cancel()
+		r := &Rooms{SessionRepo: repository.NewSessionsRepository(), Events: h}
+
+		r.Handle(context.Background(), &entity.SessionEvent{
+			Type:        entity.ParticipantJoined,
+			SessionName: "test session",
+		})
+		So(events, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,75 @@
+// Package logging provides structured JSON logging with request correlation.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader is a HTTP header that carries request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// contextKey is a type of keys of values stored in context by this package.
+type contextKey int
+
+const (
+	// entryKey is a key of log entry stored in context.
+	entryKey contextKey = iota
+
+	// requestIDKey is a key of request ID stored in context.
+	requestIDKey
+)
+
+// Configure sets up standard logger to write JSON records of given level
+// to given writer.
+func Configure(out io.Writer, level string) error {
+	l, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logrus.SetOutput(out)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	logrus.SetLevel(l)
+	return nil
+}
+
+// NewRequestID returns new random request ID.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns copy of given context that carries given request ID
+// and log entry annotated with it.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
+	return NewContext(ctx, FromContext(ctx).WithField("request_id", requestID))
+}
+
+// RequestID returns request ID carried by given context or empty string if
+// there is no one.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// NewContext returns copy of given context that carries given log entry.
+func NewContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey, entry)
+}
+
+// FromContext returns log entry carried by given context or entry of
+// standard logger if there is no one.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(entryKey).(*logrus.Entry); ok {
+		return entry
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
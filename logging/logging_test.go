@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConfigure(t *testing.T) {
+	Convey("Writes JSON records of given level", t, func() {
+		out := &bytes.Buffer{}
+		err := Configure(out, "warn")
+		So(err, ShouldBeNil)
+
+		logrus.Info("skipped")
+		logrus.Warn("written")
+
+		var record map[string]interface{}
+		So(json.Unmarshal(out.Bytes(), &record), ShouldBeNil)
+		So(record["msg"], ShouldEqual, "written")
+		So(record["level"], ShouldEqual, "warning")
+
+		Convey("Returns an error for unknown level", func() {
+			So(Configure(out, "wrong"), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestNewRequestID(t *testing.T) {
+	Convey("Returns unique IDs", t, func() {
+		So(NewRequestID(), ShouldHaveLength, 32)
+		So(NewRequestID(), ShouldNotEqual, NewRequestID())
+	})
+}
+
+func TestWithRequestID(t *testing.T) {
+	Convey("Annotates context with request ID", t, func() {
+		ctx := WithRequestID(context.Background(), "test id")
+
+		So(RequestID(ctx), ShouldEqual, "test id")
+		So(FromContext(ctx).Data["request_id"], ShouldEqual, "test id")
+	})
+
+	Convey("Returns defaults for empty context", t, func() {
+		So(RequestID(context.Background()), ShouldBeEmpty)
+		So(FromContext(context.Background()), ShouldNotBeNil)
+	})
+}
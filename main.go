@@ -1,21 +1,114 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/flexconstructor/openvidu-tutorial/cli"
+	"github.com/flexconstructor/openvidu-tutorial/config"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
 	"github.com/flexconstructor/openvidu-tutorial/route"
 	"github.com/flexconstructor/openvidu-tutorial/service"
+	"github.com/flexconstructor/openvidu-tutorial/tracing"
 )
 
 // Is a OpenViDu GoLang tutorial.
 func main() {
-	router := route.InitRouter(&service.Client{
-		OpenViDuURL: "https://openvidu-server-kms:8443",
-		Login:       "OPENVIDUAPP",
-		Password:    "MY_SECRET",
+	healthcheck := flag.Bool("healthcheck", false,
+		"check that running application is alive and exit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: %s [flags] [command]\n\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), "\n"+cli.Usage)
+	}
+	flag.Parse()
+	if *healthcheck {
+		os.Exit(checkHealth())
+	}
+
+	cfg, err := config.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if flag.NArg() > 0 {
+		os.Exit(runAdmin(cfg, flag.Args()))
+	}
+	if err = logging.Configure(os.Stdout, cfg.LogLevel); err != nil {
+		log.Fatal(err)
+	}
+	stopTracing, err := tracing.Init(context.Background(), cfg.TracingExporter)
+	if err != nil {
+		log.Fatal(err)
+	}
+	router := route.InitRouter(cfg, &service.Client{
+		OpenViDuURL: cfg.OpenViDuURL,
+		Login:       cfg.OpenViDuLogin,
+		Password:    cfg.OpenViDuSecret,
 	})
 	router.LoadHTMLGlob("/resources/templates/*.tmpl")
 	router.Static("/images", "resources/static/images")
 	router.StaticFile("/style.css", "resources/static//style.css")
 	router.StaticFile("/openvidu-browser-1.1.0.js",
 		"resources/static/openvidu-browser-1.1.0.js")
-	router.Run()
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	err = router.Serve(signals)
+	if e := stopTracing(context.Background()); e != nil {
+		log.Print(e)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runAdmin runs admin command given by its arguments and returns exit code
+// of the command. Log goes to stderr, so it does not mix with command
+// results.
+func runAdmin(cfg *config.Config, args []string) int {
+	if err := logging.Configure(os.Stderr, cfg.LogLevel); err != nil {
+		log.Print(err)
+		return 1
+	}
+	err := route.RunAdmin(cfg, &service.Client{
+		OpenViDuURL: cfg.OpenViDuURL,
+		Login:       cfg.OpenViDuLogin,
+		Password:    cfg.OpenViDuSecret,
+	}, args, os.Stdin, os.Stdout)
+	switch {
+	case err == cli.ErrUsage:
+		flag.Usage()
+		return 2
+	case err != nil:
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
+
+// checkHealth requests liveness endpoint of application running on the same
+// host and returns exit code of the check.
+func checkHealth() int {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	resp, err := http.Get("http://localhost:" + port + "/healthz")
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("health check failed with status %d", resp.StatusCode)
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,185 @@
+// Package metrics provides Prometheus metrics of the example application.
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// namespace is a namespace of all application metrics.
+const namespace = "openvidu_tutorial"
+
+var (
+	// httpRequestDuration is a histogram of HTTP requests latency.
+	httpRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"route", "method", "status"},
+	)
+
+	// openViDuRequestDuration is a histogram of OpenViDu server requests
+	// latency.
+	openViDuRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "openvidu_request_duration_seconds",
+			Help:      "Latency of OpenViDu server requests by method.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+
+	// openViDuErrors is a counter of failed OpenViDu server requests.
+	openViDuErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "openvidu_request_errors_total",
+			Help:      "Number of failed OpenViDu server requests by method.",
+		},
+		[]string{"method"},
+	)
+
+	// logins is a counter of login attempts.
+	logins = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Number of login attempts by result.",
+		},
+		[]string{"result"},
+	)
+
+	// sessionEvents is a counter of session lifecycle events.
+	sessionEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "session_events_total",
+			Help:      "Number of session lifecycle events by type.",
+		},
+		[]string{"type"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(httpRequestDuration, openViDuRequestDuration,
+		openViDuErrors, logins, sessionEvents)
+}
+
+// ObserveHTTPRequest records latency of handled HTTP request.
+//
+// parameters:
+//  route    string         Route pattern of request, empty if not matched.
+//  method   string         HTTP method of request.
+//  status   int            Status of response.
+//  duration time.Duration  Time spent on request handling.
+func ObserveHTTPRequest(
+	route string, method string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequestDuration.WithLabelValues(
+		route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveOpenViDuRequest records latency of OpenViDu server request and
+// counts it as failed if it is.
+//
+// parameters:
+//  httpMethod string         HTTP method of request.
+//  method     string         Called method of OpenViDu API.
+//  duration   time.Duration  Time spent on request.
+//  failed     bool           Whether request failed.
+func ObserveOpenViDuRequest(httpMethod string, method string,
+	duration time.Duration, failed bool) {
+	label := httpMethod + " " + apiMethod(method)
+	openViDuRequestDuration.WithLabelValues(label).Observe(duration.Seconds())
+	if failed {
+		openViDuErrors.WithLabelValues(label).Inc()
+	}
+}
+
+// CountLogin counts login attempt with given result.
+func CountLogin(success bool) {
+	if success {
+		logins.WithLabelValues("success").Inc()
+		return
+	}
+	logins.WithLabelValues("failure").Inc()
+}
+
+// CountSessionEvent counts given session lifecycle event. It is a
+// subscriber of session events bus.
+func CountSessionEvent(ctx context.Context, event *entity.SessionEvent) {
+	sessionEvents.WithLabelValues(string(event.Type)).Inc()
+}
+
+// apiMethod returns OpenViDu API method without resource IDs, so it can be
+// used as a metric label, e.g. "api/sessions/:id" for "api/sessions/abc".
+func apiMethod(method string) string {
+	parts := strings.SplitN(strings.Trim(method, "/"), "/", 3)
+	if len(parts) == 3 {
+		parts[2] = ":id"
+	}
+	return strings.Join(parts, "/")
+}
+
+// SessionsCollector is a collector of active sessions and participants
+// gauges, which are read from sessions repository on every scrape.
+//
+// implements prometheus.Collector interface.
+type SessionsCollector struct {
+	sessions     entity.Sessions
+	sessionsDesc *prometheus.Desc
+	participants *prometheus.Desc
+}
+
+// NewSessionsCollector returns new collector of given sessions repository.
+func NewSessionsCollector(sessions entity.Sessions) *SessionsCollector {
+	return &SessionsCollector{
+		sessions: sessions,
+		sessionsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_sessions"),
+			"Number of active sessions.", nil, nil),
+		participants: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_participants"),
+			"Number of participants of active sessions including owners.",
+			nil, nil),
+	}
+}
+
+// Describe sends descriptors of collected metrics to given channel.
+//
+// implements prometheus.Collector interface.
+func (c *SessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sessionsDesc
+	ch <- c.participants
+}
+
+// Collect sends current values of collected metrics to given channel.
+//
+// implements prometheus.Collector interface.
+func (c *SessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	list, total, err := c.sessions.List(entity.SessionFilter{})
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.sessionsDesc, err)
+		return
+	}
+	participants := 0
+	for _, s := range list {
+		participants += s.ParticipantsCount()
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.sessionsDesc, prometheus.GaugeValue, float64(total))
+	ch <- prometheus.MustNewConstMetric(
+		c.participants, prometheus.GaugeValue, float64(participants))
+}
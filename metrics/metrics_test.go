@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	Convey("Records request latency", t, func() {
+		ObserveHTTPRequest("/test", "GET", 200, time.Second)
+		ObserveHTTPRequest("", "GET", 404, time.Second)
+
+		So(testutil.CollectAndCount(httpRequestDuration), ShouldEqual, 2)
+	})
+}
+
+func TestObserveOpenViDuRequest(t *testing.T) {
+	Convey("Counts failed requests", t, func() {
+		ObserveOpenViDuRequest("DELETE", "api/sessions/abc", time.Second, true)
+		ObserveOpenViDuRequest("DELETE", "api/sessions/def", time.Second, false)
+
+		So(testutil.ToFloat64(
+			openViDuErrors.WithLabelValues("DELETE api/sessions/:id")),
+			ShouldEqual, 1)
+	})
+}
+
+func TestCountLogin(t *testing.T) {
+	Convey("Counts logins by result", t, func() {
+		CountLogin(true)
+		CountLogin(false)
+		CountLogin(false)
+
+		So(testutil.ToFloat64(logins.WithLabelValues("success")),
+			ShouldEqual, 1)
+		So(testutil.ToFloat64(logins.WithLabelValues("failure")),
+			ShouldEqual, 2)
+	})
+}
+
+func TestCountSessionEvent(t *testing.T) {
+	Convey("Counts session events by type", t, func() {
+		CountSessionEvent(context.Background(),
+			&entity.SessionEvent{Type: entity.ParticipantJoined})
+		CountSessionEvent(context.Background(),
+			&entity.SessionEvent{Type: entity.ParticipantJoined})
+
+		So(testutil.ToFloat64(sessionEvents.WithLabelValues(
+			string(entity.ParticipantJoined))), ShouldEqual, 2)
+	})
+}
+
+func TestApiMethod(t *testing.T) {
+	Convey("Strips resource IDs", t, func() {
+		So(apiMethod("api/sessions"), ShouldEqual, "api/sessions")
+		So(apiMethod("api/sessions/abc"), ShouldEqual, "api/sessions/:id")
+		So(apiMethod("/api/tokens"), ShouldEqual, "api/tokens")
+	})
+}
+
+func TestSessionsCollector(t *testing.T) {
+	Convey("Collects sessions and participants", t, func() {
+		r := repository.NewSessionsRepository()
+		r.Add("first id", "first", &entity.User{Name: "owner"})
+		r.Join("first", &entity.User{Name: "participant"})
+		r.Add("second id", "second", &entity.User{Name: "owner"})
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(NewSessionsCollector(r))
+		families, err := registry.Gather()
+
+		So(err, ShouldBeNil)
+		So(families, ShouldHaveLength, 2)
+		values := map[string]float64{}
+		for _, f := range families {
+			values[f.GetName()] = f.GetMetric()[0].GetGauge().GetValue()
+		}
+		So(values["openvidu_tutorial_active_sessions"], ShouldEqual, 2)
+		So(values["openvidu_tutorial_active_participants"], ShouldEqual, 3)
+	})
+}
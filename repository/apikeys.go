@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// errAPIKeyNotFound is returned for unknown API key.
+var errAPIKeyNotFound = errors.New("API key is invalid")
+
+// APIKeys is a repository that stores API keys.
+//
+// implements entity.APIKeys interface.
+type APIKeys struct {
+	mu      sync.Mutex
+	storage map[string]*entity.APIKey
+}
+
+// NewAPIKeysRepository returns new API keys repository instance.
+func NewAPIKeysRepository() *APIKeys {
+	return &APIKeys{
+		storage: make(map[string]*entity.APIKey),
+	}
+}
+
+// Add adds new API key to repository.
+//
+// implements entity.APIKeys interface.
+func (r *APIKeys) Add(key *entity.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.storage[key.TokenHash]; ok {
+		return fmt.Errorf("API key %s already exists", key.ID)
+	}
+	stored := *key
+	r.storage[key.TokenHash] = &stored
+	return nil
+}
+
+// Get returns API key by given token hash.
+//
+// implements entity.APIKeys interface.
+func (r *APIKeys) Get(tokenHash string) (*entity.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.storage[tokenHash]
+	if !ok {
+		return nil, errAPIKeyNotFound
+	}
+	found := *key
+	return &found, nil
+}
+
+// Touch sets time API key with given token hash was used at.
+//
+// implements entity.APIKeys interface.
+func (r *APIKeys) Touch(tokenHash string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.storage[tokenHash]
+	if !ok {
+		return errAPIKeyNotFound
+	}
+	key.LastUsedAt = at
+	return nil
+}
+
+// List returns API keys of user with given name sorted by creation time.
+//
+// implements entity.APIKeys interface.
+func (r *APIKeys) List(userName string) ([]*entity.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*entity.APIKey, 0)
+	for _, key := range r.storage {
+		if key.UserName == userName {
+			found := *key
+			list = append(list, &found)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+// Delete removes API key with given ID of user with given name.
+//
+// implements entity.APIKeys interface.
+func (r *APIKeys) Delete(userName string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, key := range r.storage {
+		if key.ID == id && key.UserName == userName {
+			delete(r.storage, hash)
+			return nil
+		}
+	}
+	return fmt.Errorf("API key %s does not exists", id)
+}
+
+// DeleteByUser removes all API keys of user with given name.
+//
+// implements entity.APIKeys interface.
+func (r *APIKeys) DeleteByUser(userName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, key := range r.storage {
+		if key.UserName == userName {
+			delete(r.storage, hash)
+		}
+	}
+	return nil
+}
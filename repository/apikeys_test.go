@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestNewAPIKeysRepository(t *testing.T) {
+	Convey("Returns new instance of repository", t, func() {
+		r := NewAPIKeysRepository()
+
+		So(r, ShouldNotBeNil)
+		So(r.storage, ShouldNotBeNil)
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	Convey("Adds API key", t, func() {
+		now := time.Now()
+		r := NewAPIKeysRepository()
+		err := r.Add(&entity.APIKey{ID: "1", TokenHash: "test hash",
+			UserName: "test user", CreatedAt: now})
+
+		So(err, ShouldBeNil)
+
+		Convey("Returns an error if it exists", func() {
+			So(r.Add(&entity.APIKey{TokenHash: "test hash"}), ShouldNotBeNil)
+		})
+
+		Convey("Returns it by token hash", func() {
+			key, err := r.Get("test hash")
+
+			So(err, ShouldBeNil)
+			So(key.UserName, ShouldEqual, "test user")
+
+			_, err = r.Get("other hash")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "API key is invalid")
+		})
+
+		Convey("Sets time it was used at", func() {
+			So(r.Touch("test hash", now), ShouldBeNil)
+			key, _ := r.Get("test hash")
+			So(key.LastUsedAt, ShouldEqual, now)
+		})
+
+		Convey("Lists keys of user by creation time", func() {
+			r.Add(&entity.APIKey{ID: "2", TokenHash: "second hash",
+				UserName: "test user", CreatedAt: now.Add(time.Second)})
+			r.Add(&entity.APIKey{ID: "3", TokenHash: "other hash",
+				UserName: "other user", CreatedAt: now})
+			list, err := r.List("test user")
+
+			So(err, ShouldBeNil)
+			So(list, ShouldHaveLength, 2)
+			So(list[0].ID, ShouldEqual, "1")
+			So(list[1].ID, ShouldEqual, "2")
+		})
+
+		Convey("Deletes key only of its user", func() {
+			So(r.Delete("other user", "1"), ShouldNotBeNil)
+			So(r.Delete("test user", "1"), ShouldBeNil)
+			_, err := r.Get("test hash")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Deletes keys of user", func() {
+			So(r.DeleteByUser("test user"), ShouldBeNil)
+			list, _ := r.List("test user")
+			So(list, ShouldBeEmpty)
+		})
+	})
+}
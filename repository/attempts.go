@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Attempts is a repository that stores failed login attempts.
+//
+// Failures expire after lockout period and are removed on update, so
+// repository does not grow with every user name and IP ever seen.
+//
+// implements entity.LoginAttempts interface.
+type Attempts struct {
+	mu      sync.Mutex
+	storage map[string]entity.LoginFailures
+	lockout time.Duration
+	swept   time.Time
+}
+
+// NewAttemptsRepository returns new login attempts repository instance.
+//
+// parameters:
+// lockout - period after which failures expire.
+func NewAttemptsRepository(lockout time.Duration) *Attempts {
+	return &Attempts{
+		storage: make(map[string]entity.LoginFailures),
+		lockout: lockout,
+	}
+}
+
+// Get retrieves failures by given key.
+//
+// implements entity.LoginAttempts interface.
+func (r *Attempts) Get(key string) entity.LoginFailures {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.storage[key]
+}
+
+// Update applies given function to failures by given key under the lock.
+// Expired failures are removed instead of being stored; all the stored
+// ones are checked for expiration once per lockout period.
+//
+// implements entity.LoginAttempts interface.
+func (r *Attempts) Update(key string, now time.Time,
+	update func(failures *entity.LoginFailures) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweep(now)
+	f := r.storage[key]
+	if f.Expired(now, r.lockout) {
+		delete(r.storage, key)
+		f = entity.LoginFailures{}
+	}
+	if err := update(&f); err != nil {
+		return err
+	}
+	if f.Expired(now, r.lockout) {
+		delete(r.storage, key)
+	} else {
+		r.storage[key] = f
+	}
+	return nil
+}
+
+// Delete removes failures by given key.
+//
+// implements entity.LoginAttempts interface.
+func (r *Attempts) Delete(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.storage, key)
+}
+
+// sweep removes failures expired at given time if lockout period passed
+// since previous sweep. Must be called under the lock.
+func (r *Attempts) sweep(now time.Time) {
+	if now.Sub(r.swept) < r.lockout {
+		return
+	}
+	for key, f := range r.storage {
+		if f.Expired(now, r.lockout) {
+			delete(r.storage, key)
+		}
+	}
+	r.swept = now
+}
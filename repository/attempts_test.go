@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestNewAttemptsRepository(t *testing.T) {
+	Convey("Returns new instance of repository", t, func() {
+		r := NewAttemptsRepository(time.Minute)
+
+		So(r, ShouldNotBeNil)
+		So(r.storage, ShouldNotBeNil)
+		So(r.lockout, ShouldEqual, time.Minute)
+	})
+}
+
+func TestAttempts(t *testing.T) {
+	now := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	count := func(f *entity.LoginFailures) error {
+		f.Count++
+		f.Last = now
+		return nil
+	}
+
+	Convey("Returns zero failures for unknown key", t, func() {
+		r := NewAttemptsRepository(time.Minute)
+
+		So(r.Get("user:test"), ShouldResemble, entity.LoginFailures{})
+
+		Convey("Returns updated failures", func() {
+			So(r.Update("user:test", now, count), ShouldBeNil)
+
+			So(r.Get("user:test"), ShouldResemble,
+				entity.LoginFailures{Count: 1, Last: now})
+
+			Convey("Keeps failures when update fails", func() {
+				err := errors.New("refused")
+				So(r.Update("user:test", now,
+					func(f *entity.LoginFailures) error {
+						f.Count = 10
+						return err
+					}), ShouldEqual, err)
+
+				So(r.Get("user:test").Count, ShouldEqual, 1)
+			})
+
+			Convey("Deletes failures", func() {
+				r.Delete("user:test")
+
+				So(r.Get("user:test"), ShouldResemble, entity.LoginFailures{})
+			})
+		})
+	})
+
+	Convey("Removes expired failures", t, func() {
+		r := NewAttemptsRepository(time.Minute)
+		So(r.Update("user:one", now, count), ShouldBeNil)
+		So(r.Update("user:two", now, count), ShouldBeNil)
+
+		later := now.Add(2 * time.Minute)
+		So(r.Update("user:one", later, func(f *entity.LoginFailures) error {
+			So(f.Count, ShouldEqual, 0)
+			return nil
+		}), ShouldBeNil)
+
+		So(r.storage, ShouldBeEmpty)
+	})
+
+	Convey("Updates failures atomically", t, func() {
+		r := NewAttemptsRepository(time.Minute)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				r.Update(fmt.Sprintf("ip:%d", i%2), now, count)
+			}(i)
+		}
+		wg.Wait()
+
+		So(r.Get("ip:0").Count, ShouldEqual, 25)
+		So(r.Get("ip:1").Count, ShouldEqual, 25)
+	})
+}
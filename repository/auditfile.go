@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// AuditFile is an audit sink that appends events to file as JSON lines.
+//
+// implements entity.AuditSink interface.
+type AuditFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewAuditFileRepository returns new audit sink that appends events to file
+// with given path. The file is created if it does not exist.
+func NewAuditFileRepository(path string) (*AuditFile, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditFile{path: path, file: f}, nil
+}
+
+// Record appends given event to the file.
+//
+// implements entity.AuditSink interface.
+func (r *AuditFile) Record(event *entity.AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.file.Write(append(line, '\n'))
+	return err
+}
+
+// Query returns events matching given filter, newest first, and total
+// number of matching events. The whole file is read, so it suits logs that
+// are rotated regularly.
+//
+// implements entity.AuditSink interface.
+func (r *AuditFile) Query(
+	filter entity.AuditFilter) ([]*entity.AuditEvent, int, error) {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var matched []*entity.AuditEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		e := &entity.AuditEvent{}
+		if err = json.Unmarshal(scanner.Bytes(), e); err != nil {
+			return nil, 0, err
+		}
+		if filter.Matches(e) {
+			matched = append(matched, e)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return page(matched, filter.Offset, filter.Limit), len(matched), nil
+}
+
+// Close closes the file.
+func (r *AuditFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// page returns part of given events defined by offset and limit. Zero
+// limit means no limit.
+func page(events []*entity.AuditEvent,
+	offset int, limit int) []*entity.AuditEvent {
+	if offset >= len(events) {
+		return []*entity.AuditEvent{}
+	}
+	events = events[offset:]
+	if limit > 0 && limit < len(events) {
+		events = events[:limit]
+	}
+	return events
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestAuditFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.jsonl")
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	Convey("Appends events to file", t, func() {
+		r, err := NewAuditFileRepository(path)
+		So(err, ShouldBeNil)
+		for i, name := range []string{"alice", "bob", "alice"} {
+			So(r.Record(&entity.AuditEvent{
+				Time:    start.Add(time.Duration(i) * time.Hour),
+				Action:  entity.AuditLogin,
+				Actor:   name,
+				Session: "test session",
+			}), ShouldBeNil)
+		}
+		So(r.Close(), ShouldBeNil)
+	})
+
+	Convey("Queries events by filter newest first", t, func() {
+		r, _ := NewAuditFileRepository(path)
+		defer r.Close()
+
+		list, total, err := r.Query(entity.AuditFilter{User: "alice"})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 2)
+		So(list[0].Time.Equal(start.Add(2*time.Hour)), ShouldBeTrue)
+
+		list, total, _ = r.Query(entity.AuditFilter{
+			From: start.Add(time.Hour), Offset: 1, Limit: 1,
+		})
+		So(total, ShouldEqual, 2)
+		So(list, ShouldHaveLength, 1)
+		So(list[0].Actor, ShouldEqual, "bob")
+
+		list, total, _ = r.Query(entity.AuditFilter{Offset: 10})
+		So(total, ShouldEqual, 3)
+		So(list, ShouldBeEmpty)
+	})
+
+	Convey("Keeps events of previous runs", t, func() {
+		r, err := NewAuditFileRepository(path)
+		So(err, ShouldBeNil)
+		defer r.Close()
+		So(r.Record(&entity.AuditEvent{
+			Time: start.Add(3 * time.Hour), Action: entity.AuditLogin,
+			Actor: "carol",
+		}), ShouldBeNil)
+
+		list, total, err := r.Query(entity.AuditFilter{})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 4)
+		So(list[0].Actor, ShouldEqual, "carol")
+		So(list[3].Time.Equal(start), ShouldBeTrue)
+	})
+
+	Convey("Returns an error for unavailable file", t, func() {
+		_, err := NewAuditFileRepository(filepath.Join(dir, "no", "audit"))
+
+		So(err, ShouldNotBeNil)
+	})
+}
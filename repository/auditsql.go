@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// auditSchema creates table of audit events if it does not exist.
+const auditSchema = `CREATE TABLE IF NOT EXISTS audit_events (
+	id BIGSERIAL PRIMARY KEY,
+	time TIMESTAMPTZ NOT NULL,
+	action TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	session TEXT NOT NULL,
+	target TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	request_id TEXT NOT NULL,
+	details TEXT NOT NULL,
+	error TEXT NOT NULL
+)`
+
+// AuditSQL is an audit sink that inserts events into PostgreSQL table.
+// Events are only ever inserted, so database user may be granted INSERT
+// and SELECT privileges only.
+//
+// implements entity.AuditSink interface.
+type AuditSQL struct {
+	db *sql.DB
+}
+
+// NewAuditSQLRepository returns new audit sink that stores events in given
+// database. The table of events is created if it does not exist.
+func NewAuditSQLRepository(db *sql.DB) (*AuditSQL, error) {
+	if _, err := db.Exec(auditSchema); err != nil {
+		return nil, err
+	}
+	return &AuditSQL{db: db}, nil
+}
+
+// Record inserts given event into the table.
+//
+// implements entity.AuditSink interface.
+func (r *AuditSQL) Record(e *entity.AuditEvent) error {
+	_, err := r.db.Exec(`INSERT INTO audit_events (time, action, actor,
+		session, target, ip, request_id, details, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		e.Time, string(e.Action), e.Actor, e.Session, e.Target, e.IP,
+		e.RequestID, e.Details, e.Error)
+	return err
+}
+
+// Query returns events matching given filter, newest first, and total
+// number of matching events.
+//
+// implements entity.AuditSink interface.
+func (r *AuditSQL) Query(
+	filter entity.AuditFilter) ([]*entity.AuditEvent, int, error) {
+	where, args := auditWhere(filter)
+	var total int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM audit_events"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := `SELECT time, action, actor, session, target, ip, request_id,
+		details, error FROM audit_events` + where +
+		" ORDER BY time DESC, id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += " LIMIT $" + strconv.Itoa(len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += " OFFSET $" + strconv.Itoa(len(args))
+	}
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	list := []*entity.AuditEvent{}
+	for rows.Next() {
+		e := &entity.AuditEvent{}
+		var action string
+		if err = rows.Scan(&e.Time, &action, &e.Actor, &e.Session,
+			&e.Target, &e.IP, &e.RequestID, &e.Details, &e.Error); err != nil {
+			return nil, 0, err
+		}
+		e.Action = entity.AuditAction(action)
+		list = append(list, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// auditWhere returns WHERE clause and its arguments for given filter.
+func auditWhere(filter entity.AuditFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(
+			condition, "?", "$"+strconv.Itoa(len(args)), -1))
+	}
+	if filter.User != "" {
+		add("(actor = ? OR target = ?)", filter.User)
+	}
+	if filter.Session != "" {
+		add("session = ?", filter.Session)
+	}
+	if !filter.From.IsZero() {
+		add("time >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("time < ?", filter.To)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestAuditSQL(t *testing.T) {
+	Convey("Creates table of events", t, func() {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS audit_events").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := NewAuditSQLRepository(db)
+		So(err, ShouldBeNil)
+		So(mock.ExpectationsWereMet(), ShouldBeNil)
+	})
+
+	Convey("Returns an error of table creation", t, func() {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+		mock.ExpectExec("CREATE TABLE").WillReturnError(errors.New("some"))
+
+		_, err := NewAuditSQLRepository(db)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Inserts events", t, func() {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+		mock.ExpectExec("CREATE TABLE").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		now := time.Now()
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(now, "kick", "moderator", "test session", "con_1", "",
+				"", "", "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		r, _ := NewAuditSQLRepository(db)
+
+		So(r.Record(&entity.AuditEvent{
+			Time: now, Action: entity.AuditKick, Actor: "moderator",
+			Session: "test session", Target: "con_1",
+		}), ShouldBeNil)
+		So(mock.ExpectationsWereMet(), ShouldBeNil)
+	})
+
+	Convey("Queries events by filter", t, func() {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+		mock.ExpectExec("CREATE TABLE").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM audit_events"+
+			" WHERE (actor = $1 OR target = $1) AND time >= $2")).
+			WithArgs("alice", from).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"ORDER BY time DESC, id DESC LIMIT $3 OFFSET $4")).
+			WithArgs("alice", from, 1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"time", "action",
+				"actor", "session", "target", "ip", "request_id", "details",
+				"error"}).AddRow(from, "login", "alice", "", "",
+				"127.0.0.1", "", "", ""))
+		r, _ := NewAuditSQLRepository(db)
+
+		list, total, err := r.Query(entity.AuditFilter{
+			User: "alice", From: from, Offset: 2, Limit: 1,
+		})
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 3)
+		So(list, ShouldHaveLength, 1)
+		So(list[0].Action, ShouldEqual, entity.AuditLogin)
+		So(list[0].IP, ShouldEqual, "127.0.0.1")
+		So(mock.ExpectationsWereMet(), ShouldBeNil)
+	})
+
+	Convey("Returns an error of query", t, func() {
+		db, mock, _ := sqlmock.New()
+		defer db.Close()
+		mock.ExpectExec("CREATE TABLE").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT COUNT").WillReturnError(errors.New("some"))
+		r, _ := NewAuditSQLRepository(db)
+
+		_, _, err := r.Query(entity.AuditFilter{})
+		So(err, ShouldNotBeNil)
+	})
+}
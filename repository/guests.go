@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Guests is a repository that stores guests of OpenViDu sessions.
+//
+// implements entity.Guests interface.
+type Guests struct {
+	mu      sync.Mutex
+	storage map[string]*entity.Guest
+}
+
+// NewGuestsRepository returns new guests repository instance.
+func NewGuestsRepository() *Guests {
+	return &Guests{
+		storage: make(map[string]*entity.Guest),
+	}
+}
+
+// Add adds new guest to repository.
+//
+// implements entity.Guests interface.
+func (r *Guests) Add(guest *entity.Guest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.storage[guest.User.Name]; ok {
+		return fmt.Errorf("guest %s already exists", guest.User.Name)
+	}
+	r.storage[guest.User.Name] = guest
+	return nil
+}
+
+// Get retrieves guest from repository by given user name.
+//
+// implements entity.Guests interface.
+func (r *Guests) Get(name string) (*entity.Guest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.storage[name]
+	if !ok {
+		return nil, fmt.Errorf("guest %s does not exists", name)
+	}
+	return g, nil
+}
+
+// List returns all guests.
+//
+// implements entity.Guests interface.
+func (r *Guests) List() ([]*entity.Guest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*entity.Guest, 0, len(r.storage))
+	for _, g := range r.storage {
+		list = append(list, g)
+	}
+	return list, nil
+}
+
+// Delete removes guest with given user name from repository.
+//
+// implements entity.Guests interface.
+func (r *Guests) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.storage[name]; !ok {
+		return fmt.Errorf("guest %s does not exists", name)
+	}
+	delete(r.storage, name)
+	return nil
+}
+
+// DeleteBySession removes all guests of session with given name from
+// repository.
+//
+// implements entity.Guests interface.
+func (r *Guests) DeleteBySession(sessionName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, g := range r.storage {
+		if g.SessionName == sessionName {
+			delete(r.storage, name)
+		}
+	}
+	return nil
+}
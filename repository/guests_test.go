@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestNewGuestsRepository(t *testing.T) {
+	Convey("Returns new instance of repository", t, func() {
+		r := NewGuestsRepository()
+
+		So(r, ShouldNotBeNil)
+
+		Convey("The repository storage is not nil", func() {
+			So(r.storage, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGuests_Add(t *testing.T) {
+	Convey("Adds new guest to repository", t, func() {
+		r := NewGuestsRepository()
+		err := r.Add(entity.NewGuest("1", "test guest", "test session", 0))
+
+		So(err, ShouldBeNil)
+		So(r.storage["guest:test guest#1"], ShouldNotBeNil)
+
+		Convey("Returns an error", func() {
+			err := r.Add(entity.NewGuest("1", "test guest", "other session", 0))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"guest guest:test guest#1 already exists")
+		})
+	})
+}
+
+func TestGuests_Get(t *testing.T) {
+	Convey("Returns a guest", t, func() {
+		r := NewGuestsRepository()
+		r.Add(entity.NewGuest("1", "test guest", "test session", 0))
+		g, err := r.Get("guest:test guest#1")
+
+		So(err, ShouldBeNil)
+		So(g.SessionName, ShouldEqual, "test session")
+
+		Convey("Returns an error", func() {
+			_, err := r.Get("wrong guest")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"guest wrong guest does not exists")
+		})
+	})
+}
+
+func TestGuests_List(t *testing.T) {
+	Convey("Returns all guests", t, func() {
+		r := NewGuestsRepository()
+		r.Add(entity.NewGuest("1", "first", "test session", 0))
+		r.Add(entity.NewGuest("1", "second", "other session", 0))
+		list, err := r.List()
+
+		So(err, ShouldBeNil)
+		So(list, ShouldHaveLength, 2)
+	})
+}
+
+func TestGuests_Delete(t *testing.T) {
+	Convey("Removes a guest", t, func() {
+		r := NewGuestsRepository()
+		r.Add(entity.NewGuest("1", "test guest", "test session", 0))
+		err := r.Delete("guest:test guest#1")
+
+		So(err, ShouldBeNil)
+		So(r.storage, ShouldBeEmpty)
+
+		Convey("Returns an error", func() {
+			err := r.Delete("guest:test guest#1")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGuests_DeleteBySession(t *testing.T) {
+	Convey("Removes all guests of session", t, func() {
+		r := NewGuestsRepository()
+		r.Add(entity.NewGuest("1", "first", "test session", 0))
+		r.Add(entity.NewGuest("1", "second", "test session", 0))
+		r.Add(entity.NewGuest("1", "third", "other session", 0))
+		err := r.DeleteBySession("test session")
+
+		So(err, ShouldBeNil)
+		So(r.storage, ShouldHaveLength, 1)
+		So(r.storage["guest:third#1"], ShouldNotBeNil)
+	})
+}
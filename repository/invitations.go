@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Invitations is a repository that stores invitations to OpenViDu sessions.
+//
+// implements entity.Invitations interface.
+type Invitations struct {
+	mu      sync.Mutex
+	storage map[string]*entity.Invitation
+}
+
+// NewInvitationsRepository returns new invitations repository instance.
+func NewInvitationsRepository() *Invitations {
+	return &Invitations{
+		storage: make(map[string]*entity.Invitation),
+	}
+}
+
+// Add adds new invitation to repository.
+//
+// implements entity.Invitations interface.
+func (r *Invitations) Add(invitation *entity.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.storage[invitation.ID]; ok {
+		return fmt.Errorf("invitation %s already exists", invitation.ID)
+	}
+	r.storage[invitation.ID] = invitation
+	return nil
+}
+
+// Get retrieves invitation from repository by given ID.
+//
+// implements entity.Invitations interface.
+func (r *Invitations) Get(id string) (*entity.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.get(id)
+}
+
+// Use increments number of uses of invitation with given ID if it can be
+// used at given time.
+//
+// implements entity.Invitations interface.
+func (r *Invitations) Use(
+	id string, now time.Time) (*entity.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, err := r.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = i.Check(now); err != nil {
+		return nil, err
+	}
+	i.Uses++
+	return i, nil
+}
+
+// Revoke marks invitation with given ID as revoked.
+//
+// implements entity.Invitations interface.
+func (r *Invitations) Revoke(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, err := r.get(id)
+	if err != nil {
+		return err
+	}
+	i.Revoked = true
+	return nil
+}
+
+// List returns all invitations to session with given name.
+//
+// implements entity.Invitations interface.
+func (r *Invitations) List(sessionName string) ([]*entity.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*entity.Invitation, 0)
+	for _, i := range r.storage {
+		if i.SessionName == sessionName {
+			list = append(list, i)
+		}
+	}
+	return list, nil
+}
+
+// get retrieves invitation from repository without locking.
+func (r *Invitations) get(id string) (*entity.Invitation, error) {
+	i, ok := r.storage[id]
+	if !ok {
+		return nil, fmt.Errorf("invitation %s does not exists", id)
+	}
+	return i, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestNewInvitationsRepository(t *testing.T) {
+	Convey("Returns new instance of repository", t, func() {
+		r := NewInvitationsRepository()
+
+		So(r, ShouldNotBeNil)
+
+		Convey("The repository storage is not nil", func() {
+			So(r.storage, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestInvitations_Add(t *testing.T) {
+	Convey("Adds new invitation to repository", t, func() {
+		r := NewInvitationsRepository()
+		err := r.Add(&entity.Invitation{ID: "test ID"})
+
+		So(err, ShouldBeNil)
+		So(r.storage["test ID"], ShouldNotBeNil)
+
+		Convey("Returns an error", func() {
+			err := r.Add(&entity.Invitation{ID: "test ID"})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"invitation test ID already exists")
+		})
+	})
+}
+
+func TestInvitations_Get(t *testing.T) {
+	Convey("Returns an invitation", t, func() {
+		r := NewInvitationsRepository()
+		r.Add(&entity.Invitation{ID: "test ID", SessionName: "test session"})
+		i, err := r.Get("test ID")
+
+		So(err, ShouldBeNil)
+		So(i.SessionName, ShouldEqual, "test session")
+
+		Convey("Returns an error", func() {
+			_, err := r.Get("wrong ID")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"invitation wrong ID does not exists")
+		})
+	})
+}
+
+func TestInvitations_Use(t *testing.T) {
+	Convey("Increments number of uses", t, func() {
+		now := time.Now()
+		r := NewInvitationsRepository()
+		r.Add(&entity.Invitation{
+			ID: "test ID", MaxUses: 1, ExpiresAt: now.Add(time.Minute)})
+		i, err := r.Use("test ID", now)
+
+		So(err, ShouldBeNil)
+		So(i.Uses, ShouldEqual, 1)
+
+		Convey("Single-use invitation can not be used twice", func() {
+			_, err := r.Use("test ID", now)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "invitation already used")
+		})
+
+		Convey("Returns an error", func() {
+			_, err := r.Use("wrong ID", now)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestInvitations_Revoke(t *testing.T) {
+	Convey("Revokes an invitation", t, func() {
+		r := NewInvitationsRepository()
+		r.Add(&entity.Invitation{ID: "test ID"})
+		err := r.Revoke("test ID")
+
+		So(err, ShouldBeNil)
+		So(r.storage["test ID"].Revoked, ShouldBeTrue)
+
+		Convey("Returns an error", func() {
+			err := r.Revoke("wrong ID")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestInvitations_List(t *testing.T) {
+	Convey("Returns invitations of session", t, func() {
+		r := NewInvitationsRepository()
+		r.Add(&entity.Invitation{ID: "first", SessionName: "test session"})
+		r.Add(&entity.Invitation{ID: "second", SessionName: "other session"})
+		list, err := r.List("test session")
+
+		So(err, ShouldBeNil)
+		So(list, ShouldHaveLength, 1)
+		So(list[0].ID, ShouldEqual, "first")
+	})
+}
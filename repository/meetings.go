@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// Meetings is a repository that stores scheduled meetings.
+//
+// implements entity.Meetings interface.
+type Meetings struct {
+	mu      sync.Mutex
+	storage map[string]*entity.Meeting
+}
+
+// NewMeetingsRepository returns new meetings repository instance.
+func NewMeetingsRepository() *Meetings {
+	return &Meetings{
+		storage: make(map[string]*entity.Meeting),
+	}
+}
+
+// Add adds new meeting to repository.
+//
+// implements entity.Meetings interface.
+func (r *Meetings) Add(meeting *entity.Meeting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.storage[meeting.SessionName]; ok {
+		return fmt.Errorf("meeting %s already exists", meeting.SessionName)
+	}
+	r.storage[meeting.SessionName] = meeting
+	return nil
+}
+
+// Get retrieves meeting from repository by given session name.
+//
+// implements entity.Meetings interface.
+func (r *Meetings) Get(sessionName string) (*entity.Meeting, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.storage[sessionName]
+	if !ok {
+		return nil, fmt.Errorf("meeting %s does not exists", sessionName)
+	}
+	return m, nil
+}
+
+// Delete removes meeting with given session name from repository.
+//
+// implements entity.Meetings interface.
+func (r *Meetings) Delete(sessionName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.storage[sessionName]; !ok {
+		return fmt.Errorf("meeting %s does not exists", sessionName)
+	}
+	delete(r.storage, sessionName)
+	return nil
+}
+
+// List returns all meetings ordered by start time.
+//
+// implements entity.Meetings interface.
+func (r *Meetings) List() ([]*entity.Meeting, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*entity.Meeting, 0, len(r.storage))
+	for _, m := range r.storage {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Start.Before(list[j].Start)
+	})
+	return list, nil
+}
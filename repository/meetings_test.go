@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestNewMeetingsRepository(t *testing.T) {
+	Convey("Returns new instance of repository", t, func() {
+		r := NewMeetingsRepository()
+
+		So(r, ShouldNotBeNil)
+
+		Convey("The repository storage is not nil", func() {
+			So(r.storage, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestMeetings_Add(t *testing.T) {
+	Convey("Adds new meeting to repository", t, func() {
+		r := NewMeetingsRepository()
+		err := r.Add(&entity.Meeting{SessionName: "test meeting"})
+
+		So(err, ShouldBeNil)
+		So(r.storage["test meeting"], ShouldNotBeNil)
+
+		Convey("Returns an error", func() {
+			err := r.Add(&entity.Meeting{SessionName: "test meeting"})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"meeting test meeting already exists")
+		})
+	})
+}
+
+func TestMeetings_Get(t *testing.T) {
+	Convey("Returns a meeting", t, func() {
+		r := NewMeetingsRepository()
+		r.Add(&entity.Meeting{SessionName: "test meeting", Title: "test"})
+		m, err := r.Get("test meeting")
+
+		So(err, ShouldBeNil)
+		So(m.Title, ShouldEqual, "test")
+
+		Convey("Returns an error", func() {
+			_, err := r.Get("wrong meeting")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"meeting wrong meeting does not exists")
+		})
+	})
+}
+
+func TestMeetings_Delete(t *testing.T) {
+	Convey("Removes a meeting", t, func() {
+		r := NewMeetingsRepository()
+		r.Add(&entity.Meeting{SessionName: "test meeting"})
+		err := r.Delete("test meeting")
+
+		So(err, ShouldBeNil)
+		So(r.storage, ShouldBeEmpty)
+
+		Convey("Returns an error", func() {
+			So(r.Delete("test meeting"), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestMeetings_List(t *testing.T) {
+	Convey("Returns meetings ordered by start time", t, func() {
+		r := NewMeetingsRepository()
+		r.Add(&entity.Meeting{SessionName: "second", Start: time.Unix(200, 0)})
+		r.Add(&entity.Meeting{SessionName: "first", Start: time.Unix(100, 0)})
+		list, err := r.List()
+
+		So(err, ShouldBeNil)
+		So(list, ShouldHaveLength, 2)
+		So(list[0].SessionName, ShouldEqual, "first")
+		So(list[1].SessionName, ShouldEqual, "second")
+	})
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// errRefreshNotFound is returned for unknown refresh token.
+var errRefreshNotFound = errors.New("refresh token is invalid")
+
+// RefreshTokens is a repository that stores refresh tokens.
+//
+// implements entity.RefreshTokens interface.
+type RefreshTokens struct {
+	mu      sync.Mutex
+	storage map[string]*entity.RefreshToken
+}
+
+// NewRefreshTokensRepository returns new refresh tokens repository
+// instance.
+func NewRefreshTokensRepository() *RefreshTokens {
+	return &RefreshTokens{
+		storage: make(map[string]*entity.RefreshToken),
+	}
+}
+
+// Add adds new refresh token to repository.
+//
+// implements entity.RefreshTokens interface.
+func (r *RefreshTokens) Add(token *entity.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.storage[token.TokenHash]; ok {
+		return fmt.Errorf("refresh token %s already exists", token.TokenHash)
+	}
+	stored := *token
+	r.storage[token.TokenHash] = &stored
+	return nil
+}
+
+// Use marks refresh token with given hash as used and returns it as it was
+// before.
+//
+// implements entity.RefreshTokens interface.
+func (r *RefreshTokens) Use(tokenHash string) (*entity.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.storage[tokenHash]
+	if !ok {
+		return nil, errRefreshNotFound
+	}
+	found := *token
+	token.Used = true
+	return &found, nil
+}
+
+// DeleteFamily removes all refresh tokens of given family.
+//
+// implements entity.RefreshTokens interface.
+func (r *RefreshTokens) DeleteFamily(family string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, token := range r.storage {
+		if token.Family == family {
+			delete(r.storage, hash)
+		}
+	}
+	return nil
+}
+
+// DeleteByUser removes all refresh tokens of user with given name.
+//
+// implements entity.RefreshTokens interface.
+func (r *RefreshTokens) DeleteByUser(userName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, token := range r.storage {
+		if token.UserName == userName {
+			delete(r.storage, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestNewRefreshTokensRepository(t *testing.T) {
+	Convey("Returns new instance of repository", t, func() {
+		r := NewRefreshTokensRepository()
+
+		So(r, ShouldNotBeNil)
+		So(r.storage, ShouldNotBeNil)
+	})
+}
+
+func TestRefreshTokens(t *testing.T) {
+	Convey("Adds refresh token", t, func() {
+		r := NewRefreshTokensRepository()
+		err := r.Add(&entity.RefreshToken{TokenHash: "test hash",
+			UserName: "test user", Family: "test family"})
+
+		So(err, ShouldBeNil)
+
+		Convey("Returns an error if it exists", func() {
+			So(r.Add(&entity.RefreshToken{TokenHash: "test hash"}),
+				ShouldNotBeNil)
+		})
+
+		Convey("Marks it as used", func() {
+			token, err := r.Use("test hash")
+
+			So(err, ShouldBeNil)
+			So(token.Used, ShouldBeFalse)
+			token, err = r.Use("test hash")
+			So(err, ShouldBeNil)
+			So(token.Used, ShouldBeTrue)
+		})
+
+		Convey("Returns an error for unknown token", func() {
+			_, err := r.Use("other hash")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "refresh token is invalid")
+		})
+
+		Convey("Deletes tokens of family", func() {
+			r.Add(&entity.RefreshToken{TokenHash: "other hash",
+				UserName: "test user", Family: "other family"})
+
+			So(r.DeleteFamily("test family"), ShouldBeNil)
+			_, err := r.Use("test hash")
+			So(err, ShouldNotBeNil)
+			_, err = r.Use("other hash")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Deletes tokens of user", func() {
+			So(r.DeleteByUser("test user"), ShouldBeNil)
+			_, err := r.Use("test hash")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// errResetNotFound is returned for unknown password reset token.
+var errResetNotFound = errors.New("password reset link is invalid")
+
+// Resets is a repository that stores password resets.
+//
+// implements entity.PasswordResets interface.
+type Resets struct {
+	mu      sync.Mutex
+	storage map[string]*entity.PasswordReset
+}
+
+// NewResetsRepository returns new password resets repository instance.
+func NewResetsRepository() *Resets {
+	return &Resets{
+		storage: make(map[string]*entity.PasswordReset),
+	}
+}
+
+// Add adds new password reset to repository.
+//
+// implements entity.PasswordResets interface.
+func (r *Resets) Add(reset *entity.PasswordReset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.storage[reset.TokenHash]; ok {
+		return fmt.Errorf("password reset %s already exists", reset.TokenHash)
+	}
+	r.storage[reset.TokenHash] = reset
+	return nil
+}
+
+// Get returns password reset by given token hash.
+//
+// implements entity.PasswordResets interface.
+func (r *Resets) Get(tokenHash string) (*entity.PasswordReset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reset, ok := r.storage[tokenHash]
+	if !ok {
+		return nil, errResetNotFound
+	}
+	return reset, nil
+}
+
+// Take returns password reset by given token hash and removes it from
+// repository.
+//
+// implements entity.PasswordResets interface.
+func (r *Resets) Take(tokenHash string) (*entity.PasswordReset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reset, ok := r.storage[tokenHash]
+	if !ok {
+		return nil, errResetNotFound
+	}
+	delete(r.storage, tokenHash)
+	return reset, nil
+}
+
+// DeleteByUser removes all password resets of user with given name.
+//
+// implements entity.PasswordResets interface.
+func (r *Resets) DeleteByUser(userName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, reset := range r.storage {
+		if reset.UserName == userName {
+			delete(r.storage, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestNewResetsRepository(t *testing.T) {
+	Convey("Returns new instance of repository", t, func() {
+		r := NewResetsRepository()
+
+		So(r, ShouldNotBeNil)
+		So(r.storage, ShouldNotBeNil)
+	})
+}
+
+func TestResets(t *testing.T) {
+	Convey("Adds password reset", t, func() {
+		r := NewResetsRepository()
+		err := r.Add(&entity.PasswordReset{
+			TokenHash: "test hash", UserName: "test user"})
+
+		So(err, ShouldBeNil)
+
+		Convey("Returns an error if it exists", func() {
+			So(r.Add(&entity.PasswordReset{TokenHash: "test hash"}),
+				ShouldNotBeNil)
+		})
+
+		Convey("Returns it by token hash", func() {
+			reset, err := r.Get("test hash")
+
+			So(err, ShouldBeNil)
+			So(reset.UserName, ShouldEqual, "test user")
+		})
+
+		Convey("Takes it only once", func() {
+			reset, err := r.Take("test hash")
+
+			So(err, ShouldBeNil)
+			So(reset.UserName, ShouldEqual, "test user")
+			_, err = r.Take("test hash")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "password reset link is invalid")
+		})
+
+		Convey("Deletes resets of user", func() {
+			r.Add(&entity.PasswordReset{
+				TokenHash: "other hash", UserName: "other user"})
+
+			So(r.DeleteByUser("test user"), ShouldBeNil)
+			_, err := r.Get("test hash")
+			So(err, ShouldNotBeNil)
+			_, err = r.Get("other hash")
+			So(err, ShouldBeNil)
+		})
+	})
+}
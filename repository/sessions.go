@@ -2,14 +2,21 @@ package repository
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/flexconstructor/openvidu-tutorial/entity"
 )
 
-// Sessions is a repository that stores OpenViDu sessions.
+// Sessions is a repository that stores OpenViDu sessions. Stored sessions
+// never leave the repository: copies are returned and taken, so sessions
+// are read and changed only under its lock.
 //
 // implements entity.Sessions interface.
 type Sessions struct {
+	mu      sync.RWMutex
 	storage map[string]*entity.Session
 }
 
@@ -28,21 +35,63 @@ func NewSessionsRepository() *Sessions {
 func (r *Sessions) Add(
 	sessionID string, sessionName string,
 	owner *entity.User) (*entity.Session, error) {
-	if _, ok := r.storage[sessionName]; ok {
-		return nil, fmt.Errorf("session %s already exists", sessionName)
-	}
 	s := entity.NewSession()
 	s.Name = sessionName
 	s.ID = sessionID
 	s.Owner = owner
-	r.storage[sessionName] = s
+	s.CreatedAt = time.Now()
+	if err := r.Create(s); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
+// Restore puts given previously stored session to repository.
+//
+// implements entity.Sessions interface.
+func (r *Sessions) Restore(session *entity.Session) error {
+	return r.Create(session)
+}
+
+// Create puts copy of given new session to repository.
+//
+// implements entity.Sessions interface.
+func (r *Sessions) Create(session *entity.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.storage[session.Name]; ok {
+		return fmt.Errorf("session %s already exists", session.Name)
+	}
+	r.storage[session.Name] = session.Copy()
+	return nil
+}
+
+// Modify applies given change to copy of session by given session name and
+// stores the copy if change succeeds.
+//
+// implements entity.Sessions interface.
+func (r *Sessions) Modify(sessionName string,
+	change func(session *entity.Session) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, err := r.get(sessionName)
+	if err != nil {
+		return err
+	}
+	changed := session.Copy()
+	if err = change(changed); err != nil {
+		return err
+	}
+	r.storage[sessionName] = changed
+	return nil
+}
+
 // Delete removes session from repository by given sessionName.
 //
 // implements entity.Sessions interface.
 func (r *Sessions) Delete(sessionName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if _, ok := r.storage[sessionName]; !ok {
 		return fmt.Errorf("session %s does not exists", sessionName)
 	}
@@ -50,15 +99,31 @@ func (r *Sessions) Delete(sessionName string) error {
 	return nil
 }
 
-// Get retrieves session from repository by given session name.
+// Get retrieves copy of session from repository by given session name.
 //
 // implements entity.Sessions interface.
 func (r *Sessions) Get(sessionName string) (*entity.Session, error) {
-	s, ok := r.storage[sessionName]
-	if !ok {
-		return nil, fmt.Errorf("session %s does not exists", sessionName)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	session, err := r.get(sessionName)
+	if err != nil {
+		return nil, err
 	}
-	return s, nil
+	return session.Copy(), nil
+}
+
+// Join adds given user to participants of session by given session name.
+//
+// implements entity.Sessions interface.
+func (r *Sessions) Join(sessionName string, user *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, err := r.get(sessionName)
+	if err != nil {
+		return err
+	}
+	session.AddParticipant(copyUser(user))
+	return nil
 }
 
 // Leave removes participant from session by given session name and user
@@ -66,7 +131,9 @@ func (r *Sessions) Get(sessionName string) (*entity.Session, error) {
 //
 // implements entity.Sessions interface.
 func (r *Sessions) Leave(sessionName string, userName string) error {
-	session, err := r.Get(sessionName)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, err := r.get(sessionName)
 	if err != nil {
 		return err
 	}
@@ -76,3 +143,81 @@ func (r *Sessions) Leave(sessionName string, userName string) error {
 	delete(session.Subscribers, userName)
 	return nil
 }
+
+// SetOwner hands session by given session name over to given participant.
+// Previous owner becomes participant of session.
+//
+// implements entity.Sessions interface.
+func (r *Sessions) SetOwner(sessionName string, owner *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, err := r.get(sessionName)
+	if err != nil {
+		return err
+	}
+	if _, ok := session.Subscribers[owner.Name]; !ok {
+		return fmt.Errorf("user %s does not exists", owner.Name)
+	}
+	delete(session.Subscribers, owner.Name)
+	if session.Owner != nil {
+		session.AddParticipant(session.Owner)
+	}
+	session.Owner = copyUser(owner)
+	return nil
+}
+
+// List returns copies of sessions that match given filter, newest first,
+// along with total number of matched sessions.
+//
+// implements entity.Sessions interface.
+func (r *Sessions) List(
+	filter entity.SessionFilter) ([]*entity.Session, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	name := strings.ToLower(filter.Name)
+	list := make([]*entity.Session, 0, len(r.storage))
+	for _, s := range r.storage {
+		if filter.Owner != "" &&
+			(s.Owner == nil || s.Owner.Name != filter.Owner) {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(s.Name), name) {
+			continue
+		}
+		list = append(list, s.Copy())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	total := len(list)
+	if filter.Offset > 0 {
+		if filter.Offset >= total {
+			return []*entity.Session{}, total, nil
+		}
+		list = list[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(list) {
+		list = list[:filter.Limit]
+	}
+	return list, total, nil
+}
+
+// copyUser returns copy of given user, so the repository does not share
+// it with callers.
+func copyUser(user *entity.User) *entity.User {
+	c := *user
+	return &c
+}
+
+// get retrieves stored session from repository without locking.
+func (r *Sessions) get(sessionName string) (*entity.Session, error) {
+	s, ok := r.storage[sessionName]
+	if !ok {
+		return nil, fmt.Errorf("session %s does not exists", sessionName)
+	}
+	return s, nil
+}
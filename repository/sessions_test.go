@@ -1,7 +1,11 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 
@@ -90,6 +94,25 @@ func TestSessions_Delete(t *testing.T) {
 	})
 }
 
+func TestSessions_Restore(t *testing.T) {
+	Convey("Restores session to repository", t, func() {
+		r := NewSessionsRepository()
+		s := entity.NewSession()
+		s.Name = "test session"
+		err := r.Restore(s)
+
+		So(err, ShouldBeNil)
+		So(r.storage["test session"], ShouldEqual, s)
+
+		Convey("Returns an error", func() {
+			err := r.Restore(s)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"session test session already exists")
+		})
+	})
+}
+
 func TestSessions_Get(t *testing.T) {
 	Convey("Returns a session", t, func() {
 		r := NewSessionsRepository()
@@ -106,7 +129,15 @@ func TestSessions_Get(t *testing.T) {
 		})
 
 		Convey("Returns session that was be added", func() {
-			So(session, ShouldEqual, s)
+			So(session, ShouldResemble, s)
+		})
+
+		Convey("Returns copy of stored session", func() {
+			session.AddParticipant(&entity.User{Name: "test participant"})
+			session.AllowList["test participant"] = true
+			stored, _ := r.Get(s.Name)
+			So(stored.Subscribers, ShouldBeEmpty)
+			So(stored.AllowList, ShouldBeEmpty)
 		})
 
 		Convey("Returns an error", func() {
@@ -118,17 +149,37 @@ func TestSessions_Get(t *testing.T) {
 	})
 }
 
+func TestSessions_Join(t *testing.T) {
+	Convey("Adds session participant", t, func() {
+		r := NewSessionsRepository()
+		r.Add("test session ID", "test session name",
+			&entity.User{Name: "test user", Role: 1})
+
+		So(r.Join("test session name",
+			&entity.User{Name: "test participant"}), ShouldBeNil)
+		s, _ := r.Get("test session name")
+		So(s.Subscribers, ShouldContainKey, "test participant")
+
+		Convey("Returns a session error", func() {
+			err := r.Join("wrong session name",
+				&entity.User{Name: "test participant"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
 func TestSessions_Leave(t *testing.T) {
 	Convey("Removes session participant", t, func() {
 		r := NewSessionsRepository()
-		s, _ := r.Add("test session ID", "test session name",
+		r.Add("test session ID", "test session name",
 			&entity.User{Name: "test user", Password: "test password", Role: 1})
-		s.AddParticipant(&entity.User{Name: "test participant"})
+		r.Join("test session name", &entity.User{Name: "test participant"})
 		err := r.Leave("test session name", "test participant")
 
 		So(err, ShouldBeNil)
 
 		Convey("Session subscribers has no any participants", func() {
+			s, _ := r.Get("test session name")
 			So(s.Subscribers, ShouldBeEmpty)
 		})
 
@@ -147,3 +198,158 @@ func TestSessions_Leave(t *testing.T) {
 		})
 	})
 }
+
+func TestSessions_SetOwner(t *testing.T) {
+	Convey("Hands session over to participant", t, func() {
+		r := NewSessionsRepository()
+		r.Add("test session ID", "test session name",
+			&entity.User{Name: "test user", Role: 1})
+		r.Join("test session name",
+			&entity.User{Name: "test participant", Role: 1})
+
+		So(r.SetOwner("test session name",
+			&entity.User{Name: "test participant", Role: 1}), ShouldBeNil)
+		s, _ := r.Get("test session name")
+		So(s.Owner.Name, ShouldEqual, "test participant")
+		So(s.Subscribers, ShouldContainKey, "test user")
+		So(s.Subscribers, ShouldNotContainKey, "test participant")
+
+		Convey("Returns a session error", func() {
+			err := r.SetOwner("wrong session name",
+				&entity.User{Name: "test user"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Returns participant error", func() {
+			err := r.SetOwner("test session name",
+				&entity.User{Name: "wrong participant"})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring,
+				"user wrong participant does not exists")
+		})
+	})
+}
+
+func TestSessions_List(t *testing.T) {
+	Convey("Returns list of sessions", t, func() {
+		r := NewSessionsRepository()
+		owner := &entity.User{Name: "test user"}
+		other := &entity.User{Name: "other user"}
+		r.Add("first ID", "First room", owner)
+		r.Add("second ID", "Second room", other)
+		r.Add("third ID", "Third room", owner)
+		r.storage["First room"].CreatedAt = time.Unix(100, 0)
+		r.storage["Second room"].CreatedAt = time.Unix(200, 0)
+		r.storage["Third room"].CreatedAt = time.Unix(300, 0)
+
+		list, total, err := r.List(entity.SessionFilter{})
+
+		So(err, ShouldBeNil)
+		So(total, ShouldEqual, 3)
+
+		Convey("newest first", func() {
+			So(list, ShouldHaveLength, 3)
+			So(list[0].Name, ShouldEqual, "Third room")
+			So(list[1].Name, ShouldEqual, "Second room")
+			So(list[2].Name, ShouldEqual, "First room")
+		})
+
+		Convey("Filtered by owner", func() {
+			list, total, _ := r.List(entity.SessionFilter{Owner: "test user"})
+			So(total, ShouldEqual, 2)
+			So(list[0].Name, ShouldEqual, "Third room")
+			So(list[1].Name, ShouldEqual, "First room")
+		})
+
+		Convey("Filtered by part of name", func() {
+			list, total, _ := r.List(entity.SessionFilter{Name: "SECOND"})
+			So(total, ShouldEqual, 1)
+			So(list[0].Name, ShouldEqual, "Second room")
+		})
+
+		Convey("Paginated", func() {
+			list, total, _ := r.List(entity.SessionFilter{Offset: 1, Limit: 1})
+			So(total, ShouldEqual, 3)
+			So(list, ShouldHaveLength, 1)
+			So(list[0].Name, ShouldEqual, "Second room")
+		})
+
+		Convey("Returns empty page if offset is out of range", func() {
+			list, total, _ := r.List(entity.SessionFilter{Offset: 10})
+			So(total, ShouldEqual, 3)
+			So(list, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestSessions_Create(t *testing.T) {
+	Convey("Stores copy of given session", t, func() {
+		r := NewSessionsRepository()
+		s := entity.NewSession()
+		s.Name = "test session name"
+		s.Access = entity.AccessPrivate
+
+		So(r.Create(s), ShouldBeNil)
+		s.Access = entity.AccessPublic
+		stored, _ := r.Get("test session name")
+		So(stored.Access, ShouldEqual, entity.AccessPrivate)
+		So(r.Create(s), ShouldNotBeNil)
+	})
+}
+
+func TestSessions_Modify(t *testing.T) {
+	Convey("Applies change to session", t, func() {
+		r := NewSessionsRepository()
+		r.Add("test session ID", "test session name",
+			&entity.User{Name: "test user"})
+
+		So(r.Modify("test session name", func(s *entity.Session) error {
+			s.AllowGuests = true
+			return nil
+		}), ShouldBeNil)
+		s, _ := r.Get("test session name")
+		So(s.AllowGuests, ShouldBeTrue)
+	})
+
+	Convey("Keeps session if change fails", t, func() {
+		r := NewSessionsRepository()
+		r.Add("test session ID", "test session name",
+			&entity.User{Name: "test user"})
+
+		So(r.Modify("test session name", func(s *entity.Session) error {
+			s.AllowGuests = true
+			return errors.New("test error")
+		}), ShouldNotBeNil)
+		s, _ := r.Get("test session name")
+		So(s.AllowGuests, ShouldBeFalse)
+		So(r.Modify("wrong name", func(s *entity.Session) error {
+			return nil
+		}), ShouldNotBeNil)
+	})
+}
+
+func TestSessions_Concurrent(t *testing.T) {
+	Convey("Serves concurrent changes", t, func() {
+		r := NewSessionsRepository()
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				name := fmt.Sprintf("session %d", i)
+				r.Add("test session ID", name, &entity.User{Name: "owner"})
+				r.Join(name, &entity.User{Name: "test participant"})
+				if s, err := r.Get(name); err == nil {
+					entity.NewRoomEvent(entity.RoomParticipants, s)
+				}
+				r.List(entity.SessionFilter{})
+				r.Leave(name, "test participant")
+				r.Delete(name)
+			}(i)
+		}
+		wg.Wait()
+
+		_, total, _ := r.List(entity.SessionFilter{})
+		So(total, ShouldEqual, 0)
+	})
+}
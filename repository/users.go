@@ -1,13 +1,16 @@
 package repository
 
 import (
-	"errors"
+	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/flexconstructor/openvidu-tutorial/entity"
 )
 
 // Users implementation of entity.Users repository.
 type Users struct {
+	mu    sync.RWMutex
 	users map[string]*entity.User
 }
 
@@ -18,26 +21,97 @@ func NewUsersRepository() *Users {
 	}
 }
 
-// Add adds user data to repository.
+// Add adds user data to repository. Hash of given password is stored;
+// weak password is refused.
 //
 // Implements entity.Users interface.
-func (r *Users) Add(username string, password string, role uint8) {
-	r.users[username] = &entity.User{
-		Name:     username,
-		Password: password,
-		Role:     entity.UserRole(role),
+func (r *Users) Add(username string, password string, role uint8) error {
+	if err := entity.CheckPassword(password); err != nil {
+		return err
 	}
+	user := &entity.User{Name: username, Role: entity.UserRole(role)}
+	if err := user.SetPassword(password); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[username] = user
+	return nil
 }
 
 // Get retrieves user from repository.
 //
 // Implements entity.Users interface.
 func (r *Users) Get(username string) (*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	user, ok := r.users[username]
 	switch {
 	case !ok:
-		return nil, errors.New("login incorrect")
+		return nil, entity.ErrUnknownUser
 	default:
 		return user, nil
 	}
 }
+
+// Create adds given user to repository.
+//
+// Implements entity.Users interface.
+func (r *Users) Create(user *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[user.Name]; ok {
+		return fmt.Errorf("user %s already exists", user.Name)
+	}
+	r.users[user.Name] = user
+	return nil
+}
+
+// Modify applies given change to copy of user with given name under the
+// lock and stores the copy.
+//
+// Implements entity.Users interface.
+func (r *Users) Modify(
+	username string, change func(user *entity.User) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[username]
+	if !ok {
+		return entity.ErrUnknownUser
+	}
+	updated := *user
+	if err := change(&updated); err != nil {
+		return err
+	}
+	r.users[username] = &updated
+	return nil
+}
+
+// Delete removes user with given name from repository.
+//
+// Implements entity.Users interface.
+func (r *Users) Delete(username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[username]; !ok {
+		return fmt.Errorf("user %s does not exist", username)
+	}
+	delete(r.users, username)
+	return nil
+}
+
+// List returns all users sorted by name.
+//
+// Implements entity.Users interface.
+func (r *Users) List() ([]*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*entity.User, 0, len(r.users))
+	for _, user := range r.users {
+		list = append(list, user)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
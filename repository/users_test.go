@@ -1,11 +1,20 @@
 package repository
 
 import (
+	"errors"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
 )
 
+func init() {
+	// Minimal cost keeps tests that hash passwords fast.
+	entity.PasswordCost = bcrypt.MinCost
+}
+
 func TestNewUsersRepository(t *testing.T) {
 	Convey("Returns new instance of repository", t, func() {
 		r := NewUsersRepository()
@@ -25,10 +34,18 @@ func TestUsers_Add(t *testing.T) {
 
 		Convey("with correct user data", func() {
 			So(r.users["test login"].Name, ShouldEqual, "test login")
-			So(r.users["test login"].Password, ShouldEqual, "test password")
+			So(r.users["test login"].ValidPassword("test password"), ShouldBeTrue)
 			So(r.users["test login"].Role, ShouldEqual, 1)
 		})
 	})
+
+	Convey("Returns weak password error", t, func() {
+		r := NewUsersRepository()
+		err := r.Add("test login", "pass", 1)
+
+		So(err, ShouldNotBeNil)
+		So(r.users, ShouldBeEmpty)
+	})
 }
 
 func TestUsers_Get(t *testing.T) {
@@ -39,7 +56,7 @@ func TestUsers_Get(t *testing.T) {
 		Convey("with correct user data", func() {
 			user, _ := r.Get("test login")
 			So(user.Name, ShouldEqual, "test login")
-			So(user.Password, ShouldEqual, "test password")
+			So(user.ValidPassword("test password"), ShouldBeTrue)
 			So(user.Role, ShouldEqual, 1)
 		})
 
@@ -50,8 +67,88 @@ func TestUsers_Get(t *testing.T) {
 
 		Convey("Returns an error", func() {
 			_, err := r.Get("wrong login")
-			So(err, ShouldNotBeNil)
+			So(err, ShouldEqual, entity.ErrUnknownUser)
 			So(err.Error(), ShouldContainSubstring, "login incorrect")
 		})
 	})
 }
+
+func TestUsers_Create(t *testing.T) {
+	Convey("Creates new user", t, func() {
+		r := NewUsersRepository()
+		err := r.Create(&entity.User{Name: "test login", Pending: true})
+
+		So(err, ShouldBeNil)
+		So(r.users["test login"].Pending, ShouldBeTrue)
+
+		Convey("Returns an error if user exists", func() {
+			err := r.Create(&entity.User{Name: "test login"})
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "user test login already exists")
+		})
+	})
+}
+
+func TestUsers_Modify(t *testing.T) {
+	Convey("Stores changed copy of user", t, func() {
+		r := NewUsersRepository()
+		r.Add("test login", "test password", 1)
+		before, _ := r.Get("test login")
+		err := r.Modify("test login", func(user *entity.User) error {
+			user.DisplayName = "Test"
+			return nil
+		})
+
+		So(err, ShouldBeNil)
+		So(r.users["test login"].DisplayName, ShouldEqual, "Test")
+		So(before.DisplayName, ShouldBeEmpty)
+	})
+
+	Convey("Keeps user if change fails", t, func() {
+		r := NewUsersRepository()
+		r.Add("test login", "test password", 1)
+		err := r.Modify("test login", func(user *entity.User) error {
+			user.DisplayName = "Test"
+			return errors.New("some error")
+		})
+
+		So(err, ShouldNotBeNil)
+		So(r.users["test login"].DisplayName, ShouldBeEmpty)
+	})
+
+	Convey("Returns an error if user does not exist", t, func() {
+		err := NewUsersRepository().Modify("test login",
+			func(user *entity.User) error { return nil })
+
+		So(err, ShouldEqual, entity.ErrUnknownUser)
+	})
+}
+
+func TestUsers_Delete(t *testing.T) {
+	Convey("Deletes user", t, func() {
+		r := NewUsersRepository()
+		r.Add("test login", "test password", 1)
+
+		So(r.Delete("test login"), ShouldBeNil)
+		So(r.users, ShouldNotContainKey, "test login")
+
+		Convey("Returns an error if user does not exist", func() {
+			So(r.Delete("test login"), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestUsers_List(t *testing.T) {
+	Convey("Returns users sorted by name", t, func() {
+		r := NewUsersRepository()
+		r.Add("b", "password", 1)
+		r.Add("a", "password", 0)
+		list, err := r.List()
+
+		So(err, ShouldBeNil)
+		So(list, ShouldHaveLength, 2)
+		So(list[0].Name, ShouldEqual, "a")
+		So(list[1].Name, ShouldEqual, "b")
+	})
+}
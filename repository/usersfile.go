@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// UsersFile is a repository that stores users in JSON file, so they survive
+// restart and are shared between application and admin commands. Changes
+// written to the file by other process are read on next access.
+//
+// implements entity.Users interface.
+type UsersFile struct {
+	mu    sync.Mutex
+	path  string
+	cache *Users
+
+	// modTime and size identify the file version cache is loaded from.
+	modTime time.Time
+	size    int64
+}
+
+// NewUsersFileRepository returns new repository that stores users in file
+// with given path. The file is created on first change if it does not
+// exist.
+func NewUsersFileRepository(path string) (*UsersFile, error) {
+	r := &UsersFile{path: path, cache: NewUsersRepository(), size: -1}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Add adds user data to repository and writes the file.
+//
+// implements entity.Users interface.
+func (r *UsersFile) Add(username string, password string, role uint8) error {
+	return r.update(func(users *Users) error {
+		return users.Add(username, password, role)
+	})
+}
+
+// Get retrieves user from repository.
+//
+// implements entity.Users interface.
+func (r *UsersFile) Get(username string) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r.cache.Get(username)
+}
+
+// Create adds given user to repository.
+//
+// implements entity.Users interface.
+func (r *UsersFile) Create(user *entity.User) error {
+	return r.update(func(users *Users) error {
+		return users.Create(user)
+	})
+}
+
+// Modify applies given change to copy of actual user with given name and
+// writes it to the file.
+//
+// implements entity.Users interface.
+func (r *UsersFile) Modify(
+	username string, change func(user *entity.User) error) error {
+	return r.update(func(users *Users) error {
+		return users.Modify(username, change)
+	})
+}
+
+// Delete removes user with given name from repository.
+//
+// implements entity.Users interface.
+func (r *UsersFile) Delete(username string) error {
+	return r.update(func(users *Users) error {
+		return users.Delete(username)
+	})
+}
+
+// List returns all users sorted by name.
+//
+// implements entity.Users interface.
+func (r *UsersFile) List() ([]*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r.cache.List()
+}
+
+// update applies given change to actual users and writes them to the file.
+func (r *UsersFile) update(change func(users *Users) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(); err != nil {
+		return err
+	}
+	if err := change(r.cache); err != nil {
+		return err
+	}
+	if err := r.save(); err != nil {
+		// Cache is ahead of the file now, so it is read again on next
+		// access.
+		r.size = -1
+		return err
+	}
+	return nil
+}
+
+// load reads users from the file if it has changed since last read.
+func (r *UsersFile) load() error {
+	info, err := os.Stat(r.path)
+	if os.IsNotExist(err) {
+		if r.size != 0 {
+			r.cache = NewUsersRepository()
+			r.modTime, r.size = time.Time{}, 0
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(r.modTime) && info.Size() == r.size {
+		return nil
+	}
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	var list []*entity.User
+	if err = json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	cache := NewUsersRepository()
+	for _, user := range list {
+		if err = cache.Create(user); err != nil {
+			return err
+		}
+	}
+	r.cache = cache
+	r.modTime, r.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// save writes cached users to the file atomically.
+func (r *UsersFile) save() error {
+	list, err := r.cache.List()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(r.path), ".users")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err = os.Rename(f.Name(), r.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	r.modTime, r.size = info.ModTime(), info.Size()
+	return nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+func TestUsersFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	Convey("Starts empty without file", t, func() {
+		r, err := NewUsersFileRepository(filepath.Join(dir, "missing.json"))
+		So(err, ShouldBeNil)
+		list, err := r.List()
+		So(err, ShouldBeNil)
+		So(list, ShouldBeEmpty)
+	})
+
+	Convey("Stores users in file", t, func() {
+		path := filepath.Join(dir, "users.json")
+		r, _ := NewUsersFileRepository(path)
+		r.Add("test login", "test password", 1)
+		So(r.Create(&entity.User{Name: "second", TOTPSecret: "secret"}),
+			ShouldBeNil)
+
+		other, err := NewUsersFileRepository(path)
+		So(err, ShouldBeNil)
+		list, _ := other.List()
+		So(list, ShouldHaveLength, 2)
+		user, err := other.Get("second")
+		So(err, ShouldBeNil)
+		So(user.TOTPSecret, ShouldEqual, "secret")
+
+		Convey("Reads changes written by other repository", func() {
+			So(other.Modify("second", func(user *entity.User) error {
+				user.Role = entity.Moderator
+				user.DisplayName = "Second"
+				return nil
+			}), ShouldBeNil)
+			So(other.Delete("test login"), ShouldBeNil)
+
+			user, err := r.Get("second")
+			So(err, ShouldBeNil)
+			So(user.Role, ShouldEqual, entity.Moderator)
+			So(user.DisplayName, ShouldEqual, "Second")
+			_, err = r.Get("test login")
+			So(err, ShouldEqual, entity.ErrUnknownUser)
+		})
+	})
+
+	Convey("Returns errors of changes", t, func() {
+		r, _ := NewUsersFileRepository(filepath.Join(dir, "errors.json"))
+
+		So(r.Modify("unknown", func(user *entity.User) error {
+			return nil
+		}), ShouldNotBeNil)
+		So(r.Delete("unknown"), ShouldNotBeNil)
+		r.Add("test login", "test password", 1)
+		So(r.Create(&entity.User{Name: "test login"}), ShouldNotBeNil)
+	})
+
+	Convey("Returns error of broken file", t, func() {
+		path := filepath.Join(dir, "broken.json")
+		ioutil.WriteFile(path, []byte("wrong"), 0600)
+
+		_, err := NewUsersFileRepository(path)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,206 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/flexconstructor/openvidu-tutorial/action"
+	"github.com/flexconstructor/openvidu-tutorial/cli"
+	"github.com/flexconstructor/openvidu-tutorial/config"
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/event"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+	"github.com/flexconstructor/openvidu-tutorial/service"
+)
+
+// RunAdmin runs admin command given by its arguments on users stored in
+// users file and sessions persisted to state file, the same repositories
+// the application uses. Sessions are written back to state file if the
+// command changes them. Running application holds its own sessions in
+// memory and does not write them to state file until it stops, so commands
+// that change or list sessions are refused until it stops and export
+// leaves sessions out.
+//
+// parameters:
+//  cfg         *config.Config       Configuration of application.
+//  HTTPClient  service.HTTPClient   Client of OpenViDu server API.
+//  args        []string             Command and its arguments.
+//  in          io.Reader            Source of passwords and imported data.
+//  out         io.Writer            Destination of command results.
+func RunAdmin(cfg *config.Config, HTTPClient service.HTTPClient,
+	args []string, in io.Reader, out io.Writer) error {
+	if cfg.UsersFile == "" {
+		return errors.New("USERS_FILE must be set to run admin commands")
+	}
+	userRepo, err := newUsersRepository(cfg)
+	if err != nil {
+		return err
+	}
+	auditSink, err := newAuditSink(cfg)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(stateLock(cfg))
+	running := err == errLocked
+	switch {
+	case err == nil:
+		defer unlock()
+	case running && strings.Join(args, " ") == "sessions list":
+		return errors.New(
+			"application is running, its sessions are listed on /admin")
+	case !changesSessions(args):
+	case err == errLocked:
+		return errors.New(
+			"application is running, stop it to change sessions")
+	default:
+		return err
+	}
+
+	sessionRepo := &changedSessions{
+		Sessions: repository.NewSessionsRepository(),
+	}
+	sessionEvents := event.NewBus()
+	defer sessionEvents.Close()
+	sessionEvents.Subscribe(action.AuditSessions(auditSink))
+	registry := &action.Registry{
+		SessionRepo: sessionRepo,
+		OpenViDu:    &service.Service{OpenViDu: HTTPClient},
+		Events:      sessionEvents,
+	}
+	// State file of running application holds sessions of its last stop.
+	f, err := os.Open(cfg.StateFile)
+	switch {
+	case running:
+		if err == nil {
+			f.Close()
+		}
+		if args[0] == "export" {
+			logging.FromContext(context.Background()).Warn(
+				"application is running, its sessions are not exported")
+		}
+	case err == nil:
+		err = registry.Restore(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	sessionRepo.changed = false
+
+	c := &cli.Admin{
+		AdminAction: &action.Admin{
+			UserRepo:    userRepo,
+			SessionRepo: sessionRepo,
+			Registry:    registry,
+			AuditSink:   auditSink,
+		},
+		In:  in,
+		Out: out,
+	}
+	if err = c.Run(context.Background(), args); err != nil {
+		return err
+	}
+	if !sessionRepo.changed {
+		return nil
+	}
+	return persistSessions(registry, cfg.StateFile)
+}
+
+// changesSessions returns true if admin command given by its arguments may
+// change sessions.
+func changesSessions(args []string) bool {
+	switch command := strings.Join(args, " "); {
+	case strings.HasPrefix(command, "sessions close"),
+		strings.HasPrefix(command, "users remove"),
+		command == "import":
+		return true
+	}
+	return false
+}
+
+// changedSessions is a sessions repository that records whether sessions
+// are changed through it, so admin commands persist them only if needed.
+//
+// implements entity.Sessions interface.
+type changedSessions struct {
+	entity.Sessions
+
+	// changed is true after successful change of sessions.
+	changed bool
+}
+
+// Add adds new session to repository.
+//
+// implements entity.Sessions interface.
+func (r *changedSessions) Add(sessionID string, sessionName string,
+	owner *entity.User) (*entity.Session, error) {
+	session, err := r.Sessions.Add(sessionID, sessionName, owner)
+	return session, r.change(err)
+}
+
+// Delete removes session from repository.
+//
+// implements entity.Sessions interface.
+func (r *changedSessions) Delete(sessionName string) error {
+	return r.change(r.Sessions.Delete(sessionName))
+}
+
+// Join adds participant to session.
+//
+// implements entity.Sessions interface.
+func (r *changedSessions) Join(sessionName string, user *entity.User) error {
+	return r.change(r.Sessions.Join(sessionName, user))
+}
+
+// Leave removes participant from session.
+//
+// implements entity.Sessions interface.
+func (r *changedSessions) Leave(sessionName string, userName string) error {
+	return r.change(r.Sessions.Leave(sessionName, userName))
+}
+
+// SetOwner transfers ownership of session.
+//
+// implements entity.Sessions interface.
+func (r *changedSessions) SetOwner(
+	sessionName string, owner *entity.User) error {
+	return r.change(r.Sessions.SetOwner(sessionName, owner))
+}
+
+// Restore puts given previously stored session to repository.
+//
+// implements entity.Sessions interface.
+func (r *changedSessions) Restore(session *entity.Session) error {
+	return r.change(r.Sessions.Restore(session))
+}
+
+// Create puts given new session to repository.
+//
+// implements entity.Sessions interface.
+func (r *changedSessions) Create(session *entity.Session) error {
+	return r.change(r.Sessions.Create(session))
+}
+
+// Modify applies given change to session.
+//
+// implements entity.Sessions interface.
+func (r *changedSessions) Modify(sessionName string,
+	change func(session *entity.Session) error) error {
+	return r.change(r.Sessions.Modify(sessionName, change))
+}
+
+// change records successful change of sessions and returns given error of
+// the change.
+func (r *changedSessions) change(err error) error {
+	if err == nil {
+		r.changed = true
+	}
+	return err
+}
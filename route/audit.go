@@ -0,0 +1,38 @@
+package route
+
+import (
+	"database/sql"
+
+	// PostgreSQL driver of "database" audit sink.
+	_ "github.com/lib/pq"
+
+	"github.com/flexconstructor/openvidu-tutorial/config"
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+// newAuditSink returns audit sink defined by given configuration or nil if
+// audit log is disabled.
+func newAuditSink(cfg *config.Config) (entity.AuditSink, error) {
+	switch cfg.AuditSink {
+	case config.FileAudit:
+		sink, err := repository.NewAuditFileRepository(cfg.AuditFile)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	case config.DatabaseAudit:
+		db, err := sql.Open("postgres", cfg.AuditDatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		sink, err := repository.NewAuditSQLRepository(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return sink, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,34 @@
+package route
+
+import (
+	"github.com/flexconstructor/openvidu-tutorial/action"
+	"github.com/flexconstructor/openvidu-tutorial/config"
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/service"
+)
+
+// newAuthenticator returns authenticator of user credentials defined by
+// given configuration. Directory server, if configured, is asked first and
+// local accounts of given repository are the fallback.
+func newAuthenticator(
+	cfg *config.Config, userRepo entity.Users) entity.Authenticator {
+	local := &action.LocalAuthenticator{UserRepo: userRepo}
+	if cfg.LDAPURL == "" {
+		return local
+	}
+	return action.ChainAuthenticator{
+		&service.LDAP{
+			URL:            cfg.LDAPURL,
+			StartTLS:       cfg.LDAPStartTLS,
+			BindDN:         cfg.LDAPBindDN,
+			BindPassword:   cfg.LDAPBindPassword,
+			BaseDN:         cfg.LDAPBaseDN,
+			UserFilter:     cfg.LDAPUserFilter,
+			GroupAttribute: cfg.LDAPGroupAttribute,
+			Roles:          cfg.LDAPRoles,
+			DefaultRole:    cfg.LDAPDefaultRole,
+			Timeout:        cfg.LDAPTimeout,
+		},
+		local,
+	}
+}
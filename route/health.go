@@ -0,0 +1,58 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// indexTemplate is a name of template which presence indicates that
+// templates are loaded.
+const indexTemplate = "index.tmpl"
+
+// templatesCheck returns readiness check that verifies that HTML templates
+// are loaded into given router.
+func templatesCheck(router *gin.Engine) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		switch r := router.HTMLRender.(type) {
+		case render.HTMLProduction:
+			if r.Template.Lookup(indexTemplate) == nil {
+				return fmt.Errorf("template %s is not loaded", indexTemplate)
+			}
+			return nil
+		case render.HTMLDebug:
+			// Templates are parsed on every render in debug mode.
+			if r.Glob == "" {
+				return nil
+			}
+			files, err := filepath.Glob(r.Glob)
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				return fmt.Errorf("no templates match %s", r.Glob)
+			}
+			return nil
+		}
+		return errors.New("templates are not loaded")
+	}
+}
+
+// repositoriesCheck returns readiness check that verifies that given
+// repositories answer queries.
+func repositoriesCheck(sessions entity.Sessions,
+	meetings entity.Meetings) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if _, _, err := sessions.List(entity.SessionFilter{Limit: 1}); err != nil {
+			return err
+		}
+		_, err := meetings.List()
+		return err
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package route
+
+// lockFile does nothing, as file locks are not supported on this platform,
+// so admin commands do not detect running application.
+func lockFile(file string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package route
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes exclusive lock of given file, creating it if needed, and
+// returns function that releases the lock. Lock is released on exit of the
+// process as well, so it does not outlive crashed application.
+func lockFile(file string) (func(), error) {
+	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, errLocked
+		}
+		return nil, err
+	}
+	return func() { f.Close() }, nil
+}
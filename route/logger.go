@@ -0,0 +1,54 @@
+package route
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+	"github.com/flexconstructor/openvidu-tutorial/metrics"
+)
+
+// requestIDPattern is a pattern of request ID that is accepted from client.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// logRequests is a function that assigns ID to HTTP request, passes it to
+// request context and writes JSON log record and metrics of request when it
+// is handled.
+//
+// Request ID sent by client in X-Request-ID header is reused if it is valid.
+// Errors attached to gin context by handlers are logged with the request.
+func logRequests(ctx *gin.Context) {
+	start := time.Now()
+	id := ctx.GetHeader(logging.RequestIDHeader)
+	if !requestIDPattern.MatchString(id) {
+		id = logging.NewRequestID()
+	}
+	ctx.Request = ctx.Request.WithContext(
+		logging.WithRequestID(ctx.Request.Context(), id))
+	ctx.Header(logging.RequestIDHeader, id)
+
+	ctx.Next()
+
+	metrics.ObserveHTTPRequest(ctx.FullPath(), ctx.Request.Method,
+		ctx.Writer.Status(), time.Since(start))
+	log := logging.FromContext(ctx.Request.Context()).WithFields(logrus.Fields{
+		"method":   ctx.Request.Method,
+		"path":     ctx.Request.URL.Path,
+		"route":    ctx.FullPath(),
+		"status":   ctx.Writer.Status(),
+		"duration": time.Since(start).Seconds(),
+		"client":   ctx.ClientIP(),
+	})
+	if span := trace.SpanContextFromContext(ctx.Request.Context()); span.IsValid() {
+		log = log.WithField("trace_id", span.TraceID().String())
+	}
+	if len(ctx.Errors) > 0 {
+		log.WithField("errors", ctx.Errors.Errors()).Error("request failed")
+		return
+	}
+	log.Info("request")
+}
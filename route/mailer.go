@@ -0,0 +1,28 @@
+package route
+
+import (
+	"github.com/flexconstructor/openvidu-tutorial/config"
+	"github.com/flexconstructor/openvidu-tutorial/service"
+)
+
+// mailQueueSize is a number of mail messages queued for sending.
+const mailQueueSize = 100
+
+// newMailer returns mail sender defined by given configuration. Messages
+// are queued, so requests do not wait for mail server.
+func newMailer(cfg *config.Config) *service.MailQueue {
+	var mailer service.Mailer = &service.FileMailer{
+		From: cfg.MailFrom,
+		Path: cfg.MailFile,
+	}
+	if cfg.Mailer == config.SMTPMailer {
+		mailer = &service.SMTPMailer{
+			Addr:     cfg.SMTPAddr,
+			From:     cfg.MailFrom,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			Timeout:  cfg.SMTPTimeout,
+		}
+	}
+	return service.NewMailQueue(mailer, mailQueueSize)
+}
@@ -3,9 +3,19 @@ package route
 import "github.com/gin-gonic/gin"
 
 // renderHTML is a function that renders HTTP pages.
+//
+// Responses of handlers that define no template (e.g. JSON API) are left
+// untouched. CSRF token written to context is passed to every template as
+// "csrfToken" parameter.
 func renderHTML(ctx *gin.Context) {
 	ctx.Next()
-	ctx.HTML(ctx.Writer.Status(),
-		ctx.MustGet("template").(string),
-		ctx.MustGet("parameters").(gin.H))
+	template, ok := ctx.Get("template")
+	if !ok {
+		return
+	}
+	parameters := ctx.MustGet("parameters").(gin.H)
+	if token, ok := ctx.Get("csrfToken"); ok {
+		parameters["csrfToken"] = token
+	}
+	ctx.HTML(ctx.Writer.Status(), template.(string), parameters)
 }
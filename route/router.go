@@ -1,53 +1,342 @@
 package route
 
 import (
+	"context"
+	"crypto/rand"
+	"time"
+
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/sessions"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"github.com/flexconstructor/openvidu-tutorial/action"
+	"github.com/flexconstructor/openvidu-tutorial/config"
 	"github.com/flexconstructor/openvidu-tutorial/controller"
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/event"
+	"github.com/flexconstructor/openvidu-tutorial/metrics"
 	"github.com/flexconstructor/openvidu-tutorial/repository"
 	"github.com/flexconstructor/openvidu-tutorial/service"
+	"github.com/flexconstructor/openvidu-tutorial/tracing"
 )
 
 // InitRouter initializes new HTTP router that performs routing of HTTP
 // requests.
-// Initializes all controllers.
-func InitRouter(HTTPClient service.HTTPClient) *gin.Engine {
-	router := gin.Default()
-	store := sessions.NewCookieStore([]byte("secret"))
-	userRepo := repository.NewUsersRepository()
-	userRepo.Add("publisher1", "pass", 1)
-	userRepo.Add("publisher2", "pass", 1)
-	userRepo.Add("subscriber", "pass", 0)
+// Initializes all controllers, restores persisted sessions and starts
+// closing of expired meetings.
+func InitRouter(cfg *config.Config, HTTPClient service.HTTPClient) *Router {
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		panic(err)
+	}
+	router.Use(otelgin.Middleware(tracing.ServiceName),
+		logRequests, gin.Recovery())
+	drain := &controller.Drain{}
+	store := newSessionStore(cfg)
+	userRepo, err := newUsersRepository(cfg)
+	if err != nil {
+		panic(err)
+	}
+	auditSink, err := newAuditSink(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	sessionRepo := repository.NewSessionsRepository()
+	roomEvents := event.NewHub()
+	sessionEvents := event.NewBus()
+	sessionEvents.Subscribe(metrics.CountSessionEvent)
+	sessionEvents.Subscribe(action.AuditSessions(auditSink))
+	sessionEvents.Subscribe((&event.Rooms{
+		SessionRepo: sessionRepo,
+		Events:      roomEvents,
+	}).Handle)
+	guestRepo := repository.NewGuestsRepository()
+	meetingRepo := repository.NewMeetingsRepository()
+	openViDu := &service.Service{
+		OpenViDu: HTTPClient,
+	}
+	invitationRepo := repository.NewInvitationsRepository()
+	sessionAction := &action.Session{
+		UserRepo:       userRepo,
+		SessionRepo:    sessionRepo,
+		GuestRepo:      guestRepo,
+		InvitationRepo: invitationRepo,
+		MeetingRepo:    meetingRepo,
+		EarlyJoin:      cfg.EarlyJoin,
+		AuditSink:      auditSink,
+		Events:         sessionEvents,
+		Recorder:       openViDu,
+	}
+	meetingAction := &action.Meeting{
+		MeetingRepo: meetingRepo,
+		SessionRepo: sessionRepo,
+		UserRepo:    userRepo,
+		GuestRepo:   guestRepo,
+		OpenViDu:    openViDu,
+		AuditSink:   auditSink,
+		Events:      sessionEvents,
+	}
+	registry := &action.Registry{
+		SessionRepo: sessionRepo,
+		GuestRepo:   guestRepo,
+		OpenViDu:    openViDu,
+		Events:      sessionEvents,
+	}
+	if cfg.ShutdownPolicy == config.PersistSessions {
+		restoreSessions(registry, cfg.StateFile)
+	}
+	stopJobs := make(chan struct{})
+	go meetingAction.Run(time.Minute, stopJobs)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	invitationAction := &action.Invitation{
+		InvitationRepo: invitationRepo,
+		SessionRepo:    sessionRepo,
+		Secret:         secret,
+	}
+	guestAction := &action.Guest{
+		GuestRepo:        guestRepo,
+		SessionRepo:      sessionRepo,
+		DefaultRole:      cfg.GuestRole,
+		JoinTimeout:      cfg.GuestJoinTimeout,
+		InvitationAction: invitationAction,
+	}
+	go guestAction.Run(time.Minute, stopJobs)
+	keyRepo := repository.NewAPIKeysRepository()
+	apiKeyAction := &action.APIKey{
+		KeyRepo:   keyRepo,
+		UserRepo:  userRepo,
+		AuditSink: auditSink,
+	}
+	loginAction := &action.Login{
+		UserRepo:      userRepo,
+		Authenticator: newAuthenticator(cfg, userRepo),
+		AttemptRepo:   repository.NewAttemptsRepository(cfg.LoginLockout),
+		MaxFailures:   cfg.LoginMaxFailures,
+		MaxIPFailures: cfg.LoginMaxIPFailures,
+		Lockout:       cfg.LoginLockout,
+		Delay:         cfg.LoginDelay,
+		AuditSink:     auditSink,
+	}
+	twoFactorAction := &action.TwoFactor{
+		UserRepo:    userRepo,
+		Issuer:      cfg.TOTPIssuer,
+		LoginAction: loginAction,
+		AuditSink:   auditSink,
+	}
+	jwtSecret := cfg.JWTSecret
+	if len(jwtSecret) == 0 {
+		jwtSecret = make([]byte, 32)
+		if _, err := rand.Read(jwtSecret); err != nil {
+			panic(err)
+		}
+	}
+	refreshRepo := repository.NewRefreshTokensRepository()
+	tokenAction := &action.Token{
+		UserRepo:        userRepo,
+		RefreshRepo:     refreshRepo,
+		LoginAction:     loginAction,
+		TwoFactorAction: twoFactorAction,
+		Secret:          jwtSecret,
+		AccessTTL:       cfg.JWTAccessTTL,
+		RefreshTTL:      cfg.JWTRefreshTTL,
+	}
 
 	s := &controller.Session{
 		Store: store,
 		UserAction: &action.User{
 			UsersRepo: userRepo,
 		},
+		GuestAction:  guestAction,
+		APIKeyAction: apiKeyAction,
+		TokenAction:  tokenAction,
 	}
 	router.Use(s.Check)
 	router.Use(renderHTML)
-
+	router.Use((&controller.CSRF{
+		Store:  store,
+		Exempt: []string{"/api/token", "/api/token/revoke"},
+	}).Protect)
 	c := &controller.Pages{
-		SessionStore: store,
-		LoginAction: &action.Login{
-			UserRepo: userRepo,
-		},
-		SessionAction: &action.Session{
-			UserRepo:    userRepo,
-			SessionRepo: repository.NewSessionsRepository(),
-		},
-		OpenViDuService: &service.Service{
-			OpenViDu: HTTPClient,
-		},
+		SessionStore:     store,
+		LoginAction:      loginAction,
+		SessionAction:    sessionAction,
+		GuestAction:      guestAction,
+		InvitationAction: invitationAction,
+		TwoFactorAction:  twoFactorAction,
+		OpenViDuService:  openViDu,
+		Signup:           cfg.Signup,
+		SSO:              cfg.OIDCIssuer != "",
+		DemoUsers:        cfg.UsersFile == "",
 	}
 	router.NoMethod(c.Index)
 	router.NoRoute(c.Index)
 	router.GET("/", c.Index)
+	router.GET("/dashboard", c.Rooms)
 	router.POST("/dashboard", c.Dashboard)
-	router.POST("/session", c.Session)
+	router.POST("/session", drain.RefuseJoins, c.Session)
 	router.POST("/leave-session", c.Leave)
-	return router
+	router.POST("/session/access", c.Access)
+	router.POST("/session/owner", c.Owner)
+	router.POST("/session/recording", c.Recording)
+	re := &controller.RoomEvents{
+		SessionAction: sessionAction,
+		Events:        roomEvents,
+	}
+	router.GET("/session/events", re.Stream)
+	router.GET("/guest", c.GuestForm)
+	router.POST("/guest", drain.RefuseJoins, c.Guest)
+
+	api := &controller.API{
+		OpenViDuService: openViDu,
+		SessionAction:   sessionAction,
+	}
+	router.GET("/api/sessions",
+		controller.Scope(entity.ScopeListSessions), api.Sessions)
+	router.POST("/api/sessions", controller.Scope(entity.ScopeCreateSession),
+		drain.RefuseJoins, api.CreateSession)
+	router.POST("/api/sessions/:name/token",
+		controller.Scope(entity.ScopeGetToken), drain.RefuseJoins, api.Token)
+
+	t := &controller.Token{TokenAction: tokenAction}
+	router.POST("/api/token", t.Issue)
+	router.POST("/api/token/revoke", t.Revoke)
+
+	i := &controller.Invitations{InvitationAction: invitationAction}
+	router.GET("/join/:token", i.Join)
+	router.GET("/invitations", i.List)
+	router.POST("/invitations", i.Create)
+	router.POST("/invitations/revoke", i.Revoke)
+
+	a := &controller.Accounts{
+		SessionStore: store,
+		AccountAction: &action.Account{
+			UserRepo:      userRepo,
+			SessionAction: sessionAction,
+			KeyRepo:       keyRepo,
+			RefreshRepo:   refreshRepo,
+			DefaultRole:   cfg.SignupRole,
+			Approval:      cfg.SignupApproval,
+			AuditSink:     auditSink,
+		},
+	}
+	if cfg.Signup {
+		router.GET("/signup", a.SignupForm)
+		router.POST("/signup", a.Signup)
+	}
+	router.GET("/account", a.Profile)
+	router.POST("/account/profile", a.UpdateProfile)
+	router.POST("/account/password", a.ChangePassword)
+	router.POST("/account/delete", a.Delete)
+	router.POST("/account/approve", a.Approve)
+
+	tf := &controller.TwoFactor{
+		SessionStore:    store,
+		TwoFactorAction: twoFactorAction,
+	}
+	router.GET("/login/2fa", tf.Form)
+	router.POST("/login/2fa", tf.Verify)
+	router.GET("/account/2fa", tf.Setup)
+	router.POST("/account/2fa", tf.Activate)
+	router.POST("/account/2fa/disable", tf.Disable)
+
+	k := &controller.APIKeys{APIKeyAction: apiKeyAction}
+	router.GET("/account/api-keys", k.List)
+	router.POST("/account/api-keys", k.Create)
+	router.POST("/account/api-keys/revoke", k.Revoke)
+
+	ad := &controller.Admin{
+		ModerationAction: &action.Moderation{
+			UserRepo:      userRepo,
+			SessionRepo:   sessionRepo,
+			Registry:      registry,
+			OpenViDu:      openViDu,
+			SessionAction: sessionAction,
+			LoginAction:   loginAction,
+			AuditSink:     auditSink,
+		},
+	}
+	router.GET("/admin", ad.Moderator, ad.Index)
+	router.GET("/admin/session", ad.Moderator, ad.Session)
+	router.POST("/admin/session/kick", ad.Moderator, ad.Kick)
+	router.POST("/admin/session/unpublish", ad.Moderator, ad.Unpublish)
+	router.POST("/admin/session/close", ad.Moderator, ad.CloseSession)
+	router.POST("/admin/users/role", ad.Moderator, ad.SetRole)
+	router.POST("/admin/users/unlock", ad.Moderator, ad.Unlock)
+	router.GET("/admin/audit", ad.Moderator, ad.Audit)
+
+	mailer := newMailer(cfg)
+	r := &controller.PasswordReset{
+		ResetAction: &action.PasswordReset{
+			UserRepo:    userRepo,
+			ResetRepo:   repository.NewResetsRepository(),
+			RefreshRepo: refreshRepo,
+			Mailer:      mailer,
+			LoginAction: loginAction,
+			TTL:         cfg.PasswordResetTTL,
+		},
+		PublicURL: cfg.PublicURL,
+	}
+	router.GET("/reset", r.RequestForm)
+	router.POST("/reset", r.Request)
+	router.GET("/reset/:token", r.ResetForm)
+	router.POST("/reset/:token", r.Reset)
+
+	if cfg.OIDCIssuer != "" {
+		sso := &controller.SSO{
+			SessionStore: store,
+			Provider: &service.OIDC{
+				Issuer:       cfg.OIDCIssuer,
+				ClientID:     cfg.OIDCClientID,
+				ClientSecret: cfg.OIDCClientSecret,
+				RedirectURL:  cfg.OIDCRedirectURL,
+				Scopes:       cfg.OIDCScopes,
+			},
+			SSOAction: &action.SSO{
+				UserRepo:      userRepo,
+				UsernameClaim: cfg.OIDCUsernameClaim,
+				RoleClaim:     cfg.OIDCRoleClaim,
+				Roles:         cfg.OIDCRoles,
+				DefaultRole:   cfg.OIDCDefaultRole,
+				Provision:     cfg.OIDCProvision,
+				AuditSink:     auditSink,
+			},
+			TwoFactorAction: twoFactorAction,
+		}
+		router.GET("/login/oidc", sso.Login)
+		router.GET("/login/oidc/callback", sso.Callback)
+	}
+
+	m := &controller.Meetings{MeetingAction: meetingAction}
+	router.GET("/meetings", m.List)
+	router.POST("/meetings", m.Schedule)
+	router.POST("/meetings/cancel", m.Cancel)
+
+	h := &controller.Health{
+		Checks: map[string]func(ctx context.Context) error{
+			"templates":    templatesCheck(router),
+			"repositories": repositoriesCheck(sessionRepo, meetingRepo),
+			"openvidu":     openViDu.Ping,
+			"drain":        drain.Check,
+		},
+	}
+	router.GET("/healthz", h.Live)
+	router.GET("/readyz", h.Ready)
+
+	prometheus.MustRegister(metrics.NewSessionsCollector(sessionRepo))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	return &Router{
+		Engine:   router,
+		cfg:      cfg,
+		drain:    drain,
+		registry: registry,
+		events:   roomEvents,
+		bus:      sessionEvents,
+		mailer:   mailer,
+		stopJobs: stopJobs,
+	}
 }
@@ -0,0 +1,143 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/flexconstructor/openvidu-tutorial/action"
+	"github.com/flexconstructor/openvidu-tutorial/config"
+	"github.com/flexconstructor/openvidu-tutorial/controller"
+	"github.com/flexconstructor/openvidu-tutorial/event"
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+	"github.com/flexconstructor/openvidu-tutorial/service"
+)
+
+// Router is a HTTP router of the application that serves HTTP requests and
+// shuts down gracefully.
+type Router struct {
+	*gin.Engine
+
+	cfg      *config.Config
+	drain    *controller.Drain
+	registry *action.Registry
+	events   *event.Hub
+	bus      *event.Bus
+	mailer   *service.MailQueue
+	stopJobs chan struct{}
+}
+
+// Serve serves HTTP requests until a signal is received from given channel,
+// then shuts down gracefully:
+//  1. new joins are refused during drain period, which is cut short by
+//     second signal;
+//  2. HTTP server stops and waits for in-flight requests;
+//  3. active sessions are closed or persisted according to shutdown policy;
+//  4. asynchronous subscribers of session events handle queued events.
+func (r *Router) Serve(signals <-chan os.Signal) error {
+	log := logging.FromContext(context.Background())
+	unlock, err := lockFile(stateLock(r.cfg))
+	if err != nil {
+		log.WithError(err).Warn("failed to lock state file, " +
+			"admin commands may change sessions while running")
+	} else {
+		defer unlock()
+	}
+	server := &http.Server{Addr: r.cfg.Addr, Handler: r.Engine}
+	server.RegisterOnShutdown(r.events.Close)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	log.WithField("addr", r.cfg.Addr).Info("server started")
+
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-signals:
+		log.WithField("signal", sig.String()).Info("draining")
+	}
+	r.drain.Start()
+	select {
+	case <-time.After(r.cfg.DrainPeriod):
+	case <-signals:
+	}
+
+	log.Info("shutting down")
+	ctx, cancel := context.WithTimeout(
+		context.Background(), r.cfg.ShutdownTimeout)
+	defer cancel()
+	err = server.Shutdown(ctx)
+	close(r.stopJobs)
+	if e := r.releaseSessions(ctx); e != nil && err == nil {
+		err = e
+	}
+	r.bus.Close()
+	r.mailer.Close()
+	return err
+}
+
+// releaseSessions closes or persists active sessions according to shutdown
+// policy.
+func (r *Router) releaseSessions(ctx context.Context) error {
+	if r.cfg.ShutdownPolicy != config.PersistSessions {
+		return r.registry.CloseAll(ctx)
+	}
+	return persistSessions(r.registry, r.cfg.StateFile)
+}
+
+// errLocked is returned by lockFile if the file is locked by another
+// process.
+var errLocked = errors.New("file is locked by another process")
+
+// stateLock returns name of file locked while application runs, so admin
+// commands do not change sessions it holds in memory.
+func stateLock(cfg *config.Config) string {
+	return cfg.StateFile + ".lock"
+}
+
+// persistSessions atomically writes sessions of given registry to given
+// file.
+func persistSessions(registry *action.Registry, file string) error {
+	f, err := ioutil.TempFile(filepath.Dir(file), ".sessions")
+	if err != nil {
+		return err
+	}
+	if err = registry.Persist(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
+
+// restoreSessions puts sessions persisted to given file on previous exit
+// to repository of given registry. Errors are logged only, so application
+// starts with empty registry if sessions can not be restored.
+func restoreSessions(registry *action.Registry, file string) {
+	log := logging.FromContext(context.Background()).WithField("file", file)
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to restore sessions")
+		return
+	}
+	defer f.Close()
+	if err = registry.Restore(f); err != nil {
+		log.WithError(err).Error("failed to restore sessions")
+		return
+	}
+	log.Info("sessions restored")
+}
@@ -0,0 +1,52 @@
+package route
+
+import (
+	"crypto/rand"
+
+	"github.com/gorilla/sessions"
+	"github.com/sirupsen/logrus"
+
+	"github.com/flexconstructor/openvidu-tutorial/config"
+)
+
+// newSessionStore returns HTTP session store defined by given configuration.
+//
+// Cookies are signed and encrypted by first configured key pair and decoded
+// by any of them, so keys can be rotated without logging users out.
+func newSessionStore(cfg *config.Config) sessions.Store {
+	var keyPairs [][]byte
+	for _, key := range cfg.SessionKeys {
+		keyPairs = append(keyPairs, key.Hash, key.Encryption)
+	}
+	if len(keyPairs) == 0 {
+		logrus.Warn("SESSION_KEYS is not set, " +
+			"HTTP sessions will not survive restart")
+		keyPairs = [][]byte{randomKey(64), randomKey(32)}
+	}
+	options := &sessions.Options{
+		Path:     "/",
+		MaxAge:   int(cfg.SessionMaxAge.Seconds()),
+		Secure:   cfg.SessionSecure,
+		HttpOnly: cfg.SessionHTTPOnly,
+		SameSite: cfg.SessionSameSite,
+	}
+	if cfg.SessionStore == config.FilesystemStore {
+		store := sessions.NewFilesystemStore(cfg.SessionDir, keyPairs...)
+		store.Options = options
+		store.MaxAge(options.MaxAge)
+		return store
+	}
+	store := sessions.NewCookieStore(keyPairs...)
+	store.Options = options
+	store.MaxAge(options.MaxAge)
+	return store
+}
+
+// randomKey returns random key of given length.
+func randomKey(length int) []byte {
+	key := make([]byte, length)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
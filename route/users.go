@@ -0,0 +1,34 @@
+package route
+
+import (
+	"github.com/flexconstructor/openvidu-tutorial/config"
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/repository"
+)
+
+// demoPassword is a password of demo users. It is shown on index page.
+const demoPassword = "demopass"
+
+// newUsersRepository returns users repository defined by given
+// configuration. Users file is never seeded, its first moderator is added
+// by "users add" command. Without users file demo users that can not
+// moderate are kept in memory.
+func newUsersRepository(cfg *config.Config) (entity.Users, error) {
+	if cfg.UsersFile != "" {
+		return repository.NewUsersFileRepository(cfg.UsersFile)
+	}
+	repo := repository.NewUsersRepository()
+	for _, u := range []struct {
+		name string
+		role entity.UserRole
+	}{
+		{"publisher1", entity.Publisher},
+		{"publisher2", entity.Publisher},
+		{"subscriber", entity.Subscriber},
+	} {
+		if err := repo.Add(u.name, demoPassword, uint8(u.role)); err != nil {
+			return nil, err
+		}
+	}
+	return repo, nil
+}
@@ -2,19 +2,37 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+	"github.com/flexconstructor/openvidu-tutorial/metrics"
+	"github.com/flexconstructor/openvidu-tutorial/tracing"
 )
 
 // HTTPClient is an interface of  client HTTP service.
 type HTTPClient interface {
 	// Post that performs sending of POST request to HTTP server.
-	Post(method string,
+	Post(ctx context.Context, method string,
 		args map[string]interface{}) (map[string]interface{}, error)
+
+	// Get performs sending of GET request to HTTP server.
+	Get(ctx context.Context, method string) (map[string]interface{}, error)
+
+	// Delete performs sending of DELETE request to HTTP server.
+	Delete(ctx context.Context, method string) error
 }
 
 // Client is an implementation of HTTPClient interface.
@@ -26,7 +44,7 @@ type Client struct {
 
 // Post sends HTTP post request to HTTP server.
 func (c *Client) Post(
-	method string,
+	ctx context.Context, method string,
 	args map[string]interface{}) (map[string]interface{}, error) {
 	var requestData io.Reader
 	if args != nil {
@@ -36,24 +54,14 @@ func (c *Client) Post(
 		}
 		requestData = bytes.NewBuffer(rawMessage)
 	}
-	req, err := http.NewRequest(
-		http.MethodPost, fmt.Sprintf(
-			"%s/%s", c.OpenViDuURL, method), requestData)
+	req, err := c.newRequest(ctx, http.MethodPost, method, requestData)
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(c.Login, c.Password)
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
-	}
 	if args != nil {
 		req.Header.Add("Content-Type", "application/json")
 	}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -70,3 +78,110 @@ func (c *Client) Post(
 	}
 	return result, nil
 }
+
+// Get sends HTTP get request to HTTP server.
+func (c *Client) Get(
+	ctx context.Context, method string) (map[string]interface{}, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, method, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("GET %s failed with status %d",
+			method, resp.StatusCode)
+	}
+	var result map[string]interface{}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Delete sends HTTP delete request to HTTP server.
+func (c *Client) Delete(ctx context.Context, method string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, method, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("DELETE %s failed with status %d",
+			method, resp.StatusCode)
+	}
+	return nil
+}
+
+// newRequest returns new authorized request to given method of HTTP server.
+// Request ID carried by given context is sent in request header.
+func (c *Client) newRequest(ctx context.Context,
+	httpMethod string, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(
+		httpMethod, fmt.Sprintf("%s/%s", c.OpenViDuURL, method), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth(c.Login, c.Password)
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+	return req, nil
+}
+
+// do sends given request to HTTP server, logs its result and records its
+// metrics and tracing span. Trace context is propagated in request headers.
+func (c *Client) do(req *http.Request) (resp *http.Response, err error) {
+	ctx, span := tracing.Start(req.Context(), "OpenViDu "+req.Method,
+		attribute.String("http.method", req.Method),
+		attribute.String("openvidu.method", req.URL.Path))
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(
+				attribute.Int("http.status_code", resp.StatusCode))
+		}
+		tracing.End(span, err)
+	}()
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	start := time.Now()
+	resp, err = c.client().Do(req)
+	metrics.ObserveOpenViDuRequest(req.Method,
+		strings.TrimPrefix(req.URL.Path, "/"), time.Since(start),
+		err != nil || resp.StatusCode >= http.StatusBadRequest)
+	log := logging.FromContext(req.Context()).WithFields(logrus.Fields{
+		"openvidu_method": req.Method,
+		"openvidu_path":   req.URL.Path,
+		"duration":        time.Since(start).Seconds(),
+	})
+	if err != nil {
+		log.WithError(err).Error("OpenViDu request failed")
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		log.WithField("status", resp.StatusCode).Warn("OpenViDu request failed")
+	} else {
+		log.WithField("status", resp.StatusCode).Debug("OpenViDu request")
+	}
+	return resp, nil
+}
+
+// client returns HTTP client that performs requests to HTTP server.
+func (c *Client) client() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+}
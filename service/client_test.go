@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -8,6 +9,11 @@ import (
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/flexconstructor/openvidu-tutorial/logging"
 )
 
 func TestClient_Post(t *testing.T) {
@@ -35,7 +41,8 @@ func TestClient_Post(t *testing.T) {
 			Password:    "test password",
 		}
 
-		resp, err := client.Post("test", map[string]interface{}{
+		ctx := logging.WithRequestID(context.Background(), "test request")
+		resp, err := client.Post(ctx, "test", map[string]interface{}{
 			"test": "test_data",
 		})
 		<-c
@@ -50,6 +57,7 @@ func TestClient_Post(t *testing.T) {
 				"Basic dGVzdCBsb2dpbjp0ZXN0IHBhc3N3b3Jk")
 			So(request.Header.Get("Content-Type"),
 				ShouldContainSubstring, "application/json")
+			So(request.Header.Get("X-Request-ID"), ShouldEqual, "test request")
 		})
 
 		Convey("with correct body", func() {
@@ -73,7 +81,7 @@ func TestClient_Post(t *testing.T) {
 			Password:    "test password",
 		}
 
-		_, err := client.Post("test", map[string]interface{}{
+		_, err := client.Post(context.Background(), "test", map[string]interface{}{
 			"wrong": make(chan interface{}),
 		})
 
@@ -87,7 +95,7 @@ func TestClient_Post(t *testing.T) {
 			Password:    "test password",
 		}
 
-		_, err := client.Post("test", nil)
+		_, err := client.Post(context.Background(), "test", nil)
 
 		So(err, ShouldNotBeNil)
 	})
@@ -105,7 +113,7 @@ func TestClient_Post(t *testing.T) {
 			Password:    "test password",
 		}
 
-		_, err := client.Post("test", map[string]interface{}{
+		_, err := client.Post(context.Background(), "test", map[string]interface{}{
 			"test": "test_data",
 		})
 		<-c
@@ -113,3 +121,128 @@ func TestClient_Post(t *testing.T) {
 		So(err, ShouldNotBeNil)
 	})
 }
+
+func TestClient_Get(t *testing.T) {
+	Convey("Sends request", t, func() {
+		var request *http.Request
+		ts := httptest.NewTLSServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request = r
+				io.WriteString(w, `{"content": []}`)
+			}))
+		defer ts.Close()
+		client := &Client{
+			OpenViDuURL: ts.URL,
+			Login:       "test login",
+			Password:    "test password",
+		}
+
+		resp, err := client.Get(context.Background(), "test")
+
+		So(err, ShouldBeNil)
+		So(resp["content"], ShouldBeEmpty)
+
+		Convey("With correct parameters", func() {
+			So(request.Method, ShouldEqual, http.MethodGet)
+			So(request.URL.Path, ShouldEqual, "/test")
+			So(request.Header.Get("Authorization"), ShouldContainSubstring,
+				"Basic dGVzdCBsb2dpbjp0ZXN0IHBhc3N3b3Jk")
+		})
+	})
+
+	Convey("Propagates trace context", t, func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+		var header string
+		ts := httptest.NewTLSServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header.Get("traceparent")
+				io.WriteString(w, `{}`)
+			}))
+		defer ts.Close()
+		client := &Client{OpenViDuURL: ts.URL}
+
+		_, err := client.Get(context.Background(), "test")
+
+		So(err, ShouldBeNil)
+		So(header, ShouldStartWith, "00-")
+	})
+
+	Convey("Returns status error", t, func() {
+		ts := httptest.NewTLSServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			}))
+		defer ts.Close()
+		client := &Client{OpenViDuURL: ts.URL}
+
+		_, err := client.Get(context.Background(), "test")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"GET test failed with status 401")
+	})
+
+	Convey("Returns unmarshall error", t, func() {
+		ts := httptest.NewTLSServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "wrong")
+			}))
+		defer ts.Close()
+		client := &Client{OpenViDuURL: ts.URL}
+
+		_, err := client.Get(context.Background(), "test")
+
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestClient_Delete(t *testing.T) {
+	Convey("Sends request", t, func() {
+		var request *http.Request
+		ts := httptest.NewTLSServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request = r
+				w.WriteHeader(http.StatusNoContent)
+			}))
+		defer ts.Close()
+		client := &Client{
+			OpenViDuURL: ts.URL,
+			Login:       "test login",
+			Password:    "test password",
+		}
+
+		err := client.Delete(context.Background(), "test/id")
+
+		So(err, ShouldBeNil)
+
+		Convey("With correct parameters", func() {
+			So(request.Method, ShouldEqual, http.MethodDelete)
+			So(request.URL.Path, ShouldEqual, "/test/id")
+			So(request.Header.Get("Authorization"), ShouldContainSubstring,
+				"Basic dGVzdCBsb2dpbjp0ZXN0IHBhc3N3b3Jk")
+		})
+	})
+
+	Convey("Returns status error", t, func() {
+		ts := httptest.NewTLSServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+		defer ts.Close()
+		client := &Client{OpenViDuURL: ts.URL}
+
+		err := client.Delete(context.Background(), "test")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"DELETE test failed with status 404")
+	})
+
+	Convey("Returns send error", t, func() {
+		client := &Client{OpenViDuURL: "wrong url"}
+
+		So(client.Delete(context.Background(), "test"), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// LDAP authenticates users against directory server: it searches user
+// entry with service account, binds as the user to verify password and
+// maps groups of the user to its role.
+//
+// implements entity.Authenticator interface.
+type LDAP struct {
+	// URL is an URL of directory server, e.g. "ldaps://ldap.example.com".
+	URL string
+
+	// StartTLS upgrades "ldap://" connection to TLS before binding.
+	StartTLS bool
+
+	// BindDN and BindPassword authenticate service account that searches
+	// users. Search is anonymous if BindDN is empty.
+	BindDN       string
+	BindPassword string
+
+	// BaseDN is a base of user search.
+	BaseDN string
+
+	// UserFilter is a filter of user search, "%s" is replaced by escaped
+	// user name, e.g. "(uid=%s)".
+	UserFilter string
+
+	// GroupAttribute is an attribute of user entry that lists DNs of its
+	// groups, e.g. "memberOf".
+	GroupAttribute string
+
+	// Roles maps group DNs to user roles. The highest role wins. DNs are
+	// compared case insensitively.
+	Roles map[string]entity.UserRole
+
+	// DefaultRole is a role of user none of whose groups is mapped.
+	DefaultRole entity.UserRole
+
+	// Timeout limits connection and every request to directory server.
+	Timeout time.Duration
+
+	// Dial connects to directory server. ldap.DialURL is used if nil.
+	Dial func(url string) (ldap.Client, error)
+}
+
+// Authenticate returns user with given name found in directory if given
+// password is correct.
+func (s *LDAP) Authenticate(ctx context.Context,
+	username string, password string) (*entity.User, error) {
+	if username == "" || password == "" {
+		// Simple bind with empty password is unauthenticated bind that
+		// succeeds on many servers, so it is refused before.
+		return nil, entity.ErrUnknownUser
+	}
+	conn, err := s.dial()
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	defer conn.Close()
+	if s.StartTLS {
+		if err = conn.StartTLS(&tls.Config{
+			ServerName: hostname(s.URL)}); err != nil {
+			return nil, unavailable(err)
+		}
+	}
+	if s.BindDN != "" {
+		if err = conn.Bind(s.BindDN, s.BindPassword); err != nil {
+			return nil, unavailable(err)
+		}
+	}
+	entry, err := s.search(conn, username)
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, errors.New("password incorrect")
+		}
+		return nil, unavailable(err)
+	}
+	groups := entry.GetAttributeValues(s.GroupAttribute)
+	for i := range groups {
+		groups[i] = strings.ToLower(groups[i])
+	}
+	roles := make(map[string]entity.UserRole, len(s.Roles))
+	for dn, role := range s.Roles {
+		roles[strings.ToLower(dn)] = role
+	}
+	displayName := entry.GetAttributeValue("displayName")
+	if displayName == "" {
+		displayName = entry.GetAttributeValue("cn")
+	}
+	return &entity.User{
+		Name:        username,
+		Role:        entity.HighestRole(groups, roles, s.DefaultRole),
+		DisplayName: displayName,
+		Email:       entry.GetAttributeValue("mail"),
+		Subject:     s.URL + " " + entry.DN,
+	}, nil
+}
+
+// search returns the only entry of user with given name.
+func (s *LDAP) search(
+	conn ldap.Client, username string) (*ldap.Entry, error) {
+	result, err := conn.Search(ldap.NewSearchRequest(
+		s.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(s.Timeout/time.Second), false,
+		fmt.Sprintf(s.UserFilter, ldap.EscapeFilter(username)),
+		[]string{"cn", "displayName", "mail", s.GroupAttribute},
+		nil))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, fmt.Errorf("user %s is ambiguous in directory",
+				username)
+		}
+		return nil, unavailable(err)
+	}
+	switch len(result.Entries) {
+	case 0:
+		return nil, entity.ErrUnknownUser
+	case 1:
+		return result.Entries[0], nil
+	default:
+		return nil, fmt.Errorf("user %s is ambiguous in directory", username)
+	}
+}
+
+// dial connects to directory server.
+func (s *LDAP) dial() (ldap.Client, error) {
+	if s.Dial != nil {
+		return s.Dial(s.URL)
+	}
+	conn, err := ldap.DialURL(s.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: s.Timeout}))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(s.Timeout)
+	return conn, nil
+}
+
+// unavailable wraps given error of directory server, so it is recognized
+// as entity.ErrAuthUnavailable.
+func unavailable(err error) error {
+	return fmt.Errorf("%w: %s", entity.ErrAuthUnavailable, err)
+}
+
+// hostname returns host name of given URL.
+func hostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+)
+
+// ldapMock is a mock that imitates directory server with given entries and
+// their passwords. Methods that are not used panic.
+type ldapMock struct {
+	ldap.Client
+	behavior  string
+	entries   []*ldap.Entry
+	passwords map[string]string
+	filter    string
+	startTLS  bool
+}
+
+// StartTLS imitates ldap.Client StartTLS method.
+func (c *ldapMock) StartTLS(*tls.Config) error {
+	c.startTLS = true
+	return nil
+}
+
+// Close imitates ldap.Client Close method.
+func (c *ldapMock) Close() error {
+	return nil
+}
+
+// Bind imitates ldap.Client Bind method behavior depending on one defined.
+func (c *ldapMock) Bind(username string, password string) error {
+	if c.behavior == "failure" {
+		return ldap.NewError(ldap.LDAPResultUnavailable, errors.New("down"))
+	}
+	if p, ok := c.passwords[username]; !ok || p != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials,
+			errors.New("invalid credentials"))
+	}
+	return nil
+}
+
+// Search imitates ldap.Client Search method returning all entries.
+func (c *ldapMock) Search(r *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	c.filter = r.Filter
+	if c.behavior == "empty" {
+		return &ldap.SearchResult{}, nil
+	}
+	return &ldap.SearchResult{Entries: c.entries}, nil
+}
+
+// newLDAP returns LDAP authenticator connected to directory mock with given
+// behavior.
+func newLDAP(behavior string) (*LDAP, *ldapMock) {
+	conn := &ldapMock{
+		behavior: behavior,
+		entries: []*ldap.Entry{ldap.NewEntry(
+			"uid=test,ou=people,dc=example,dc=com",
+			map[string][]string{
+				"cn":   {"Test User"},
+				"mail": {"test@example.com"},
+				"memberOf": {
+					"CN=Staff,ou=groups,dc=example,dc=com",
+					"cn=other,ou=groups,dc=example,dc=com",
+				},
+			})},
+		passwords: map[string]string{
+			"cn=service,dc=example,dc=com":         "service password",
+			"uid=test,ou=people,dc=example,dc=com": "test password",
+		},
+	}
+	return &LDAP{
+		URL:            "ldap://ldap.example.com",
+		StartTLS:       true,
+		BindDN:         "cn=service,dc=example,dc=com",
+		BindPassword:   "service password",
+		BaseDN:         "dc=example,dc=com",
+		UserFilter:     "(uid=%s)",
+		GroupAttribute: "memberOf",
+		Roles: map[string]entity.UserRole{
+			"cn=staff,ou=groups,dc=example,dc=com": entity.Publisher,
+		},
+		DefaultRole: entity.Subscriber,
+		Dial: func(url string) (ldap.Client, error) {
+			if behavior == "unreachable" {
+				return nil, errors.New("connection refused")
+			}
+			return conn, nil
+		},
+	}, conn
+}
+
+func TestLDAP_Authenticate(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Returns user mapped from directory entry", t, func() {
+		s, conn := newLDAP("ok")
+		user, err := s.Authenticate(ctx, "test", "test password")
+
+		So(err, ShouldBeNil)
+		So(conn.startTLS, ShouldBeTrue)
+		So(conn.filter, ShouldEqual, "(uid=test)")
+		So(user.Name, ShouldEqual, "test")
+		So(user.DisplayName, ShouldEqual, "Test User")
+		So(user.Email, ShouldEqual, "test@example.com")
+		So(user.Role, ShouldEqual, entity.Publisher)
+		So(user.Subject, ShouldEqual,
+			"ldap://ldap.example.com uid=test,ou=people,dc=example,dc=com")
+	})
+
+	Convey("Escapes user name in search filter", t, func() {
+		s, conn := newLDAP("ok")
+		s.Authenticate(ctx, "*)(uid=*", "test password")
+
+		So(conn.filter, ShouldEqual, `(uid=\2a\29\28uid=\2a)`)
+	})
+
+	Convey("Returns an error", t, func() {
+		Convey("if password is incorrect", func() {
+			s, _ := newLDAP("ok")
+			_, err := s.Authenticate(ctx, "test", "wrong password")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "password incorrect")
+		})
+
+		Convey("if password is empty", func() {
+			s, _ := newLDAP("ok")
+			_, err := s.Authenticate(ctx, "test", "")
+
+			So(err, ShouldEqual, entity.ErrUnknownUser)
+		})
+
+		Convey("if user is not found", func() {
+			s, _ := newLDAP("empty")
+			_, err := s.Authenticate(ctx, "test", "test password")
+
+			So(err, ShouldEqual, entity.ErrUnknownUser)
+		})
+
+		Convey("if user is ambiguous", func() {
+			s, conn := newLDAP("ok")
+			conn.entries = append(conn.entries, conn.entries[0])
+			_, err := s.Authenticate(ctx, "test", "test password")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "ambiguous")
+		})
+
+		Convey("if directory is unavailable", func() {
+			for _, behavior := range []string{"unreachable", "failure"} {
+				s, _ := newLDAP(behavior)
+				_, err := s.Authenticate(ctx, "test", "test password")
+
+				So(errors.Is(err, entity.ErrAuthUnavailable), ShouldBeTrue)
+			}
+		})
+	})
+}
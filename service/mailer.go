@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// Mailer sends mail messages to users.
+type Mailer interface {
+	// Send sends message with given subject and plain text body to given
+	// address.
+	Send(ctx context.Context, to string, subject string, body string) error
+}
+
+// SMTPMailer sends mail messages through SMTP server.
+//
+// implements Mailer interface.
+type SMTPMailer struct {
+	// Addr is a "host:port" address of SMTP server.
+	Addr string
+
+	// From is a sender address of messages.
+	From string
+
+	// Username and Password authenticate to SMTP server. Messages are sent
+	// without authentication if Username is empty.
+	Username string
+	Password string
+
+	// Timeout limits duration of sending one message. Sending is not
+	// limited if it is zero.
+	Timeout time.Duration
+}
+
+// Send sends message through SMTP server.
+//
+// implements Mailer interface.
+func (m *SMTPMailer) Send(
+	ctx context.Context, to string, subject string, body string) error {
+	msg, err := newMessage(m.From, to, subject, body)
+	if err != nil {
+		return err
+	}
+	if err = m.send(ctx, to, msg); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithField("subject", subject).Info("mail sent")
+	return nil
+}
+
+// send sends given message to given address like smtp.SendMail does, but
+// gives up when Timeout passes or given context is canceled.
+func (m *SMTPMailer) send(ctx context.Context, to string, msg []byte) error {
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return err
+	}
+	dialer := &net.Dialer{Timeout: m.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", m.Addr)
+	if err != nil {
+		return err
+	}
+	if m.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(m.Timeout))
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("SMTP server does not support AUTH")
+		}
+		err = c.Auth(smtp.PlainAuth("", m.Username, m.Password, host))
+		if err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(m.From); err != nil {
+		return err
+	}
+	if err = c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// FileMailer appends mail messages to file instead of sending them, so mail
+// can be read without SMTP server, e.g. offline.
+//
+// implements Mailer interface.
+type FileMailer struct {
+	// From is a sender address of messages.
+	From string
+
+	// Path is a file messages are appended to. Messages are discarded if
+	// it is empty, as they may carry secrets that must not reach log.
+	Path string
+
+	mu sync.Mutex
+}
+
+// Send appends message to file or discards it.
+//
+// implements Mailer interface.
+func (m *FileMailer) Send(
+	ctx context.Context, to string, subject string, body string) error {
+	msg, err := newMessage(m.From, to, subject, body)
+	if err != nil {
+		return err
+	}
+	if m.Path == "" {
+		logging.FromContext(ctx).WithField("subject", subject).
+			Warn("mail discarded without mail file")
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(msg, '\r', '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// newMessage returns mail message with given headers and plain text body.
+// Header values must not contain line breaks.
+func newMessage(
+	from string, to string, subject string, body string) ([]byte, error) {
+	for _, v := range []string{from, to, subject} {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("invalid mail header value %q", v)
+		}
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	msg.WriteString("\r\n")
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// serveSMTP accepts one SMTP session on given listener and sends received
+// message data to given channel.
+func serveSMTP(l net.Listener, data chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	reply("220 localhost ESMTP")
+	var msg []string
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case inData && line == ".":
+			inData = false
+			data <- strings.Join(msg, "\n")
+			reply("250 OK")
+		case inData:
+			msg = append(msg, line)
+		case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+			reply("250 localhost")
+		case line == "DATA":
+			inData = true
+			reply("354 Go ahead")
+		case line == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	Convey("Sends message through SMTP server", t, func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer l.Close()
+		data := make(chan string, 1)
+		go serveSMTP(l, data)
+		m := &SMTPMailer{Addr: l.Addr().String(), From: "noreply@test"}
+		err = m.Send(context.Background(),
+			"user@test", "test subject", "test body")
+
+		So(err, ShouldBeNil)
+		msg := <-data
+		So(msg, ShouldContainSubstring, "To: user@test")
+		So(msg, ShouldContainSubstring, "Subject: test subject")
+		So(msg, ShouldContainSubstring, "test body")
+	})
+
+	Convey("Gives up on silent SMTP server after timeout", t, func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer l.Close()
+		m := &SMTPMailer{
+			Addr:    l.Addr().String(),
+			From:    "noreply@test",
+			Timeout: 100 * time.Millisecond,
+		}
+		start := time.Now()
+		err = m.Send(context.Background(),
+			"user@test", "test subject", "test body")
+
+		So(err, ShouldNotBeNil)
+		So(time.Since(start), ShouldBeLessThan, time.Second)
+	})
+
+	Convey("Returns an error for header with line break", t, func() {
+		m := &SMTPMailer{Addr: "127.0.0.1:0", From: "noreply@test"}
+		err := m.Send(context.Background(),
+			"user@test\r\nBcc: other@test", "test subject", "test body")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "invalid mail header value")
+	})
+}
+
+func TestFileMailer_Send(t *testing.T) {
+	Convey("Appends messages to file", t, func() {
+		dir, err := ioutil.TempDir("", "mailer")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		m := &FileMailer{
+			From: "noreply@test",
+			Path: filepath.Join(dir, "mail.txt"),
+		}
+
+		So(m.Send(context.Background(), "first@test", "first", "body"),
+			ShouldBeNil)
+		So(m.Send(context.Background(), "second@test", "second", "body"),
+			ShouldBeNil)
+		b, err := ioutil.ReadFile(m.Path)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldContainSubstring, "To: first@test")
+		So(string(b), ShouldContainSubstring, "To: second@test")
+	})
+
+	Convey("Discards message without file", t, func() {
+		m := &FileMailer{From: "noreply@test"}
+
+		So(m.Send(context.Background(), "user@test", "subject", "body"),
+			ShouldBeNil)
+	})
+}
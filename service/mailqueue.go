@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/flexconstructor/openvidu-tutorial/logging"
+)
+
+// Errors returned by MailQueue if message can not be queued.
+var (
+	errMailQueueFull   = errors.New("mail queue is full")
+	errMailQueueClosed = errors.New("mail queue is closed")
+)
+
+// MailQueue sends mail messages through given mailer from its own
+// goroutine, so callers do not wait for mail server. Messages that do not
+// fit into the queue are refused.
+//
+// implements Mailer interface.
+type MailQueue struct {
+	mailer Mailer
+	mu     sync.RWMutex
+	queue  chan queuedMail
+	closed bool
+	done   chan struct{}
+}
+
+// queuedMail is a mail message queued for sending along with context it is
+// sent with.
+type queuedMail struct {
+	ctx     context.Context
+	to      string
+	subject string
+	body    string
+}
+
+// NewMailQueue returns new queue of given size that sends messages through
+// given mailer.
+func NewMailQueue(mailer Mailer, size int) *MailQueue {
+	q := &MailQueue{
+		mailer: mailer,
+		queue:  make(chan queuedMail, size),
+		done:   make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Send queues message for sending. Failure of sending is logged. Message
+// is sent with context that keeps log entry and request ID of given one
+// but is never canceled.
+//
+// implements Mailer interface.
+func (q *MailQueue) Send(
+	ctx context.Context, to string, subject string, body string) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return errMailQueueClosed
+	}
+	detached := logging.NewContext(logging.WithRequestID(
+		context.Background(), logging.RequestID(ctx)), logging.FromContext(ctx))
+	select {
+	case q.queue <- queuedMail{detached, to, subject, body}:
+		return nil
+	default:
+		return errMailQueueFull
+	}
+}
+
+// Close stops accepting messages and waits until queued ones are sent.
+func (q *MailQueue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.queue)
+	q.mu.Unlock()
+	<-q.done
+}
+
+// run sends queued messages until the queue is closed.
+func (q *MailQueue) run() {
+	defer close(q.done)
+	for m := range q.queue {
+		err := q.mailer.Send(m.ctx, m.to, m.subject, m.body)
+		if err != nil {
+			logging.FromContext(m.ctx).WithError(err).
+				WithField("subject", m.subject).Error("mail is not sent")
+		}
+	}
+}
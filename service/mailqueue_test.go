@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mailerMock is a mock that remembers recipients of sent messages. Sending
+// waits until release channel is closed if it is not nil.
+type mailerMock struct {
+	behavior string
+	release  chan struct{}
+	mu       sync.Mutex
+	to       []string
+}
+
+// Send imitates Mailer Send method behavior depending on one defined.
+func (m *mailerMock) Send(
+	ctx context.Context, to string, subject string, body string) error {
+	if m.release != nil {
+		<-m.release
+	}
+	if m.behavior != "ok" {
+		return errors.New("some error")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.to = append(m.to, to)
+	return nil
+}
+
+func TestMailQueue(t *testing.T) {
+	Convey("Sends queued messages", t, func() {
+		m := &mailerMock{behavior: "ok"}
+		q := NewMailQueue(m, 2)
+
+		So(q.Send(context.Background(), "first@test", "subject", "body"),
+			ShouldBeNil)
+		So(q.Send(context.Background(), "second@test", "subject", "body"),
+			ShouldBeNil)
+		q.Close()
+
+		So(m.to, ShouldResemble, []string{"first@test", "second@test"})
+
+		Convey("Refuses messages after close", func() {
+			err := q.Send(context.Background(), "third@test", "subject", "body")
+			So(err, ShouldEqual, errMailQueueClosed)
+		})
+	})
+
+	Convey("Does not wait for mailer", t, func() {
+		m := &mailerMock{behavior: "ok", release: make(chan struct{})}
+		q := NewMailQueue(m, 1)
+		q.Send(context.Background(), "first@test", "subject", "body")
+		q.Send(context.Background(), "second@test", "subject", "body")
+		err := q.Send(context.Background(), "third@test", "subject", "body")
+
+		So(err, ShouldEqual, errMailQueueFull)
+		close(m.release)
+		q.Close()
+		So(m.to, ShouldNotContain, "third@test")
+	})
+
+	Convey("Logs failure of mailer", t, func() {
+		m := &mailerMock{behavior: "failure"}
+		q := NewMailQueue(m, 1)
+
+		So(q.Send(context.Background(), "first@test", "subject", "body"),
+			ShouldBeNil)
+		q.Close()
+		So(m.to, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDC is a client of OpenID Connect identity provider that logs users in
+// with authorization code flow protected by PKCE.
+//
+// Provider metadata is discovered on first use, so the application starts
+// even if identity provider is unavailable.
+type OIDC struct {
+	// Issuer is an URL of identity provider, its metadata is discovered at
+	// "<Issuer>/.well-known/openid-configuration".
+	Issuer string
+
+	// ClientID and ClientSecret identify the application at identity
+	// provider.
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL is an URL identity provider returns users to after
+	// authentication.
+	RedirectURL string
+
+	// Scopes are requested scopes. "openid" is always requested.
+	Scopes []string
+
+	mu       sync.Mutex
+	config   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// AuthCodeURL returns URL of identity provider page where user
+// authenticates.
+//
+// parameters:
+//  ctx       context.Context  Context of request.
+//  state     string           Value identity provider returns with
+//                             authorization code.
+//  nonce     string           Value identity provider puts into ID token.
+//  verifier  string           PKCE code verifier, its S256 challenge is
+//                             sent to identity provider.
+func (s *OIDC) AuthCodeURL(ctx context.Context,
+	state string, nonce string, verifier string) (string, error) {
+	config, _, err := s.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return config.AuthCodeURL(state, oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange exchanges given authorization code for ID token, validates the
+// token and returns its claims.
+//
+// parameters:
+//  ctx       context.Context  Context of request.
+//  code      string           Authorization code returned by identity
+//                             provider.
+//  verifier  string           PKCE code verifier given to AuthCodeURL.
+//  nonce     string           Nonce given to AuthCodeURL.
+func (s *OIDC) Exchange(ctx context.Context, code string, verifier string,
+	nonce string) (map[string]interface{}, error) {
+	config, idVerifier, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := token.Extra("id_token").(string)
+	if !ok || raw == "" {
+		return nil, errors.New("identity provider returned no ID token")
+	}
+	idToken, err := idVerifier.Verify(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(
+		[]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("ID token nonce does not match")
+	}
+	claims := make(map[string]interface{})
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// discover returns OAuth2 configuration and ID token verifier of identity
+// provider, discovering its metadata if it is not discovered yet.
+func (s *OIDC) discover(
+	ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.config != nil {
+		return s.config, s.verifier, nil
+	}
+	provider, err := oidc.NewProvider(ctx, s.Issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	scopes := []string{oidc.ScopeOpenID}
+	for _, scope := range s.Scopes {
+		if scope != oidc.ScopeOpenID {
+			scopes = append(scopes, scope)
+		}
+	}
+	s.config = &oauth2.Config{
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		RedirectURL:  s.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+	s.verifier = provider.Verifier(&oidc.Config{ClientID: s.ClientID})
+	return s.config, s.verifier, nil
+}
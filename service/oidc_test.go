@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/coreos/go-oidc/v3/oidc/oidctest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// mockIssuer is a local OpenID Connect identity provider that issues ID
+// tokens with given claims for authorization codes it has granted.
+type mockIssuer struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+
+	mu    sync.Mutex
+	codes map[string]url.Values
+}
+
+// newMockIssuer starts new mock identity provider.
+func newMockIssuer(claims map[string]interface{}) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	i := &mockIssuer{key: key, claims: claims, codes: map[string]url.Values{}}
+	discovery := &oidctest.Server{PublicKeys: []oidctest.PublicKey{{
+		PublicKey: key.Public(),
+		KeyID:     "test key",
+		Algorithm: oidc.RS256,
+	}}}
+	mux := http.NewServeMux()
+	mux.Handle("/", discovery)
+	mux.HandleFunc("/token", i.token)
+	i.Server = httptest.NewServer(mux)
+	discovery.SetIssuer(i.URL)
+	return i
+}
+
+// authorize imitates authentication of user at given authorization URL
+// and returns granted authorization code.
+func (i *mockIssuer) authorize(authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		panic(err)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	code := "code" + u.Query().Get("state")
+	i.codes[code] = u.Query()
+	return code
+}
+
+// token exchanges authorization code for ID token if PKCE code verifier
+// matches challenge given on authorization.
+func (i *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	i.mu.Lock()
+	auth, ok := i.codes[r.PostFormValue("code")]
+	delete(i.codes, r.PostFormValue("code"))
+	i.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || auth.Get("code_challenge_method") != "S256" ||
+		auth.Get("code_challenge") !=
+			base64.RawURLEncoding.EncodeToString(sum[:]) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	claims := map[string]interface{}{
+		"iss":   i.URL,
+		"aud":   auth.Get("client_id"),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": auth.Get("nonce"),
+	}
+	for k, v := range i.claims {
+		claims[k] = v
+	}
+	raw, _ := json.Marshal(claims)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "test access token",
+		"token_type":   "Bearer",
+		"id_token": oidctest.SignIDToken(
+			i.key, "test key", oidc.RS256, string(raw)),
+	})
+}
+
+func TestOIDC_Exchange(t *testing.T) {
+	ctx := context.Background()
+	verifier := "test-verifier-test-verifier-test-verifier-43"
+
+	Convey("Returns claims of validated ID token", t, func() {
+		issuer := newMockIssuer(map[string]interface{}{
+			"sub":                "test subject",
+			"preferred_username": "test user",
+		})
+		defer issuer.Close()
+		s := &OIDC{
+			Issuer:      issuer.URL,
+			ClientID:    "test client",
+			RedirectURL: "http://localhost/login/oidc/callback",
+			Scopes:      []string{"profile"},
+		}
+		authURL, err := s.AuthCodeURL(ctx, "state", "nonce", verifier)
+		So(err, ShouldBeNil)
+		u, _ := url.Parse(authURL)
+		So(u.Query().Get("scope"), ShouldEqual, "openid profile")
+		So(u.Query().Get("redirect_uri"), ShouldEqual, s.RedirectURL)
+
+		claims, err := s.Exchange(
+			ctx, issuer.authorize(authURL), verifier, "nonce")
+
+		So(err, ShouldBeNil)
+		So(claims["sub"], ShouldEqual, "test subject")
+		So(claims["preferred_username"], ShouldEqual, "test user")
+
+		Convey("Returns an error if nonce does not match", func() {
+			authURL, _ := s.AuthCodeURL(ctx, "other", "nonce", verifier)
+			_, err := s.Exchange(
+				ctx, issuer.authorize(authURL), verifier, "other nonce")
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "ID token nonce does not match")
+		})
+
+		Convey("Returns an error if code verifier does not match", func() {
+			authURL, _ := s.AuthCodeURL(ctx, "other", "nonce", verifier)
+			_, err := s.Exchange(
+				ctx, issuer.authorize(authURL), "wrong verifier", "nonce")
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Returns an error if ID token is issued to other client",
+			func() {
+				other := &OIDC{Issuer: issuer.URL, ClientID: "other client"}
+				authURL, _ := s.AuthCodeURL(ctx, "other", "nonce", verifier)
+				_, err := other.Exchange(
+					ctx, issuer.authorize(authURL), verifier, "nonce")
+
+				So(err, ShouldNotBeNil)
+			})
+	})
+
+	Convey("Returns an error if discovery fails", t, func() {
+		s := &OIDC{Issuer: "http://127.0.0.1:1"}
+		_, err := s.AuthCodeURL(ctx, "state", "nonce", verifier)
+
+		So(err, ShouldNotBeNil)
+	})
+}
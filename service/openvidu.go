@@ -1,16 +1,31 @@
 package service
 
-import "errors"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
+	"github.com/flexconstructor/openvidu-tutorial/tracing"
+)
 
 // OpenViDu is an interface of OpenViDu server.
 type OpenViDu interface {
 	// GetMediaSession calls OpenViDu server to retrieve of OpenViDu session
 	// data object.
-	GetMediaSession(sessionName string) (string, error)
+	GetMediaSession(ctx context.Context, sessionName string) (string, error)
 
 	// GetToken calls OpenViDu server to retrieve of OpenViDu auth token
 	// data object.
-	GetToken(params map[string]interface{}) (map[string]interface{}, error)
+	GetToken(ctx context.Context,
+		params map[string]interface{}) (map[string]interface{}, error)
+
+	// CloseSession calls OpenViDu server to close session with given ID and
+	// disconnect all its participants.
+	CloseSession(ctx context.Context, sessionID string) error
 }
 
 // Service is an implementation of OpenViDu interface that performs retrieving
@@ -24,8 +39,11 @@ type Service struct {
 //
 // Implements OpenViDu interface.
 func (s *Service) GetMediaSession(
-	sessionName string) (string, error) {
-	m, err := s.OpenViDu.Post("api/sessions", nil)
+	ctx context.Context, sessionName string) (id string, err error) {
+	ctx, span := tracing.Start(ctx, "OpenViDu.GetMediaSession",
+		attribute.String("session.name", sessionName))
+	defer func() { tracing.End(span, err) }()
+	m, err := s.OpenViDu.Post(ctx, "api/sessions", nil)
 	if err != nil {
 		return "", err
 	}
@@ -45,11 +63,145 @@ func (s *Service) GetMediaSession(
 // data object.
 //
 // Implements OpenViDu interface.
-func (s *Service) GetToken(
-	params map[string]interface{}) (map[string]interface{}, error) {
-	m, err := s.OpenViDu.Post("api/tokens", params)
+func (s *Service) GetToken(ctx context.Context,
+	params map[string]interface{}) (_ map[string]interface{}, err error) {
+	ctx, span := tracing.Start(ctx, "OpenViDu.GetToken")
+	if session, ok := params["session"].(string); ok {
+		span.SetAttributes(attribute.String("session.id", session))
+	}
+	defer func() { tracing.End(span, err) }()
+	m, err := s.OpenViDu.Post(ctx, "api/tokens", params)
 	if err != nil {
 		return nil, err
 	}
 	return m, nil
 }
+
+// Ping performs authenticated request to OpenViDu server and returns an
+// error if server does not answer it.
+func (s *Service) Ping(ctx context.Context) error {
+	_, err := s.OpenViDu.Get(ctx, "api/sessions")
+	return err
+}
+
+// CloseSession calls OpenViDu server to close session with given ID and
+// disconnect all its participants.
+//
+// Implements OpenViDu interface.
+func (s *Service) CloseSession(
+	ctx context.Context, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "OpenViDu.CloseSession",
+		attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+	return s.OpenViDu.Delete(ctx, "api/sessions/"+sessionID)
+}
+
+// Connections calls OpenViDu server to retrieve participants connected to
+// session with given ID.
+func (s *Service) Connections(ctx context.Context,
+	sessionID string) (_ []*entity.Connection, err error) {
+	ctx, span := tracing.Start(ctx, "OpenViDu.Connections",
+		attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+	m, err := s.OpenViDu.Get(ctx, "api/sessions/"+sessionID)
+	if err != nil {
+		return nil, err
+	}
+	connections, _ := m["connections"].(map[string]interface{})
+	content, _ := connections["content"].([]interface{})
+	list := make([]*entity.Connection, 0, len(content))
+	for _, item := range content {
+		c, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("can not cast OpenViDu connection")
+		}
+		list = append(list, newConnection(c))
+	}
+	return list, nil
+}
+
+// Disconnect calls OpenViDu server to force participant with given
+// connection ID to leave session with given ID.
+func (s *Service) Disconnect(ctx context.Context,
+	sessionID string, connectionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "OpenViDu.Disconnect",
+		attribute.String("session.id", sessionID),
+		attribute.String("connection.id", connectionID))
+	defer func() { tracing.End(span, err) }()
+	return s.OpenViDu.Delete(ctx,
+		"api/sessions/"+sessionID+"/connection/"+connectionID)
+}
+
+// Unpublish calls OpenViDu server to stop media stream with given ID
+// published to session with given ID. Its publisher stays connected.
+func (s *Service) Unpublish(ctx context.Context,
+	sessionID string, streamID string) (err error) {
+	ctx, span := tracing.Start(ctx, "OpenViDu.Unpublish",
+		attribute.String("session.id", sessionID),
+		attribute.String("stream.id", streamID))
+	defer func() { tracing.End(span, err) }()
+	return s.OpenViDu.Delete(ctx,
+		"api/sessions/"+sessionID+"/stream/"+streamID)
+}
+
+// StartRecording calls OpenViDu server to start recording of session with
+// given ID and returns ID of the started recording.
+func (s *Service) StartRecording(
+	ctx context.Context, sessionID string) (id string, err error) {
+	ctx, span := tracing.Start(ctx, "OpenViDu.StartRecording",
+		attribute.String("session.id", sessionID))
+	defer func() { tracing.End(span, err) }()
+	m, err := s.OpenViDu.Post(ctx, "api/recordings/start",
+		map[string]interface{}{"session": sessionID})
+	if err != nil {
+		return "", err
+	}
+	id, ok := m["id"].(string)
+	if !ok {
+		return "", errors.New("OpenViDu response contains no recording ID")
+	}
+	return id, nil
+}
+
+// StopRecording calls OpenViDu server to stop recording with given ID.
+func (s *Service) StopRecording(
+	ctx context.Context, recordingID string) (err error) {
+	ctx, span := tracing.Start(ctx, "OpenViDu.StopRecording",
+		attribute.String("recording.id", recordingID))
+	defer func() { tracing.End(span, err) }()
+	_, err = s.OpenViDu.Post(ctx, "api/recordings/stop/"+recordingID, nil)
+	return err
+}
+
+// newConnection returns connection described by given OpenViDu response
+// item.
+func newConnection(m map[string]interface{}) *entity.Connection {
+	c := &entity.Connection{}
+	c.ID, _ = m["connectionId"].(string)
+	if role, ok := m["role"].(string); ok {
+		c.Role, _ = entity.ParseUserRole(role)
+	}
+	if ms, ok := m["createdAt"].(float64); ok {
+		c.CreatedAt = time.Unix(0, int64(ms)*int64(time.Millisecond))
+	}
+	// Server data is the JSON given as token data on join.
+	data, _ := m["serverData"].(string)
+	var serverData struct {
+		ServerData string `json:"serverData"`
+		UserName   string `json:"userName"`
+	}
+	if json.Unmarshal([]byte(data), &serverData) == nil {
+		c.Participant = serverData.ServerData
+		c.UserName = serverData.UserName
+	} else {
+		c.Participant = data
+	}
+	publishers, _ := m["publishers"].([]interface{})
+	for _, p := range publishers {
+		publisher, _ := p.(map[string]interface{})
+		if id, ok := publisher["streamId"].(string); ok {
+			c.Streams = append(c.Streams, id)
+		}
+	}
+	return c
+}
@@ -1,10 +1,15 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flexconstructor/openvidu-tutorial/entity"
 )
 
 // httpClientMock is a mock that imitates the HTTP Client behavior.
@@ -13,7 +18,7 @@ type httpClientMock struct {
 }
 
 // Post imitates HTTP Client Post method behavior depending on one defined.
-func (c *httpClientMock) Post(method string,
+func (c *httpClientMock) Post(ctx context.Context, method string,
 	args map[string]interface{}) (map[string]interface{}, error) {
 	switch c.behavior {
 	case "session":
@@ -33,12 +38,59 @@ func (c *httpClientMock) Post(method string,
 	return nil, nil
 }
 
+// Get imitates HTTP Client Get method behavior depending on one defined.
+func (c *httpClientMock) Get(
+	ctx context.Context, method string) (map[string]interface{}, error) {
+	switch c.behavior {
+	case "ok":
+		return map[string]interface{}{}, nil
+	case "connections":
+		var m map[string]interface{}
+		err := json.Unmarshal([]byte(testConnections), &m)
+		return m, err
+	default:
+		return nil, errors.New("some error")
+	}
+}
+
+// Delete imitates HTTP Client Delete method behavior depending on one defined.
+func (c *httpClientMock) Delete(ctx context.Context, method string) error {
+	if c.behavior == "ok" {
+		return nil
+	}
+	return errors.New("some error")
+}
+
+// testConnections is a response of OpenViDu server with session
+// connections.
+const testConnections = `{"sessionId": "test id", "connections": {
+	"numberOfElements": 2, "content": [{
+		"connectionId": "con_1", "createdAt": 1538481999022,
+		"role": "PUBLISHER", "serverData": "{\"serverData\": \"Alice\", \"userName\": \"alice\"}",
+		"publishers": [{"streamId": "str_1"}], "subscribers": []
+	}, {
+		"connectionId": "con_2", "role": "SUBSCRIBER", "serverData": "bob"
+	}]}}`
+
+// deleteRecorderMock is a mock of HTTP Client that records methods of
+// DELETE requests.
+type deleteRecorderMock struct {
+	httpClientMock
+	methods []string
+}
+
+// Delete records method and imitates HTTP Client Delete method behavior.
+func (c *deleteRecorderMock) Delete(ctx context.Context, method string) error {
+	c.methods = append(c.methods, method)
+	return c.httpClientMock.Delete(ctx, method)
+}
+
 func TestService_GetMediaSession(t *testing.T) {
 	Convey("Returns media session", t, func() {
 		s := &Service{
 			OpenViDu: &httpClientMock{"session"},
 		}
-		sessionID, err := s.GetMediaSession("session name")
+		sessionID, err := s.GetMediaSession(context.Background(), "session name")
 
 		So(err, ShouldBeNil)
 		So(sessionID, ShouldEqual, "sessionID")
@@ -48,7 +100,7 @@ func TestService_GetMediaSession(t *testing.T) {
 		s := &Service{
 			OpenViDu: &httpClientMock{"wrong"},
 		}
-		_, err := s.GetMediaSession("session name")
+		_, err := s.GetMediaSession(context.Background(), "session name")
 
 		So(err, ShouldNotBeNil)
 	})
@@ -57,7 +109,7 @@ func TestService_GetMediaSession(t *testing.T) {
 		s := &Service{
 			OpenViDu: &httpClientMock{"no id"},
 		}
-		_, err := s.GetMediaSession("session name")
+		_, err := s.GetMediaSession(context.Background(), "session name")
 
 		So(err, ShouldNotBeNil)
 	})
@@ -66,7 +118,7 @@ func TestService_GetMediaSession(t *testing.T) {
 		s := &Service{
 			OpenViDu: &httpClientMock{"not string"},
 		}
-		_, err := s.GetMediaSession("session name")
+		_, err := s.GetMediaSession(context.Background(), "session name")
 
 		So(err, ShouldNotBeNil)
 	})
@@ -78,7 +130,7 @@ func TestService_GetToken(t *testing.T) {
 			OpenViDu: &httpClientMock{"token"},
 		}
 
-		m, err := s.GetToken(map[string]interface{}{
+		m, err := s.GetToken(context.Background(), map[string]interface{}{
 			"test": "test",
 		})
 
@@ -91,8 +143,150 @@ func TestService_GetToken(t *testing.T) {
 		s := &Service{
 			OpenViDu: &httpClientMock{"wrong"},
 		}
-		_, err := s.GetToken(nil)
+		_, err := s.GetToken(context.Background(), nil)
 
 		So(err, ShouldNotBeNil)
 	})
 }
+
+func TestService_Ping(t *testing.T) {
+	Convey("Returns no error", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"ok"}}
+
+		So(s.Ping(context.Background()), ShouldBeNil)
+	})
+
+	Convey("Returns an error", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"wrong"}}
+
+		So(s.Ping(context.Background()), ShouldNotBeNil)
+	})
+}
+
+func TestService_CloseSession(t *testing.T) {
+	Convey("Closes session", t, func() {
+		s := &Service{
+			OpenViDu: &httpClientMock{"ok"},
+		}
+
+		So(s.CloseSession(context.Background(), "sessionID"), ShouldBeNil)
+	})
+
+	Convey("Returns an error", t, func() {
+		s := &Service{
+			OpenViDu: &httpClientMock{"wrong"},
+		}
+
+		So(s.CloseSession(context.Background(), "sessionID"), ShouldNotBeNil)
+	})
+}
+
+func TestService_Connections(t *testing.T) {
+	Convey("Returns participants connected to session", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"connections"}}
+		list, err := s.Connections(context.Background(), "test id")
+
+		So(err, ShouldBeNil)
+		So(list, ShouldHaveLength, 2)
+		So(list[0].ID, ShouldEqual, "con_1")
+		So(list[0].Participant, ShouldEqual, "Alice")
+		So(list[0].UserName, ShouldEqual, "alice")
+		So(list[0].Role, ShouldEqual, entity.Publisher)
+		So(list[0].Streams, ShouldResemble, []string{"str_1"})
+		So(list[0].CreatedAt, ShouldEqual,
+			time.Unix(1538481999, 22*int64(time.Millisecond)))
+		So(list[1].Participant, ShouldEqual, "bob")
+		So(list[1].UserName, ShouldBeEmpty)
+		So(list[1].Role, ShouldEqual, entity.Subscriber)
+		So(list[1].Streams, ShouldBeEmpty)
+	})
+
+	Convey("Returns no participants of empty session", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"ok"}}
+		list, err := s.Connections(context.Background(), "test id")
+
+		So(err, ShouldBeNil)
+		So(list, ShouldBeEmpty)
+	})
+
+	Convey("Returns an error", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"wrong"}}
+		_, err := s.Connections(context.Background(), "test id")
+
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestService_Disconnect(t *testing.T) {
+	Convey("Disconnects participant", t, func() {
+		client := &deleteRecorderMock{httpClientMock: httpClientMock{"ok"}}
+		s := &Service{OpenViDu: client}
+
+		So(s.Disconnect(context.Background(), "test id", "con_1"), ShouldBeNil)
+		So(client.methods, ShouldResemble,
+			[]string{"api/sessions/test id/connection/con_1"})
+	})
+
+	Convey("Returns an error", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"wrong"}}
+
+		So(s.Disconnect(context.Background(), "test id", "con_1"),
+			ShouldNotBeNil)
+	})
+}
+
+func TestService_Unpublish(t *testing.T) {
+	Convey("Stops published stream", t, func() {
+		client := &deleteRecorderMock{httpClientMock: httpClientMock{"ok"}}
+		s := &Service{OpenViDu: client}
+
+		So(s.Unpublish(context.Background(), "test id", "str_1"), ShouldBeNil)
+		So(client.methods, ShouldResemble,
+			[]string{"api/sessions/test id/stream/str_1"})
+	})
+
+	Convey("Returns an error", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"wrong"}}
+
+		So(s.Unpublish(context.Background(), "test id", "str_1"),
+			ShouldNotBeNil)
+	})
+}
+
+func TestService_StartRecording(t *testing.T) {
+	Convey("Returns recording ID", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"session"}}
+		id, err := s.StartRecording(context.Background(), "test id")
+
+		So(err, ShouldBeNil)
+		So(id, ShouldEqual, "sessionID")
+	})
+
+	Convey("Returns an error if response contains no ID", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"no id"}}
+		_, err := s.StartRecording(context.Background(), "test id")
+
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Returns an error", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"wrong"}}
+		_, err := s.StartRecording(context.Background(), "test id")
+
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestService_StopRecording(t *testing.T) {
+	Convey("Stops recording", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"no id"}}
+
+		So(s.StopRecording(context.Background(), "rec_1"), ShouldBeNil)
+	})
+
+	Convey("Returns an error", t, func() {
+		s := &Service{OpenViDu: &httpClientMock{"wrong"}}
+
+		So(s.StopRecording(context.Background(), "rec_1"), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,87 @@
+// Package tracing provides OpenTelemetry tracing of the example application.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is a name of the application in traces.
+const ServiceName = "openvidu-tutorial"
+
+// instrumentation is a name of tracer used by the application.
+const instrumentation = "github.com/flexconstructor/openvidu-tutorial"
+
+// Exporters of spans.
+const (
+	// NoExporter disables tracing.
+	NoExporter = "none"
+
+	// StdoutExporter writes spans to standard output for local debugging.
+	StdoutExporter = "stdout"
+
+	// OTLPExporter sends spans to OTLP collector over HTTP. Collector is
+	// configured by standard OTEL_EXPORTER_OTLP_* environment variables.
+	OTLPExporter = "otlp"
+)
+
+// Init sets up global tracer provider that sends spans to given exporter
+// and returns function that flushes remaining spans and stops it.
+func Init(ctx context.Context,
+	exporter string) (func(ctx context.Context) error, error) {
+	var e sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case NoExporter, "":
+		return func(ctx context.Context) error { return nil }, nil
+	case StdoutExporter:
+		e, err = stdouttrace.New(
+			stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case OTLPExporter:
+		e, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %s", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(e),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL, semconv.ServiceName(ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start starts new span with given name and attributes as a child of span
+// carried by given context.
+func Start(ctx context.Context, name string,
+	attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(
+		ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records given error in given span, if any, and ends span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInit(t *testing.T) {
+	Convey("Initializes tracing with known exporters", t, func() {
+		for _, exporter := range []string{"", NoExporter, StdoutExporter} {
+			shutdown, err := Init(context.Background(), exporter)
+
+			So(err, ShouldBeNil)
+			So(shutdown(context.Background()), ShouldBeNil)
+		}
+	})
+
+	Convey("Returns an error", t, func() {
+		_, err := Init(context.Background(), "wrong")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"unknown tracing exporter wrong")
+	})
+}
+
+func TestStart(t *testing.T) {
+	Convey("Records span with attributes", t, func() {
+		recorder := tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(
+			sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+		ctx, span := Start(context.Background(), "parent",
+			attribute.String("test", "value"))
+		_, child := Start(ctx, "child")
+		End(child, errors.New("some error"))
+		End(span, nil)
+
+		spans := recorder.Ended()
+		So(spans, ShouldHaveLength, 2)
+		So(spans[0].Name(), ShouldEqual, "child")
+		So(spans[0].Parent().SpanID(), ShouldEqual,
+			spans[1].SpanContext().SpanID())
+		So(spans[0].Status().Code, ShouldEqual, codes.Error)
+		So(spans[1].Attributes(), ShouldContain,
+			attribute.String("test", "value"))
+	})
+}